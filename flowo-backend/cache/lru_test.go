@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	// set is false for a read, which refreshes the key's recency
+	type op struct {
+		key string
+		set bool
+		ttl time.Duration
+	}
+	set := func(key string, ttl time.Duration) op { return op{key, true, ttl} }
+	get := func(key string) op { return op{key: key} }
+
+	tests := []struct {
+		name     string
+		capacity int
+		ops      []op
+		wantKeys []string
+		goneKeys []string
+	}{
+		{
+			name:     "under capacity",
+			capacity: 3,
+			ops:      []op{set("a", time.Minute), set("b", time.Minute)},
+			wantKeys: []string{"a", "b"},
+		},
+		{
+			name:     "least recently set is evicted",
+			capacity: 2,
+			ops:      []op{set("a", time.Minute), set("b", time.Minute), set("c", time.Minute)},
+			wantKeys: []string{"b", "c"},
+			goneKeys: []string{"a"},
+		},
+		{
+			name:     "a read keeps an entry",
+			capacity: 2,
+			ops:      []op{set("a", time.Minute), set("b", time.Minute), get("a"), set("c", time.Minute)},
+			wantKeys: []string{"a", "c"},
+			goneKeys: []string{"b"},
+		},
+		{
+			name:     "setting a key again does not grow the cache",
+			capacity: 2,
+			ops:      []op{set("a", time.Minute), set("b", time.Minute), set("a", time.Minute), set("c", time.Minute)},
+			wantKeys: []string{"a", "c"},
+			goneKeys: []string{"b"},
+		},
+		{
+			name:     "expired entries are misses",
+			capacity: 2,
+			ops:      []op{set("a", -time.Second), set("b", time.Minute)},
+			wantKeys: []string{"b"},
+			goneKeys: []string{"a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewLRU[string](tt.capacity)
+			for _, o := range tt.ops {
+				if !o.set {
+					c.Get(o.key)
+					continue
+				}
+				c.Set(o.key, "value of "+o.key, o.ttl)
+			}
+			for _, key := range tt.wantKeys {
+				if v, ok := c.Get(key); !ok || v != "value of "+key {
+					t.Errorf("Get(%q) = %q, %v, want a hit", key, v, ok)
+				}
+			}
+			for _, key := range tt.goneKeys {
+				if v, ok := c.Get(key); ok {
+					t.Errorf("Get(%q) = %q, want a miss", key, v)
+				}
+			}
+		})
+	}
+}
+
+func TestLRUOverwrite(t *testing.T) {
+	c := NewLRU[int](2)
+	c.Set("a", 1, -time.Second)
+	c.Set("a", 2, time.Minute)
+	if v, ok := c.Get("a"); !ok || v != 2 {
+		t.Errorf("Get() = %d, %v, want 2 with a fresh TTL", v, ok)
+	}
+}
+
+func TestLRUDeletePrefix(t *testing.T) {
+	c := NewLRU[int](10)
+	for i, key := range []string{"user:1:top", "user:1:similar", "user:12:top", "shared:trending"} {
+		c.Set(key, i, time.Minute)
+	}
+
+	c.DeletePrefix("user:1:")
+
+	for _, key := range []string{"user:1:top", "user:1:similar"} {
+		if _, ok := c.Get(key); ok {
+			t.Errorf("Get(%q) hit after DeletePrefix", key)
+		}
+	}
+	for _, key := range []string{"user:12:top", "shared:trending"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("Get(%q) missed, want it kept", key)
+		}
+	}
+
+	// the freed slots can be used again
+	c.DeletePrefix("")
+	for i := 0; i < 10; i++ {
+		c.Set(string(rune('a'+i)), i, time.Minute)
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("Get(\"a\") missed in a cache filled to capacity")
+	}
+}
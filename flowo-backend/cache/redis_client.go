@@ -4,13 +4,12 @@ import (
 	"context"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
-var Ctx = context.Background()
-
 type RedisCache struct {
 	Client *redis.Client
 }
@@ -22,7 +21,7 @@ func NewRedisCache(addr string, password string, db int) *RedisCache {
 		DB:       db,
 	})
 
-	if err := rdb.Ping(Ctx).Err(); err != nil {
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
 		log.Fatalf(" Redis connection failed: %v", err)
 	}
 
@@ -30,16 +29,42 @@ func NewRedisCache(addr string, password string, db int) *RedisCache {
 	return &RedisCache{Client: rdb}
 }
 
-func (r *RedisCache) Set(key string, value string, ttl time.Duration) error {
-	return r.Client.Set(Ctx, key, value, ttl).Err()
+func (r *RedisCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return r.Client.Set(ctx, key, value, ttl).Err()
+}
+
+func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
+	return r.Client.Get(ctx, key).Result()
 }
 
-func (r *RedisCache) Get(key string) (string, error) {
-	return r.Client.Get(Ctx, key).Result()
+func (r *RedisCache) Delete(ctx context.Context, key string) error {
+	return r.Client.Del(ctx, key).Err()
 }
 
-func (r *RedisCache) Delete(key string) error {
-	return r.Client.Del(Ctx, key).Err()
+// globEscaper escapes the characters SCAN MATCH treats as a pattern
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// DeletePrefix removes every key starting with prefix, scanning rather than
+// blocking the server with KEYS
+func (r *RedisCache) DeletePrefix(ctx context.Context, prefix string) error {
+	iter := r.Client.Scan(ctx, 0, globEscaper.Replace(prefix)+"*", 100).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == 100 {
+			if err := r.Client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return r.Client.Del(ctx, keys...).Err()
+	}
+	return nil
 }
 func ProvideRedisCache() *RedisCache {
 	addr := os.Getenv("REDIS_ADDR")
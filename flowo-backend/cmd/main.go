@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
@@ -20,12 +21,16 @@ import (
 	"flowo-backend/config"
 	"flowo-backend/database"
 	_ "flowo-backend/docs" // This will be created by swag
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/controller"
+	"flowo-backend/internal/jobs"
 	"flowo-backend/internal/logger"
 	"flowo-backend/internal/middleware"
+	"flowo-backend/internal/moderation"
 	"flowo-backend/internal/payos"
 	"flowo-backend/internal/repository"
 	"flowo-backend/internal/service"
+	"flowo-backend/internal/storage"
 )
 
 // @title           Flowo List API
@@ -67,6 +72,9 @@ func main() {
 			NewAuthMiddleware,
 
 			cache.ProvideRedisCache,
+			jobs.NewScheduler,
+			storage.NewLocalStorage,
+			moderation.NewDefaultFilter,
 
 			repository.NewRepository,
 			repository.NewReviewRepository,
@@ -77,7 +85,18 @@ func main() {
 			repository.NewAddressRepository,
 			repository.NewPaymentRepository,
 			repository.NewReportRepository,
-
+			repository.NewInventoryRepository,
+			repository.NewNotificationRepository,
+			repository.NewCatalogRepository,
+			repository.NewSearchRepository,
+			repository.NewPriceTableRepository,
+			repository.NewProductStatsRepository,
+			repository.NewRecommendationRepository,
+			repository.NewImportantDateRepository,
+
+			service.NewProductIndexer,
+			service.NewPriceTableService,
+			service.NewProductStatsService,
 			service.NewService,
 			service.NewReviewService,
 			service.NewCartService,
@@ -87,6 +106,12 @@ func main() {
 			service.NewAddressService,
 			service.NewPaymentService,
 			service.NewReportService,
+			service.NewInventoryService,
+			service.NewNotificationService,
+			service.NewCatalogService,
+			service.NewRecommendationCache,
+			service.NewRecommendationService,
+			service.NewImportantDateService,
 
 			controller.NewPricingController,
 			controller.NewController,
@@ -98,7 +123,13 @@ func main() {
 			controller.NewAddressController,
 			controller.NewPaymentController,
 			controller.NewReportController,
+			controller.NewInventoryController,
+			controller.NewNotificationController,
+			controller.NewCatalogController,
+			controller.NewRecommendationController,
+			controller.NewImportantDateController,
 		),
+		fx.Invoke(RegisterJobs),
 		fx.Invoke(RegisterRoutes),
 	)
 
@@ -106,7 +137,13 @@ func main() {
 }
 
 func NewConfig() (*config.Config, error) {
-	return config.NewConfig()
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return nil, err
+	}
+	// first thing, so everything after logs in the configured format
+	logger.Init(cfg.Log)
+	return cfg, nil
 }
 
 func NewFirebaseAuth(cfg *config.Config) (*auth.Client, error) {
@@ -134,26 +171,102 @@ func NewAuthMiddleware(firebaseAuth *auth.Client, userRepo repository.UserReposi
 
 func NewGinEngine(cfg *config.Config) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
+	apperror.UseJSONFieldNames()
 	r := gin.New()
-	r.Use(gin.Recovery())
+	r.Use(
+		middleware.RequestID(),
+		middleware.RequestLogger(),
+		middleware.ErrorHandler(),
+		middleware.Recovery(),
+		middleware.Timeout(cfg.Timeout),
+	)
 
 	// Configure CORS
 	r.Use(cors.New(cors.Config{
 		//AllowOrigins: []string{"*"},
 		AllowOrigins:     []string{cfg.Domain, "https://api-merchant.payos.vn", "https://3da59b85ac29.ngrok-free.app", "http://localhost:8081"}, // Add your frontend URLs
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	r.NoRoute(func(c *gin.Context) {
+		c.Error(apperror.NotFound("route not found"))
+	})
+
 	// Add swagger route
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	return r
 }
 
+func RegisterJobs(
+	scheduler *jobs.Scheduler,
+	cfg *config.Config,
+	inventoryService service.InventoryService,
+	priceTable *service.PriceTableService,
+	productStats *service.ProductStatsService,
+	recommendations service.RecommendationService,
+	importantDates service.ImportantDateService,
+) {
+	scheduler.Register(jobs.Job{
+		Name:     "inventory-freshness",
+		Interval: cfg.Inventory.FreshnessInterval,
+		Run: func(ctx context.Context) error {
+			report, err := inventoryService.RefreshFreshness(ctx, time.Now(), "system:freshness-job")
+			if err != nil {
+				return err
+			}
+			log.Info().Interface("report", report).Msg("Inventory freshness refreshed")
+			return nil
+		},
+	})
+	scheduler.Register(jobs.Job{
+		Name:     "effective-prices",
+		Interval: cfg.Pricing.PriceTableInterval,
+		Run: func(ctx context.Context) error {
+			return priceTable.EnsureFresh(ctx, time.Now())
+		},
+	})
+	scheduler.Register(jobs.Job{
+		Name:     "product-stats",
+		Interval: cfg.Stats.RebuildInterval,
+		Run: func(ctx context.Context) error {
+			return productStats.Rebuild(ctx, time.Now())
+		},
+	})
+	scheduler.Register(jobs.Job{
+		Name:     "trending-products",
+		Interval: cfg.Recommendation.TrendingInterval,
+		Run: func(ctx context.Context) error {
+			return recommendations.UpdateTrendingProducts(ctx)
+		},
+	})
+	scheduler.Register(jobs.Job{
+		Name:     "product-similarity",
+		Interval: cfg.Recommendation.SimilarityInterval,
+		Run: func(ctx context.Context) error {
+			return recommendations.UpdateSimilarityMatrix(ctx)
+		},
+	})
+	scheduler.Register(jobs.Job{
+		Name:     "preference-learner",
+		Interval: cfg.Recommendation.PreferenceInterval,
+		Run: func(ctx context.Context) error {
+			return recommendations.RefreshPreferences(ctx)
+		},
+	})
+	scheduler.Register(jobs.Job{
+		Name:     "occasion-reminders",
+		Interval: cfg.Reminder.Interval,
+		Run: func(ctx context.Context) error {
+			return importantDates.SendDueReminders(ctx)
+		},
+	})
+}
+
 func RegisterRoutes(
 	lifecycle fx.Lifecycle,
 	router *gin.Engine,
@@ -169,10 +282,17 @@ func RegisterRoutes(
 	authMiddleware *middleware.AuthMiddleware,
 	paymentCtrl *controller.PaymentController,
 	reportCtrl *controller.ReportController,
+	inventoryCtrl *controller.InventoryController,
+	notificationCtrl *controller.NotificationController,
+	catalogCtrl *controller.CatalogController,
+	recommendationCtrl *controller.RecommendationController,
+	importantDateCtrl *controller.ImportantDateController,
 ) {
 
 	payos.InitPayOS(cfg)
-	controller.RegisterRoutes(router)
+	controller.RegisterRoutes(router, authMiddleware)
+	recommendationCtrl.RegisterRoutes(router, authMiddleware)
+	router.Static(cfg.Storage.PublicURL, cfg.Storage.LocalDir)
 
 	v1 := router.Group("/api/v1")
 	authCtrl.RegisterRoutes(v1, authMiddleware)
@@ -182,17 +302,25 @@ func RegisterRoutes(
 
 	v1.Use(authMiddleware.RequireAuth())
 
-	reviewCtrl.RegisterRoutes(v1)
+	reviewCtrl.RegisterRoutes(v1, authMiddleware)
 	cartCtrl.RegisterRoutes(v1)
 	orderCtrl.RegisterRoutes(v1)
 	addressCtrl.RegisterRoutes(v1)
 	reportCtrl.RegisterRoutes(v1)
-
-	logger.Init()
-
+	inventoryCtrl.RegisterRoutes(v1, authMiddleware)
+	notificationCtrl.RegisterRoutes(v1)
+	catalogCtrl.RegisterRoutes(v1, authMiddleware)
+	recommendationCtrl.RegisterUserRoutes(v1)
+	recommendationCtrl.RegisterAdminRoutes(v1, authMiddleware)
+	importantDateCtrl.RegisterRoutes(v1)
+
+	// Request contexts derive from this one, so requests still running when
+	// the shutdown grace period ends have their queries cancelled
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
 	server := &http.Server{
-		Addr:    ":" + cfg.Server.Port,
-		Handler: router,
+		Addr:        ":" + cfg.Server.Port,
+		Handler:     router,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
 	}
 
 	lifecycle.Append(fx.Hook{
@@ -207,7 +335,9 @@ func RegisterRoutes(
 		},
 		OnStop: func(ctx context.Context) error {
 			log.Info().Msg("Shutting down server")
-			return server.Shutdown(ctx)
+			err := server.Shutdown(ctx)
+			cancelRequests()
+			return err
 		},
 	})
 }
@@ -1,17 +1,29 @@
 package config
 
 import (
+	"strings"
+	"time"
+
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server       ServerConfig
-	Database     DatabaseConfig
-	Firebase     FirebaseConfig
-	Domain       string
-	IsProduction bool
-	PayOS        PayOSConfig
+	Server         ServerConfig
+	Database       DatabaseConfig
+	Firebase       FirebaseConfig
+	Domain         string
+	IsProduction   bool
+	PayOS          PayOSConfig
+	Inventory      InventoryConfig
+	Storage        StorageConfig
+	Pricing        PricingConfig
+	Stats          StatsConfig
+	Review         ReviewConfig
+	Recommendation RecommendationConfig
+	Reminder       ReminderConfig
+	Timeout        TimeoutConfig
+	Log            LogConfig
 }
 
 type ServerConfig struct {
@@ -31,6 +43,63 @@ type FirebaseConfig struct {
 	APIKey          string
 }
 
+type InventoryConfig struct {
+	NearExpiryDays    int
+	FreshnessInterval time.Duration
+}
+
+type PricingConfig struct {
+	// how often the effective price table is checked for expired prices
+	PriceTableInterval time.Duration
+}
+
+type StatsConfig struct {
+	// how often product rating and sales aggregates are fully rebuilt
+	RebuildInterval time.Duration
+}
+
+type RecommendationConfig struct {
+	// how often trending products are recomputed
+	TrendingInterval time.Duration
+	// how often the item-item similarity matrix is rebuilt
+	SimilarityInterval time.Duration
+	// how often user preference profiles learn from new behavior
+	PreferenceInterval time.Duration
+}
+
+type ReminderConfig struct {
+	// how often due occasion reminders are sent
+	Interval time.Duration
+}
+
+type LogConfig struct {
+	// zerolog level name: trace, debug, info, warn, error
+	Level string
+	// json for log collectors, console for human-readable local output
+	Format string
+}
+
+type TimeoutConfig struct {
+	// how long a request may spend on database and cache calls
+	Default time.Duration
+	// overrides for slow routes, keyed by route prefix
+	Routes map[string]time.Duration
+}
+
+type ReviewConfig struct {
+	// words held for moderation on top of the built-in list
+	BlockedWords []string
+}
+
+type StorageConfig struct {
+	LocalDir      string
+	PublicURL     string
+	MaxImageBytes int64
+	MinImageSize  int
+	MaxImageSize  int
+	ThumbnailSize int
+}
+
 type PayOSConfig struct {
 	ClientID    string
 	APIKey      string
@@ -64,12 +133,119 @@ func NewConfig() (*Config, error) {
 	config.Domain = viper.GetString("DOMAIN")
 	config.IsProduction = viper.GetBool("IS_PRODUCTION")
 
+	// Logging
+	config.Log.Level = strings.ToLower(viper.GetString("LOG_LEVEL"))
+	config.Log.Format = strings.ToLower(viper.GetString("LOG_FORMAT"))
+	if config.Log.Level == "" {
+		config.Log.Level = "info"
+	}
+	if config.Log.Format == "" {
+		config.Log.Format = "json"
+	}
+
 	// PayOS
 	config.PayOS.ClientID = viper.GetString("PAYOS_CLIENT_ID")
 	config.PayOS.APIKey = viper.GetString("PAYOS_API_KEY")
 	config.PayOS.ChecksumKey = viper.GetString("PAYOS_CHECKSUM_KEY")
 	config.PayOS.Domain = viper.GetString("PAYOS_DOMAIN")
 
+	// Inventory
+	config.Inventory.NearExpiryDays = viper.GetInt("INVENTORY_NEAR_EXPIRY_DAYS")
+	config.Inventory.FreshnessInterval = viper.GetDuration("INVENTORY_FRESHNESS_INTERVAL")
+	if config.Inventory.NearExpiryDays <= 0 {
+		config.Inventory.NearExpiryDays = 2
+	}
+	if config.Inventory.FreshnessInterval <= 0 {
+		config.Inventory.FreshnessInterval = time.Hour
+	}
+
+	// Pricing
+	config.Pricing.PriceTableInterval = viper.GetDuration("PRICING_PRICE_TABLE_INTERVAL")
+	if config.Pricing.PriceTableInterval <= 0 {
+		config.Pricing.PriceTableInterval = time.Minute
+	}
+
+	// Product statistics
+	config.Stats.RebuildInterval = viper.GetDuration("STATS_REBUILD_INTERVAL")
+	if config.Stats.RebuildInterval <= 0 {
+		config.Stats.RebuildInterval = time.Hour
+	}
+
+	// Recommendations
+	config.Recommendation.TrendingInterval = viper.GetDuration("RECOMMENDATION_TRENDING_INTERVAL")
+	if config.Recommendation.TrendingInterval <= 0 {
+		config.Recommendation.TrendingInterval = time.Hour
+	}
+	config.Recommendation.SimilarityInterval = viper.GetDuration("RECOMMENDATION_SIMILARITY_INTERVAL")
+	if config.Recommendation.SimilarityInterval <= 0 {
+		config.Recommendation.SimilarityInterval = 6 * time.Hour
+	}
+	config.Recommendation.PreferenceInterval = viper.GetDuration("RECOMMENDATION_PREFERENCE_INTERVAL")
+	if config.Recommendation.PreferenceInterval <= 0 {
+		config.Recommendation.PreferenceInterval = 15 * time.Minute
+	}
+
+	// Occasion reminders
+	config.Reminder.Interval = viper.GetDuration("REMINDER_INTERVAL")
+	if config.Reminder.Interval <= 0 {
+		config.Reminder.Interval = time.Hour
+	}
+
+	// Request timeouts
+	config.Timeout.Default = viper.GetDuration("REQUEST_TIMEOUT")
+	if config.Timeout.Default <= 0 {
+		config.Timeout.Default = 15 * time.Second
+	}
+	config.Timeout.Routes = map[string]time.Duration{
+		"/api/v1/admin/reports": time.Minute,
+		"/api/v1/admin/catalog": 2 * time.Minute,
+	}
+	for _, entry := range strings.Split(viper.GetString("REQUEST_TIMEOUT_ROUTES"), ",") {
+		route, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d <= 0 {
+			log.Warn().Str("entry", entry).Msg("Ignoring invalid route timeout")
+			continue
+		}
+		config.Timeout.Routes[strings.TrimSpace(route)] = d
+	}
+
+	// Reviews
+	for _, w := range strings.Split(viper.GetString("REVIEW_BLOCKED_WORDS"), ",") {
+		if w = strings.TrimSpace(w); w != "" {
+			config.Review.BlockedWords = append(config.Review.BlockedWords, w)
+		}
+	}
+
+	// Storage
+	config.Storage.LocalDir = viper.GetString("STORAGE_LOCAL_DIR")
+	config.Storage.PublicURL = viper.GetString("STORAGE_PUBLIC_URL")
+	config.Storage.MaxImageBytes = viper.GetInt64("STORAGE_MAX_IMAGE_BYTES")
+	config.Storage.MinImageSize = viper.GetInt("STORAGE_MIN_IMAGE_SIZE")
+	config.Storage.MaxImageSize = viper.GetInt("STORAGE_MAX_IMAGE_SIZE")
+	config.Storage.ThumbnailSize = viper.GetInt("STORAGE_THUMBNAIL_SIZE")
+	if config.Storage.LocalDir == "" {
+		config.Storage.LocalDir = "uploads"
+	}
+	if config.Storage.PublicURL == "" {
+		config.Storage.PublicURL = "/uploads"
+	}
+	if config.Storage.MaxImageBytes <= 0 {
+		config.Storage.MaxImageBytes = 5 << 20
+	}
+	if config.Storage.MinImageSize <= 0 {
+		config.Storage.MinImageSize = 200
+	}
+	if config.Storage.MaxImageSize <= 0 {
+		config.Storage.MaxImageSize = 6000
+	}
+	if config.Storage.ThumbnailSize <= 0 {
+		config.Storage.ThumbnailSize = 320
+	}
+
 	// Set default Firebase credentials path if not specified
 	if config.Firebase.CredentialsPath == "" {
 		config.Firebase.CredentialsPath = "private_key.json"
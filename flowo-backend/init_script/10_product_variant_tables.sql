@@ -0,0 +1,47 @@
+-- Product Variant Tables
+-- A product (e.g. "Red Rose Bouquet") can be sold in several variants
+-- (12/24/50 stems, different colors or wrapping), each with its own SKU,
+-- price delta and stock.
+
+USE flowo_db;
+
+-- Table: ProductVariant
+CREATE TABLE IF NOT EXISTS ProductVariant (
+    variant_id INT PRIMARY KEY AUTO_INCREMENT,
+    product_id INT NOT NULL,
+    sku VARCHAR(100) NOT NULL UNIQUE COMMENT 'Stock keeping unit, unique across the catalog',
+    name VARCHAR(255) COMMENT 'Display name, e.g. 24 stems - Kraft paper',
+    size VARCHAR(50) COMMENT 'e.g. 12 stems, 24 stems, 50 stems',
+    color VARCHAR(50) COMMENT 'e.g. Red, White, Mixed',
+    wrapping VARCHAR(50) COMMENT 'e.g. Kraft paper, Box, Vase',
+    price_delta DECIMAL(10, 2) NOT NULL DEFAULT 0.00 COMMENT 'Added to the product base price',
+    stock_quantity INT NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (product_id) REFERENCES FlowerProduct(product_id),
+    INDEX idx_variant_product (product_id, is_active),
+    INDEX idx_variant_size (size),
+    INDEX idx_variant_color (color),
+    INDEX idx_variant_wrapping (wrapping)
+);
+
+-- Cart and order lines reference the variant that was chosen (NULL for products without variants)
+ALTER TABLE CartItem
+ADD COLUMN variant_id INT NULL,
+ADD FOREIGN KEY (variant_id) REFERENCES ProductVariant(variant_id);
+
+ALTER TABLE OrderItem
+ADD COLUMN variant_id INT NULL,
+ADD FOREIGN KEY (variant_id) REFERENCES ProductVariant(variant_id);
+
+-- Pricing rules can target a single variant
+ALTER TABLE PricingRule
+ADD COLUMN applicable_variant_id INT NULL,
+ADD FOREIGN KEY (applicable_variant_id) REFERENCES ProductVariant(variant_id);
+
+-- Sample variants for the seeded catalog
+INSERT IGNORE INTO ProductVariant (product_id, sku, name, size, color, wrapping, price_delta, stock_quantity) VALUES
+(1, 'ROSE-RED-12-KRAFT', '12 stems - Kraft paper', '12 stems', 'Red', 'Kraft paper', 0.00, 40),
+(1, 'ROSE-RED-24-KRAFT', '24 stems - Kraft paper', '24 stems', 'Red', 'Kraft paper', 20.00, 25),
+(1, 'ROSE-RED-50-BOX', '50 stems - Gift box', '50 stems', 'Red', 'Box', 55.00, 10);
@@ -0,0 +1,59 @@
+-- Perishable Inventory Tables
+-- Stock is received in batches with a received date and shelf life. Sales
+-- consume batches first-in-first-out and expired batches are written off
+-- as wastage. Runs after 10_product_variant_tables.sql.
+
+USE flowo_db;
+
+-- Table: InventoryBatch
+CREATE TABLE IF NOT EXISTS InventoryBatch (
+    batch_id INT PRIMARY KEY AUTO_INCREMENT,
+    product_id INT NOT NULL,
+    variant_id INT NULL COMMENT 'NULL when the batch is stock of the product itself',
+    quantity_received INT NOT NULL,
+    quantity_remaining INT NOT NULL,
+    received_date DATETIME NOT NULL,
+    shelf_life_days INT NOT NULL,
+    expires_at DATETIME NOT NULL COMMENT 'received_date + shelf_life_days',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (product_id) REFERENCES FlowerProduct(product_id),
+    FOREIGN KEY (variant_id) REFERENCES ProductVariant(variant_id),
+    INDEX idx_batch_fifo (product_id, variant_id, received_date),
+    INDEX idx_batch_expiry (expires_at, quantity_remaining),
+    CHECK (quantity_remaining >= 0 AND quantity_remaining <= quantity_received),
+    CHECK (shelf_life_days > 0)
+);
+
+-- Table: BatchConsumption
+-- Which batches an order was served from, so a cancellation can put the stock back
+CREATE TABLE IF NOT EXISTS BatchConsumption (
+    consumption_id INT PRIMARY KEY AUTO_INCREMENT,
+    order_id INT NOT NULL,
+    batch_id INT NOT NULL,
+    quantity INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (order_id) REFERENCES `Order`(order_id),
+    FOREIGN KEY (batch_id) REFERENCES InventoryBatch(batch_id),
+    INDEX idx_consumption_order (order_id)
+);
+
+-- Table: Wastage
+CREATE TABLE IF NOT EXISTS Wastage (
+    wastage_id INT PRIMARY KEY AUTO_INCREMENT,
+    batch_id INT NOT NULL,
+    product_id INT NOT NULL,
+    variant_id INT NULL,
+    quantity INT NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (batch_id) REFERENCES InventoryBatch(batch_id),
+    FOREIGN KEY (product_id) REFERENCES FlowerProduct(product_id),
+    FOREIGN KEY (variant_id) REFERENCES ProductVariant(variant_id),
+    INDEX idx_wastage_product (product_id, recorded_at)
+);
+
+-- Seed one batch per product for its current stock so FIFO has something to consume
+INSERT INTO InventoryBatch (product_id, quantity_received, quantity_remaining, received_date, shelf_life_days, expires_at)
+SELECT product_id, stock_quantity, stock_quantity, NOW(), 7, DATE_ADD(NOW(), INTERVAL 7 DAY)
+FROM FlowerProduct
+WHERE stock_quantity > 0;
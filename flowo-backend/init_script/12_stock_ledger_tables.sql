@@ -0,0 +1,66 @@
+-- Inventory Ledger Tables
+-- Every stock change is appended to InventoryMovement with the actor and
+-- reason, so stock can be derived by summing quantity_change. Runs after
+-- 11_stock_batch_tables.sql.
+
+USE flowo_db;
+
+-- Table: InventoryMovement (append-only)
+CREATE TABLE IF NOT EXISTS InventoryMovement (
+    movement_id INT PRIMARY KEY AUTO_INCREMENT,
+    product_id INT NOT NULL,
+    variant_id INT NULL COMMENT 'NULL when the movement is on the product stock itself',
+    movement_type VARCHAR(30) NOT NULL COMMENT "('sale', 'cancellation_restock', 'manual_adjustment', 'receipt', 'wastage')",
+    quantity_change INT NOT NULL COMMENT 'Positive adds stock, negative removes it',
+    stock_after INT NOT NULL COMMENT 'Stock counter right after this movement',
+    actor VARCHAR(255) NOT NULL COMMENT 'Firebase UID of the user, or system:<job> for background work',
+    reason VARCHAR(255),
+    reference_id INT NULL COMMENT 'order_id for sales/restocks, batch_id for receipts/wastage',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (product_id) REFERENCES FlowerProduct(product_id),
+    FOREIGN KEY (variant_id) REFERENCES ProductVariant(variant_id),
+    INDEX idx_movement_product (product_id, variant_id, created_at),
+    CHECK (movement_type IN ('sale', 'cancellation_restock', 'manual_adjustment', 'receipt', 'wastage'))
+);
+
+DELIMITER //
+CREATE TRIGGER inventory_movement_no_update BEFORE UPDATE ON InventoryMovement
+FOR EACH ROW
+BEGIN
+    SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'InventoryMovement is append-only';
+END //
+
+CREATE TRIGGER inventory_movement_no_delete BEFORE DELETE ON InventoryMovement
+FOR EACH ROW
+BEGIN
+    SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'InventoryMovement is append-only';
+END //
+DELIMITER ;
+
+-- Per-product low-stock threshold; variants use the threshold of their product
+ALTER TABLE FlowerProduct
+ADD COLUMN low_stock_threshold INT NOT NULL DEFAULT 5;
+
+-- Table: Notification
+CREATE TABLE IF NOT EXISTS Notification (
+    notification_id INT PRIMARY KEY AUTO_INCREMENT,
+    firebase_uid VARCHAR(255) NOT NULL,
+    type VARCHAR(50) NOT NULL COMMENT "e.g. 'low_stock'",
+    title VARCHAR(255) NOT NULL,
+    message TEXT,
+    is_read BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (firebase_uid) REFERENCES User(firebase_uid),
+    INDEX idx_notification_user (firebase_uid, is_read, created_at)
+);
+
+-- Opening balances so the ledger sums to the seeded stock
+INSERT INTO InventoryMovement (product_id, variant_id, movement_type, quantity_change, stock_after, actor, reason)
+SELECT product_id, NULL, 'receipt', stock_quantity, stock_quantity, 'system', 'opening balance'
+FROM FlowerProduct
+WHERE stock_quantity > 0;
+
+INSERT INTO InventoryMovement (product_id, variant_id, movement_type, quantity_change, stock_after, actor, reason)
+SELECT product_id, variant_id, 'receipt', stock_quantity, stock_quantity, 'system', 'opening balance'
+FROM ProductVariant
+WHERE stock_quantity > 0;
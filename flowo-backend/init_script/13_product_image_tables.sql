@@ -0,0 +1,12 @@
+-- Product Image Storage
+-- Uploaded images are kept in the configured storage backend; the row keeps
+-- the storage keys so files can be removed together with the row.
+
+USE flowo_db;
+
+ALTER TABLE ProductImage
+ADD COLUMN thumbnail_url VARCHAR(512) NULL AFTER image_url,
+ADD COLUMN storage_key VARCHAR(255) NULL COMMENT 'Key of the original in storage, NULL for external URLs',
+ADD COLUMN thumbnail_key VARCHAR(255) NULL COMMENT 'Key of the thumbnail in storage',
+ADD COLUMN created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+ADD INDEX idx_product_image_product (product_id, is_primary);
@@ -0,0 +1,13 @@
+-- Search Query Statistics
+-- Counts searches per normalized query so popular queries can be offered as
+-- autocomplete suggestions.
+
+USE flowo_db;
+
+CREATE TABLE IF NOT EXISTS SearchQuery (
+    normalized_query VARCHAR(255) PRIMARY KEY COMMENT 'Lowercased, accent-folded query',
+    display_query VARCHAR(255) NOT NULL COMMENT 'Most recent spelling as typed',
+    search_count INT NOT NULL DEFAULT 0,
+    last_searched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_search_query_count (search_count DESC)
+);
@@ -0,0 +1,14 @@
+-- Product Effective Price Table
+-- Precomputed price of every active product after pricing rules, so search
+-- can filter, sort and paginate on the price customers actually pay.
+
+USE flowo_db;
+
+CREATE TABLE IF NOT EXISTS ProductEffectivePrice (
+    product_id INT PRIMARY KEY,
+    effective_price DECIMAL(10,2) NOT NULL,
+    computed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    valid_until TIMESTAMP NULL COMMENT 'Next time a pricing rule starts or stops applying',
+    FOREIGN KEY (product_id) REFERENCES FlowerProduct(product_id) ON DELETE CASCADE,
+    INDEX idx_effective_price (effective_price)
+);
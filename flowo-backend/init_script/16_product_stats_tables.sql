@@ -0,0 +1,20 @@
+-- Product Statistics Table
+-- Denormalized review and sales aggregates per product, kept up to date on
+-- review creation and order completion, so listings can show and sort by
+-- rating and best sellers without aggregating on every query.
+
+USE flowo_db;
+
+CREATE TABLE IF NOT EXISTS ProductStats (
+    product_id INT PRIMARY KEY,
+    average_rating DECIMAL(3,2) NOT NULL DEFAULT 0,
+    review_count INT NOT NULL DEFAULT 0,
+    units_sold_7d INT NOT NULL DEFAULT 0,
+    units_sold_30d INT NOT NULL DEFAULT 0,
+    units_sold_total INT NOT NULL DEFAULT 0,
+    sales_rank INT NULL COMMENT '1 = most units sold in the last 30 days; NULL if never sold',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (product_id) REFERENCES FlowerProduct(product_id) ON DELETE CASCADE,
+    INDEX idx_product_stats_rating (average_rating DESC, review_count DESC),
+    INDEX idx_product_stats_rank (sales_rank)
+);
@@ -0,0 +1,18 @@
+-- Review Moderation
+-- Ties reviews to the purchased order item (verified purchase, one review
+-- per order item) and adds the moderation workflow. Existing reviews stay
+-- published as unverified.
+
+USE flowo_db;
+
+ALTER TABLE Review
+    ADD COLUMN order_item_id INT NULL COMMENT 'Purchased order item; NULL for reviews written before verification',
+    ADD COLUMN status ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'approved',
+    ADD COLUMN moderation_reason VARCHAR(255) NULL COMMENT 'Filter findings or the moderator''s note',
+    ADD COLUMN moderated_by VARCHAR(255) NULL,
+    ADD COLUMN moderated_at TIMESTAMP NULL,
+    ADD COLUMN updated_at TIMESTAMP NULL,
+    ADD UNIQUE KEY uq_review_order_item (order_item_id),
+    ADD FOREIGN KEY (order_item_id) REFERENCES OrderItem(order_item_id) ON DELETE SET NULL,
+    ADD INDEX idx_review_status (status, review_date),
+    ADD INDEX idx_review_product_status (product_id, status, review_date);
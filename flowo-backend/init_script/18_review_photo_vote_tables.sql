@@ -0,0 +1,35 @@
+-- Review Photos and Helpfulness Votes
+-- Photos are kept in the configured storage backend; the row keeps the
+-- storage keys so files can be removed together with the review. Vote
+-- counts are denormalized on Review for sorting by most helpful.
+
+USE flowo_db;
+
+CREATE TABLE IF NOT EXISTS ReviewPhoto (
+    photo_id INT PRIMARY KEY AUTO_INCREMENT,
+    review_id INT NOT NULL,
+    image_url VARCHAR(512) NOT NULL,
+    thumbnail_url VARCHAR(512) NOT NULL,
+    storage_key VARCHAR(255) NOT NULL COMMENT 'Key of the original in storage',
+    thumbnail_key VARCHAR(255) NOT NULL COMMENT 'Key of the thumbnail in storage',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (review_id) REFERENCES Review(review_id) ON DELETE CASCADE,
+    INDEX idx_review_photo_review (review_id, photo_id)
+);
+
+CREATE TABLE IF NOT EXISTS ReviewVote (
+    review_id INT NOT NULL,
+    firebase_uid VARCHAR(255) NOT NULL,
+    helpful BOOLEAN NOT NULL COMMENT 'TRUE for helpful, FALSE for not helpful',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (review_id, firebase_uid),
+    FOREIGN KEY (review_id) REFERENCES Review(review_id) ON DELETE CASCADE,
+    FOREIGN KEY (firebase_uid) REFERENCES User(firebase_uid) ON DELETE CASCADE
+);
+
+ALTER TABLE Review
+    ADD COLUMN helpful_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN not_helpful_count INT NOT NULL DEFAULT 0,
+    ADD INDEX idx_review_product_helpful (product_id, status, helpful_count),
+    ADD INDEX idx_review_product_rating (product_id, status, rating);
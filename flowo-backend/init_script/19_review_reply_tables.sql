@@ -0,0 +1,18 @@
+-- Review Replies
+-- Public replies by shop staff on reviews. A reply may answer another
+-- reply of the same review, forming a thread.
+
+USE flowo_db;
+
+CREATE TABLE IF NOT EXISTS ReviewReply (
+    reply_id INT PRIMARY KEY AUTO_INCREMENT,
+    review_id INT NOT NULL,
+    parent_reply_id INT NULL COMMENT 'Reply this one answers; NULL for a reply to the review itself',
+    firebase_uid VARCHAR(255) NOT NULL COMMENT 'Staff member who wrote the reply',
+    message TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (review_id) REFERENCES Review(review_id) ON DELETE CASCADE,
+    FOREIGN KEY (parent_reply_id) REFERENCES ReviewReply(reply_id) ON DELETE CASCADE,
+    FOREIGN KEY (firebase_uid) REFERENCES User(firebase_uid),
+    INDEX idx_review_reply_review (review_id, created_at)
+);
@@ -0,0 +1,28 @@
+-- Recommendation Engine Configuration
+-- Single-row table holding the blend weights and thresholds of the
+-- recommendation engine, editable by admins at runtime.
+
+USE flowo_db;
+
+CREATE TABLE IF NOT EXISTS RecommendationConfig (
+    config_id TINYINT PRIMARY KEY DEFAULT 1,
+    collaborative_weight DECIMAL(5, 4) NOT NULL,
+    content_weight DECIMAL(5, 4) NOT NULL,
+    popularity_weight DECIMAL(5, 4) NOT NULL,
+    trending_weight DECIMAL(5, 4) NOT NULL,
+    min_similarity DECIMAL(5, 4) NOT NULL COMMENT 'Similarity below this is not stored or recommended',
+    min_interactions INT NOT NULL COMMENT 'Interactions a user needs before getting a personalized blend',
+    max_per_flower_type INT NOT NULL COMMENT 'Most items of one flower type in a personalized list',
+    updated_by VARCHAR(255) NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CHECK (config_id = 1),
+    CHECK (collaborative_weight BETWEEN 0 AND 1 AND content_weight BETWEEN 0 AND 1
+        AND popularity_weight BETWEEN 0 AND 1 AND trending_weight BETWEEN 0 AND 1),
+    CHECK (min_similarity BETWEEN 0 AND 1),
+    CHECK (min_interactions >= 0 AND max_per_flower_type >= 1)
+);
+
+INSERT IGNORE INTO RecommendationConfig
+    (config_id, collaborative_weight, content_weight, popularity_weight, trending_weight,
+     min_similarity, min_interactions, max_per_flower_type)
+VALUES (1, 0.4, 0.3, 0.2, 0.1, 0.1, 3, 3);
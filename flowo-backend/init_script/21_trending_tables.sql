@@ -0,0 +1,17 @@
+-- Trending Products
+-- Trend scores are now computed by the application from OrderItem sales and
+-- UserProductInteraction views, so the database events that overwrote them
+-- are removed. Purchases can exceed tracked views (views of anonymous or
+-- untracked clients), so that check is dropped as well.
+
+USE flowo_db;
+
+DROP EVENT IF EXISTS UpdateDailyTrending;
+DROP EVENT IF EXISTS UpdateWeeklyTrending;
+DROP EVENT IF EXISTS UpdateMonthlyTrending;
+DROP PROCEDURE IF EXISTS UpdateTrendingProducts;
+
+ALTER TABLE TrendingProduct DROP CHECK chk_trending_counts;
+
+CREATE INDEX idx_user_interaction_type_timestamp
+ON UserProductInteraction(interaction_type, timestamp);
@@ -0,0 +1,17 @@
+-- Product Similarity Types
+-- Content, collaborative and hybrid similarities are stored side by side,
+-- so the similarity type becomes part of the key. Collaborative and hybrid
+-- rows keep the lower product id in product_id_1. Runs after
+-- 03_recommendation_tables.sql, which creates ProductSimilarity.
+
+USE flowo_db;
+
+ALTER TABLE ProductSimilarity
+    DROP PRIMARY KEY,
+    ADD PRIMARY KEY (product_id_1, product_id_2, similarity_type),
+    ADD INDEX idx_similarity_type_product1 (similarity_type, product_id_1, similarity_score),
+    ADD INDEX idx_similarity_type_product2 (similarity_type, product_id_2, similarity_score);
+
+ALTER TABLE ProductSimilarity
+    MODIFY similarity_type VARCHAR(50) NOT NULL DEFAULT 'content' COMMENT 'Type of similarity: content, collaborative, hybrid',
+    ADD CONSTRAINT chk_similarity_type CHECK (similarity_type IN ('content', 'collaborative', 'hybrid'));
@@ -0,0 +1,59 @@
+-- Recommendation A/B Experiments
+-- Experiments split signed-in users deterministically into variants that
+-- override the blend weights. Every recommendation list served to a
+-- signed-in user is logged as an impression, and feedback rows carry the
+-- variant the user was in, so CTR and conversion can be compared per variant.
+
+USE flowo_db;
+
+CREATE TABLE IF NOT EXISTS RecommendationExperiment (
+    experiment_id INT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(500) NULL,
+    status ENUM('draft', 'running', 'stopped') NOT NULL DEFAULT 'draft' COMMENT 'At most one experiment runs at a time',
+    created_by VARCHAR(255) NULL,
+    started_at TIMESTAMP NULL,
+    stopped_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_experiment_name (name),
+    INDEX idx_experiment_status (status)
+);
+
+-- Weight columns left NULL keep the global RecommendationConfig value; a
+-- weight of 0 turns that strategy off for the variant
+CREATE TABLE IF NOT EXISTS RecommendationExperimentVariant (
+    variant_id INT PRIMARY KEY AUTO_INCREMENT,
+    experiment_id INT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    traffic_percent INT NOT NULL COMMENT 'Share of users; the variants of an experiment add up to 100',
+    collaborative_weight DECIMAL(5, 4) NULL,
+    content_weight DECIMAL(5, 4) NULL,
+    popularity_weight DECIMAL(5, 4) NULL,
+    trending_weight DECIMAL(5, 4) NULL,
+    max_per_flower_type INT NULL,
+    FOREIGN KEY (experiment_id) REFERENCES RecommendationExperiment(experiment_id) ON DELETE CASCADE,
+    UNIQUE KEY uq_experiment_variant (experiment_id, name),
+    CHECK (traffic_percent BETWEEN 1 AND 100)
+);
+
+CREATE TABLE IF NOT EXISTS RecommendationImpression (
+    impression_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    firebase_uid VARCHAR(255) NOT NULL,
+    recommendation_type VARCHAR(50) NOT NULL,
+    experiment_id INT NULL,
+    variant VARCHAR(50) NULL,
+    product_count INT NOT NULL,
+    average_score DECIMAL(5, 4) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (firebase_uid) REFERENCES User(firebase_uid) ON DELETE CASCADE,
+    FOREIGN KEY (experiment_id) REFERENCES RecommendationExperiment(experiment_id) ON DELETE SET NULL,
+    INDEX idx_impression_created (created_at),
+    INDEX idx_impression_experiment (experiment_id, variant)
+);
+
+ALTER TABLE RecommendationFeedback
+    ADD COLUMN experiment_id INT NULL AFTER session_id,
+    ADD COLUMN variant VARCHAR(50) NULL AFTER experiment_id,
+    ADD CONSTRAINT fk_feedback_experiment FOREIGN KEY (experiment_id)
+        REFERENCES RecommendationExperiment(experiment_id) ON DELETE SET NULL,
+    ADD INDEX idx_feedback_experiment (experiment_id, variant, action);
@@ -0,0 +1,24 @@
+-- Learned User Preferences
+-- The preference learner folds purchases, reviews, cart adds and
+-- recommendation feedback into time-decayed affinities. The raw decayed
+-- sums are kept next to the normalized preferences so that each refresh
+-- only has to add the signals since learned_at. When a signal is undone
+-- (an order cancelled, a review edited or rejected) the scores are learned
+-- again from learned_from.
+
+USE flowo_db;
+
+ALTER TABLE UserPreference
+    ADD COLUMN flower_scores JSON NULL COMMENT 'Decayed flower type affinity sums, normalized into flower_preferences',
+    ADD COLUMN occasion_scores JSON NULL COMMENT 'Decayed occasion affinity sums, normalized into occasion_preferences',
+    ADD COLUMN price_weight DOUBLE NOT NULL DEFAULT 0 COMMENT 'Decayed units bought, weighting the price statistics',
+    ADD COLUMN price_sum DOUBLE NOT NULL DEFAULT 0,
+    ADD COLUMN price_square_sum DOUBLE NOT NULL DEFAULT 0,
+    ADD COLUMN learned_at TIMESTAMP NULL COMMENT 'Signals up to this time are folded into the scores',
+    ADD COLUMN learned_from TIMESTAMP NULL COMMENT 'Signals before this time are ignored; set when the user resets their preferences';
+
+-- Signals are read per user and time
+ALTER TABLE UserProductInteraction
+    ADD INDEX idx_user_interaction_user_timestamp (firebase_uid, timestamp);
+ALTER TABLE RecommendationFeedback
+    ADD INDEX idx_feedback_user_created (firebase_uid, created_at);
@@ -0,0 +1,27 @@
+-- Important Dates
+-- Customers save birthdays, anniversaries and other recurring occasions
+-- for the people they buy for. A scheduler reminds them a few days ahead
+-- with occasion-based recommendations; last_reminded_for records the
+-- occurrence already reminded so each one is only sent once.
+
+USE flowo_db;
+
+CREATE TABLE IF NOT EXISTS ImportantDate (
+    date_id INT PRIMARY KEY AUTO_INCREMENT,
+    firebase_uid VARCHAR(255) NOT NULL,
+    recipient_name VARCHAR(255) NOT NULL,
+    occasion_id INT NOT NULL,
+    address_id INT NULL COMMENT 'Recipient address from the address book',
+    event_date DATE NOT NULL COMMENT 'For recurring dates only month and day are used',
+    recurring BOOLEAN NOT NULL DEFAULT TRUE COMMENT 'Repeats every year',
+    remind_days_before INT NOT NULL DEFAULT 7,
+    note VARCHAR(500) NULL,
+    last_reminded_for DATE NULL COMMENT 'Occurrence the last reminder was sent for',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (firebase_uid) REFERENCES User(firebase_uid) ON DELETE CASCADE,
+    FOREIGN KEY (occasion_id) REFERENCES Occasion(occasion_id),
+    FOREIGN KEY (address_id) REFERENCES Address(address_id) ON DELETE SET NULL,
+    CHECK (remind_days_before BETWEEN 0 AND 60),
+    INDEX idx_important_date_user (firebase_uid, event_date)
+);
@@ -0,0 +1,250 @@
+// Package apperror defines the typed errors returned by services and
+// repositories. Handlers pass them to c.Error and the error middleware
+// renders them as a model.ErrorResponse with a matching HTTP status.
+package apperror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"flowo-backend/internal/model"
+)
+
+// Error codes returned to clients
+const (
+	CodeBadRequest   = "bad_request"
+	CodeValidation   = "validation_failed"
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+	CodeNotFound     = "not_found"
+	CodeConflict     = "conflict"
+	CodeOutOfStock   = "out_of_stock"
+	CodeTooLarge     = "payload_too_large"
+	CodeInternal     = "internal_error"
+	CodeTimeout      = "timeout"
+	CodeCanceled     = "canceled"
+)
+
+// statusClientClosedRequest is the non-standard status for requests the
+// client gave up on; nobody reads the response, it only shows in logs
+const statusClientClosedRequest = 499
+
+// Error is an error that is safe to show to clients. The cause, if any, is
+// only logged.
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+	Fields  []model.FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(code string, status int, message string) *Error {
+	return &Error{Code: code, Status: status, Message: message}
+}
+
+func BadRequest(message string) *Error {
+	return newError(CodeBadRequest, http.StatusBadRequest, message)
+}
+
+func Unauthorized(message string) *Error {
+	return newError(CodeUnauthorized, http.StatusUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return newError(CodeForbidden, http.StatusForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return newError(CodeNotFound, http.StatusNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return newError(CodeConflict, http.StatusConflict, message)
+}
+
+// OutOfStock is a conflict with the stock on hand
+func OutOfStock(message string) *Error {
+	return newError(CodeOutOfStock, http.StatusConflict, message)
+}
+
+func TooLarge(message string) *Error {
+	return newError(CodeTooLarge, http.StatusRequestEntityTooLarge, message)
+}
+
+// Internal is a server-side failure without an underlying error to wrap
+func Internal(message string) *Error {
+	return newError(CodeInternal, http.StatusInternalServerError, message)
+}
+
+// Timeout is a request that ran past its deadline
+func Timeout(message string) *Error {
+	return newError(CodeTimeout, http.StatusServiceUnavailable, message)
+}
+
+// Validation is a request that is well formed but has invalid values,
+// optionally pointing at the offending fields
+func Validation(message string, fields ...model.FieldError) *Error {
+	e := newError(CodeValidation, http.StatusBadRequest, message)
+	e.Fields = fields
+	return e
+}
+
+// Field describes one invalid request field
+func Field(name, message string) model.FieldError {
+	return model.FieldError{Field: name, Message: message}
+}
+
+// Wrap keeps typed errors as they are and turns anything else into an
+// internal error with the given message, hiding the cause from clients
+func Wrap(err error, message string) error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+	if e := fromContext(err); e != nil {
+		return e
+	}
+	e := Internal(message)
+	e.Err = err
+	return e
+}
+
+// Is reports whether err is a typed error with the given code
+func Is(err error, code string) bool {
+	var appErr *Error
+	return errors.As(err, &appErr) && appErr.Code == code
+}
+
+// InvalidRequest converts an error from binding the request body or query
+func InvalidRequest(err error) *Error {
+	if e := fromBinding(err); e != nil {
+		return e
+	}
+	e := BadRequest("invalid request")
+	e.Err = err
+	return e
+}
+
+// From converts any error into a typed one. Request binding errors become
+// validation errors, cancelled request contexts become timeouts or
+// cancellations; unknown errors become internal errors.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if e := fromBinding(err); e != nil {
+		return e
+	}
+	if e := fromContext(err); e != nil {
+		return e
+	}
+
+	e := Internal("internal server error")
+	e.Err = err
+	return e
+}
+
+// fromContext converts a request whose context ended: the per-route
+// timeout fired, or the client went away or the server is shutting down
+func fromContext(err error) *Error {
+	var e *Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		e = Timeout("request timed out")
+	case errors.Is(err, context.Canceled):
+		e = newError(CodeCanceled, statusClientClosedRequest, "request canceled")
+	default:
+		return nil
+	}
+	e.Err = err
+	return e
+}
+
+func fromBinding(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]model.FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, Field(fieldName(fe), validationMessage(fe)))
+		}
+		return Validation("invalid request", fields...)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return Validation("invalid request", Field(typeErr.Field, "must be of type "+typeErr.Type.String()))
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return BadRequest("invalid JSON body")
+	}
+	return nil
+}
+
+// UseJSONFieldNames makes request validation errors name fields as they
+// appear in the request: by their json tag, else their form tag
+func UseJSONFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+		return ""
+	})
+}
+
+// fieldName drops the request struct name from the namespace, e.g.
+// CreateExperimentRequest.variants[0].name becomes variants[0].name
+func fieldName(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "lt":
+		return fmt.Sprintf("must be less than %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", fe.Param())
+	case "email":
+		return "must be a valid email"
+	case "len":
+		return fmt.Sprintf("must have length %s", fe.Param())
+	}
+	return "is invalid"
+}
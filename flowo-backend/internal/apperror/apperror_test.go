@@ -0,0 +1,168 @@
+package apperror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+
+	"flowo-backend/internal/model"
+)
+
+func TestFrom(t *testing.T) {
+	notFound := NotFound("product not found")
+	cause := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   string
+		wantStatus int
+		wantCause  error
+	}{
+		{"typed error is kept", notFound, CodeNotFound, http.StatusNotFound, nil},
+		{"wrapped typed error is unwrapped", fmt.Errorf("loading: %w", notFound), CodeNotFound, http.StatusNotFound, nil},
+		{"deadline is a timeout", context.DeadlineExceeded, CodeTimeout, http.StatusServiceUnavailable, context.DeadlineExceeded},
+		{"wrapped deadline is a timeout", fmt.Errorf("query: %w", context.DeadlineExceeded), CodeTimeout, http.StatusServiceUnavailable, nil},
+		{"cancellation is a client closed request", context.Canceled, CodeCanceled, statusClientClosedRequest, context.Canceled},
+		{"syntax error is a bad request", &json.SyntaxError{}, CodeBadRequest, http.StatusBadRequest, nil},
+		{"unknown error is internal", cause, CodeInternal, http.StatusInternalServerError, cause},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := From(tt.err)
+			if got.Code != tt.wantCode || got.Status != tt.wantStatus {
+				t.Errorf("From() = %s %d, want %s %d", got.Code, got.Status, tt.wantCode, tt.wantStatus)
+			}
+			if tt.wantCause != nil && !errors.Is(got, tt.wantCause) {
+				t.Errorf("From() does not wrap %v", tt.wantCause)
+			}
+		})
+	}
+
+	if got := From(notFound); got != notFound {
+		t.Error("From() copied a typed error instead of returning it")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	conflict := Conflict("already exists")
+	cause := errors.New("duplicate key")
+
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    string
+		wantMessage string
+	}{
+		{"typed error is kept", conflict, CodeConflict, "already exists"},
+		{"wrapped typed error is kept", fmt.Errorf("saving: %w", conflict), CodeConflict, "already exists"},
+		{"deadline is a timeout", context.DeadlineExceeded, CodeTimeout, "request timed out"},
+		{"cancellation is a client closed request", context.Canceled, CodeCanceled, "request canceled"},
+		{"unknown error gets the message", cause, CodeInternal, "Failed to save"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Wrap(tt.err, "Failed to save")
+			var got *Error
+			if !errors.As(err, &got) {
+				t.Fatalf("Wrap() = %T, want *Error", err)
+			}
+			if got.Code != tt.wantCode || got.Message != tt.wantMessage {
+				t.Errorf("Wrap() = %s %q, want %s %q", got.Code, got.Message, tt.wantCode, tt.wantMessage)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Wrap() lost the cause %v", tt.err)
+			}
+		})
+	}
+}
+
+func TestIs(t *testing.T) {
+	tests := []struct {
+		err  error
+		code string
+		want bool
+	}{
+		{OutOfStock("no stock"), CodeOutOfStock, true},
+		{fmt.Errorf("reserving: %w", OutOfStock("no stock")), CodeOutOfStock, true},
+		{OutOfStock("no stock"), CodeConflict, false},
+		{errors.New("no stock"), CodeOutOfStock, false},
+		{nil, CodeNotFound, false},
+	}
+	for _, tt := range tests {
+		if got := Is(tt.err, tt.code); got != tt.want {
+			t.Errorf("Is(%v, %s) = %v, want %v", tt.err, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestInvalidRequest(t *testing.T) {
+	UseJSONFieldNames()
+
+	type variant struct {
+		Name string `json:"name" binding:"required"`
+	}
+	type request struct {
+		Quantity int       `json:"quantity" binding:"min=1"`
+		Status   string    `form:"status" binding:"oneof=approved rejected"`
+		Variants []variant `json:"variants" binding:"dive"`
+	}
+	validationErr := func(req request) error {
+		err := binding.Validator.ValidateStruct(req)
+		if err == nil {
+			t.Fatalf("ValidateStruct(%+v) passed", req)
+		}
+		return err
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   string
+		wantFields []model.FieldError
+	}{
+		{
+			name:     "fields are named by their json or form tag",
+			err:      validationErr(request{Quantity: 0, Status: "maybe", Variants: []variant{{}}}),
+			wantCode: CodeValidation,
+			wantFields: []model.FieldError{
+				{Field: "quantity", Message: "must be at least 1"},
+				{Field: "status", Message: "must be one of: approved rejected"},
+				{Field: "variants[0].name", Message: "is required"},
+			},
+		},
+		{
+			name:       "type mismatch names the field",
+			err:        &json.UnmarshalTypeError{Field: "quantity", Type: reflect.TypeOf(0)},
+			wantCode:   CodeValidation,
+			wantFields: []model.FieldError{{Field: "quantity", Message: "must be of type int"}},
+		},
+		{
+			name:     "malformed body",
+			err:      &json.SyntaxError{},
+			wantCode: CodeBadRequest,
+		},
+		{
+			name:     "anything else",
+			err:      errors.New("EOF"),
+			wantCode: CodeBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := InvalidRequest(tt.err)
+			if got.Code != tt.wantCode {
+				t.Errorf("InvalidRequest() code = %s, want %s", got.Code, tt.wantCode)
+			}
+			if !reflect.DeepEqual(got.Fields, tt.wantFields) {
+				t.Errorf("InvalidRequest() fields = %v, want %v", got.Fields, tt.wantFields)
+			}
+		})
+	}
+}
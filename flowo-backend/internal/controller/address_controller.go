@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/middleware"
 	"flowo-backend/internal/service"
@@ -36,32 +37,32 @@ func (ctrl *AddressController) RegisterRoutes(rg *gin.RouterGroup) {
 // @Security BearerAuth
 // @Param request body dto.CreateAddressRequest true "Address details"
 // @Success 201 {object} dto.AddressResponse
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/addresses [post]
 func (ctrl *AddressController) CreateAddress(c *gin.Context) {
 	firebaseUID, exists := middleware.GetFirebaseUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
-	user, err := ctrl.userService.GetUserByFirebaseUID(firebaseUID)
+	user, err := ctrl.userService.GetUserByFirebaseUID(c.Request.Context(), firebaseUID)
 	if err != nil || user == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		c.Error(apperror.Wrap(err, "user not found"))
 		return
 	}
 
 	var req dto.CreateAddressRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		c.Error(apperror.InvalidRequest(err))
 		return
 	}
 
-	address, err := ctrl.addressService.CreateAddress(user.FirebaseUID, req)
+	address, err := ctrl.addressService.CreateAddress(c.Request.Context(), user.FirebaseUID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create address"})
+		c.Error(apperror.Wrap(err, "failed to create address"))
 		return
 	}
 
@@ -75,25 +76,25 @@ func (ctrl *AddressController) CreateAddress(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {array} dto.AddressResponse
-// @Failure 401 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/addresses [get]
 func (ctrl *AddressController) GetAddresses(c *gin.Context) {
 	firebaseUID, exists := middleware.GetFirebaseUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
-	user, err := ctrl.userService.GetUserByFirebaseUID(firebaseUID)
+	user, err := ctrl.userService.GetUserByFirebaseUID(c.Request.Context(), firebaseUID)
 	if err != nil || user == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		c.Error(apperror.Wrap(err, "user not found"))
 		return
 	}
 
-	addresses, err := ctrl.addressService.GetAddresses(user.FirebaseUID)
+	addresses, err := ctrl.addressService.GetAddresses(c.Request.Context(), user.FirebaseUID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot fetch addresses"})
+		c.Error(apperror.Wrap(err, "cannot fetch addresses"))
 		return
 	}
 
@@ -108,32 +109,32 @@ func (ctrl *AddressController) GetAddresses(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path int true "Address ID"
 // @Success 200 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/addresses/{id} [delete]
 func (ctrl *AddressController) DeleteAddress(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address id"})
+		c.Error(apperror.BadRequest("invalid address id"))
 		return
 	}
 
 	firebaseUID, exists := middleware.GetFirebaseUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
-	user, err := ctrl.userService.GetUserByFirebaseUID(firebaseUID)
+	user, err := ctrl.userService.GetUserByFirebaseUID(c.Request.Context(), firebaseUID)
 	if err != nil || user == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		c.Error(apperror.Wrap(err, "user not found"))
 		return
 	}
 
-	if err := ctrl.addressService.DeleteAddress(user.FirebaseUID, id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete address"})
+	if err := ctrl.addressService.DeleteAddress(c.Request.Context(), user.FirebaseUID, id); err != nil {
+		c.Error(apperror.Wrap(err, "failed to delete address"))
 		return
 	}
 
@@ -148,32 +149,32 @@ func (ctrl *AddressController) DeleteAddress(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path int true "Address ID"
 // @Success 200 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/addresses/{id}/default [put]
 func (ctrl *AddressController) SetDefaultAddress(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address id"})
+		c.Error(apperror.BadRequest("invalid address id"))
 		return
 	}
 
 	firebaseUID, exists := middleware.GetFirebaseUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
-	user, err := ctrl.userService.GetUserByFirebaseUID(firebaseUID)
+	user, err := ctrl.userService.GetUserByFirebaseUID(c.Request.Context(), firebaseUID)
 	if err != nil || user == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		c.Error(apperror.Wrap(err, "user not found"))
 		return
 	}
 
-	if err := ctrl.addressService.SetDefaultAddress(user.FirebaseUID, id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set default"})
+	if err := ctrl.addressService.SetDefaultAddress(c.Request.Context(), user.FirebaseUID, id); err != nil {
+		c.Error(apperror.Wrap(err, "failed to set default"))
 		return
 	}
 
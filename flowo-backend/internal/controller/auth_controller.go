@@ -12,10 +12,11 @@ import (
 
 	"firebase.google.com/go/v4/auth"
 	"github.com/gin-gonic/gin"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 	"gorm.io/gorm"
 
 	"flowo-backend/config"
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/middleware"
 	"flowo-backend/internal/service"
 	"flowo-backend/internal/utils"
@@ -129,50 +130,37 @@ type ForgotPasswordResponse struct {
 // @Produce json
 // @Param user body SignUpRequest true "User signup data"
 // @Success 200 {object} SignUpResponse
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/auth/signup [post]
 func (ac *AuthController) SignUpHandler(c *gin.Context) {
 	var req SignUpRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "bad_request",
-			"message": err.Error(),
-		})
-		log.Error().Err(err).Msg("Failed to bind JSON for user signup")
+		c.Error(apperror.InvalidRequest(err))
+		middleware.GetLogger(c).Error().Err(err).Msg("Failed to bind JSON for user signup")
 		return
 	}
 
 	// Validate email format
 	if err := utils.ValidateEmail(req.Email); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "bad_request",
-			"message": "Invalid email format",
-		})
-		log.Error().Err(err).Str("email", req.Email).Msg("Invalid email format")
+		c.Error(apperror.BadRequest("Invalid email format"))
+		middleware.GetLogger(c).Error().Err(err).Str("email", req.Email).Msg("Invalid email format")
 		return
 	}
 
 	// Check if user already exists
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	existingUser, err := ac.firebaseAuth.GetUserByEmail(ctx, req.Email)
 	if err == nil && existingUser != nil {
 		// User already exists
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "user_exists",
-			"message": "User with this email already exists",
-		})
-		log.Warn().Str("email", req.Email).Msg("Attempted to signup with existing email")
+		c.Error(apperror.Conflict("User with this email already exists"))
+		middleware.GetLogger(c).Warn().Str("email", req.Email).Msg("Attempted to signup with existing email")
 		return
 	}
 
 	// Check if error is something other than user not found
 	if err != nil && !auth.IsUserNotFound(err) {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "internal_server_error",
-			"message": "Failed to check user existence",
-		})
-		log.Error().Err(err).Str("email", req.Email).Msg("Error checking user existence")
+		c.Error(apperror.Wrap(err, "Failed to check user existence"))
 		return
 	}
 
@@ -180,11 +168,7 @@ func (ac *AuthController) SignUpHandler(c *gin.Context) {
 	// and generate verification link (frontend will handle email sending)
 	tempPassword, err := utils.GenerateSecurePassword(16)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "internal_server_error",
-			"message": "Failed to generate temporary password",
-		})
-		log.Error().Err(err).Str("email", req.Email).Msg("Failed to generate temporary password")
+		c.Error(apperror.Wrap(err, "Failed to generate temporary password"))
 		return
 	}
 
@@ -197,38 +181,31 @@ func (ac *AuthController) SignUpHandler(c *gin.Context) {
 
 	firebaseUser, err := ac.firebaseAuth.CreateUser(ctx, params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "internal_server_error",
-			"message": "Failed to create user account",
-		})
-		log.Error().Err(err).Str("email", req.Email).Msg("Failed to create user")
+		c.Error(apperror.Wrap(err, "Failed to create user account"))
 		return
 	}
 
 	// Create user record in local database with minimal Firebase information
-	localUser, err := ac.userService.CreateUserFromFirebase(firebaseUser.UID, req.Email)
+	localUser, err := ac.userService.CreateUserFromFirebase(ctx, firebaseUser.UID, req.Email)
 	if err != nil {
 		// Attempt to clean up the Firebase user to maintain consistency
 		cleanupErr := ac.firebaseAuth.DeleteUser(ctx, firebaseUser.UID)
 		if cleanupErr != nil {
-			log.Error().Err(err).Str("email", req.Email).Str("firebase_uid", firebaseUser.UID).Msg("Failed to create user in local database")
-			log.Error().Err(cleanupErr).Str("email", req.Email).Str("firebase_uid", firebaseUser.UID).Msg("Failed to clean up Firebase user after local DB creation failure")
+			middleware.GetLogger(c).Error().Err(err).Str("email", req.Email).Str("firebase_uid", firebaseUser.UID).Msg("Failed to create user in local database")
+			middleware.GetLogger(c).Error().Err(cleanupErr).Str("email", req.Email).Str("firebase_uid", firebaseUser.UID).Msg("Failed to clean up Firebase user after local DB creation failure")
 		} else {
-			log.Error().Err(err).Str("email", req.Email).Str("firebase_uid", firebaseUser.UID).Msg("Failed to create user in local database; Firebase user deleted for cleanup")
+			middleware.GetLogger(c).Error().Err(err).Str("email", req.Email).Str("firebase_uid", firebaseUser.UID).Msg("Failed to create user in local database; Firebase user deleted for cleanup")
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "internal_server_error",
-			"message": "Failed to create user account",
-		})
+		c.Error(apperror.Wrap(err, "Failed to create user account"))
 		return
 	} else {
-		log.Info().Str("email", req.Email).Str("firebase_uid", firebaseUser.UID).Str("firebase_uid", localUser.FirebaseUID).Msg("User created successfully in both Firebase and local database")
+		middleware.GetLogger(c).Info().Str("email", req.Email).Str("firebase_uid", firebaseUser.UID).Str("firebase_uid", localUser.FirebaseUID).Msg("User created successfully in both Firebase and local database")
 	}
 
 	// Send password reset email using Firebase REST API
-	if err := ac.sendPasswordResetEmail(req.Email); err != nil {
+	if err := ac.sendPasswordResetEmail(c.Request.Context(), req.Email); err != nil {
 		// User is created but email failed - log warning but don't fail the signup
-		log.Warn().Err(err).Str("email", req.Email).Msg("Failed to send password reset email after signup")
+		middleware.GetLogger(c).Warn().Err(err).Str("email", req.Email).Msg("Failed to send password reset email after signup")
 
 		// Still return success but with a different message
 		response := SignUpResponse{
@@ -238,7 +215,7 @@ func (ac *AuthController) SignUpHandler(c *gin.Context) {
 			Password: tempPassword, // Include password for reference
 		}
 		c.JSON(http.StatusOK, response)
-		log.Info().Str("email", req.Email).Msg("Signup completed but password reset email failed")
+		middleware.GetLogger(c).Info().Str("email", req.Email).Msg("Signup completed but password reset email failed")
 		return
 	}
 
@@ -250,7 +227,7 @@ func (ac *AuthController) SignUpHandler(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, response)
-	log.Info().Str("email", req.Email).Msg("Signup process initiated successfully")
+	middleware.GetLogger(c).Info().Str("email", req.Email).Msg("Signup process initiated successfully")
 }
 
 // LoginHandler handles user login process using Firebase REST API
@@ -261,28 +238,22 @@ func (ac *AuthController) SignUpHandler(c *gin.Context) {
 // @Produce json
 // @Param user body LoginRequest true "User login data"
 // @Success 200 {object} LoginResponse
-// @Failure 400 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/auth/login [post]
 func (ac *AuthController) LoginHandler(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "bad_request",
-			"message": err.Error(),
-		})
-		log.Error().Err(err).Msg("Failed to bind JSON for user login")
+		c.Error(apperror.InvalidRequest(err))
+		middleware.GetLogger(c).Error().Err(err).Msg("Failed to bind JSON for user login")
 		return
 	}
 
 	// Get Firebase API key from config
 	if ac.firebaseAPIKey == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "configuration_error",
-			"message": "Firebase API key not configured",
-		})
-		log.Error().Msg("Firebase API key not configured in application config")
+		c.Error(apperror.Internal("Firebase API key not configured"))
+		middleware.GetLogger(c).Error().Msg("Firebase API key not configured in application config")
 		return
 	}
 
@@ -294,11 +265,7 @@ func (ac *AuthController) LoginHandler(c *gin.Context) {
 
 	loginData, err := json.Marshal(loginPayload)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "internal_server_error",
-			"message": "Failed to prepare authentication request",
-		})
-		log.Error().Err(err).Msg("Failed to marshal login payload")
+		c.Error(apperror.Wrap(err, "Failed to prepare authentication request"))
 		return
 	}
 
@@ -307,11 +274,7 @@ func (ac *AuthController) LoginHandler(c *gin.Context) {
 	// Make HTTP request to Firebase
 	resp, err := http.Post(firebaseLoginURL, "application/json", bytes.NewBuffer(loginData))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "internal_server_error",
-			"message": "Failed to authenticate with Firebase",
-		})
-		log.Error().Err(err).Str("email", req.Email).Msg("Failed to send request to Firebase")
+		c.Error(apperror.Wrap(err, "Failed to authenticate with Firebase"))
 		return
 	}
 	defer resp.Body.Close()
@@ -320,11 +283,7 @@ func (ac *AuthController) LoginHandler(c *gin.Context) {
 	var firebaseResp map[string]interface{}
 
 	if err := json.NewDecoder(resp.Body).Decode(&firebaseResp); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "internal_server_error",
-			"message": "Failed to parse authentication response",
-		})
-		log.Error().Err(err).Str("email", req.Email).Msg("Failed to decode Firebase response")
+		c.Error(apperror.Wrap(err, "Failed to parse authentication response"))
 		return
 	}
 
@@ -350,16 +309,12 @@ func (ac *AuthController) LoginHandler(c *gin.Context) {
 			}
 		}
 
-		statusCode := http.StatusUnauthorized
 		if resp.StatusCode >= 500 {
-			statusCode = http.StatusInternalServerError
+			c.Error(apperror.Internal(errorMessage))
+		} else {
+			c.Error(apperror.Unauthorized(errorMessage))
 		}
-
-		c.JSON(statusCode, gin.H{
-			"error":   "authentication_failed",
-			"message": errorMessage,
-		})
-		log.Warn().Str("email", req.Email).Int("firebase_status", resp.StatusCode).Msg("Firebase authentication failed")
+		middleware.GetLogger(c).Warn().Str("email", req.Email).Int("firebase_status", resp.StatusCode).Msg("Firebase authentication failed")
 		return
 	}
 
@@ -368,52 +323,35 @@ func (ac *AuthController) LoginHandler(c *gin.Context) {
 
 	idToken, ok := firebaseResp["idToken"].(string)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid_response",
-			"message": "Invalid or missing idToken in authentication response",
-		})
-		log.Error().Str("email", req.Email).Msg("idToken is missing or not a string in Firebase response")
+		c.Error(apperror.Internal("Invalid or missing idToken in authentication response"))
+		middleware.GetLogger(c).Error().Str("email", req.Email).Msg("idToken is missing or not a string in Firebase response")
 		return
 	}
 
 	token, err := ac.firebaseAuth.VerifyIDToken(c, idToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "invalid_token",
-			"message": "Invalid Firebase token",
-		})
-		log.Error().Err(err).Str("email", req.Email).Msg("Failed to verify Firebase ID token")
+		c.Error(apperror.Unauthorized("Invalid Firebase token"))
+		middleware.GetLogger(c).Error().Err(err).Str("email", req.Email).Msg("Failed to verify Firebase ID token")
 		return
 	}
 
 	firebaseUID := token.UID
 
 	// Check local DB user
-	user, err := ac.userService.GetUserByFirebaseUID(firebaseUID)
+	user, err := ac.userService.GetUserByFirebaseUID(c.Request.Context(), firebaseUID)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "internal_server_error",
-			"message": "Failed to query user",
-		})
-		log.Error().Err(err).Str("firebase_uid", firebaseUID).Msg("Database error on login")
+		c.Error(apperror.Wrap(err, "Failed to query user"))
 		return
 	}
 
 	if user != nil && user.IsDeleted {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":   "account_deactivated",
-			"message": "Your account has been deactivated. Please contact support.",
-		})
-		log.Warn().Str("firebase_uid", firebaseUID).Msg("Login attempt for deactivated account")
+		c.Error(apperror.Forbidden("Your account has been deactivated. Please contact support."))
+		middleware.GetLogger(c).Warn().Str("firebase_uid", firebaseUID).Msg("Login attempt for deactivated account")
 		return
 	}
 	sessionCookie, err := ac.firebaseAuth.SessionCookie(c, idToken, expiresIn)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "internal_server_error",
-			"message": "Failed to generate session cookie",
-		})
-		log.Error().Err(err).Str("email", req.Email).Msg("Failed to create session cookie")
+		c.Error(apperror.Wrap(err, "Failed to generate session cookie"))
 		return
 	}
 
@@ -433,7 +371,7 @@ func (ac *AuthController) LoginHandler(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, response)
-	log.Info().Str("email", req.Email).Msg("User logged in successfully")
+	middleware.GetLogger(c).Info().Str("email", req.Email).Msg("User logged in successfully")
 
 }
 
@@ -443,61 +381,48 @@ func (ac *AuthController) LoginHandler(c *gin.Context) {
 // @Tags auth
 // @Produce json
 // @Success 200 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 401 {object} model.ErrorResponse
 // @Router /api/v1/auth/check-auth [get]
 func (ac *AuthController) CheckAuthHandler(c *gin.Context) {
 	// Get session cookie
 	sessionCookie, err := c.Cookie("session_id")
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":         "unauthorized",
-			"message":       "No session found",
-			"authenticated": false,
-		})
+		c.Error(apperror.Unauthorized("No session found"))
 		return
 	}
 
 	// Verify session cookie
-	decoded, err := ac.firebaseAuth.VerifySessionCookie(context.Background(), sessionCookie)
+	decoded, err := ac.firebaseAuth.VerifySessionCookie(c.Request.Context(), sessionCookie)
 	if err != nil {
 		// Clear invalid cookie
 		c.SetCookie("session_id", "", -1, "/", "", false, true)
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":         "unauthorized",
-			"message":       "Invalid or expired session",
-			"authenticated": false,
-		})
-		log.Warn().Err(err).Msg("Invalid session cookie")
+		c.Error(apperror.Unauthorized("Invalid or expired session"))
+		middleware.GetLogger(c).Warn().Err(err).Msg("Invalid session cookie")
 		return
 	}
 
 	// Get user information from Firebase
-	userRecord, err := ac.firebaseAuth.GetUser(context.Background(), decoded.UID)
+	userRecord, err := ac.firebaseAuth.GetUser(c.Request.Context(), decoded.UID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":         "internal_server_error",
-			"message":       "Failed to get user information",
-			"authenticated": false,
-		})
-		log.Error().Err(err).Str("uid", decoded.UID).Msg("Failed to get user record")
+		c.Error(apperror.Wrap(err, "Failed to get user information"))
 		return
 	}
 
 	// Ensure user exists in local database (create if doesn't exist)
-	localUser, err := ac.userService.GetUserByFirebaseUID(decoded.UID)
+	localUser, err := ac.userService.GetUserByFirebaseUID(c.Request.Context(), decoded.UID)
 	if err != nil {
-		log.Error().Err(err).Str("uid", decoded.UID).Msg("Failed to get local user record")
+		middleware.GetLogger(c).Error().Err(err).Str("uid", decoded.UID).Msg("Failed to get local user record")
 	} else if localUser == nil {
 		// User doesn't exist in local database, create them
-		localUser, err = ac.userService.CreateUserFromFirebase(decoded.UID, userRecord.Email)
+		localUser, err = ac.userService.CreateUserFromFirebase(c.Request.Context(), decoded.UID, userRecord.Email)
 		if err != nil {
-			log.Error().Err(err).Str("uid", decoded.UID).Str("email", userRecord.Email).Msg("Failed to create user in local database during auth check")
+			middleware.GetLogger(c).Error().Err(err).Str("uid", decoded.UID).Str("email", userRecord.Email).Msg("Failed to create user in local database during auth check")
 		} else {
-			log.Info().Str("uid", decoded.UID).Str("email", userRecord.Email).Str("firebase_uid", localUser.FirebaseUID).Msg("Created local user record during auth check")
+			middleware.GetLogger(c).Info().Str("uid", decoded.UID).Str("email", userRecord.Email).Str("firebase_uid", localUser.FirebaseUID).Msg("Created local user record during auth check")
 		}
 	}
 
-	log.Info().Str("uid", decoded.UID).Str("email", userRecord.Email).Msg("User session verified successfully")
+	middleware.GetLogger(c).Info().Str("uid", decoded.UID).Str("email", userRecord.Email).Msg("User session verified successfully")
 
 	// Build response with both Firebase and local user information
 	userResponse := gin.H{
@@ -529,13 +454,13 @@ func (ac *AuthController) CheckAuthHandler(c *gin.Context) {
 // @Tags auth
 // @Produce json
 // @Success 200 {object} map[string]interface{}
-// @Failure 401 {object} map[string]interface{}
+// @Failure 401 {object} model.ErrorResponse
 // @Router /api/v1/auth/logout [post]
 func (ac *AuthController) LogoutHandler(c *gin.Context) {
 	// Get user information from context (set by auth middleware)
 	firebaseUID, exists := c.Get("firebase_uid")
 	if exists {
-		log.Info().Str("firebase_uid", firebaseUID.(string)).Msg("User initiated logout")
+		middleware.GetLogger(c).Info().Str("firebase_uid", firebaseUID.(string)).Msg("User initiated logout")
 	}
 
 	// Clear the session cookie
@@ -546,11 +471,11 @@ func (ac *AuthController) LogoutHandler(c *gin.Context) {
 		"message": "Logged out successfully",
 	})
 
-	log.Info().Msg("User logged out successfully")
+	middleware.GetLogger(c).Info().Msg("User logged out successfully")
 }
 
 // sendPasswordResetEmail sends a password reset email using Firebase REST API
-func (ac *AuthController) sendPasswordResetEmail(email string) error {
+func (ac *AuthController) sendPasswordResetEmail(ctx context.Context, email string) error {
 	// Prepare the password reset request payload
 	resetPayload := map[string]interface{}{
 		"requestType": "PASSWORD_RESET",
@@ -574,7 +499,7 @@ func (ac *AuthController) sendPasswordResetEmail(email string) error {
 
 	// Check for successful response
 	if resp.StatusCode == http.StatusOK {
-		log.Info().Str("email", email).Msg("Password reset email sent successfully")
+		zerolog.Ctx(ctx).Info().Str("email", email).Msg("Password reset email sent successfully")
 		return nil
 	}
 
@@ -605,32 +530,26 @@ func (ac *AuthController) sendPasswordResetEmail(email string) error {
 // @Produce json
 // @Param user body ForgotPasswordRequest true "Forgot password data"
 // @Success 200 {object} ForgotPasswordResponse
-// @Failure 400 {object} map[string]interface{}
-// @Failure 500 {object} map[string]interface{}
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/auth/forgot-password [post]
 func (ac *AuthController) ForgotPasswordHandler(c *gin.Context) {
 	var req ForgotPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "bad_request",
-			"message": err.Error(),
-		})
-		log.Error().Err(err).Msg("Failed to bind JSON for forgot password")
+		c.Error(apperror.InvalidRequest(err))
+		middleware.GetLogger(c).Error().Err(err).Msg("Failed to bind JSON for forgot password")
 		return
 	}
 
 	// Validate email format
 	if err := utils.ValidateEmail(req.Email); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "bad_request",
-			"message": "Invalid email format",
-		})
-		log.Error().Err(err).Str("email", req.Email).Msg("Invalid email format")
+		c.Error(apperror.BadRequest("Invalid email format"))
+		middleware.GetLogger(c).Error().Err(err).Str("email", req.Email).Msg("Invalid email format")
 		return
 	}
 
 	// Check if user exists
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	userRecord, err := ac.firebaseAuth.GetUserByEmail(ctx, req.Email)
 	if err != nil {
 		if auth.IsUserNotFound(err) {
@@ -642,22 +561,18 @@ func (ac *AuthController) ForgotPasswordHandler(c *gin.Context) {
 				Email:   req.Email,
 			}
 			c.JSON(http.StatusOK, response)
-			log.Info().Str("email", req.Email).Msg("Password reset requested for non-existent user")
+			middleware.GetLogger(c).Info().Str("email", req.Email).Msg("Password reset requested for non-existent user")
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "internal_server_error",
-			"message": "Failed to check user existence",
-		})
-		log.Error().Err(err).Str("email", req.Email).Msg("Error checking user existence")
+		c.Error(apperror.Wrap(err, "Failed to check user existence"))
 		return
 	}
 
 	// Send password reset email using the helper method
-	if err := ac.sendPasswordResetEmail(req.Email); err != nil {
+	if err := ac.sendPasswordResetEmail(c.Request.Context(), req.Email); err != nil {
 		// Log the actual error for debugging
-		log.Error().Err(err).Str("email", req.Email).Msg("Failed to send password reset email")
+		middleware.GetLogger(c).Error().Err(err).Str("email", req.Email).Msg("Failed to send password reset email")
 
 		// For security, still return success for EMAIL_NOT_FOUND errors
 		if strings.Contains(err.Error(), "EMAIL_NOT_FOUND") {
@@ -667,20 +582,16 @@ func (ac *AuthController) ForgotPasswordHandler(c *gin.Context) {
 				Email:   req.Email,
 			}
 			c.JSON(http.StatusOK, response)
-			log.Info().Str("email", req.Email).Msg("Password reset requested for non-existent user (Firebase)")
+			middleware.GetLogger(c).Info().Str("email", req.Email).Msg("Password reset requested for non-existent user (Firebase)")
 			return
 		}
 
 		// For other errors, return appropriate error response
-		statusCode := http.StatusBadRequest
 		if strings.Contains(err.Error(), "status 5") { // 5xx errors
-			statusCode = http.StatusInternalServerError
+			c.Error(apperror.Wrap(err, "Failed to send password reset email"))
+		} else {
+			c.Error(apperror.BadRequest("Failed to send password reset email"))
 		}
-
-		c.JSON(statusCode, gin.H{
-			"error":   "password_reset_failed",
-			"message": "Failed to send password reset email",
-		})
 		return
 	}
 
@@ -692,5 +603,5 @@ func (ac *AuthController) ForgotPasswordHandler(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, response)
-	log.Info().Str("email", req.Email).Str("firebase_uid", userRecord.UID).Msg("Password reset email sent successfully")
+	middleware.GetLogger(c).Info().Str("email", req.Email).Str("firebase_uid", userRecord.UID).Msg("Password reset email sent successfully")
 }
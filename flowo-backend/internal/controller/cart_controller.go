@@ -1,10 +1,12 @@
 package controller
 
 import (
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/middleware"
 	"flowo-backend/internal/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,6 +26,8 @@ func (ctrl *CartController) RegisterRoutes(rg *gin.RouterGroup) {
 	cart.PUT("/update", ctrl.UpdateCartItem)
 	cart.DELETE("/remove", ctrl.RemoveCartItem)
 	cart.GET("/", ctrl.GetCartItems)
+	cart.GET("/suggestions", ctrl.GetCartSuggestions)
+	cart.POST("/prefill", ctrl.PrefillCart)
 }
 
 // AddToCart godoc
@@ -35,32 +39,32 @@ func (ctrl *CartController) RegisterRoutes(rg *gin.RouterGroup) {
 // @Security BearerAuth
 // @Param request body dto.AddToCartRequest true "Add to cart request"
 // @Success 200 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/cart/add [post]
 func (ctrl *CartController) AddToCart(c *gin.Context) {
 	firebaseUID, ok := middleware.GetFirebaseUserID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
-	user, err := ctrl.UserService.GetUserByFirebaseUID(firebaseUID)
+	user, err := ctrl.UserService.GetUserByFirebaseUID(c.Request.Context(), firebaseUID)
 	if err != nil || user == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		c.Error(apperror.Wrap(err, "user not found"))
 		return
 	}
 
 	var req dto.AddToCartRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.InvalidRequest(err))
 		return
 	}
 	req.FirebaseUID = firebaseUID
 
-	if err := ctrl.Service.AddToCart(req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not add to cart"})
+	if err := ctrl.Service.AddToCart(c.Request.Context(), req); err != nil {
+		c.Error(apperror.Wrap(err, "Could not add to cart"))
 		return
 	}
 
@@ -76,32 +80,32 @@ func (ctrl *CartController) AddToCart(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.UpdateCartItemRequest true "Update cart item request"
 // @Success 200 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/cart/update [put]
 func (ctrl *CartController) UpdateCartItem(c *gin.Context) {
 	firebaseUID, ok := middleware.GetFirebaseUserID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
-	user, err := ctrl.UserService.GetUserByFirebaseUID(firebaseUID)
+	user, err := ctrl.UserService.GetUserByFirebaseUID(c.Request.Context(), firebaseUID)
 	if err != nil || user == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		c.Error(apperror.Wrap(err, "user not found"))
 		return
 	}
 
 	var req dto.UpdateCartItemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.InvalidRequest(err))
 		return
 	}
 	req.FirebaseUID = firebaseUID
 
-	if err := ctrl.Service.UpdateCartItem(req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update cart item"})
+	if err := ctrl.Service.UpdateCartItem(c.Request.Context(), req); err != nil {
+		c.Error(apperror.Wrap(err, "Could not update cart item"))
 		return
 	}
 
@@ -117,33 +121,33 @@ func (ctrl *CartController) UpdateCartItem(c *gin.Context) {
 // @Security BearerAuth
 // @Param request body dto.RemoveCartItemRequest true "Remove cart item request"
 // @Success 200 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/cart/remove [delete]
 func (ctrl *CartController) RemoveCartItem(c *gin.Context) {
 	firebaseUID, ok := middleware.GetFirebaseUserID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
-	user, err := ctrl.UserService.GetUserByFirebaseUID(firebaseUID)
+	user, err := ctrl.UserService.GetUserByFirebaseUID(c.Request.Context(), firebaseUID)
 	if err != nil || user == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		c.Error(apperror.Wrap(err, "user not found"))
 		return
 	}
 
 	var req dto.RemoveCartItemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.InvalidRequest(err))
 		return
 	}
 
 	req.FirebaseUID = firebaseUID
 
-	if err := ctrl.Service.RemoveCartItem(req.FirebaseUID, req.ProductID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not remove item"})
+	if err := ctrl.Service.RemoveCartItem(c.Request.Context(), req.FirebaseUID, req.ProductID, req.VariantID); err != nil {
+		c.Error(apperror.Wrap(err, "Could not remove item"))
 		return
 	}
 
@@ -157,27 +161,97 @@ func (ctrl *CartController) RemoveCartItem(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {array} dto.CartItemResponse
-// @Failure 401 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/cart [get]
 func (ctrl *CartController) GetCartItems(c *gin.Context) {
 	firebaseUID, ok := middleware.GetFirebaseUserID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
-	user, err := ctrl.UserService.GetUserByFirebaseUID(firebaseUID)
+	user, err := ctrl.UserService.GetUserByFirebaseUID(c.Request.Context(), firebaseUID)
 	if err != nil || user == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		c.Error(apperror.Wrap(err, "user not found"))
 		return
 	}
 
-	items, err := ctrl.Service.GetCartWithPrices(user.FirebaseUID)
+	items, err := ctrl.Service.GetCartWithPrices(c.Request.Context(), user.FirebaseUID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not get cart items"})
+		c.Error(apperror.Wrap(err, "Could not get cart items"))
 		return
 	}
 
 	c.JSON(http.StatusOK, items)
 }
+
+// GetCartSuggestions godoc
+// @Summary Get "complete your order" suggestions
+// @Description Suggest add-ons (vases, cards, complementary flowers) often bought with the items in the cart. Out-of-stock products and products already in the cart are excluded.
+// @Tags cart
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of suggestions to return" default(10)
+// @Success 200 {object} dto.RecommendationResponseDTO
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/cart/suggestions [get]
+func (ctrl *CartController) GetCartSuggestions(c *gin.Context) {
+	firebaseUID, ok := middleware.GetFirebaseUserID(c)
+	if !ok {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 || limit > 50 {
+		c.Error(apperror.BadRequest("limit must be between 1 and 50"))
+		return
+	}
+
+	suggestions, err := ctrl.Service.GetCartSuggestions(c.Request.Context(), firebaseUID, limit)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Could not get cart suggestions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestions)
+}
+
+// PrefillCart godoc
+// @Summary Prefill the cart with suggested products
+// @Description Add one of each product that is not in the cart yet, e.g. from the link in an occasion reminder. Products that are unavailable or out of stock are skipped.
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.PrefillCartRequest true "Products to add"
+// @Success 200 {object} dto.PrefillCartResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/cart/prefill [post]
+func (ctrl *CartController) PrefillCart(c *gin.Context) {
+	firebaseUID, ok := middleware.GetFirebaseUserID(c)
+	if !ok {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req dto.PrefillCartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+	req.FirebaseUID = firebaseUID
+
+	result, err := ctrl.Service.PrefillCart(c.Request.Context(), req)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Could not prefill cart"))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,507 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"flowo-backend/config"
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/middleware"
+	"flowo-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogController struct {
+	catalogService service.CatalogService
+	maxImageBytes  int64
+}
+
+func NewCatalogController(cs service.CatalogService, cfg *config.Config) *CatalogController {
+	return &CatalogController{catalogService: cs, maxImageBytes: cfg.Storage.MaxImageBytes}
+}
+
+func (ctrl *CatalogController) RegisterRoutes(rg *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := rg.Group("/admin/catalog", authMiddleware.RequireAdmin())
+
+	admin.POST("/products/:productID/images", ctrl.UploadProductImage)
+	admin.PUT("/products/:productID/images/:imageID/primary", ctrl.SetPrimaryImage)
+	admin.DELETE("/products/:productID/images/:imageID", ctrl.DeleteProductImage)
+	admin.PUT("/products/:productID/occasions", ctrl.SetProductOccasions)
+	admin.POST("/products/import", ctrl.ImportProducts)
+	admin.GET("/products/export", ctrl.ExportProducts)
+
+	admin.POST("/flower-types", ctrl.CreateFlowerType)
+	admin.PUT("/flower-types/:flowerTypeID", ctrl.UpdateFlowerType)
+	admin.DELETE("/flower-types/:flowerTypeID", ctrl.DeleteFlowerType)
+
+	admin.POST("/occasions", ctrl.CreateOccasion)
+	admin.PUT("/occasions/:occasionID", ctrl.UpdateOccasion)
+	admin.DELETE("/occasions/:occasionID", ctrl.DeleteOccasion)
+}
+
+// UploadProductImage godoc
+// @Summary Upload a product image (admin)
+// @Description Upload a JPEG, PNG or GIF image for a product. A thumbnail is generated; the first image of a product becomes primary.
+// @Tags admin-catalog
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param productID path int true "Product ID"
+// @Param file formData file true "Image file"
+// @Param alt_text formData string false "Alt text"
+// @Param is_primary formData bool false "Make this the primary image"
+// @Success 201 {object} model.ProductImage
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 413 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/catalog/products/{productID}/images [post]
+func (ctrl *CatalogController) UploadProductImage(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("productID"), 10, 32)
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid product id"))
+		return
+	}
+
+	var req dto.ProductImageUpload
+	if err := c.ShouldBind(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.Error(apperror.BadRequest("file is required"))
+		return
+	}
+	if fileHeader.Size > ctrl.maxImageBytes {
+		c.Error(apperror.TooLarge("image is too large"))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Error(apperror.BadRequest("failed to read file"))
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, ctrl.maxImageBytes+1))
+	if err != nil {
+		c.Error(apperror.BadRequest("failed to read file"))
+		return
+	}
+
+	image, err := ctrl.catalogService.UploadProductImage(c.Request.Context(), uint(productID), data, req)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to upload image"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, image)
+}
+
+// SetPrimaryImage godoc
+// @Summary Set the primary image of a product (admin)
+// @Tags admin-catalog
+// @Produce json
+// @Security BearerAuth
+// @Param productID path int true "Product ID"
+// @Param imageID path int true "Image ID"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/catalog/products/{productID}/images/{imageID}/primary [put]
+func (ctrl *CatalogController) SetPrimaryImage(c *gin.Context) {
+	productID, imageID, ok := productImageParams(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.catalogService.SetPrimaryImage(c.Request.Context(), productID, imageID); err != nil {
+		c.Error(apperror.Wrap(err, "failed to set primary image"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Primary image updated"})
+}
+
+// DeleteProductImage godoc
+// @Summary Delete a product image (admin)
+// @Description Remove the image and its stored files; the oldest remaining image becomes primary if needed
+// @Tags admin-catalog
+// @Produce json
+// @Security BearerAuth
+// @Param productID path int true "Product ID"
+// @Param imageID path int true "Image ID"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/catalog/products/{productID}/images/{imageID} [delete]
+func (ctrl *CatalogController) DeleteProductImage(c *gin.Context) {
+	productID, imageID, ok := productImageParams(c)
+	if !ok {
+		return
+	}
+
+	if err := ctrl.catalogService.DeleteProductImage(c.Request.Context(), productID, imageID); err != nil {
+		c.Error(apperror.Wrap(err, "failed to delete image"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Image deleted"})
+}
+
+// SetProductOccasions godoc
+// @Summary Set the occasions of a product (admin)
+// @Description Replace the occasions a product is linked to
+// @Tags admin-catalog
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param productID path int true "Product ID"
+// @Param request body dto.ProductOccasionsRequest true "Occasion IDs"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/catalog/products/{productID}/occasions [put]
+func (ctrl *CatalogController) SetProductOccasions(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("productID"), 10, 32)
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid product id"))
+		return
+	}
+
+	var req dto.ProductOccasionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	if err := ctrl.catalogService.SetProductOccasions(c.Request.Context(), uint(productID), req.OccasionIDs); err != nil {
+		c.Error(apperror.Wrap(err, "failed to set occasions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Product occasions updated"})
+}
+
+// CreateFlowerType godoc
+// @Summary Create a flower type (admin)
+// @Tags admin-catalog
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.FlowerTypeRequest true "Flower type"
+// @Success 201 {object} model.FlowerType
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/catalog/flower-types [post]
+func (ctrl *CatalogController) CreateFlowerType(c *gin.Context) {
+	var req dto.FlowerTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	flowerType, err := ctrl.catalogService.CreateFlowerType(c.Request.Context(), req)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to create flower type"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, flowerType)
+}
+
+// UpdateFlowerType godoc
+// @Summary Update a flower type (admin)
+// @Tags admin-catalog
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param flowerTypeID path int true "Flower type ID"
+// @Param request body dto.FlowerTypeRequest true "Flower type"
+// @Success 200 {object} model.FlowerType
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/catalog/flower-types/{flowerTypeID} [put]
+func (ctrl *CatalogController) UpdateFlowerType(c *gin.Context) {
+	flowerTypeID, err := strconv.ParseUint(c.Param("flowerTypeID"), 10, 32)
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid flower type id"))
+		return
+	}
+
+	var req dto.FlowerTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	flowerType, err := ctrl.catalogService.UpdateFlowerType(c.Request.Context(), uint(flowerTypeID), req)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to update flower type"))
+		return
+	}
+
+	c.JSON(http.StatusOK, flowerType)
+}
+
+// DeleteFlowerType godoc
+// @Summary Delete a flower type (admin)
+// @Description Only flower types that no product or pricing rule refers to can be deleted
+// @Tags admin-catalog
+// @Produce json
+// @Security BearerAuth
+// @Param flowerTypeID path int true "Flower type ID"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/catalog/flower-types/{flowerTypeID} [delete]
+func (ctrl *CatalogController) DeleteFlowerType(c *gin.Context) {
+	flowerTypeID, err := strconv.ParseUint(c.Param("flowerTypeID"), 10, 32)
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid flower type id"))
+		return
+	}
+
+	if err := ctrl.catalogService.DeleteFlowerType(c.Request.Context(), uint(flowerTypeID)); err != nil {
+		c.Error(apperror.Wrap(err, "failed to delete flower type"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Flower type deleted"})
+}
+
+// CreateOccasion godoc
+// @Summary Create an occasion (admin)
+// @Tags admin-catalog
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.OccasionRequest true "Occasion"
+// @Success 201 {object} model.Occasion
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/catalog/occasions [post]
+func (ctrl *CatalogController) CreateOccasion(c *gin.Context) {
+	var req dto.OccasionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	occasion, err := ctrl.catalogService.CreateOccasion(c.Request.Context(), req)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to create occasion"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, occasion)
+}
+
+// UpdateOccasion godoc
+// @Summary Update an occasion (admin)
+// @Tags admin-catalog
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param occasionID path int true "Occasion ID"
+// @Param request body dto.OccasionRequest true "Occasion"
+// @Success 200 {object} model.Occasion
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/catalog/occasions/{occasionID} [put]
+func (ctrl *CatalogController) UpdateOccasion(c *gin.Context) {
+	occasionID, err := strconv.ParseUint(c.Param("occasionID"), 10, 32)
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid occasion id"))
+		return
+	}
+
+	var req dto.OccasionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	occasion, err := ctrl.catalogService.UpdateOccasion(c.Request.Context(), uint(occasionID), req)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to update occasion"))
+		return
+	}
+
+	c.JSON(http.StatusOK, occasion)
+}
+
+// DeleteOccasion godoc
+// @Summary Delete an occasion (admin)
+// @Description Delete the occasion and unlink it from all products
+// @Tags admin-catalog
+// @Produce json
+// @Security BearerAuth
+// @Param occasionID path int true "Occasion ID"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/catalog/occasions/{occasionID} [delete]
+func (ctrl *CatalogController) DeleteOccasion(c *gin.Context) {
+	occasionID, err := strconv.ParseUint(c.Param("occasionID"), 10, 32)
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid occasion id"))
+		return
+	}
+
+	if err := ctrl.catalogService.DeleteOccasion(c.Request.Context(), uint(occasionID)); err != nil {
+		c.Error(apperror.Wrap(err, "failed to delete occasion"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Occasion deleted"})
+}
+
+// maxImportFileBytes bounds catalog import uploads
+const maxImportFileBytes = 10 << 20
+
+// ImportProducts godoc
+// @Summary Bulk import products (admin)
+// @Description Create or update products from a CSV or JSON file (see the export for the format). Every row is validated; rows are applied in one transaction only when all are valid. With dry_run nothing is written and the row-level errors are reported.
+// @Tags admin-catalog
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "CSV or JSON file"
+// @Param format query string false "File format, taken from the file extension when omitted" Enums(csv, json)
+// @Param dry_run query bool false "Only validate the file"
+// @Success 200 {object} dto.ProductImportResult
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 413 {object} model.ErrorResponse
+// @Failure 422 {object} dto.ProductImportResult
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/catalog/products/import [post]
+func (ctrl *CatalogController) ImportProducts(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.Error(apperror.BadRequest("file is required"))
+		return
+	}
+	if fileHeader.Size > maxImportFileBytes {
+		c.Error(apperror.TooLarge("import file is too large"))
+		return
+	}
+
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Error(apperror.BadRequest("failed to read file"))
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxImportFileBytes))
+	if err != nil {
+		c.Error(apperror.BadRequest("failed to read file"))
+		return
+	}
+
+	result, err := ctrl.catalogService.ImportProducts(c.Request.Context(), format, data, dryRun, actorFromContext(c))
+	if err != nil {
+		if result != nil {
+			c.JSON(http.StatusUnprocessableEntity, result)
+			return
+		}
+		c.Error(apperror.Wrap(err, "failed to import products"))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportProducts godoc
+// @Summary Export products (admin)
+// @Description Download all active products as CSV or JSON in the format accepted by the import
+// @Tags admin-catalog
+// @Produce text/csv
+// @Produce json
+// @Security BearerAuth
+// @Param format query string false "File format" Enums(csv, json) default(csv)
+// @Success 200 {file} file
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/catalog/products/export [get]
+func (ctrl *CatalogController) ExportProducts(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", service.FormatCSV))
+
+	data, err := ctrl.catalogService.ExportProducts(c.Request.Context(), format)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to export products"))
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == service.FormatJSON {
+		contentType = "application/json; charset=utf-8"
+	}
+	c.Header("Content-Disposition", `attachment; filename="products.`+format+`"`)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+func productImageParams(c *gin.Context) (productID, imageID uint, ok bool) {
+	pid, err := strconv.ParseUint(c.Param("productID"), 10, 32)
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid product id"))
+		return 0, 0, false
+	}
+	iid, err := strconv.ParseUint(c.Param("imageID"), 10, 32)
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid image id"))
+		return 0, 0, false
+	}
+	return uint(pid), uint(iid), true
+}
@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"strconv"
 
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
+	"flowo-backend/internal/middleware"
 	"flowo-backend/internal/model"
 	"flowo-backend/internal/service"
 
@@ -13,16 +15,18 @@ import (
 )
 
 type Controller struct {
-	service service.Service
+	service         service.Service
+	recommendations service.RecommendationService
 }
 
-func NewController(service service.Service) *Controller {
+func NewController(service service.Service, recommendations service.RecommendationService) *Controller {
 	return &Controller{
-		service: service,
+		service:         service,
+		recommendations: recommendations,
 	}
 }
 
-func (c *Controller) RegisterRoutes(router *gin.Engine) {
+func (c *Controller) RegisterRoutes(router *gin.Engine, authMiddleware *middleware.AuthMiddleware) {
 	router.GET("/health", c.HealthCheck)
 	v1 := router.Group("/api/v1")
 	{
@@ -38,10 +42,12 @@ func (c *Controller) RegisterRoutes(router *gin.Engine) {
 		// Enhanced product routes
 		products := v1.Group("/products")
 		{
-			products.GET("", c.GetAllProducts)                    // Basic product listing
-			products.GET("/search", c.SearchProducts)             // Advanced search with filters
-			products.GET("/filters", c.GetProductFilters)         // Get available filter options
-			products.GET("/:id", c.GetProductDetails)             // Enhanced product details
+			products.GET("", c.GetAllProducts)                                       // Basic product listing
+			products.GET("/search", c.SearchProducts)                                // Advanced search with filters
+			products.GET("/suggest", c.SuggestProducts)                              // Search box autocomplete
+			products.GET("/filters", c.GetProductFilters)                            // Get available filter options
+			products.GET("/:id", authMiddleware.OptionalAuth(), c.GetProductDetails) // Enhanced product details
+			products.GET("/:id/variants", c.GetProductVariants)                      // Purchasable variants of a product
 		}
 		
 		// Legacy single product routes (maintain backward compatibility)
@@ -49,9 +55,17 @@ func (c *Controller) RegisterRoutes(router *gin.Engine) {
 		{
 			product.GET("/:id", c.GetProductByID)
 			product.GET("/flower-type/:flower_type", c.GetProductsByFlowerType)
-			product.POST("", c.CreateProduct)
-			product.PUT("/:id", c.UpdateProduct)
-			product.DELETE("/:id", c.DeleteProduct)
+		}
+		
+		// Product and variant changes are admin only
+		productAdmin := v1.Group("/product", authMiddleware.RequireAuth(), authMiddleware.RequireAdmin())
+		{
+			productAdmin.POST("", c.CreateProduct)
+			productAdmin.PUT("/:id", c.UpdateProduct)
+			productAdmin.DELETE("/:id", c.DeleteProduct)
+			productAdmin.POST("/:id/variants", c.CreateVariant)
+			productAdmin.PUT("/:id/variants/:variant_id", c.UpdateVariant)
+			productAdmin.DELETE("/:id/variants/:variant_id", c.DeleteVariant)
 		}
 		
 		// Catalog routes
@@ -80,12 +94,12 @@ func (x *Controller) HealthCheck(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} model.Response{data=[]model.Todo}
-// @Failure 500 {object} model.Response
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/todos [get]
 func (c *Controller) GetAllTodos(ctx *gin.Context) {
-	todos, err := c.service.GetAllTodos()
+	todos, err := c.service.GetAllTodos(ctx.Request.Context())
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, model.NewResponse("Failed to fetch todos", nil))
+		ctx.Error(apperror.Wrap(err, "Failed to fetch todos"))
 		return
 	}
 
@@ -100,19 +114,19 @@ func (c *Controller) GetAllTodos(ctx *gin.Context) {
 // @Produce json
 // @Param id path int true "Todo ID"
 // @Success 200 {object} model.Response{data=model.Todo}
-// @Failure 400 {object} model.Response
-// @Failure 404 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
 // @Router /api/v1/todos/{id} [get]
 func (c *Controller) GetTodoByID(ctx *gin.Context) {
 	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, model.NewResponse("Invalid ID format", nil))
+		ctx.Error(apperror.BadRequest("Invalid ID format"))
 		return
 	}
 
-	todo, err := c.service.GetTodoByID(uint(id))
+	todo, err := c.service.GetTodoByID(ctx.Request.Context(), uint(id))
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, model.NewResponse("Todo not found", nil))
+		ctx.Error(apperror.Wrap(err, "Failed to fetch todo"))
 		return
 	}
 
@@ -127,19 +141,19 @@ func (c *Controller) GetTodoByID(ctx *gin.Context) {
 // @Produce json
 // @Param todo body dto.TodoCreate true "Create todo"
 // @Success 201 {object} model.Response{data=model.Todo}
-// @Failure 400 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/todos [post]
 func (c *Controller) CreateTodo(ctx *gin.Context) {
 	var input dto.TodoCreate
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, model.NewResponse("Invalid input", nil))
+		ctx.Error(apperror.InvalidRequest(err))
 		return
 	}
 
-	todo, err := c.service.CreateTodo(&input)
+	todo, err := c.service.CreateTodo(ctx.Request.Context(), &input)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, model.NewResponse("Failed to create todo", nil))
+		ctx.Error(apperror.Wrap(err, "Failed to create todo"))
 		return
 	}
 
@@ -155,25 +169,25 @@ func (c *Controller) CreateTodo(ctx *gin.Context) {
 // @Param id path int true "Todo ID"
 // @Param todo body dto.TodoCreate true "Update todo"
 // @Success 200 {object} model.Response{data=model.Todo}
-// @Failure 400 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/todos/{id} [put]
 func (c *Controller) UpdateTodo(ctx *gin.Context) {
 	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, model.NewResponse("Invalid ID format", nil))
+		ctx.Error(apperror.BadRequest("Invalid ID format"))
 		return
 	}
 
 	var input dto.TodoCreate
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, model.NewResponse("Invalid input", nil))
+		ctx.Error(apperror.InvalidRequest(err))
 		return
 	}
 
-	todo, err := c.service.UpdateTodo(uint(id), &input)
+	todo, err := c.service.UpdateTodo(ctx.Request.Context(), uint(id), &input)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, model.NewResponse("Failed to update todo", nil))
+		ctx.Error(apperror.Wrap(err, "Failed to update todo"))
 		return
 	}
 
@@ -188,18 +202,18 @@ func (c *Controller) UpdateTodo(ctx *gin.Context) {
 // @Produce json
 // @Param id path int true "Todo ID"
 // @Success 200 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/todos/{id} [delete]
 func (c *Controller) DeleteTodo(ctx *gin.Context) {
 	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, model.NewResponse("Invalid ID format", nil))
+		ctx.Error(apperror.BadRequest("Invalid ID format"))
 		return
 	}
 
-	if err := c.service.DeleteTodo(uint(id)); err != nil {
-		ctx.JSON(http.StatusInternalServerError, model.NewResponse("Failed to delete todo", nil))
+	if err := c.service.DeleteTodo(ctx.Request.Context(), uint(id)); err != nil {
+		ctx.Error(apperror.Wrap(err, "Failed to delete todo"))
 		return
 	}
 
@@ -213,12 +227,12 @@ func (c *Controller) DeleteTodo(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} model.Response{data=[]model.Product}
-// @Failure 500 {object} model.Response
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/products [get]
 func (c *Controller) GetAllProducts(ctx *gin.Context) {
-	products, err := c.service.GetAllProductsWithEffectivePrice()
+	products, err := c.service.GetAllProductsWithEffectivePrice(ctx.Request.Context())
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, model.NewResponse("Failed to fetch products", nil))
+		ctx.Error(apperror.Wrap(err, "Failed to fetch products"))
 		return
 	}
 
@@ -233,19 +247,19 @@ func (c *Controller) GetAllProducts(ctx *gin.Context) {
 // @Produce json
 // @Param id path int true "Product ID"
 // @Success 200 {object} model.Response{data=model.Product}
-// @Failure 400 {object} model.Response
-// @Failure 404 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
 // @Router /api/v1/product/{id} [get]
 func (c *Controller) GetProductByID(ctx *gin.Context) {
 	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, model.NewResponse("Invalid ID format", nil))
+		ctx.Error(apperror.BadRequest("Invalid ID format"))
 		return
 	}
 
-	product, err := c.service.GetProductByIDWithEffectivePrice(uint(id))
+	product, err := c.service.GetProductByIDWithEffectivePrice(ctx.Request.Context(), uint(id))
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, model.NewResponse("Product not found", nil))
+		ctx.Error(apperror.Wrap(err, "Failed to fetch product"))
 		return
 	}
 
@@ -258,26 +272,26 @@ func (c *Controller) GetProductByID(ctx *gin.Context) {
 // @Tags products
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param product body dto.ProductCreate true "Create product"
 // @Success 201 {object} model.Response{data=model.Product}
-// @Failure 400 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/product [post]
 func (c *Controller) CreateProduct(ctx *gin.Context) {
 	var input dto.ProductCreate
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, model.NewResponse("Invalid input", nil))
+		ctx.Error(apperror.InvalidRequest(err))
 		return
 	}
 
-	product, err := c.service.CreateProduct(&input)
+	input.Actor = actorFromContext(ctx)
+
+	product, err := c.service.CreateProduct(ctx.Request.Context(), &input)
 	if err != nil {
-		if err.Error() == "flower type not found" {
-			ctx.JSON(http.StatusBadRequest, model.NewResponse("Invalid flower type", nil))
-			return
-		}
-		log.Error().Err(err).Msg("Failed to create product")
-		ctx.JSON(http.StatusInternalServerError, model.NewResponse("Failed to create product", nil))
+		ctx.Error(apperror.Wrap(err, "Failed to create product"))
 		return
 	}
 
@@ -290,37 +304,33 @@ func (c *Controller) CreateProduct(ctx *gin.Context) {
 // @Tags products
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Product ID"
 // @Param product body dto.ProductCreate true "Update product"
 // @Success 200 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/product/{id} [put]
 func (c *Controller) UpdateProduct(ctx *gin.Context) {
 	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, model.NewResponse("Invalid ID format", nil))
+		ctx.Error(apperror.BadRequest("Invalid ID format"))
 		return
 	}
 
 	var input dto.ProductCreate
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		ctx.JSON(http.StatusBadRequest, model.NewResponse("Invalid input", nil))
+		ctx.Error(apperror.InvalidRequest(err))
 		return
 	}
 
-	err = c.service.UpdateProduct(uint(id), &input)
+	input.Actor = actorFromContext(ctx)
+
+	err = c.service.UpdateProduct(ctx.Request.Context(), uint(id), &input)
 	if err != nil {
-		if err.Error() == "flower type not found" {
-			ctx.JSON(http.StatusBadRequest, model.NewResponse("Invalid flower type", nil))
-			return
-		}
-		if err.Error() == "product not found" {
-			ctx.JSON(http.StatusNotFound, model.NewResponse("Product not found", nil))
-			return
-		}
-		log.Error().Err(err).Msg("Failed to update product")
-		ctx.JSON(http.StatusInternalServerError, model.NewResponse("Failed to update product", nil))
+		ctx.Error(apperror.Wrap(err, "Failed to update product"))
 		return
 	}
 
@@ -333,26 +343,23 @@ func (c *Controller) UpdateProduct(ctx *gin.Context) {
 // @Tags products
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Product ID"
 // @Success 200 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 404 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
 // @Router /api/v1/product/{id} [delete]
 func (c *Controller) DeleteProduct(ctx *gin.Context) {
 	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, model.NewResponse("Invalid ID format", nil))
+		ctx.Error(apperror.BadRequest("Invalid ID format"))
 		return
 	}
 
-	if err := c.service.DeleteProduct(uint(id)); err != nil {
-		if err.Error() == "not found" {
-			ctx.JSON(http.StatusNotFound, model.NewResponse("Product not found", nil))
-			return
-		}
-		log.Error().Err(err).Msg("Failed to delete product")
-
-		ctx.JSON(http.StatusInternalServerError, model.NewResponse("Failed to delete product", nil))
+	if err := c.service.DeleteProduct(ctx.Request.Context(), uint(id)); err != nil {
+		ctx.Error(apperror.Wrap(err, "Failed to delete product"))
 		return
 	}
 
@@ -367,24 +374,19 @@ func (c *Controller) DeleteProduct(ctx *gin.Context) {
 // @Produce json
 // @Param flower_type path string true "Flower Type"
 // @Success 200 {object} model.Response{data=[]model.Product}
-// @Failure 400 {object} model.Response
-// @Failure 404 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
 // @Router /api/v1/product/flower-type/{flower_type} [get]
 func (c *Controller) GetProductsByFlowerType(ctx *gin.Context) {
 	flowerType := ctx.Param("flower_type")
 	if flowerType == "" {
-		ctx.JSON(http.StatusBadRequest, model.NewResponse("Flower type is required", nil))
+		ctx.Error(apperror.BadRequest("Flower type is required"))
 		return
 	}
 
-	products, err := c.service.GetProductsByFlowerType(flowerType)
+	products, err := c.service.GetProductsByFlowerType(ctx.Request.Context(), flowerType)
 	if err != nil {
-		if err.Error() == "flower type not found" {
-			ctx.JSON(http.StatusNotFound, model.NewResponse("Invalid flower type", nil))
-			return
-		}
-		log.Error().Err(err).Msg("Failed to fetch products by flower type")
-		ctx.JSON(http.StatusInternalServerError, model.NewResponse("Failed to fetch products", nil))
+		ctx.Error(apperror.Wrap(err, "Failed to fetch products"))
 		return
 	}
 
@@ -398,12 +400,12 @@ func (c *Controller) GetProductsByFlowerType(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} model.Response{data=[]model.FlowerType}
-// @Failure 500 {object} model.Response
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/flower-types [get]
 func (c *Controller) GetAllFlowerTypes(ctx *gin.Context) {
-	flowerTypes, err := c.service.GetAllFlowerTypes()
+	flowerTypes, err := c.service.GetAllFlowerTypes(ctx.Request.Context())
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, model.NewResponse("Failed to fetch flower types", nil))
+		ctx.Error(apperror.Wrap(err, "Failed to fetch flower types"))
 		return
 	}
 
@@ -412,78 +414,93 @@ func (c *Controller) GetAllFlowerTypes(ctx *gin.Context) {
 
 // SearchProducts godoc
 // @Summary Search products with advanced filters
-// @Description Search and filter products by multiple criteria with pagination and sorting
+// @Description Search and filter products by multiple criteria with pagination and sorting. The response includes facet counts for the current filters.
 // @Tags products
 // @Accept json
 // @Produce json
-// @Param query query string false "Search query for product name or description"
-// @Param flower_type query string false "Filter by flower type"
-// @Param occasion query string false "Filter by occasion"
-// @Param price_min query number false "Minimum price filter"
-// @Param price_max query number false "Maximum price filter"
-// @Param condition query string false "Filter by product condition" Enums(NewFlower, OldFlower, LowStock)
-// @Param sort_by query string false "Sort by option" Enums(price_asc, price_desc, name_asc, name_desc, newest, best_selling)
+// @Param query query string false "Full-text search over name, description, flower type and occasions; tolerant of missing accents and typos"
+// @Param flower_type query []string false "Filter by flower type; repeat to match any of several" collectionFormat(multi)
+// @Param occasion query []string false "Filter by occasion; repeat to match any of several" collectionFormat(multi)
+// @Param price_min query number false "Minimum effective (discounted) price"
+// @Param price_max query number false "Maximum effective (discounted) price"
+// @Param size query string false "Filter by variant size"
+// @Param color query string false "Filter by variant color"
+// @Param wrapping query string false "Filter by variant wrapping"
+// @Param min_rating query number false "Minimum average review rating" minimum(0) maximum(5)
+// @Param condition query []string false "Filter by product condition; repeat to match any of several" Enums(NewFlower, OldFlower, LowStock) collectionFormat(multi)
+// @Param sort_by query string false "Sort by option" Enums(relevance, price_asc, price_desc, name_asc, name_desc, newest, best_selling, top_rated)
 // @Param page query int false "Page number (default: 1)" minimum(1)
+// @Param cursor query string false "Opaque cursor from pagination.next_cursor or prev_cursor; replaces page, newest sort only"
 // @Param limit query int false "Items per page (default: 20, max: 100)" minimum(1) maximum(100)
 // @Success 200 {object} model.Response{data=model.ProductSearchResponse}
-// @Failure 400 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/products/search [get]
 func (c *Controller) SearchProducts(ctx *gin.Context) {
 	var query dto.ProductSearchQuery
 	if err := ctx.ShouldBindQuery(&query); err != nil {
-		ctx.JSON(http.StatusBadRequest, model.NewResponse("Invalid query parameters", nil))
+		ctx.Error(apperror.InvalidRequest(err))
 		return
 	}
 
-	result, err := c.service.SearchProducts(&query)
+	result, err := c.service.SearchProducts(ctx.Request.Context(), &query)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to search products")
-		
-		// Handle specific validation errors
-		if err.Error() == "minimum price cannot be greater than maximum price" ||
-		   err.Error() == "invalid product condition" ||
-		   err.Error() == "invalid sort option" {
-			ctx.JSON(http.StatusBadRequest, model.NewResponse(err.Error(), nil))
-			return
-		}
-		
-		ctx.JSON(http.StatusInternalServerError, model.NewResponse("Failed to search products", nil))
+		ctx.Error(apperror.Wrap(err, "Failed to search products"))
 		return
 	}
 
 	ctx.JSON(http.StatusOK, model.NewResponse("Products searched successfully", result))
 }
 
+// SuggestProducts godoc
+// @Summary Autocomplete search queries
+// @Description Ranked completions of a partially typed query from product names, flower types, occasions and popular searches
+// @Tags products
+// @Produce json
+// @Param q query string true "Partially typed query"
+// @Param limit query int false "Maximum suggestions (default: 8, max: 20)" minimum(1) maximum(20)
+// @Success 200 {object} model.Response{data=dto.SuggestionResponse}
+// @Failure 400 {object} model.ErrorResponse
+// @Router /api/v1/products/suggest [get]
+func (c *Controller) SuggestProducts(ctx *gin.Context) {
+	q := ctx.Query("q")
+	if q == "" {
+		ctx.Error(apperror.BadRequest("q is required"))
+		return
+	}
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "8"))
+
+	ctx.JSON(http.StatusOK, model.NewResponse("Suggestions retrieved successfully", c.service.SuggestProducts(q, limit)))
+}
+
 // GetProductDetails godoc
 // @Summary Get detailed product information
-// @Description Get comprehensive product details including images, occasions, ratings, and sales data
+// @Description Get comprehensive product details including images, occasions, ratings, and sales data. Views by signed-in users are recorded for trending and recommendations.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
 // @Success 200 {object} model.Response{data=model.Product}
-// @Failure 400 {object} model.Response
-// @Failure 404 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/products/{id} [get]
 func (c *Controller) GetProductDetails(ctx *gin.Context) {
 	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, model.NewResponse("Invalid ID format", nil))
+		ctx.Error(apperror.BadRequest("Invalid ID format"))
 		return
 	}
 
-	product, err := c.service.GetProductDetails(uint(id))
+	product, err := c.service.GetProductDetails(ctx.Request.Context(), uint(id))
 	if err != nil {
-		if err.Error() == "not found" {
-			ctx.JSON(http.StatusNotFound, model.NewResponse("Product not found", nil))
-			return
-		}
-		log.Error().Err(err).Msg("Failed to fetch product details")
-		ctx.JSON(http.StatusInternalServerError, model.NewResponse("Failed to fetch product details", nil))
+		ctx.Error(apperror.Wrap(err, "Failed to fetch product details"))
 		return
 	}
+	if firebaseUID, ok := middleware.GetFirebaseUserID(ctx); ok {
+		c.recommendations.RecordInteraction(ctx.Request.Context(), firebaseUID, "", uint(id), model.InteractionView)
+	}
 
 	ctx.JSON(http.StatusOK, model.NewResponse("Product details fetched successfully", product))
 }
@@ -495,13 +512,12 @@ func (c *Controller) GetProductDetails(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} model.Response{data=model.FilterOptions}
-// @Failure 500 {object} model.Response
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/products/filters [get]
 func (c *Controller) GetProductFilters(ctx *gin.Context) {
-	filters, err := c.service.GetSearchFilters()
+	filters, err := c.service.GetSearchFilters(ctx.Request.Context())
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to fetch product filters")
-		ctx.JSON(http.StatusInternalServerError, model.NewResponse("Failed to fetch filter options", nil))
+		ctx.Error(apperror.Wrap(err, "Failed to fetch filter options"))
 		return
 	}
 
@@ -515,13 +531,12 @@ func (c *Controller) GetProductFilters(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} model.Response{data=[]model.Occasion}
-// @Failure 500 {object} model.Response
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/occasions [get]
 func (c *Controller) GetAllOccasions(ctx *gin.Context) {
-	occasions, err := c.service.GetAllOccasions()
+	occasions, err := c.service.GetAllOccasions(ctx.Request.Context())
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to fetch occasions")
-		ctx.JSON(http.StatusInternalServerError, model.NewResponse("Failed to fetch occasions", nil))
+		ctx.Error(apperror.Wrap(err, "Failed to fetch occasions"))
 		return
 	}
 
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/middleware"
+	"flowo-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImportantDateController struct {
+	importantDateService service.ImportantDateService
+}
+
+func NewImportantDateController(s service.ImportantDateService) *ImportantDateController {
+	return &ImportantDateController{importantDateService: s}
+}
+
+func (ctrl *ImportantDateController) RegisterRoutes(rg *gin.RouterGroup) {
+	dates := rg.Group("/important-dates")
+
+	dates.GET("", ctrl.GetDates)
+	dates.GET("/upcoming", ctrl.GetUpcoming)
+	dates.POST("", ctrl.CreateDate)
+	dates.PUT("/:dateID", ctrl.UpdateDate)
+	dates.DELETE("/:dateID", ctrl.DeleteDate)
+}
+
+// GetDates godoc
+// @Summary Get my important dates
+// @Description Birthdays, anniversaries and other occasions saved by the authenticated user, with their next occurrence
+// @Tags important-dates
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.ImportantDateResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/important-dates [get]
+func (ctrl *ImportantDateController) GetDates(c *gin.Context) {
+	uid, ok := middleware.GetFirebaseUserID(c)
+	if !ok {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	dates, err := ctrl.importantDateService.GetDates(c.Request.Context(), uid)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to get important dates"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dates)
+}
+
+// GetUpcoming godoc
+// @Summary Get my gifting calendar
+// @Description Important dates coming up within the next days, soonest first
+// @Tags important-dates
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "Days ahead to include (<=366)" default(30)
+// @Success 200 {array} dto.ImportantDateResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/important-dates/upcoming [get]
+func (ctrl *ImportantDateController) GetUpcoming(c *gin.Context) {
+	uid, ok := middleware.GetFirebaseUserID(c)
+	if !ok {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 || days > 366 {
+		c.Error(apperror.BadRequest("days must be between 1 and 366"))
+		return
+	}
+
+	dates, err := ctrl.importantDateService.GetUpcoming(c.Request.Context(), uid, days)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to get upcoming dates"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dates)
+}
+
+// CreateDate godoc
+// @Summary Save an important date
+// @Description Save a birthday, anniversary or other occasion. A reminder with suggestions is sent remind_days_before days ahead.
+// @Tags important-dates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.ImportantDateRequest true "Important date"
+// @Success 201 {object} dto.ImportantDateResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/important-dates [post]
+func (ctrl *ImportantDateController) CreateDate(c *gin.Context) {
+	uid, ok := middleware.GetFirebaseUserID(c)
+	if !ok {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req dto.ImportantDateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	date, err := ctrl.importantDateService.CreateDate(c.Request.Context(), uid, req)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to save important date"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, date)
+}
+
+// UpdateDate godoc
+// @Summary Update an important date
+// @Tags important-dates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param dateID path int true "Important date ID"
+// @Param request body dto.ImportantDateRequest true "Important date"
+// @Success 200 {object} dto.ImportantDateResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/important-dates/{dateID} [put]
+func (ctrl *ImportantDateController) UpdateDate(c *gin.Context) {
+	uid, ok := middleware.GetFirebaseUserID(c)
+	if !ok {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	dateID, err := strconv.Atoi(c.Param("dateID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid important date id"))
+		return
+	}
+
+	var req dto.ImportantDateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	date, err := ctrl.importantDateService.UpdateDate(c.Request.Context(), uid, dateID, req)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to update important date"))
+		return
+	}
+
+	c.JSON(http.StatusOK, date)
+}
+
+// DeleteDate godoc
+// @Summary Delete an important date
+// @Tags important-dates
+// @Produce json
+// @Security BearerAuth
+// @Param dateID path int true "Important date ID"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/important-dates/{dateID} [delete]
+func (ctrl *ImportantDateController) DeleteDate(c *gin.Context) {
+	uid, ok := middleware.GetFirebaseUserID(c)
+	if !ok {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	dateID, err := strconv.Atoi(c.Param("dateID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid important date id"))
+		return
+	}
+
+	if err := ctrl.importantDateService.DeleteDate(c.Request.Context(), uid, dateID); err != nil {
+		c.Error(apperror.Wrap(err, "failed to delete important date"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Important date deleted"})
+}
@@ -0,0 +1,279 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/middleware"
+	"flowo-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InventoryController struct {
+	inventoryService service.InventoryService
+}
+
+func NewInventoryController(is service.InventoryService) *InventoryController {
+	return &InventoryController{inventoryService: is}
+}
+
+func (ctrl *InventoryController) RegisterRoutes(rg *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := rg.Group("/admin/inventory", authMiddleware.RequireAdmin())
+
+	admin.GET("/products/:productID/batches", ctrl.GetBatches)
+	admin.POST("/products/:productID/batches", ctrl.ReceiveBatch)
+	admin.GET("/products/:productID/wastage", ctrl.GetWastage)
+	admin.GET("/products/:productID/movements", ctrl.GetMovements)
+	admin.POST("/products/:productID/adjustments", ctrl.AdjustStock)
+	admin.PUT("/products/:productID/low-stock-threshold", ctrl.SetLowStockThreshold)
+	admin.POST("/freshness/run", ctrl.RunFreshness)
+}
+
+// ReceiveBatch godoc
+// @Summary Receive an inventory batch (admin)
+// @Description Record a received batch of a product or variant with its shelf life and add it to stock
+// @Tags admin-inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param productID path int true "Product ID"
+// @Param request body dto.ReceiveBatchRequest true "Batch"
+// @Success 201 {object} model.InventoryBatch
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/inventory/products/{productID}/batches [post]
+func (ctrl *InventoryController) ReceiveBatch(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("productID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid product id"))
+		return
+	}
+
+	var req dto.ReceiveBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	batch, err := ctrl.inventoryService.ReceiveBatch(c.Request.Context(), productID, actorFromContext(c), req)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to receive batch"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, batch)
+}
+
+// GetBatches godoc
+// @Summary List inventory batches of a product (admin)
+// @Description Batches in FIFO order with remaining quantity and expiry
+// @Tags admin-inventory
+// @Produce json
+// @Security BearerAuth
+// @Param productID path int true "Product ID"
+// @Success 200 {array} model.InventoryBatch
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/inventory/products/{productID}/batches [get]
+func (ctrl *InventoryController) GetBatches(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("productID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid product id"))
+		return
+	}
+
+	batches, err := ctrl.inventoryService.GetBatches(c.Request.Context(), productID)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to get batches"))
+		return
+	}
+
+	c.JSON(http.StatusOK, batches)
+}
+
+// GetWastage godoc
+// @Summary List wastage of a product (admin)
+// @Description Stock written off from expired batches, newest first
+// @Tags admin-inventory
+// @Produce json
+// @Security BearerAuth
+// @Param productID path int true "Product ID"
+// @Success 200 {array} model.Wastage
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/inventory/products/{productID}/wastage [get]
+func (ctrl *InventoryController) GetWastage(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("productID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid product id"))
+		return
+	}
+
+	wastage, err := ctrl.inventoryService.GetWastage(c.Request.Context(), productID)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to get wastage"))
+		return
+	}
+
+	c.JSON(http.StatusOK, wastage)
+}
+
+// RunFreshness godoc
+// @Summary Run the freshness job now (admin)
+// @Description Write off expired batches and re-derive product statuses without waiting for the scheduler
+// @Tags admin-inventory
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.FreshnessReport
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/inventory/freshness/run [post]
+func (ctrl *InventoryController) RunFreshness(c *gin.Context) {
+	report, err := ctrl.inventoryService.RefreshFreshness(c.Request.Context(), time.Now(), actorFromContext(c))
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to refresh freshness"))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetMovements godoc
+// @Summary List inventory movements of a product (admin)
+// @Description Append-only stock ledger of one of a product's variants, or without variant_id of the product-level stock, newest first, with the stock derived from it
+// @Tags admin-inventory
+// @Produce json
+// @Security BearerAuth
+// @Param productID path int true "Product ID"
+// @Param variant_id query int false "Movements of this variant instead of the product-level stock"
+// @Param page query int false "Page number"
+// @Param limit query int false "Limit per page (<=100)" default(50)
+// @Success 200 {object} dto.InventoryMovementsResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/inventory/products/{productID}/movements [get]
+func (ctrl *InventoryController) GetMovements(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("productID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid product id"))
+		return
+	}
+
+	var variantID *int
+	if v := c.Query("variant_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.Error(apperror.BadRequest("invalid variant id"))
+			return
+		}
+		variantID = &id
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	res, err := ctrl.inventoryService.GetMovements(c.Request.Context(), productID, variantID, page, limit)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to get movements"))
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// AdjustStock godoc
+// @Summary Manually adjust stock (admin)
+// @Description Apply a stock correction (positive or negative) to a product or variant and record it in the ledger
+// @Tags admin-inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param productID path int true "Product ID"
+// @Param request body dto.StockAdjustmentRequest true "Adjustment"
+// @Success 201 {object} model.InventoryMovement
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/inventory/products/{productID}/adjustments [post]
+func (ctrl *InventoryController) AdjustStock(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("productID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid product id"))
+		return
+	}
+
+	var req dto.StockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	movement, err := ctrl.inventoryService.AdjustStock(c.Request.Context(), productID, actorFromContext(c), req)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to adjust stock"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, movement)
+}
+
+// SetLowStockThreshold godoc
+// @Summary Set the low-stock threshold of a product (admin)
+// @Description Admins are notified when a stock change takes the product (or one of its variants) below this threshold
+// @Tags admin-inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param productID path int true "Product ID"
+// @Param request body dto.LowStockThresholdRequest true "Threshold"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/inventory/products/{productID}/low-stock-threshold [put]
+func (ctrl *InventoryController) SetLowStockThreshold(c *gin.Context) {
+	productID, err := strconv.Atoi(c.Param("productID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid product id"))
+		return
+	}
+
+	var req dto.LowStockThresholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	if err := ctrl.inventoryService.SetLowStockThreshold(c.Request.Context(), productID, *req.Threshold); err != nil {
+		c.Error(apperror.Wrap(err, "failed to set threshold"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Low-stock threshold updated"})
+}
+
+// actorFromContext identifies who is making a change for the inventory ledger
+func actorFromContext(c *gin.Context) string {
+	if uid, ok := middleware.GetFirebaseUserID(c); ok && uid != "" {
+		return uid
+	}
+	return "anonymous"
+}
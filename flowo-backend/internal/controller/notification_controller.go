@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/middleware"
+	"flowo-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationController struct {
+	notificationService service.NotificationService
+}
+
+func NewNotificationController(ns service.NotificationService) *NotificationController {
+	return &NotificationController{notificationService: ns}
+}
+
+func (ctrl *NotificationController) RegisterRoutes(rg *gin.RouterGroup) {
+	notifications := rg.Group("/notifications")
+
+	notifications.GET("", ctrl.GetNotifications)
+	notifications.PUT("/:notificationID/read", ctrl.MarkAsRead)
+	notifications.PUT("/read-all", ctrl.MarkAllAsRead)
+}
+
+// GetNotifications godoc
+// @Summary Get my notifications
+// @Description Notifications of the authenticated user, newest first
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "Only unread notifications"
+// @Param page query int false "Page number"
+// @Param limit query int false "Limit per page (<=100)" default(20)
+// @Success 200 {array} model.Notification
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/notifications [get]
+func (ctrl *NotificationController) GetNotifications(c *gin.Context) {
+	uid, ok := middleware.GetFirebaseUserID(c)
+	if !ok {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	unreadOnly := c.Query("unread") == "true"
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	notifications, err := ctrl.notificationService.GetNotifications(c.Request.Context(), uid, unreadOnly, page, limit)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "failed to get notifications"))
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+// MarkAsRead godoc
+// @Summary Mark a notification as read
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Param notificationID path int true "Notification ID"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/notifications/{notificationID}/read [put]
+func (ctrl *NotificationController) MarkAsRead(c *gin.Context) {
+	uid, ok := middleware.GetFirebaseUserID(c)
+	if !ok {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	notificationID, err := strconv.Atoi(c.Param("notificationID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid notification id"))
+		return
+	}
+
+	if err := ctrl.notificationService.MarkAsRead(c.Request.Context(), notificationID, uid); err != nil {
+		c.Error(apperror.Wrap(err, "failed to mark notification as read"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+// MarkAllAsRead godoc
+// @Summary Mark all my notifications as read
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.Response
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/notifications/read-all [put]
+func (ctrl *NotificationController) MarkAllAsRead(c *gin.Context) {
+	uid, ok := middleware.GetFirebaseUserID(c)
+	if !ok {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	if err := ctrl.notificationService.MarkAllAsRead(c.Request.Context(), uid); err != nil {
+		c.Error(apperror.Wrap(err, "failed to mark notifications as read"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notifications marked as read"})
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/service"
 	"net/http"
@@ -46,32 +47,32 @@ func (ctrl *OrderController) RegisterRoutes(rg *gin.RouterGroup) {
 // @Security BearerAuth
 // @Param request body dto.CreateOrderRequest true "Order details"
 // @Success 201 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/orders [post]
 func (ctrl *OrderController) CreateOrder(c *gin.Context) {
 	firebaseUID, exists := middleware.GetFirebaseUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
-	user, err := ctrl.userService.GetUserByFirebaseUID(firebaseUID)
+	user, err := ctrl.userService.GetUserByFirebaseUID(c.Request.Context(), firebaseUID)
 	if err != nil || user == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		c.Error(apperror.Wrap(err, "user not found"))
 		return
 	}
 
 	var req dto.CreateOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request format"})
+		c.Error(apperror.InvalidRequest(err))
 		return
 	}
 
-	orderID, err := ctrl.orderService.CreateOrder(user.FirebaseUID, req)
+	orderID, err := ctrl.orderService.CreateOrder(c.Request.Context(), user.FirebaseUID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create order"})
+		c.Error(apperror.Wrap(err, "failed to create order"))
 		return
 	}
 
@@ -89,26 +90,26 @@ func (ctrl *OrderController) CreateOrder(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {array} dto.OrderResponse
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/orders [get]
 func (ctrl *OrderController) GetUserOrders(c *gin.Context) {
 	firebaseUID, exists := middleware.GetFirebaseUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
-	user, err := ctrl.userService.GetUserByFirebaseUID(firebaseUID)
+	user, err := ctrl.userService.GetUserByFirebaseUID(c.Request.Context(), firebaseUID)
 	if err != nil || user == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		c.Error(apperror.Wrap(err, "user not found"))
 		return
 	}
 
-	orders, err := ctrl.orderService.GetUserOrders(user.FirebaseUID)
+	orders, err := ctrl.orderService.GetUserOrders(c.Request.Context(), user.FirebaseUID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot fetch orders"})
+		c.Error(apperror.Wrap(err, "cannot fetch orders"))
 		return
 	}
 
@@ -125,44 +126,44 @@ func (ctrl *OrderController) GetUserOrders(c *gin.Context) {
 // @Param orderID path int true "Order ID"
 // @Param request body dto.UpdateOrderStatusRequest true "Update status request"
 // @Success 200 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 403 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/admin/orders/{orderID}/status [put]
 func (ctrl *OrderController) UpdateOrderStatus(c *gin.Context) {
 	orderID, err := strconv.Atoi(c.Param("orderID"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order_id"})
+		c.Error(apperror.BadRequest("invalid order_id"))
 		return
 	}
 
 	var req dto.UpdateOrderStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.InvalidRequest(err))
 		return
 	}
 
 	firebaseUID, exists := middleware.GetFirebaseUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
-	user, err := ctrl.userService.GetUserByFirebaseUID(firebaseUID)
+	user, err := ctrl.userService.GetUserByFirebaseUID(c.Request.Context(), firebaseUID)
 	if err != nil || user == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		c.Error(apperror.Unauthorized("user not found"))
 		return
 	}
 
 	// role, _ := c.Get("role")
 	// if role != "Admin" {
-	// 	c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: admin only"})
+	// 	c.Error(apperror.Forbidden("forbidden: admin only"))
 	// 	return
 	// }
 
-	if err := ctrl.orderService.UpdateStatus(orderID, req, user.FirebaseUID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+	if err := ctrl.orderService.UpdateStatus(c.Request.Context(), orderID, req, user.FirebaseUID); err != nil {
+		c.Error(apperror.Wrap(err, "update failed"))
 		return
 	}
 
@@ -177,44 +178,44 @@ func (ctrl *OrderController) UpdateOrderStatus(c *gin.Context) {
 // @Security BearerAuth
 // @Param orderID path int true "Order ID"
 // @Success 200 {object} dto.OrderDetailResponse
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 403 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/orders/{orderID} [get]
 func (ctrl *OrderController) GetOrderDetailByID(c *gin.Context) {
 	orderID, err := strconv.Atoi(c.Param("orderID"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order_id"})
+		c.Error(apperror.BadRequest("invalid order_id"))
 		return
 	}
 
 	firebaseUID, exists := middleware.GetFirebaseUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
-	user, err := ctrl.userService.GetUserByFirebaseUID(firebaseUID)
+	user, err := ctrl.userService.GetUserByFirebaseUID(c.Request.Context(), firebaseUID)
 	if err != nil || user == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		c.Error(apperror.Wrap(err, "user not found"))
 		return
 	}
 
-	ownerID, err := ctrl.orderService.GetOrderOwnerID(orderID)
+	ownerID, err := ctrl.orderService.GetOrderOwnerID(c.Request.Context(), orderID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot verify order owner"})
+		c.Error(apperror.Wrap(err, "cannot verify order owner"))
 		return
 	}
 
 	if ownerID != user.FirebaseUID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed"})
+		c.Error(apperror.Forbidden("not allowed"))
 		return
 	}
 
-	order, err := ctrl.orderService.GetOrderDetailByID(orderID)
+	order, err := ctrl.orderService.GetOrderDetailByID(c.Request.Context(), orderID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot fetch order detail"})
+		c.Error(apperror.Wrap(err, "cannot fetch order detail"))
 		return
 	}
 
@@ -229,54 +230,54 @@ func (ctrl *OrderController) GetOrderDetailByID(c *gin.Context) {
 // @Security BearerAuth
 // @Param order_id query int true "Order ID"
 // @Success 200 {object} dto.OrderStatusResponse
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 403 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/orders/status [get]
 func (ctrl *OrderController) GetOrderStatus(c *gin.Context) {
 	orderIDStr := c.Query("order_id")
 	if orderIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "order_id is required"})
+		c.Error(apperror.BadRequest("order_id is required"))
 		return
 	}
 	orderID, err := strconv.Atoi(orderIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order_id"})
+		c.Error(apperror.BadRequest("invalid order_id"))
 		return
 	}
 
 	firebaseUID, exists := middleware.GetFirebaseUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
-	user, err := ctrl.userService.GetUserByFirebaseUID(firebaseUID)
+	user, err := ctrl.userService.GetUserByFirebaseUID(c.Request.Context(), firebaseUID)
 	if err != nil || user == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+		c.Error(apperror.Wrap(err, "user not found"))
 		return
 	}
 
-	ownerID, err := ctrl.orderService.GetOrderOwnerID(orderID)
+	ownerID, err := ctrl.orderService.GetOrderOwnerID(c.Request.Context(), orderID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot verify order owner"})
+		c.Error(apperror.Wrap(err, "cannot verify order owner"))
 		return
 	}
 
 	if ownerID != user.FirebaseUID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed"})
+		c.Error(apperror.Forbidden("not allowed"))
 		return
 	}
 
-	order, err := ctrl.orderService.GetOrderByID(orderID)
+	order, err := ctrl.orderService.GetOrderByID(c.Request.Context(), orderID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot fetch order status"})
+		c.Error(apperror.Wrap(err, "cannot fetch order status"))
 		return
 	}
 
 	if order == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "order not found"})
+		c.Error(apperror.BadRequest("order not found"))
 		return
 	}
 
@@ -294,16 +295,17 @@ func (ctrl *OrderController) GetOrderStatus(c *gin.Context) {
 // @Param start_date query string false "Start date (YYYY-MM-DD)"
 // @Param end_date query string false "End date (YYYY-MM-DD)"
 // @Param page query int false "Page number"
+// @Param cursor query string false "Opaque cursor from pagination.next_cursor or prev_cursor; replaces page"
 // @Param limit query int false "Limit per page"
-// @Success 200 {array} dto.AdminOrderResponse
-// @Failure 401 {object} model.Response
-// @Failure 403 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Success 200 {object} dto.AdminOrderListResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/admin/orders [get]
 func (ctrl *OrderController) AdminGetOrders(c *gin.Context) {
 	// role, _ := c.Get("role")
 	// if role != "Admin" {
-	// 	c.JSON(http.StatusForbidden, gin.H{"error": "not allowed"})
+	// 	c.Error(apperror.Forbidden("not allowed"))
 	// 	return
 	// }
 
@@ -314,9 +316,11 @@ func (ctrl *OrderController) AdminGetOrders(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	orders, err := ctrl.orderService.AdminGetOrders(status, userID, startDate, endDate, page, limit)
+	cursor := c.Query("cursor")
+
+	orders, err := ctrl.orderService.AdminGetOrders(c.Request.Context(), status, userID, startDate, endDate, cursor, page, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot fetch orders"})
+		c.Error(apperror.Wrap(err, "cannot fetch orders"))
 		return
 	}
 
@@ -331,27 +335,27 @@ func (ctrl *OrderController) AdminGetOrders(c *gin.Context) {
 // @Security BearerAuth
 // @Param orderID path int true "Order ID"
 // @Success 200 {object} dto.AdminOrderDetailResponse
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 403 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/admin/orders/{orderID} [get]
 func (ctrl *OrderController) GetAdminOrderDetailByID(c *gin.Context) {
 	orderID, err := strconv.Atoi(c.Param("orderID"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order_id"})
+		c.Error(apperror.BadRequest("invalid order_id"))
 		return
 	}
 
 	// role, exists := c.Get("role")
 	// if !exists || role != "Admin" {
-	// 	c.JSON(http.StatusForbidden, gin.H{"error": "not allowed"})
+	// 	c.Error(apperror.Forbidden("not allowed"))
 	// 	return
 	// }
 
-	order, err := ctrl.orderService.GetAdminOrderDetailByID(orderID)
+	order, err := ctrl.orderService.GetAdminOrderDetailByID(c.Request.Context(), orderID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot fetch order detail"})
+		c.Error(apperror.Wrap(err, "cannot fetch order detail"))
 		return
 	}
 
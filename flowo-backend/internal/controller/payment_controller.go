@@ -6,8 +6,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/payOSHQ/payos-lib-golang"
-	"github.com/rs/zerolog/log"
 
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/middleware"
 	"flowo-backend/internal/service"
@@ -38,26 +38,26 @@ func (pc *PaymentController) RegisterRoutes(rg *gin.RouterGroup, authMiddleware
 // @Produce      json
 // @Param        body  body  dto.CreatePaymentLinkRequest  true  "Create payment link request"
 // @Success      200 {object} dto.PaymentLinkResponse
-// @Failure      400 {object} map[string]string
-// @Failure      401 {object} map[string]string
-// @Failure      500 {object} map[string]string
+// @Failure      400 {object} model.ErrorResponse
+// @Failure      401 {object} model.ErrorResponse
+// @Failure      500 {object} model.ErrorResponse
 // @Security     BearerAuth
 // @Router       /api/v1/payments/create [post]
 func (pc *PaymentController) createPaymentLink(c *gin.Context) {
 	var req dto.CreatePaymentLinkRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.InvalidRequest(err))
 		return
 	}
 	// get firebase uid from middleware
 	uidStr, exists := middleware.GetFirebaseUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Firebase UID not found"})
+		c.Error(apperror.Unauthorized("Firebase UID not found"))
 		return
 	}
-	resp, err := pc.PaymentService.CreatePaymentLink(req, uidStr)
+	resp, err := pc.PaymentService.CreatePaymentLink(c.Request.Context(), req, uidStr)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(apperror.Wrap(err, "failed to create payment link"))
 		return
 	}
 	c.JSON(http.StatusOK, resp)
@@ -71,23 +71,23 @@ func (pc *PaymentController) createPaymentLink(c *gin.Context) {
 // @Produce      json
 // @Param        payload  body  dto.PayOSWebhookRequest  true  "PayOS webhook payload"
 // @Success      200 {object} map[string]string
-// @Failure      400 {object} map[string]string
+// @Failure      400 {object} model.ErrorResponse
 // @Router       /api/v1/payments/webhook [post]
 func (pc *PaymentController) webhook(c *gin.Context) {
 	// Keep webhook handler minimal: read raw body and delegate to service which
 	// performs signature verification and idempotent processing.
-	log.Info().Msg("Received PayOS webhook")
+	middleware.GetLogger(c).Info().Msg("Received PayOS webhook")
 
 	var raw payos.WebhookType
 	if err := c.ShouldBindJSON(&raw); err != nil {
-		log.Warn().Err(err).Msg("failed to unmarshal webhook body")
+		middleware.GetLogger(c).Warn().Err(err).Msg("failed to unmarshal webhook body")
 		c.Status(http.StatusOK)
 		return
 	}
-	log.Debug().Interface("webhook", raw).Msg("PayOS webhook received")
+	middleware.GetLogger(c).Debug().Interface("webhook", raw).Msg("PayOS webhook received")
 
-	if err := pc.PaymentService.HandleWebhook(raw); err != nil {
-		log.Error().Err(err).Msg("payOS webhook: processing error")
+	if err := pc.PaymentService.HandleWebhook(c.Request.Context(), raw); err != nil {
+		middleware.GetLogger(c).Error().Err(err).Msg("payOS webhook: processing error")
 		// still acknowledge to avoid retries from PayOS; processing is idempotent
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
@@ -103,29 +103,29 @@ func (pc *PaymentController) webhook(c *gin.Context) {
 // @Security BearerAuth
 // @Param order_id query int true "Order ID"
 // @Success 200 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 403 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/payments/cancel [post]
 func (pc *PaymentController) cancelOrder(c *gin.Context) {
 	orderIDStr := c.Query("order_id")
 	if orderIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "order_id is required as query param"})
+		c.Error(apperror.BadRequest("order_id is required as query param"))
 		return
 	}
 	orderID, err := strconv.Atoi(orderIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order_id"})
+		c.Error(apperror.BadRequest("invalid order_id"))
 		return
 	}
 	uid, exists := middleware.GetFirebaseUserID(c)
 	if !exists || uid == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized: invalid or missing Firebase UID"})
+		c.Error(apperror.Unauthorized("unauthorized: invalid or missing Firebase UID"))
 		return
 	}
-	if err := pc.PaymentService.CancelOrder(orderID, uid); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := pc.PaymentService.CancelOrder(c.Request.Context(), orderID, uid); err != nil {
+		c.Error(apperror.Wrap(err, "failed to cancel order"))
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "order cancelled"})
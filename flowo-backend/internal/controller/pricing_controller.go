@@ -1,10 +1,10 @@
 package controller
 
 import (
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/model"
 	"flowo-backend/internal/service"
-	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -36,18 +36,18 @@ func (ctrl *PricingController) RegisterRoutes(rg *gin.RouterGroup) {
 // @Produce json
 // @Param rule body dto.CreatePricingRuleRequest true "New Pricing Rule"
 // @Success 200 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/pricing/rule [post]
 func (c *PricingController) AddPricingRule(ctx *gin.Context) {
 	var req dto.CreatePricingRuleRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(apperror.InvalidRequest(err))
 		return
 	}
 
-	if err := c.Service.CreatePricingRule(req); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create pricing rule"})
+	if err := c.Service.CreatePricingRule(ctx.Request.Context(), req); err != nil {
+		ctx.Error(apperror.Wrap(err, "Failed to create pricing rule"))
 		return
 	}
 
@@ -57,13 +57,13 @@ func (c *PricingController) AddPricingRule(ctx *gin.Context) {
 func (c *PricingController) GetEffectivePrice(ctx *gin.Context) {
 	var product model.Product
 	if err := ctx.ShouldBindJSON(&product); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(apperror.InvalidRequest(err))
 		return
 	}
 
-	price, err := c.Service.GetEffectivePrice(product, time.Now())
+	price, err := c.Service.GetEffectivePrice(ctx.Request.Context(), product, time.Now())
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.Error(apperror.Wrap(err, "Failed to get effective price"))
 		return
 	}
 
@@ -80,13 +80,12 @@ func (c *PricingController) GetEffectivePrice(ctx *gin.Context) {
 // @Tags pricing
 // @Produce json
 // @Success 200 {array} model.PricingRule
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/pricing/rules [get]
 func (c *PricingController) GetAllRules(ctx *gin.Context) {
-	rules, err := c.Service.GetAllRules()
+	rules, err := c.Service.GetAllRules(ctx.Request.Context())
 	if err != nil {
-		log.Printf("Failed to fetch rules: %v", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch rules"})
+		ctx.Error(apperror.Wrap(err, "Could not fetch rules"))
 		return
 	}
 	ctx.JSON(http.StatusOK, rules)
@@ -101,25 +100,25 @@ func (c *PricingController) GetAllRules(ctx *gin.Context) {
 // @Param id path int true "Pricing rule ID"
 // @Param rule body model.PricingRule true "Updated pricing rule"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/pricing/rule/{id} [put]
 func (c *PricingController) UpdateRule(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		ctx.Error(apperror.BadRequest("Invalid rule ID"))
 		return
 	}
 
 	var req model.PricingRule
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		ctx.Error(apperror.InvalidRequest(err))
 		return
 	}
 	req.RuleID = id
 
-	if err := c.Service.UpdateRule(req); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rule"})
+	if err := c.Service.UpdateRule(ctx.Request.Context(), req); err != nil {
+		ctx.Error(apperror.Wrap(err, "Failed to update rule"))
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "Rule updated successfully"})
@@ -132,18 +131,18 @@ func (c *PricingController) UpdateRule(ctx *gin.Context) {
 // @Produce json
 // @Param id path int true "Pricing rule ID"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/pricing/rule/{id} [delete]
 func (c *PricingController) DeleteRule(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		ctx.Error(apperror.BadRequest("Invalid rule ID"))
 		return
 	}
 
-	if err := c.Service.DeleteRule(id); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete rule"})
+	if err := c.Service.DeleteRule(ctx.Request.Context(), id); err != nil {
+		ctx.Error(apperror.Wrap(err, "Failed to delete rule"))
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "Rule deleted successfully"})
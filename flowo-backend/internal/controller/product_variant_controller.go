@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetProductVariants godoc
+// @Summary Get product variants
+// @Description Get the purchasable variants (size, color, wrapping) of a product
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} model.Response{data=[]model.ProductVariant}
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/products/{id}/variants [get]
+func (c *Controller) GetProductVariants(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid ID format"))
+		return
+	}
+
+	variants, err := c.service.GetProductVariants(ctx.Request.Context(), uint(id))
+	if err != nil {
+		ctx.Error(apperror.Wrap(err, "Failed to fetch product variants"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewResponse("Product variants fetched successfully", variants))
+}
+
+// CreateVariant godoc
+// @Summary Create a product variant
+// @Description Add a variant with its own SKU, price delta and stock to a product
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param variant body dto.ProductVariantCreate true "Create variant"
+// @Success 201 {object} model.Response{data=model.ProductVariant}
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/product/{id}/variants [post]
+func (c *Controller) CreateVariant(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid ID format"))
+		return
+	}
+
+	var input dto.ProductVariantCreate
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	input.Actor = actorFromContext(ctx)
+
+	variant, err := c.service.CreateVariant(ctx.Request.Context(), uint(id), &input)
+	if err != nil {
+		ctx.Error(apperror.Wrap(err, "Failed to create variant"))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, model.NewResponse("Variant created successfully", variant))
+}
+
+// UpdateVariant godoc
+// @Summary Update a product variant
+// @Description Update SKU, attributes, price delta and stock of a product variant
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param variant_id path int true "Variant ID"
+// @Param variant body dto.ProductVariantCreate true "Update variant"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/product/{id}/variants/{variant_id} [put]
+func (c *Controller) UpdateVariant(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid ID format"))
+		return
+	}
+	variantID, err := strconv.ParseUint(ctx.Param("variant_id"), 10, 32)
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid variant ID format"))
+		return
+	}
+
+	var input dto.ProductVariantCreate
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	input.Actor = actorFromContext(ctx)
+
+	if err := c.service.UpdateVariant(ctx.Request.Context(), uint(id), uint(variantID), &input); err != nil {
+		ctx.Error(apperror.Wrap(err, "Failed to update variant"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewResponse("Variant updated successfully", nil))
+}
+
+// DeleteVariant godoc
+// @Summary Delete a product variant
+// @Description Deactivate a product variant so it can no longer be purchased
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param variant_id path int true "Variant ID"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/product/{id}/variants/{variant_id} [delete]
+func (c *Controller) DeleteVariant(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid ID format"))
+		return
+	}
+	variantID, err := strconv.ParseUint(ctx.Param("variant_id"), 10, 32)
+	if err != nil {
+		ctx.Error(apperror.BadRequest("Invalid variant ID format"))
+		return
+	}
+
+	if err := c.service.DeleteVariant(ctx.Request.Context(), uint(id), uint(variantID)); err != nil {
+		ctx.Error(apperror.Wrap(err, "Failed to delete variant"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, model.NewResponse("Variant deleted successfully", nil))
+}
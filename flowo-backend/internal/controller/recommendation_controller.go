@@ -1,11 +1,13 @@
 package controller
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
+	"flowo-backend/internal/middleware"
+	"flowo-backend/internal/model"
 	"flowo-backend/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -23,39 +25,41 @@ func NewRecommendationController(recommendationService service.RecommendationSer
 
 // GetRecommendations godoc
 // @Summary Get personalized recommendations
-// @Description Get personalized product recommendations based on user preferences and behavior
+// @Description Get product recommendations. Signed-in callers get personalized recommendations and are counted in the recommendation stats; anonymous callers get popular products.
 // @Tags recommendations
 // @Accept json
 // @Produce json
-// @Param firebase_uid query int false "User ID for personalized recommendations"
+// @Security BearerAuth
 // @Param session_id query string false "Session ID for anonymous users"
 // @Param recommendation_type query string true "Type of recommendation" Enums(personalized,similar,trending,occasion_based,price_based)
 // @Param product_id query int false "Product ID for similar product recommendations"
+// @Param similarity_type query string false "Similarity for similar product recommendations" Enums(content,collaborative,hybrid)
 // @Param occasion query string false "Occasion for occasion-based recommendations"
 // @Param price_min query number false "Minimum price for price-based recommendations"
 // @Param price_max query number false "Maximum price for price-based recommendations"
 // @Param limit query int false "Number of recommendations to return" default(10)
 // @Success 200 {object} dto.RecommendationResponseDTO
-// @Failure 400 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/recommendations [get]
 func (rc *RecommendationController) GetRecommendations(c *gin.Context) {
 	var req dto.RecommendationRequestDTO
 
 	// Parse query parameters
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request parameters",
-			"details": err.Error(),
-		})
+		c.Error(apperror.InvalidRequest(err))
 		return
 	}
 
+	// only the signed-in caller is personalized for and counted
+	if firebaseUID, ok := middleware.GetFirebaseUserID(c); ok {
+		req.FirebaseUID = &firebaseUID
+	}
+
 	// Validate recommendation type
 	if req.RecommendationType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "recommendation_type is required",
-		})
+		c.Error(apperror.Validation("recommendation_type is required", apperror.Field("recommendation_type", "is required")))
 		return
 	}
 
@@ -67,7 +71,7 @@ func (rc *RecommendationController) GetRecommendations(c *gin.Context) {
 		req.Limit = 50 // Max limit to prevent performance issues
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	var response *dto.RecommendationResponseDTO
 	var err error
 
@@ -76,12 +80,11 @@ func (rc *RecommendationController) GetRecommendations(c *gin.Context) {
 		response, err = rc.recommendationService.GetPersonalizedRecommendations(ctx, &req)
 	case "similar":
 		if req.ProductID == nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "product_id is required for similar product recommendations",
-			})
+			c.Error(apperror.Validation("product_id is required for similar product recommendations",
+				apperror.Field("product_id", "is required for similar product recommendations")))
 			return
 		}
-		response, err = rc.recommendationService.GetSimilarProducts(ctx, *req.ProductID, req.Limit)
+		response, err = rc.recommendationService.GetSimilarProducts(ctx, *req.ProductID, req.SimilarityType, req.Limit)
 	case "trending":
 		period := "weekly" // Default period
 		if req.SessionID != "" {
@@ -90,41 +93,40 @@ func (rc *RecommendationController) GetRecommendations(c *gin.Context) {
 		response, err = rc.recommendationService.GetTrendingProducts(ctx, period, req.Limit)
 	case "occasion_based":
 		if req.Occasion == "" {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "occasion is required for occasion-based recommendations",
-			})
+			c.Error(apperror.Validation("occasion is required for occasion-based recommendations",
+				apperror.Field("occasion", "is required for occasion-based recommendations")))
 			return
 		}
 		response, err = rc.recommendationService.GetOccasionBasedRecommendations(ctx, req.Occasion, req.Limit)
 	case "price_based":
 		if req.PriceMin == nil || req.PriceMax == nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "price_min and price_max are required for price-based recommendations",
-			})
+			c.Error(apperror.Validation("price_min and price_max are required for price-based recommendations",
+				apperror.Field("price_min", "is required for price-based recommendations"),
+				apperror.Field("price_max", "is required for price-based recommendations")))
 			return
 		}
 		if *req.PriceMin > *req.PriceMax {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "price_min cannot be greater than price_max",
-			})
+			c.Error(apperror.Validation("price_min cannot be greater than price_max",
+				apperror.Field("price_min", "cannot be greater than price_max")))
 			return
 		}
 		response, err = rc.recommendationService.GetPriceBasedRecommendations(ctx, *req.PriceMin, *req.PriceMax, req.Limit)
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid recommendation_type. Must be one of: personalized, similar, trending, occasion_based, price_based",
-		})
+		c.Error(apperror.Validation("Invalid recommendation_type. Must be one of: personalized, similar, trending, occasion_based, price_based",
+			apperror.Field("recommendation_type", "must be one of: personalized, similar, trending, occasion_based, price_based")))
 		return
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get recommendations",
-			"details": err.Error(),
-		})
+		c.Error(apperror.Wrap(err, "Failed to get recommendations"))
 		return
 	}
 
+	// personalized responses are logged by the service already
+	if req.FirebaseUID != nil && req.RecommendationType != "personalized" {
+		rc.recommendationService.RecordImpression(ctx, *req.FirebaseUID, response)
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -135,19 +137,18 @@ func (rc *RecommendationController) GetRecommendations(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param product_id path int true "Product ID"
+// @Param similarity_type query string false "Similarity to rank by" Enums(content,collaborative,hybrid) default(hybrid)
 // @Param limit query int false "Number of similar products to return" default(10)
 // @Success 200 {object} dto.RecommendationResponseDTO
-// @Failure 400 {object} model.Response
-// @Failure 404 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/recommendations/similar/{product_id} [get]
 func (rc *RecommendationController) GetSimilarProducts(c *gin.Context) {
 	productIDStr := c.Param("product_id")
 	productID, err := strconv.ParseUint(productIDStr, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid product ID",
-		})
+		c.Error(apperror.BadRequest("Invalid product ID"))
 		return
 	}
 
@@ -160,19 +161,10 @@ func (rc *RecommendationController) GetSimilarProducts(c *gin.Context) {
 		limit = 50
 	}
 
-	ctx := context.Background()
-	response, err := rc.recommendationService.GetSimilarProducts(ctx, uint(productID), limit)
+	ctx := c.Request.Context()
+	response, err := rc.recommendationService.GetSimilarProducts(ctx, uint(productID), c.Query("similarity_type"), limit)
 	if err != nil {
-		if err.Error() == "not found" {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Product not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get similar products",
-			"details": err.Error(),
-		})
+		c.Error(apperror.Wrap(err, "Failed to get similar products"))
 		return
 	}
 
@@ -188,15 +180,14 @@ func (rc *RecommendationController) GetSimilarProducts(c *gin.Context) {
 // @Param period query string false "Time period for trending analysis" Enums(daily,weekly,monthly) default(weekly)
 // @Param limit query int false "Number of trending products to return" default(10)
 // @Success 200 {object} dto.RecommendationResponseDTO
-// @Failure 400 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/recommendations/trending [get]
 func (rc *RecommendationController) GetTrendingProducts(c *gin.Context) {
 	period := c.DefaultQuery("period", "weekly")
 	if period != "daily" && period != "weekly" && period != "monthly" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid period. Must be one of: daily, weekly, monthly",
-		})
+		c.Error(apperror.Validation("Invalid period. Must be one of: daily, weekly, monthly",
+			apperror.Field("period", "must be one of: daily, weekly, monthly")))
 		return
 	}
 
@@ -209,13 +200,10 @@ func (rc *RecommendationController) GetTrendingProducts(c *gin.Context) {
 		limit = 50
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	response, err := rc.recommendationService.GetTrendingProducts(ctx, period, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get trending products",
-			"details": err.Error(),
-		})
+		c.Error(apperror.Wrap(err, "Failed to get trending products"))
 		return
 	}
 
@@ -231,15 +219,13 @@ func (rc *RecommendationController) GetTrendingProducts(c *gin.Context) {
 // @Param occasion path string true "Occasion name"
 // @Param limit query int false "Number of recommendations to return" default(10)
 // @Success 200 {object} dto.RecommendationResponseDTO
-// @Failure 400 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/recommendations/occasion/{occasion} [get]
 func (rc *RecommendationController) GetOccasionRecommendations(c *gin.Context) {
 	occasion := c.Param("occasion")
 	if occasion == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Occasion is required",
-		})
+		c.Error(apperror.Validation("Occasion is required", apperror.Field("occasion", "is required")))
 		return
 	}
 
@@ -252,13 +238,10 @@ func (rc *RecommendationController) GetOccasionRecommendations(c *gin.Context) {
 		limit = 50
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	response, err := rc.recommendationService.GetOccasionBasedRecommendations(ctx, occasion, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get occasion-based recommendations",
-			"details": err.Error(),
-		})
+		c.Error(apperror.Wrap(err, "Failed to get occasion-based recommendations"))
 		return
 	}
 
@@ -266,23 +249,21 @@ func (rc *RecommendationController) GetOccasionRecommendations(c *gin.Context) {
 }
 
 // GetPersonalizedRecommendations godoc
-// @Summary Get personalized recommendations for a user
-// @Description Get personalized product recommendations based on user's purchase history and preferences
+// @Summary Get my personalized recommendations
+// @Description Get personalized product recommendations based on the signed-in user's purchase history and preferences
 // @Tags recommendations
 // @Accept json
 // @Produce json
-// @Param firebase_uid path int true "Firebase User ID"
+// @Security BearerAuth
 // @Param limit query int false "Number of recommendations to return" default(10)
 // @Success 200 {object} dto.RecommendationResponseDTO
-// @Failure 400 {object} model.Response
-// @Failure 500 {object} model.Response
-// @Router /api/recommendations/users/{firebase_uid} [get]
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/recommendations/personalized [get]
 func (rc *RecommendationController) GetPersonalizedRecommendations(c *gin.Context) {
-	firebaseUID := c.Param("firebase_uid")
-	if firebaseUID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-		})
+	firebaseUID, ok := middleware.GetFirebaseUserID(c)
+	if !ok {
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
@@ -295,7 +276,7 @@ func (rc *RecommendationController) GetPersonalizedRecommendations(c *gin.Contex
 		limit = 50
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	req := &dto.RecommendationRequestDTO{
 		FirebaseUID:       &firebaseUID,
 		RecommendationType: "personalized",
@@ -304,10 +285,7 @@ func (rc *RecommendationController) GetPersonalizedRecommendations(c *gin.Contex
 
 	response, err := rc.recommendationService.GetPersonalizedRecommendations(ctx, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get personalized recommendations",
-			"details": err.Error(),
-		})
+		c.Error(apperror.Wrap(err, "Failed to get personalized recommendations"))
 		return
 	}
 
@@ -315,32 +293,27 @@ func (rc *RecommendationController) GetPersonalizedRecommendations(c *gin.Contex
 }
 
 // UpdateUserPreferences godoc
-// @Summary Update user preferences
-// @Description Analyze user behavior and update their preferences for better recommendations
+// @Summary Refresh my learned preferences
+// @Description Fold the signed-in user's purchases, reviews, cart adds and recommendation feedback since the last refresh into their learned preferences
 // @Tags recommendations
 // @Accept json
 // @Produce json
-// @Param firebase_uid path int true "Firebase User ID"
+// @Security BearerAuth
 // @Success 200 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 500 {object} model.Response
-// @Router /api/recommendations/users/{firebase_uid}/preferences [put]
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/recommendations/preferences [put]
 func (rc *RecommendationController) UpdateUserPreferences(c *gin.Context) {
-	firebaseUID := c.Param("firebase_uid")
-	if firebaseUID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid Firebase User ID",
-		})
+	firebaseUID, ok := middleware.GetFirebaseUserID(c)
+	if !ok {
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	err := rc.recommendationService.UpdateUserPreferences(ctx, firebaseUID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to update user preferences",
-			"details": err.Error(),
-		})
+		c.Error(apperror.Wrap(err, "Failed to update user preferences"))
 		return
 	}
 
@@ -351,23 +324,28 @@ func (rc *RecommendationController) UpdateUserPreferences(c *gin.Context) {
 
 // RecordRecommendationFeedback godoc
 // @Summary Record user feedback on recommendations
-// @Description Record user actions on recommended products for improving future recommendations
+// @Description Record the signed-in user's actions on recommended products for improving future recommendations
 // @Tags recommendations
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param feedback body dto.RecommendationFeedbackDTO true "Recommendation feedback"
 // @Success 200 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 500 {object} model.Response
-// @Router /api/recommendations/feedback [post]
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/recommendations/feedback [post]
 func (rc *RecommendationController) RecordRecommendationFeedback(c *gin.Context) {
+	firebaseUID, ok := middleware.GetFirebaseUserID(c)
+	if !ok {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
 	var feedback dto.RecommendationFeedbackDTO
 
 	if err := c.ShouldBindJSON(&feedback); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		c.Error(apperror.InvalidRequest(err))
 		return
 	}
 
@@ -382,55 +360,302 @@ func (rc *RecommendationController) RecordRecommendationFeedback(c *gin.Context)
 	}
 
 	if !isValidAction {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid action. Must be one of: clicked, purchased, dismissed, liked",
-		})
+		c.Error(apperror.Validation("Invalid action. Must be one of: clicked, purchased, dismissed, liked",
+			apperror.Field("action", "must be one of: clicked, purchased, dismissed, liked")))
+		return
+	}
+
+	if err := rc.recommendationService.RecordFeedback(c.Request.Context(), firebaseUID, feedback); err != nil {
+		c.Error(apperror.Wrap(err, "Failed to record feedback"))
 		return
 	}
 
-	// In a real implementation, you would save this feedback to the database
-	// For now, we'll just return success
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Feedback recorded successfully",
 	})
 }
 
 // GetRecommendationStats godoc
-// @Summary Get recommendation statistics
-// @Description Get performance statistics for the recommendation system
-// @Tags recommendations
-// @Accept json
+// @Summary Get recommendation statistics (admin)
+// @Description Lists shown, click-through and conversion rates per recommendation type over the period, and the per-variant report of the running A/B experiment
+// @Tags admin-recommendations
 // @Produce json
+// @Security BearerAuth
 // @Param period query string false "Time period for statistics" Enums(daily,weekly,monthly) default(weekly)
 // @Success 200 {object} dto.RecommendationStatsDTO
-// @Failure 400 {object} model.Response
-// @Failure 500 {object} model.Response
-// @Router /api/recommendations/stats [get]
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/recommendations/stats [get]
 func (rc *RecommendationController) GetRecommendationStats(c *gin.Context) {
 	period := c.DefaultQuery("period", "weekly")
 	if period != "daily" && period != "weekly" && period != "monthly" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid period. Must be one of: daily, weekly, monthly",
-		})
+		c.Error(apperror.Validation("Invalid period. Must be one of: daily, weekly, monthly",
+			apperror.Field("period", "must be one of: daily, weekly, monthly")))
 		return
 	}
 
-	// This is a placeholder implementation
-	// In a real system, you would calculate actual statistics
-	stats := dto.RecommendationStatsDTO{
-		TotalRecommendations: 1000,
-		ClickThroughRate:     0.15,
-		ConversionRate:       0.05,
-		AverageScore:         0.75,
-		TopPerformingType:    "personalized",
+	stats, err := rc.recommendationService.GetRecommendationStats(c.Request.Context(), period)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Failed to get recommendation statistics"))
+		return
 	}
 
 	c.JSON(http.StatusOK, stats)
 }
 
+// ListExperiments godoc
+// @Summary List recommendation A/B experiments (admin)
+// @Tags admin-recommendations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} model.RecommendationExperiment
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/recommendations/experiments [get]
+func (rc *RecommendationController) ListExperiments(c *gin.Context) {
+	experiments, err := rc.recommendationService.GetExperiments(c.Request.Context())
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Failed to get experiments"))
+		return
+	}
+	if experiments == nil {
+		experiments = []model.RecommendationExperiment{}
+	}
+	c.JSON(http.StatusOK, experiments)
+}
+
+// CreateExperiment godoc
+// @Summary Create a recommendation A/B experiment (admin)
+// @Description Creates a draft experiment. Signed-in users are bucketed into variants by a hash of their id once it is started; each variant may override the blend weights.
+// @Tags admin-recommendations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param experiment body dto.CreateExperimentRequest true "Experiment and its variants"
+// @Success 201 {object} model.RecommendationExperiment
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/recommendations/experiments [post]
+func (rc *RecommendationController) CreateExperiment(c *gin.Context) {
+	var req dto.CreateExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	experiment, err := rc.recommendationService.CreateExperiment(c.Request.Context(), req, actorFromContext(c))
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Failed to create experiment"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, experiment)
+}
+
+// UpdateExperimentStatus godoc
+// @Summary Start or stop a recommendation A/B experiment (admin)
+// @Description Draft experiments can be started and running ones stopped; only one experiment runs at a time
+// @Tags admin-recommendations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param experimentID path int true "Experiment ID"
+// @Param status body dto.UpdateExperimentStatusRequest true "New status"
+// @Success 200 {object} model.RecommendationExperiment
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/recommendations/experiments/{experimentID}/status [put]
+func (rc *RecommendationController) UpdateExperimentStatus(c *gin.Context) {
+	experimentID, err := strconv.ParseUint(c.Param("experimentID"), 10, 32)
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid experiment ID"))
+		return
+	}
+
+	var req dto.UpdateExperimentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	experiment, err := rc.recommendationService.UpdateExperimentStatus(c.Request.Context(), uint(experimentID), req.Status)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Failed to update experiment"))
+		return
+	}
+
+	c.JSON(http.StatusOK, experiment)
+}
+
+// GetExperimentReport godoc
+// @Summary Get the report of a recommendation A/B experiment (admin)
+// @Description Per variant: users shown recommendations, and the share who clicked or purchased with 95% confidence intervals
+// @Tags admin-recommendations
+// @Produce json
+// @Security BearerAuth
+// @Param experimentID path int true "Experiment ID"
+// @Success 200 {object} dto.ExperimentReportDTO
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/recommendations/experiments/{experimentID}/report [get]
+func (rc *RecommendationController) GetExperimentReport(c *gin.Context) {
+	experimentID, err := strconv.ParseUint(c.Param("experimentID"), 10, 32)
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid experiment ID"))
+		return
+	}
+
+	report, err := rc.recommendationService.GetExperimentReport(c.Request.Context(), uint(experimentID))
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Failed to get experiment report"))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetRecommendationConfig godoc
+// @Summary Get recommendation engine settings (admin)
+// @Description Blend weights, similarity and interaction thresholds, and the per flower type cap currently in use
+// @Tags admin-recommendations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.RecommendationConfig
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Router /api/v1/admin/recommendations/config [get]
+func (rc *RecommendationController) GetRecommendationConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, rc.recommendationService.GetConfig())
+}
+
+// UpdateRecommendationConfig godoc
+// @Summary Update recommendation engine settings (admin)
+// @Description Change blend weights and thresholds; omitted fields keep their value. Takes effect immediately.
+// @Tags admin-recommendations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param config body dto.UpdateRecommendationConfigRequest true "Settings to change"
+// @Success 200 {object} model.RecommendationConfig
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/recommendations/config [put]
+func (rc *RecommendationController) UpdateRecommendationConfig(c *gin.Context) {
+	var req dto.UpdateRecommendationConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	config, err := rc.recommendationService.UpdateConfig(c.Request.Context(), req, actorFromContext(c))
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Failed to update recommendation config"))
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// GetMyPreferences godoc
+// @Summary Get my learned preferences
+// @Description Flower type and occasion affinities between -1 and 1 learned from behavior, with recent signals counting more, and the usual price band
+// @Tags recommendations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.UserPreferenceDTO
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/recommendations/preferences [get]
+func (rc *RecommendationController) GetMyPreferences(c *gin.Context) {
+	firebaseUID, ok := middleware.GetFirebaseUserID(c)
+	if !ok {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	profile, err := rc.recommendationService.GetUserPreferences(c.Request.Context(), firebaseUID)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Failed to get preferences"))
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// ResetMyPreferences godoc
+// @Summary Reset my learned preferences
+// @Description Clears the learned profile; only behavior after the reset is learned from
+// @Tags recommendations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.Response
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/recommendations/preferences [delete]
+func (rc *RecommendationController) ResetMyPreferences(c *gin.Context) {
+	firebaseUID, ok := middleware.GetFirebaseUserID(c)
+	if !ok {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	if err := rc.recommendationService.ResetUserPreferences(c.Request.Context(), firebaseUID); err != nil {
+		c.Error(apperror.Wrap(err, "Failed to reset preferences"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Preferences reset"})
+}
+
+// RegisterRoutes registers the public recommendation routes under /api.
+// Signed-in callers are identified so their lists can be personalized.
+func (rc *RecommendationController) RegisterRoutes(router *gin.Engine, authMiddleware *middleware.AuthMiddleware) {
+	RegisterRecommendationRoutes(router, rc, authMiddleware)
+}
+
+// RegisterUserRoutes registers the signed-in user's recommendation routes
+// on an authenticated group
+func (rc *RecommendationController) RegisterUserRoutes(rg *gin.RouterGroup) {
+	recommendations := rg.Group("/recommendations")
+	recommendations.GET("/personalized", rc.GetPersonalizedRecommendations)
+	recommendations.GET("/preferences", rc.GetMyPreferences)
+	recommendations.PUT("/preferences", rc.UpdateUserPreferences)
+	recommendations.DELETE("/preferences", rc.ResetMyPreferences)
+	// feedback is attributed to the caller, so it needs a signed-in user
+	recommendations.POST("/feedback", rc.RecordRecommendationFeedback)
+}
+
+// RegisterAdminRoutes registers the recommendation admin routes on an
+// authenticated group; they require the Admin role
+func (rc *RecommendationController) RegisterAdminRoutes(rg *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
+	admin := rg.Group("/admin/recommendations", authMiddleware.RequireAdmin())
+	admin.GET("/config", rc.GetRecommendationConfig)
+	admin.PUT("/config", rc.UpdateRecommendationConfig)
+	admin.GET("/stats", rc.GetRecommendationStats)
+	admin.GET("/experiments", rc.ListExperiments)
+	admin.POST("/experiments", rc.CreateExperiment)
+	admin.PUT("/experiments/:experimentID/status", rc.UpdateExperimentStatus)
+	admin.GET("/experiments/:experimentID/report", rc.GetExperimentReport)
+}
+
 // RegisterRecommendationRoutes registers all recommendation routes
-func RegisterRecommendationRoutes(router *gin.Engine, recommendationController *RecommendationController) {
-	api := router.Group("/api")
+func RegisterRecommendationRoutes(router *gin.Engine, recommendationController *RecommendationController, authMiddleware *middleware.AuthMiddleware) {
+	api := router.Group("/api", authMiddleware.OptionalAuth())
 	{
 		recommendations := api.Group("/recommendations")
 		{
@@ -441,14 +666,6 @@ func RegisterRecommendationRoutes(router *gin.Engine, recommendationController *
 			recommendations.GET("/similar/:product_id", recommendationController.GetSimilarProducts)
 			recommendations.GET("/trending", recommendationController.GetTrendingProducts)
 			recommendations.GET("/occasion/:occasion", recommendationController.GetOccasionRecommendations)
-			recommendations.GET("/users/:firebase_uid", recommendationController.GetPersonalizedRecommendations)
-			
-			// User preference management
-			recommendations.PUT("/users/:firebase_uid/preferences", recommendationController.UpdateUserPreferences)
-			
-			// Feedback and analytics
-			recommendations.POST("/feedback", recommendationController.RecordRecommendationFeedback)
-			recommendations.GET("/stats", recommendationController.GetRecommendationStats)
 		}
 	}
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/service"
 	"net/http"
 	"strconv"
@@ -33,18 +34,18 @@ func (ctrl *ReportController) RegisterRoutes(rg *gin.RouterGroup) {
 // @Param end   query string false "YYYY-MM-DD"
 // @Param group query string false "day|week|month" default(day)
 // @Success 200 {object} dto.AdminSalesReportResponse
-// @Failure 401 {object} model.Response
-// @Failure 403 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/admin/reports/sales [get]
 func (ctrl *ReportController) AdminSalesReport(c *gin.Context) {
 	start := c.Query("start")
 	end := c.Query("end")
 	group := c.DefaultQuery("group", "day")
 
-	res, err := ctrl.reportService.GetSalesReport(start, end, group)
+	res, err := ctrl.reportService.GetSalesReport(c.Request.Context(), start, end, group)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build sales report"})
+		c.Error(apperror.Wrap(err, "failed to build sales report"))
 		return
 	}
 
@@ -62,9 +63,9 @@ func (ctrl *ReportController) AdminSalesReport(c *gin.Context) {
 // @Param sort  query string false "quantity|revenue" default(revenue)
 // @Param limit query int    false "Top N (<=100)" default(10)
 // @Success 200 {array} dto.TopProductDTO
-// @Failure 401 {object} model.Response
-// @Failure 403 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/admin/reports/top-products [get]
 func (ctrl *ReportController) AdminTopProducts(c *gin.Context) {
 	start := c.Query("start")
@@ -75,9 +76,9 @@ func (ctrl *ReportController) AdminTopProducts(c *gin.Context) {
 		limit = 10
 	}
 
-	rows, err := ctrl.reportService.GetTopProducts(start, end, sort, limit)
+	rows, err := ctrl.reportService.GetTopProducts(c.Request.Context(), start, end, sort, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build top-products"})
+		c.Error(apperror.Wrap(err, "failed to build top-products"))
 		return
 	}
 
@@ -1,8 +1,11 @@
 package controller
 
 import (
+	"flowo-backend/config"
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/service"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -12,81 +15,517 @@ import (
 )
 
 type ReviewController struct {
-	Service     *service.ReviewService
-	userService service.UserService
+	Service       *service.ReviewService
+	userService   service.UserService
+	maxImageBytes int64
 }
 
-func NewReviewController(s *service.ReviewService, us service.UserService) *ReviewController {
-	return &ReviewController{Service: s, userService: us}
+func NewReviewController(s *service.ReviewService, us service.UserService, cfg *config.Config) *ReviewController {
+	return &ReviewController{Service: s, userService: us, maxImageBytes: cfg.Storage.MaxImageBytes}
 }
 
-func (ctrl *ReviewController) RegisterRoutes(rg *gin.RouterGroup) {
+func (ctrl *ReviewController) RegisterRoutes(rg *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware) {
 	rg.GET("/products/:id/reviews", ctrl.GetReviewsByProduct)
 	rg.POST("/products/:id/reviews", ctrl.CreateReview)
+	rg.PUT("/reviews/:reviewID", ctrl.UpdateReview)
+	rg.DELETE("/reviews/:reviewID", ctrl.DeleteReview)
+	rg.POST("/reviews/:reviewID/photos", ctrl.UploadReviewPhoto)
+	rg.DELETE("/reviews/:reviewID/photos/:photoID", ctrl.DeleteReviewPhoto)
+	rg.PUT("/reviews/:reviewID/vote", ctrl.VoteReview)
+	rg.DELETE("/reviews/:reviewID/vote", ctrl.RemoveReviewVote)
+
+	admin := rg.Group("/admin/reviews", authMiddleware.RequireAdmin())
+	admin.GET("", ctrl.GetModerationQueue)
+	admin.GET("/unanswered", ctrl.GetUnansweredReviews)
+	admin.PUT("/:reviewID/moderation", ctrl.ModerateReview)
+	admin.POST("/:reviewID/replies", ctrl.ReplyToReview)
+	admin.DELETE("/:reviewID/replies/:replyID", ctrl.DeleteReviewReply)
 }
 
 // @Summary Create review for a product
-// @Description Submit a review for a specific product (user is detected automatically from Firebase)
+// @Description Submit a review for a product the user bought in a completed order. Reviews flagged by the content filter are held for moderation.
 // @Tags reviews
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Product ID"
 // @Param review body dto.CreateReviewRequest true "Review body"
-// @Success 201 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Success 201 {object} dto.ReviewResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/products/{id}/reviews [post]
 func (ctrl *ReviewController) CreateReview(c *gin.Context) {
 	firebaseUID, exists := middleware.GetFirebaseUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(apperror.Unauthorized("unauthorized"))
 		return
 	}
 
 	productID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		c.Error(apperror.BadRequest("Invalid product ID"))
 		return
 	}
 
 	var req dto.CreateReviewRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.InvalidRequest(err))
 		return
 	}
 
-	if err := ctrl.Service.CreateReview(productID, firebaseUID, req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create review"})
+	review, err := ctrl.Service.CreateReview(c.Request.Context(), productID, firebaseUID, req)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Failed to create review"))
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Review created successfully"})
+	c.JSON(http.StatusCreated, review)
 }
 
 // GetReviewsByProduct godoc
 // @Summary Get all reviews for a product
-// @Description Retrieve reviews by product ID
+// @Description Retrieve a product's published reviews with photos, helpfulness votes and the rating distribution
 // @Tags reviews
 // @Produce json
 // @Param id path int true "Product ID"
-// @Success 200 {array} dto.ReviewResponse
-// @Failure 400 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Param sort query string false "newest (default), highest, lowest or most_helpful"
+// @Param cursor query string false "Opaque cursor from pagination.next_cursor or prev_cursor; replaces page. Newest sort only"
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Reviews per page (default: 20, max: 100)"
+// @Success 200 {object} dto.ReviewListResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/products/{id}/reviews [get]
 func (ctrl *ReviewController) GetReviewsByProduct(c *gin.Context) {
 	productID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		c.Error(apperror.BadRequest("Invalid product ID"))
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	reviews, err := ctrl.Service.GetReviewsByProduct(c.Request.Context(), productID, c.Query("sort"), c.Query("cursor"), page, limit)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Failed to fetch reviews"))
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// @Summary Update own review
+// @Description Edit the rating and comment of the caller's review. The text is screened again; edits of a rejected review go back to moderation.
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param reviewID path int true "Review ID"
+// @Param review body dto.UpdateReviewRequest true "Review body"
+// @Success 200 {object} dto.ReviewResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/reviews/{reviewID} [put]
+func (ctrl *ReviewController) UpdateReview(c *gin.Context) {
+	firebaseUID, exists := middleware.GetFirebaseUserID(c)
+	if !exists {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("reviewID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid review ID"))
+		return
+	}
+
+	var req dto.UpdateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	review, err := ctrl.Service.UpdateReview(c.Request.Context(), reviewID, firebaseUID, req)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Failed to update review"))
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// @Summary Delete own review
+// @Tags reviews
+// @Produce json
+// @Security BearerAuth
+// @Param reviewID path int true "Review ID"
+// @Success 200 {object} model.Response
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/reviews/{reviewID} [delete]
+func (ctrl *ReviewController) DeleteReview(c *gin.Context) {
+	firebaseUID, exists := middleware.GetFirebaseUserID(c)
+	if !exists {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("reviewID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid review ID"))
+		return
+	}
+
+	if err := ctrl.Service.DeleteReview(c.Request.Context(), reviewID, firebaseUID); err != nil {
+		c.Error(apperror.Wrap(err, "Failed to delete review"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review deleted successfully"})
+}
+
+// @Summary Attach a photo to own review
+// @Description Upload a JPEG, PNG or GIF photo for the caller's review; a thumbnail is generated. At most 5 photos per review.
+// @Tags reviews
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param reviewID path int true "Review ID"
+// @Param file formData file true "Image file"
+// @Success 201 {object} model.ReviewPhoto
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 413 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/reviews/{reviewID}/photos [post]
+func (ctrl *ReviewController) UploadReviewPhoto(c *gin.Context) {
+	firebaseUID, exists := middleware.GetFirebaseUserID(c)
+	if !exists {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("reviewID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid review ID"))
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.Error(apperror.BadRequest("file is required"))
+		return
+	}
+	if fileHeader.Size > ctrl.maxImageBytes {
+		c.Error(apperror.TooLarge("image is too large"))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Error(apperror.BadRequest("failed to read file"))
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, ctrl.maxImageBytes+1))
+	if err != nil {
+		c.Error(apperror.BadRequest("failed to read file"))
+		return
+	}
+
+	photo, err := ctrl.Service.UploadReviewPhoto(c.Request.Context(), reviewID, firebaseUID, data)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Failed to upload photo"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, photo)
+}
+
+// @Summary Remove a photo from own review
+// @Tags reviews
+// @Produce json
+// @Security BearerAuth
+// @Param reviewID path int true "Review ID"
+// @Param photoID path int true "Photo ID"
+// @Success 200 {object} model.Response
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/reviews/{reviewID}/photos/{photoID} [delete]
+func (ctrl *ReviewController) DeleteReviewPhoto(c *gin.Context) {
+	firebaseUID, exists := middleware.GetFirebaseUserID(c)
+	if !exists {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("reviewID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid review ID"))
+		return
+	}
+	photoID, err := strconv.Atoi(c.Param("photoID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid photo ID"))
+		return
+	}
+
+	if err := ctrl.Service.DeleteReviewPhoto(c.Request.Context(), reviewID, photoID, firebaseUID); err != nil {
+		c.Error(apperror.Wrap(err, "Failed to delete photo"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Photo deleted successfully"})
+}
+
+// @Summary Vote on a review's helpfulness
+// @Description Mark another user's review as helpful or not helpful; voting again replaces the earlier vote
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param reviewID path int true "Review ID"
+// @Param vote body dto.ReviewVoteRequest true "Vote"
+// @Success 200 {object} dto.ReviewVoteResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/reviews/{reviewID}/vote [put]
+func (ctrl *ReviewController) VoteReview(c *gin.Context) {
+	firebaseUID, exists := middleware.GetFirebaseUserID(c)
+	if !exists {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("reviewID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid review ID"))
+		return
+	}
+
+	var req dto.ReviewVoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	votes, err := ctrl.Service.VoteReview(c.Request.Context(), reviewID, firebaseUID, *req.Helpful)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Failed to vote on review"))
+		return
+	}
+
+	c.JSON(http.StatusOK, votes)
+}
+
+// @Summary Withdraw a helpfulness vote
+// @Tags reviews
+// @Produce json
+// @Security BearerAuth
+// @Param reviewID path int true "Review ID"
+// @Success 200 {object} dto.ReviewVoteResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/reviews/{reviewID}/vote [delete]
+func (ctrl *ReviewController) RemoveReviewVote(c *gin.Context) {
+	firebaseUID, exists := middleware.GetFirebaseUserID(c)
+	if !exists {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("reviewID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid review ID"))
+		return
+	}
+
+	votes, err := ctrl.Service.RemoveReviewVote(c.Request.Context(), reviewID, firebaseUID)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Failed to remove vote"))
+		return
+	}
+
+	c.JSON(http.StatusOK, votes)
+}
+
+// @Summary List reviews by moderation status (admin)
+// @Description Reviews waiting for moderation by default, newest first
+// @Tags admin-reviews
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending (default), approved or rejected"
+// @Param cursor query string false "Opaque cursor from pagination.next_cursor or prev_cursor; replaces page"
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Reviews per page (default: 20, max: 100)"
+// @Success 200 {object} dto.ReviewListResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/reviews [get]
+func (ctrl *ReviewController) GetModerationQueue(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	reviews, err := ctrl.Service.GetModerationQueue(c.Request.Context(), c.Query("status"), c.Query("cursor"), page, limit)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Failed to fetch reviews"))
+		return
+	}
+
+	c.JSON(http.StatusOK, reviews)
+}
+
+// @Summary Approve or reject a review (admin)
+// @Description Only approved reviews are shown on the product and count towards its rating
+// @Tags admin-reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param reviewID path int true "Review ID"
+// @Param body body dto.ModerateReviewRequest true "Moderation decision"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/reviews/{reviewID}/moderation [put]
+func (ctrl *ReviewController) ModerateReview(c *gin.Context) {
+	reviewID, err := strconv.Atoi(c.Param("reviewID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid review ID"))
 		return
 	}
 
-	reviews, err := ctrl.Service.GetReviewsByProduct(productID)
+	var req dto.ModerateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	if err := ctrl.Service.ModerateReview(c.Request.Context(), reviewID, actorFromContext(c), req); err != nil {
+		c.Error(apperror.Wrap(err, "Failed to moderate review"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review " + req.Status})
+}
+
+// @Summary List unanswered low-rated reviews (admin)
+// @Description Published reviews rated max_rating or lower with no staff reply yet, newest first
+// @Tags admin-reviews
+// @Produce json
+// @Security BearerAuth
+// @Param max_rating query int false "Highest rating to include (default: 2)"
+// @Param cursor query string false "Opaque cursor from pagination.next_cursor or prev_cursor; replaces page"
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Reviews per page (default: 20, max: 100)"
+// @Success 200 {object} dto.ReviewListResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/reviews/unanswered [get]
+func (ctrl *ReviewController) GetUnansweredReviews(c *gin.Context) {
+	maxRating, _ := strconv.Atoi(c.Query("max_rating"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	reviews, err := ctrl.Service.GetUnansweredReviews(c.Request.Context(), maxRating, c.Query("cursor"), page, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		c.Error(apperror.Wrap(err, "Failed to fetch reviews"))
 		return
 	}
 
 	c.JSON(http.StatusOK, reviews)
 }
+
+// @Summary Reply to a review (admin)
+// @Description Post a public staff reply to a review, or to an earlier reply by setting parent_reply_id. The reviewer is notified.
+// @Tags admin-reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param reviewID path int true "Review ID"
+// @Param reply body dto.CreateReviewReplyRequest true "Reply"
+// @Success 201 {object} dto.ReviewReplyResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/reviews/{reviewID}/replies [post]
+func (ctrl *ReviewController) ReplyToReview(c *gin.Context) {
+	firebaseUID, exists := middleware.GetFirebaseUserID(c)
+	if !exists {
+		c.Error(apperror.Unauthorized("unauthorized"))
+		return
+	}
+
+	reviewID, err := strconv.Atoi(c.Param("reviewID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid review ID"))
+		return
+	}
+
+	var req dto.CreateReviewReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidRequest(err))
+		return
+	}
+
+	reply, err := ctrl.Service.ReplyToReview(c.Request.Context(), reviewID, firebaseUID, req)
+	if err != nil {
+		c.Error(apperror.Wrap(err, "Failed to reply to review"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, reply)
+}
+
+// @Summary Delete a review reply (admin)
+// @Description Removes the reply together with the replies answering it
+// @Tags admin-reviews
+// @Produce json
+// @Security BearerAuth
+// @Param reviewID path int true "Review ID"
+// @Param replyID path int true "Reply ID"
+// @Success 200 {object} model.Response
+// @Failure 403 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/v1/admin/reviews/{reviewID}/replies/{replyID} [delete]
+func (ctrl *ReviewController) DeleteReviewReply(c *gin.Context) {
+	reviewID, err := strconv.Atoi(c.Param("reviewID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid review ID"))
+		return
+	}
+	replyID, err := strconv.Atoi(c.Param("replyID"))
+	if err != nil {
+		c.Error(apperror.BadRequest("Invalid reply ID"))
+		return
+	}
+
+	if err := ctrl.Service.DeleteReviewReply(c.Request.Context(), reviewID, replyID); err != nil {
+		c.Error(apperror.Wrap(err, "Failed to delete reply"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reply deleted successfully"})
+}
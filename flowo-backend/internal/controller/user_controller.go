@@ -1,11 +1,13 @@
 package controller
 
 import (
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/middleware"
 	"flowo-backend/internal/model"
 	"flowo-backend/internal/service"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -48,28 +50,22 @@ func (ctrl *UserController) RegisterRoutes(rg *gin.RouterGroup, authMiddleware *
 // @Produce json
 // @Security BearerAuth
 // @Success 200 {object} model.Response{data=dto.CompleteUserResponse}
-// @Failure 401 {object} model.Response
-// @Failure 404 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/users/profile [get]
 func (ctrl *UserController) GetUserProfile(c *gin.Context) {
 	// Get Firebase UID from the authenticated context
 	firebaseUID, exists := middleware.GetFirebaseUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, model.Response{
-			Message: "Unauthorized: Firebase UID not found",
-			Data:    nil,
-		})
+		c.Error(apperror.Unauthorized("Firebase UID not found"))
 		return
 	}
 
 	// Get complete user information
-	completeUserInfo, err := ctrl.UserService.GetCompleteUserInfo(firebaseUID)
+	completeUserInfo, err := ctrl.UserService.GetCompleteUserInfo(c.Request.Context(), firebaseUID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			Message: "Failed to get user profile: " + err.Error(),
-			Data:    nil,
-		})
+		c.Error(apperror.Wrap(err, "Failed to get user profile"))
 		return
 	}
 
@@ -90,35 +86,26 @@ func (ctrl *UserController) GetUserProfile(c *gin.Context) {
 // @Security BearerAuth
 // @Param email path string true "User email"
 // @Success 200 {object} model.Response{data=dto.UserResponse}
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 404 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/users/email/{email} [get]
 func (ctrl *UserController) GetUserByEmail(c *gin.Context) {
 	email := c.Param("email")
 	if email == "" {
-		c.JSON(http.StatusBadRequest, model.Response{
-			Message: "Email parameter is required",
-			Data:    nil,
-		})
+		c.Error(apperror.BadRequest("Email parameter is required"))
 		return
 	}
 
-	user, err := ctrl.UserService.GetUserByEmail(email)
+	user, err := ctrl.UserService.GetUserByEmail(c.Request.Context(), email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			Message: "Failed to get user by email: " + err.Error(),
-			Data:    nil,
-		})
+		c.Error(apperror.Wrap(err, "Failed to get user by email"))
 		return
 	}
 
 	if user == nil {
-		c.JSON(http.StatusNotFound, model.Response{
-			Message: "User not found",
-			Data:    nil,
-		})
+		c.Error(apperror.NotFound("User not found"))
 		return
 	}
 
@@ -138,35 +125,26 @@ func (ctrl *UserController) GetUserByEmail(c *gin.Context) {
 // @Security BearerAuth
 // @Param uid path string true "Firebase UID"
 // @Success 200 {object} model.Response{data=dto.UserResponse}
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 404 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/users/uid/{uid} [get]
 func (ctrl *UserController) GetUserByUID(c *gin.Context) {
 	uid := c.Param("uid")
 	if uid == "" {
-		c.JSON(http.StatusBadRequest, model.Response{
-			Message: "UID parameter is required",
-			Data:    nil,
-		})
+		c.Error(apperror.BadRequest("UID parameter is required"))
 		return
 	}
 
-	user, err := ctrl.UserService.GetUserByFirebaseUID(uid)
+	user, err := ctrl.UserService.GetUserByFirebaseUID(c.Request.Context(), uid)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			Message: "Failed to get user by UID: " + err.Error(),
-			Data:    nil,
-		})
+		c.Error(apperror.Wrap(err, "Failed to get user by UID"))
 		return
 	}
 
 	if user == nil {
-		c.JSON(http.StatusNotFound, model.Response{
-			Message: "User not found",
-			Data:    nil,
-		})
+		c.Error(apperror.NotFound("User not found"))
 		return
 	}
 
@@ -187,37 +165,28 @@ func (ctrl *UserController) GetUserByUID(c *gin.Context) {
 // @Security BearerAuth
 // @Param profile body dto.UpdateProfileRequest true "Profile update data"
 // @Success 200 {object} model.Response{data=dto.UserResponse}
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/users/profile [put]
 func (ctrl *UserController) UpdateUserProfile(c *gin.Context) {
 	// Get Firebase UID from the authenticated context
 	firebaseUID, exists := middleware.GetFirebaseUserID(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, model.Response{
-			Message: "Unauthorized: Firebase UID not found",
-			Data:    nil,
-		})
+		c.Error(apperror.Unauthorized("Firebase UID not found"))
 		return
 	}
 
 	var updateData dto.UpdateProfileRequest
 	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, model.Response{
-			Message: "Invalid request body: " + err.Error(),
-			Data:    nil,
-		})
+		c.Error(apperror.InvalidRequest(err))
 		return
 	}
 
 	// Update user profile
-	updatedUser, err := ctrl.UserService.UpdateUserProfile(firebaseUID, &updateData)
+	updatedUser, err := ctrl.UserService.UpdateUserProfile(c.Request.Context(), firebaseUID, &updateData)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			Message: "Failed to update user profile: " + err.Error(),
-			Data:    nil,
-		})
+		c.Error(apperror.Wrap(err, "Failed to update user profile"))
 		return
 	}
 
@@ -235,17 +204,21 @@ func (ctrl *UserController) UpdateUserProfile(c *gin.Context) {
 // @Tags admin
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} model.Response{data=[]model.UserWithAddress}
-// @Failure 401 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Param cursor query string false "Opaque cursor from pagination.next_cursor or prev_cursor; replaces page"
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Users per page (default: 20, max: 100)"
+// @Success 200 {object} model.Response{data=dto.UserListResponse}
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/admin/users [get]
 func (ctrl *UserController) GetAllUsers(c *gin.Context) {
-	users, err := ctrl.UserService.GetAllUsers()
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	users, err := ctrl.UserService.GetAllUsers(c.Request.Context(), c.Query("cursor"), page, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			Message: "Failed to get users: " + err.Error(),
-			Data:    nil,
-		})
+		c.Error(apperror.Wrap(err, "Failed to get users"))
 		return
 	}
 
@@ -263,27 +236,21 @@ func (ctrl *UserController) GetAllUsers(c *gin.Context) {
 // @Security BearerAuth
 // @Param uid path string true "Firebase UID"
 // @Success 200 {object} model.Response
-// @Failure 400 {object} model.Response
-// @Failure 401 {object} model.Response
-// @Failure 404 {object} model.Response
-// @Failure 500 {object} model.Response
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
 // @Router /api/v1/admin/users/{uid} [delete]
 func (ctrl *UserController) SoftDeleteUser(c *gin.Context) {
 	uid := c.Param("uid")
 	if uid == "" {
-		c.JSON(http.StatusBadRequest, model.Response{
-			Message: "UID parameter is required",
-			Data:    nil,
-		})
+		c.Error(apperror.BadRequest("UID parameter is required"))
 		return
 	}
 
-	err := ctrl.UserService.SoftDeleteUser(uid)
+	err := ctrl.UserService.SoftDeleteUser(c.Request.Context(), uid)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			Message: "Failed to soft delete user: " + err.Error(),
-			Data:    nil,
-		})
+		c.Error(apperror.Wrap(err, "Failed to soft delete user"))
 		return
 	}
 
@@ -2,22 +2,27 @@ package dto
 
 type AddToCartRequest struct {
 	ProductID int `json:"product_id" binding:"required"`
+	VariantID *int `json:"variant_id,omitempty"`
 	Quantity  int `json:"quantity" binding:"required,min=1"`
 	FirebaseUID    string `json:"-"` // sent through controller middleware, not by user
 }
 
 type UpdateCartItemRequest struct {
 	ProductID int `json:"product_id" binding:"required"`
+	VariantID *int `json:"variant_id,omitempty"`
 	Quantity  int `json:"quantity" binding:"required,min=1"`
 	FirebaseUID    string `json:"-"`
 }
 
 type RemoveCartItemRequest struct {
 	ProductID int `json:"product_id" binding:"required"`
+	VariantID *int `json:"variant_id,omitempty"`
 	FirebaseUID    string `json:"-"`
 }
 type CartItemResponse struct {
 	ProductID   int     `json:"product_id"`
+	VariantID   *int    `json:"variant_id,omitempty"`
+	SKU         string  `json:"sku,omitempty"`
 	Name        string  `json:"name"`
 	Description string  `json:"description"`
 	Quantity    int     `json:"quantity"`
@@ -26,3 +31,15 @@ type CartItemResponse struct {
 	EffectivePrice float64 `json:"effective_price"`
 	TotalPrice     float64 `json:"total_price"`
 }
+
+type PrefillCartRequest struct {
+	ProductIDs  []int  `json:"product_ids" binding:"required,min=1,max=20"`
+	FirebaseUID string `json:"-"`
+}
+
+type PrefillCartResponse struct {
+	// Products added with quantity 1
+	Added []int `json:"added"`
+	// Products left out because they are already in the cart, unavailable or out of stock
+	Skipped []int `json:"skipped"`
+}
@@ -0,0 +1,52 @@
+package dto
+
+type FlowerTypeRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+}
+
+type OccasionRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// ProductOccasionsRequest replaces the occasions a product is linked to
+type ProductOccasionsRequest struct {
+	OccasionIDs []int `json:"occasion_ids" binding:"required"`
+}
+
+// ProductImageUpload holds the multipart form fields sent with an uploaded image
+type ProductImageUpload struct {
+	AltText   string `form:"alt_text"`
+	IsPrimary bool   `form:"is_primary"`
+}
+
+// ProductImportRow is one product in a catalog import or export file. Rows
+// with a product_id update that product, rows without one create a product.
+// In CSV files occasions and images are separated by "|".
+type ProductImportRow struct {
+	ProductID     uint     `json:"product_id,omitempty"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	FlowerType    string   `json:"flower_type"`
+	BasePrice     float64  `json:"base_price"`
+	Status        string   `json:"status"`
+	StockQuantity int      `json:"stock_quantity"`
+	Occasions     []string `json:"occasions"`
+	// Image URLs, the first one becomes the primary image
+	Images []string `json:"images"`
+}
+
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ProductImportResult reports what an import did, or would do in dry-run mode
+type ProductImportResult struct {
+	DryRun    bool             `json:"dry_run"`
+	TotalRows int              `json:"total_rows"`
+	Created   int              `json:"created"`
+	Updated   int              `json:"updated"`
+	Errors    []ImportRowError `json:"errors"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+type ImportantDateRequest struct {
+	RecipientName string `json:"recipient_name" binding:"required,max=255" example:"Mom"`
+	OccasionID    int    `json:"occasion_id" binding:"required" example:"1"`
+	// Optional recipient address from the user's address book
+	AddressID *int `json:"address_id,omitempty"`
+	// Date in YYYY-MM-DD; for recurring dates the year is the first occurrence
+	Date string `json:"date" binding:"required" example:"1970-05-12"`
+	// Repeats every year (default true)
+	Recurring *bool `json:"recurring,omitempty"`
+	// Days before the date to send a reminder, 0-60 (default 7)
+	RemindDaysBefore *int   `json:"remind_days_before,omitempty" example:"7"`
+	Note             string `json:"note" binding:"max=500" example:"Loves white lilies"`
+}
+
+type ImportantDateResponse struct {
+	DateID           int    `json:"date_id"`
+	RecipientName    string `json:"recipient_name"`
+	OccasionID       int    `json:"occasion_id"`
+	OccasionName     string `json:"occasion_name"`
+	AddressID        *int   `json:"address_id,omitempty"`
+	Date             string `json:"date"`
+	Recurring        bool   `json:"recurring"`
+	RemindDaysBefore int    `json:"remind_days_before"`
+	Note             string `json:"note"`
+	// Next time the date comes up, empty for a one-off date that has passed
+	NextOccurrence string `json:"next_occurrence,omitempty"`
+	DaysUntil      *int   `json:"days_until,omitempty"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"time"
+
+	"flowo-backend/internal/model"
+)
+
+type ReceiveBatchRequest struct {
+	VariantID     *int       `json:"variant_id,omitempty"`
+	Quantity      int        `json:"quantity" binding:"required,min=1"`
+	ShelfLifeDays int        `json:"shelf_life_days" binding:"required,min=1"`
+	ReceivedDate  *time.Time `json:"received_date,omitempty"` // defaults to now
+}
+
+// FreshnessReport summarizes one run of the freshness job
+type FreshnessReport struct {
+	WrittenOffBatches int `json:"written_off_batches"`
+	WastedUnits       int `json:"wasted_units"`
+	StatusChanges     int `json:"status_changes"`
+}
+
+type StockAdjustmentRequest struct {
+	VariantID      *int   `json:"variant_id,omitempty"`
+	QuantityChange int    `json:"quantity_change" binding:"required"`
+	Reason         string `json:"reason" binding:"required"`
+}
+
+type LowStockThresholdRequest struct {
+	Threshold *int `json:"threshold" binding:"required,min=0"`
+}
+
+type InventoryMovementsResponse struct {
+	ProductID    int                       `json:"product_id"`
+	VariantID    *int                      `json:"variant_id,omitempty"`
+	CurrentStock int                       `json:"current_stock"`
+	LedgerStock  int                       `json:"ledger_stock"` // sum of all movements
+	Movements    []model.InventoryMovement `json:"movements"`
+}
@@ -1,6 +1,10 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"flowo-backend/internal/model"
+)
 
 type OrderResponse struct {
 	OrderID        int     `json:"order_id"`
@@ -48,6 +52,7 @@ type OrderDetailResponse struct {
 
 type OrderItemDetail struct {
 	ProductID int     `json:"product_id"`
+	VariantID *int    `json:"variant_id,omitempty"`
 	Quantity  int     `json:"quantity"`
 	Price     float64 `json:"price"`
 	Subtotal  float64 `json:"subtotal"`
@@ -61,6 +66,11 @@ type AdminOrderResponse struct {
 	OrderDate   time.Time `json:"order_date"`
 }
 
+type AdminOrderListResponse struct {
+	Orders     []AdminOrderResponse `json:"orders"`
+	Pagination model.PaginationInfo `json:"pagination"`
+}
+
 type AdminOrderDetailResponse struct {
 	OrderID        int       `json:"order_id"`
 	Status         string    `json:"status"`
@@ -79,6 +89,7 @@ type AdminOrderDetailResponse struct {
 type AdminOrderItemDetail struct {
 	ProductID   int     `json:"product_id"`
 	ProductName string  `json:"product_name"`
+	VariantID   *int    `json:"variant_id,omitempty"`
 	Quantity    int     `json:"quantity"`
 	Price       float64 `json:"price"`
 	Subtotal    float64 `json:"subtotal"`
@@ -14,6 +14,7 @@ type CreatePricingRuleRequest struct {
 	AdjustmentType          string     `json:"adjustment_type" binding:"required,oneof=percentage_discount fixed_discount override_price"`
 	AdjustmentValue         float64    `json:"adjustment_value" binding:"required"`
 	ApplicableProductID     *int       `json:"applicable_product_id"`
+	ApplicableVariantID     *int       `json:"applicable_variant_id"`
 	ApplicableFlowerTypeID  *int       `json:"applicable_flower_type_id"`
 	ApplicableProductStatus *string    `json:"applicable_product_status"`
 	TimeOfDayStart          *string    `json:"time_of_day_start"`
@@ -15,8 +15,34 @@ type ProductCreate struct {
 	Status string `json:"status" example:"NewFlower" enums:"NewFlower,OldFlower,LowStock" binding:"required"`
 	// Stock quantity of the product
 	StockQuantity int `json:"stock_quantity" example:"100" binding:"required"`
+	// Reason recorded in the inventory ledger when the stock quantity changes
+	StockReason string `json:"stock_reason,omitempty" example:"Recount after delivery"`
+	// Who made the change, set by the controller
+	Actor string `json:"-"`
 }
 
+// ProductVariantCreate represents the data structure for creating or updating a product variant
+// @Description Product variant creation request body
+type ProductVariantCreate struct {
+	// Stock keeping unit, unique across the catalog
+	SKU string `json:"sku" example:"ROSE-RED-24-KRAFT" binding:"required"`
+	// Display name of the variant
+	Name string `json:"name" example:"24 stems - Kraft paper"`
+	// Size attribute
+	Size string `json:"size" example:"24 stems"`
+	// Color attribute
+	Color string `json:"color" example:"Red"`
+	// Wrapping attribute
+	Wrapping string `json:"wrapping" example:"Kraft paper"`
+	// Amount added to the product base price
+	PriceDelta float64 `json:"price_delta" example:"20.00"`
+	// Stock quantity of the variant
+	StockQuantity int `json:"stock_quantity" example:"25"`
+	// Reason recorded in the inventory ledger when the stock quantity changes
+	StockReason string `json:"stock_reason,omitempty" example:"Recount after delivery"`
+	// Who made the change, set by the controller
+	Actor string `json:"-"`
+}
 
 type ProductResponse struct {
 	ProductID      uint    `json:"product_id"`
@@ -35,22 +61,34 @@ type ProductResponse struct {
 type ProductSearchQuery struct {
 	// Search query for product name or description
 	Query string `form:"query" json:"query,omitempty" example:"rose"`
-	// Filter by flower type
-	FlowerType string `form:"flower_type" json:"flower_type,omitempty" example:"Rose"`
-	// Filter by occasion
-	Occasion string `form:"occasion" json:"occasion,omitempty" example:"Valentine's Day"`
+	// Filter by flower type; repeat the parameter to match any of several
+	FlowerType []string `form:"flower_type" json:"flower_type,omitempty" example:"Rose"`
+	// Filter by occasion; repeat the parameter to match any of several
+	Occasion []string `form:"occasion" json:"occasion,omitempty" example:"Valentine's Day"`
 	// Minimum price filter
 	PriceMin *float64 `form:"price_min" json:"price_min,omitempty" example:"10.00"`
 	// Maximum price filter
 	PriceMax *float64 `form:"price_max" json:"price_max,omitempty" example:"100.00"`
-	// Filter by product condition/status
-	Condition string `form:"condition" json:"condition,omitempty" example:"NewFlower" enums:"NewFlower,OldFlower,LowStock"`
-	// Sorting option
-	SortBy string `form:"sort_by" json:"sort_by,omitempty" example:"price_asc" enums:"price_asc,price_desc,name_asc,name_desc,newest,best_selling"`
+	// Filter by variant size
+	Size string `form:"size" json:"size,omitempty" example:"24 stems"`
+	// Filter by variant color
+	Color string `form:"color" json:"color,omitempty" example:"Red"`
+	// Filter by variant wrapping
+	Wrapping string `form:"wrapping" json:"wrapping,omitempty" example:"Kraft paper"`
+	// Minimum average review rating (0-5)
+	MinRating *float64 `form:"min_rating" json:"min_rating,omitempty" example:"4"`
+	// Filter by product condition/status; repeat the parameter to match any of several
+	Condition []string `form:"condition" json:"condition,omitempty" example:"NewFlower" enums:"NewFlower,OldFlower,LowStock"`
+	// Sorting option; relevance (the default when query is set) ranks by full-text match
+	SortBy string `form:"sort_by" json:"sort_by,omitempty" example:"price_asc" enums:"relevance,price_asc,price_desc,name_asc,name_desc,newest,best_selling,top_rated"`
 	// Page number for pagination (starts from 1)
 	Page int `form:"page" json:"page,omitempty" example:"1" minimum:"1"`
 	// Number of items per page
 	Limit int `form:"limit" json:"limit,omitempty" example:"20" minimum:"1" maximum:"100"`
+	// Opaque cursor from a previous response's next_cursor or prev_cursor; replaces page (newest sort only)
+	Cursor string `form:"cursor" json:"cursor,omitempty"`
+	// Products matching Query in the search index, best match first; set by the service
+	MatchedIDs []uint `form:"-" json:"-"`
 }
 
 // ProductDetailResponse represents detailed product information
@@ -89,3 +127,19 @@ type ProductImageDTO struct {
 	IsPrimary bool `json:"is_primary" example:"true"`
 
 }
+
+// Suggestion is one autocomplete completion
+type Suggestion struct {
+	// Completion text
+	Text string `json:"text" example:"Hoa hồng đỏ"`
+	// Where the completion comes from
+	Type string `json:"type" example:"product" enums:"product,flower_type,occasion,query"`
+	// Product the completion names, for type product
+	ProductID uint `json:"product_id,omitempty" example:"1"`
+}
+
+// SuggestionResponse lists completions for a partially typed search query
+type SuggestionResponse struct {
+	Query       string       `json:"query" example:"hoa h"`
+	Suggestions []Suggestion `json:"suggestions"`
+}
@@ -2,14 +2,17 @@ package dto
 
 // RecommendationRequestDTO represents the API request for recommendations
 type RecommendationRequestDTO struct {
-	// Firebase User ID for personalized recommendations (optional)
-	FirebaseUID *string `json:"firebase_uid,omitempty" form:"firebase_uid"`
+	// Signed-in caller, for personalized recommendations; never bound from
+	// the request
+	FirebaseUID *string `json:"-" form:"-"`
 	// Session ID for anonymous users
 	SessionID string `json:"session_id,omitempty" form:"session_id"`
 	// Type of recommendation
 	RecommendationType string `json:"recommendation_type" form:"recommendation_type" binding:"required" example:"personalized" enums:"personalized,similar,trending,occasion_based,price_based"`
 	// Reference product ID for similar product recommendations
 	ProductID *uint `json:"product_id,omitempty" form:"product_id"`
+	// Similarity to rank similar products by: content, collaborative or hybrid (default)
+	SimilarityType string `json:"similarity_type,omitempty" form:"similarity_type"`
 	// Occasion filter for occasion-based recommendations
 	Occasion string `json:"occasion,omitempty" form:"occasion"`
 	// Price range for price-based recommendations
@@ -31,6 +34,9 @@ type RecommendationResponseDTO struct {
 	GeneratedAt string `json:"generated_at"`
 	// Total number of available recommendations
 	Total int `json:"total"`
+	// A/B experiment and variant the user was bucketed into, if any
+	Experiment string `json:"experiment,omitempty"`
+	Variant    string `json:"variant,omitempty"`
 }
 
 // RecommendedProductDTO represents a product with its recommendation score
@@ -70,9 +76,9 @@ type TrendingProductDTO struct {
 	Period        string          `json:"period"`
 }
 
-// RecommendationFeedbackDTO represents user feedback on recommendations
+// RecommendationFeedbackDTO represents user feedback on recommendations.
+// The user is the signed-in caller.
 type RecommendationFeedbackDTO struct {
-	FirebaseUID        string `json:"firebase_uid" binding:"required"`
 	ProductID          uint   `json:"product_id" binding:"required"`
 	RecommendationType string `json:"recommendation_type" binding:"required"`
 	Action             string `json:"action" binding:"required" enums:"clicked,purchased,dismissed,liked"`
@@ -81,9 +87,96 @@ type RecommendationFeedbackDTO struct {
 
 // RecommendationStatsDTO represents statistics about recommendation performance
 type RecommendationStatsDTO struct {
+	Period               string  `json:"period"`
 	TotalRecommendations int     `json:"total_recommendations"`
 	ClickThroughRate     float64 `json:"click_through_rate"`
 	ConversionRate       float64 `json:"conversion_rate"`
 	AverageScore         float64 `json:"average_score"`
 	TopPerformingType    string  `json:"top_performing_type"`
+	// Figures per recommendation type
+	Types []RecommendationTypeStatsDTO `json:"types"`
+	// Report of the running experiment, if any
+	Experiment *ExperimentReportDTO `json:"experiment,omitempty"`
+}
+
+// RecommendationTypeStatsDTO is the performance of one recommendation type;
+// rates are clicks and purchases per list shown
+type RecommendationTypeStatsDTO struct {
+	RecommendationType string  `json:"recommendation_type"`
+	Impressions        int     `json:"impressions"`
+	Clicks             int     `json:"clicks"`
+	Purchases          int     `json:"purchases"`
+	ClickThroughRate   float64 `json:"click_through_rate"`
+	ConversionRate     float64 `json:"conversion_rate"`
+	AverageScore       float64 `json:"average_score"`
+}
+
+// CreateExperimentRequest creates a draft A/B experiment
+type CreateExperimentRequest struct {
+	Name        string `json:"name" binding:"required,max=100" example:"more-collaborative"`
+	Description string `json:"description,omitempty" binding:"max=500"`
+	// At least two variants, whose traffic adds up to 100
+	Variants []ExperimentVariantRequest `json:"variants" binding:"required,min=2,dive"`
+}
+
+// ExperimentVariantRequest is one arm of an experiment; omitted settings
+// keep the global configuration, and a weight of 0 turns a strategy off
+type ExperimentVariantRequest struct {
+	Name                string   `json:"name" binding:"required,max=50" example:"control"`
+	TrafficPercent      int      `json:"traffic_percent" binding:"required,min=1,max=100" example:"50"`
+	CollaborativeWeight *float64 `json:"collaborative_weight,omitempty" example:"0.6"`
+	ContentWeight       *float64 `json:"content_weight,omitempty"`
+	PopularityWeight    *float64 `json:"popularity_weight,omitempty"`
+	TrendingWeight      *float64 `json:"trending_weight,omitempty"`
+	MaxPerFlowerType    *int     `json:"max_per_flower_type,omitempty"`
+}
+
+// UpdateExperimentStatusRequest starts or stops an experiment
+type UpdateExperimentStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=running stopped" example:"running"`
+}
+
+// ExperimentReportDTO compares the variants of an experiment. Rates are per
+// user: the share of users shown recommendations who clicked or purchased.
+type ExperimentReportDTO struct {
+	ExperimentID uint               `json:"experiment_id"`
+	Name         string             `json:"name"`
+	Status       string             `json:"status"`
+	StartedAt    string             `json:"started_at,omitempty"`
+	StoppedAt    string             `json:"stopped_at,omitempty"`
+	Variants     []VariantReportDTO `json:"variants"`
+}
+
+// VariantReportDTO is the outcome of one variant
+type VariantReportDTO struct {
+	Variant          string  `json:"variant"`
+	TrafficPercent   int     `json:"traffic_percent"`
+	Users            int     `json:"users"`
+	Impressions      int     `json:"impressions"`
+	ClickedUsers     int     `json:"clicked_users"`
+	PurchasedUsers   int     `json:"purchased_users"`
+	ClickThroughRate RateDTO `json:"click_through_rate"`
+	ConversionRate   RateDTO `json:"conversion_rate"`
+}
+
+// RateDTO is a proportion with its 95% Wilson confidence interval
+type RateDTO struct {
+	Rate  float64 `json:"rate"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// UpdateRecommendationConfigRequest changes the recommendation engine
+// settings; omitted fields keep their current value
+type UpdateRecommendationConfigRequest struct {
+	CollaborativeWeight *float64 `json:"collaborative_weight,omitempty" example:"0.4"`
+	ContentWeight       *float64 `json:"content_weight,omitempty" example:"0.3"`
+	PopularityWeight    *float64 `json:"popularity_weight,omitempty" example:"0.2"`
+	TrendingWeight      *float64 `json:"trending_weight,omitempty" example:"0.1"`
+	// Similarity below this is neither stored nor recommended
+	MinSimilarity *float64 `json:"min_similarity,omitempty" example:"0.1"`
+	// Interactions a user needs before getting a personalized blend
+	MinInteractions *int `json:"min_interactions,omitempty" example:"3"`
+	// Most items of one flower type in a personalized list
+	MaxPerFlowerType *int `json:"max_per_flower_type,omitempty" example:"3"`
 }
@@ -1,15 +1,87 @@
 package dto
 
+import "flowo-backend/internal/model"
+
 type CreateReviewRequest struct {
 	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
 	Comment string `json:"comment"`
+	// Purchased order item to review; defaults to the oldest one not reviewed yet
+	OrderItemID *int `json:"order_item_id,omitempty"`
+}
+
+type UpdateReviewRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment"`
+}
+
+type ModerateReviewRequest struct {
+	Status string `json:"status" binding:"required,oneof=approved rejected"`
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+type ReviewVoteRequest struct {
+	// true for helpful, false for not helpful
+	Helpful *bool `json:"helpful" binding:"required"`
+}
+
+type ReviewVoteResponse struct {
+	ReviewID        int `json:"review_id"`
+	HelpfulCount    int `json:"helpful_count"`
+	NotHelpfulCount int `json:"not_helpful_count"`
+}
+
+type CreateReviewReplyRequest struct {
+	Message string `json:"message" binding:"required,max=2000"`
+	// Reply to answer; omit to reply to the review itself
+	ParentReplyID *int `json:"parent_reply_id,omitempty"`
+}
+
+// ReviewReplyResponse is a staff reply with the replies answering it
+type ReviewReplyResponse struct {
+	ReplyID       int                   `json:"reply_id"`
+	ParentReplyID *int                  `json:"parent_reply_id,omitempty"`
+	AuthorName    string                `json:"author_name"`
+	Message       string                `json:"message"`
+	CreatedAt     string                `json:"created_at"`
+	Replies       []ReviewReplyResponse `json:"replies"`
 }
 
 type ReviewResponse struct {
-	ReviewID    int    `json:"review_id"`
-	ProductID   int    `json:"product_id"`
-	FirebaseUID string `json:"firebase_uid"`
-	Rating      int    `json:"rating"`
-	Comment     string `json:"comment"`
-	ReviewDate  string `json:"review_date"`
+	ReviewID  int `json:"review_id"`
+	ProductID int `json:"product_id"`
+	// Only shown to admins in the moderation queue
+	FirebaseUID      string              `json:"firebase_uid,omitempty"`
+	ReviewerName     string              `json:"reviewer_name"`
+	Rating           int                 `json:"rating"`
+	Comment          string              `json:"comment"`
+	ReviewDate       string              `json:"review_date"`
+	VerifiedPurchase bool                `json:"verified_purchase"`
+	Status           string              `json:"status"`
+	ModerationReason *string             `json:"moderation_reason,omitempty"`
+	UpdatedAt        string              `json:"updated_at,omitempty"`
+	HelpfulCount     int                 `json:"helpful_count"`
+	NotHelpfulCount  int                 `json:"not_helpful_count"`
+	Photos           []model.ReviewPhoto `json:"photos"`
+	// Staff replies, threaded, oldest first
+	Replies []ReviewReplyResponse `json:"replies"`
+}
+
+// RatingSummary describes all published reviews of a product
+type RatingSummary struct {
+	AverageRating float64 `json:"average_rating"`
+	ReviewCount   int     `json:"review_count"`
+	// One entry per star rating, from 5 down to 1
+	Distribution []RatingCount `json:"distribution"`
+}
+
+type RatingCount struct {
+	Rating int `json:"rating"`
+	Count  int `json:"count"`
+}
+
+type ReviewListResponse struct {
+	Reviews    []ReviewResponse     `json:"reviews"`
+	Pagination model.PaginationInfo `json:"pagination"`
+	// Rating distribution, included when listing a product's reviews
+	Summary *RatingSummary `json:"summary,omitempty"`
 }
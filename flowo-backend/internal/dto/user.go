@@ -1,6 +1,10 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"flowo-backend/internal/model"
+)
 
 // UserResponse represents the response structure for user information
 type UserResponse struct {
@@ -38,3 +42,8 @@ type FirebaseUserInfo struct {
 	CreatedAt     string `json:"created_at"`
 	LastLoginAt   string `json:"last_login_at,omitempty"`
 }
+
+type UserListResponse struct {
+	Users      []*model.UserWithAddress `json:"users"`
+	Pagination model.PaginationInfo     `json:"pagination"`
+}
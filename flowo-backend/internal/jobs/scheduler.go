@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+)
+
+// Job is a unit of background work run periodically by the Scheduler
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs registered jobs on their own interval for the lifetime of the app
+type Scheduler struct {
+	mu     sync.Mutex
+	jobs   []Job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler that starts and stops with the fx lifecycle
+func NewScheduler(lifecycle fx.Lifecycle) *Scheduler {
+	s := &Scheduler{}
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			s.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			s.Stop()
+			return nil
+		},
+	})
+	return s
+}
+
+// Register adds a job. Jobs registered after Start are not run.
+func (s *Scheduler) Register(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+}
+
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+	log.Info().Int("jobs", len(s.jobs)).Msg("Job scheduler started")
+}
+
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(ctx, job)
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Interface("panic", r).Str("job", job.Name).Msg("Job panicked")
+		}
+	}()
+
+	start := time.Now()
+	if err := job.Run(ctx); err != nil {
+		log.Error().Err(err).Str("job", job.Name).Msg("Job failed")
+		return
+	}
+	log.Debug().Str("job", job.Name).Dur("took", time.Since(start)).Msg("Job finished")
+}
@@ -1,19 +1,32 @@
 package logger
 
 import (
+	"io"
 	"os"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+
+	"flowo-backend/config"
 )
 
-func Init() {
-	logger := zerolog.New(os.Stdout).
-		With().
-		Timestamp().
-		Logger().Output(zerolog.ConsoleWriter{Out: os.Stdout})
-	log.Logger = logger
+// Init configures the global logger from the config. JSON lines go to
+// stdout unless the console format is asked for.
+func Init(cfg config.LogConfig) {
+	var out io.Writer = os.Stdout
+	if cfg.Format == "console" {
+		out = zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}
+	}
+	log.Logger = zerolog.New(out).With().Timestamp().Logger()
+
+	level, err := zerolog.ParseLevel(cfg.Level)
+	if err != nil || level == zerolog.NoLevel {
+		log.Warn().Str("level", cfg.Level).Msg("Unknown log level, using info")
+		level = zerolog.InfoLevel
+	}
+	zerolog.SetGlobalLevel(level)
 
-	// Set global log level
-	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	// zerolog.Ctx falls back to the global logger outside a request
+	zerolog.DefaultContextLogger = &log.Logger
 }
@@ -1,14 +1,13 @@
 package middleware
 
 import (
-	"context"
-	"net/http"
 	"strings"
 	"os"
 
 	"firebase.google.com/go/v4/auth"
 	"github.com/gin-gonic/gin"
 
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/repository"
 )
 
@@ -37,6 +36,7 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			}
 			c.Set("firebase_uid", uid)
 			c.Set("user_email", "")
+			withLogField(c, "uid", uid)
 			c.Next()
 			return
 		}
@@ -46,7 +46,7 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 
 		// Try session cookie first
 		if sessionCookie, cookieErr := c.Cookie("session_id"); cookieErr == nil && sessionCookie != "" {
-			token, err = m.firebaseAuth.VerifySessionCookie(context.Background(), sessionCookie)
+			token, err = m.firebaseAuth.VerifySessionCookie(c.Request.Context(), sessionCookie)
 			if err != nil {
 				// Clear invalid cookie
 				c.SetCookie("session_id", "", -1, "/", "", false, true)
@@ -57,10 +57,7 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		if token == nil {
 			authHeader := c.GetHeader("Authorization")
 			if authHeader == "" {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error":   "unauthorized",
-					"message": "Authentication required",
-				})
+				c.Error(apperror.Unauthorized("Authentication required"))
 				c.Abort()
 				return
 			}
@@ -68,10 +65,7 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			// Extract the token from "Bearer <token>"
 			tokenParts := strings.Split(authHeader, " ")
 			if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error":   "unauthorized",
-					"message": "Invalid authorization header format",
-				})
+				c.Error(apperror.Unauthorized("Invalid authorization header format"))
 				c.Abort()
 				return
 			}
@@ -79,12 +73,9 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			idToken := tokenParts[1]
 
 			// Verify the Firebase ID token
-			token, err = m.firebaseAuth.VerifyIDToken(context.Background(), idToken)
+			token, err = m.firebaseAuth.VerifyIDToken(c.Request.Context(), idToken)
 			if err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error":   "unauthorized",
-					"message": "Invalid or expired token",
-				})
+				c.Error(apperror.Unauthorized("Invalid or expired token"))
 				c.Abort()
 				return
 			}
@@ -92,17 +83,14 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 
 		// If we still don't have a valid token
 		if token == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":   "unauthorized",
-				"message": "Authentication required",
-			})
+			c.Error(apperror.Unauthorized("Authentication required"))
 			c.Abort()
 			return
 		}
 
-		user, err := m.userRepo.GetUserByFirebaseUID(token.UID)
+		user, err := m.userRepo.GetUserByFirebaseUID(c.Request.Context(), token.UID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "message": "Database error"})
+			c.Error(apperror.Wrap(err, "Database error"))
 			c.Abort()
 			return
 		}
@@ -110,7 +98,7 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		if user != nil {
 			// check if user is soft deleted
 			if user.IsDeleted {
-				c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "message": "Your account has been deactivated"})
+				c.Error(apperror.Forbidden("Your account has been deactivated"))
 				c.Abort()
 				return
 			}
@@ -120,6 +108,57 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		c.Set("firebase_uid", token.UID)
 		c.Set("user_email", token.Claims["email"])
 		c.Set("firebase_token", token)
+		withLogField(c, "uid", token.UID)
+
+		c.Next()
+	}
+}
+
+// OptionalAuth authenticates the caller like RequireAuth when credentials
+// are sent and lets requests without any through anonymously
+func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
+	requireAuth := m.RequireAuth()
+	return func(c *gin.Context) {
+		if !hasCredentials(c) {
+			c.Next()
+			return
+		}
+		requireAuth(c)
+	}
+}
+
+func hasCredentials(c *gin.Context) bool {
+	if os.Getenv("AUTH_BYPASS") == "1" {
+		return c.GetHeader("X-Test-UID") != ""
+	}
+	if cookie, err := c.Cookie("session_id"); err == nil && cookie != "" {
+		return true
+	}
+	return c.GetHeader("Authorization") != ""
+}
+
+// RequireAdmin allows only users with the Admin role. It must run after
+// RequireAuth.
+func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := GetFirebaseUserID(c)
+		if !ok {
+			c.Error(apperror.Unauthorized("Authentication required"))
+			c.Abort()
+			return
+		}
+
+		user, err := m.userRepo.GetUserByFirebaseUID(c.Request.Context(), uid)
+		if err != nil {
+			c.Error(apperror.Wrap(err, "Database error"))
+			c.Abort()
+			return
+		}
+		if user == nil || user.Role != "Admin" {
+			c.Error(apperror.Forbidden("Admin access required"))
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/model"
+)
+
+// ErrorHandler renders the last error a handler added with c.Error as a
+// model.ErrorResponse, unless the handler already wrote a response.
+// Internal errors are logged with their cause and shown without it.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		appErr := apperror.From(err)
+		if appErr.Status >= 500 {
+			GetLogger(c).Error().Err(err).
+				Str("method", c.Request.Method).
+				Str("route", c.FullPath()).
+				Msg(appErr.Message)
+		}
+
+		c.JSON(appErr.Status, model.ErrorResponse{
+			Code:      appErr.Code,
+			Message:   appErr.Message,
+			Fields:    appErr.Fields,
+			RequestID: GetRequestID(c),
+		})
+	}
+}
+
+// Recovery turns panics into internal errors rendered by ErrorHandler
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		c.Error(apperror.Wrap(fmt.Errorf("panic: %v", recovered), "internal server error"))
+		c.Abort()
+	})
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/model"
+)
+
+func TestErrorHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// keep the recovered panic's stack trace out of the test output
+	defer func(w io.Writer) { gin.DefaultErrorWriter = w }(gin.DefaultErrorWriter)
+	gin.DefaultErrorWriter = io.Discard
+
+	tests := []struct {
+		name       string
+		handler    gin.HandlerFunc
+		wantStatus int
+		// nil when the handler's own response is expected
+		want *model.ErrorResponse
+	}{
+		{
+			name:       "typed error",
+			handler:    func(c *gin.Context) { c.Error(apperror.NotFound("product not found")) },
+			wantStatus: http.StatusNotFound,
+			want:       &model.ErrorResponse{Code: apperror.CodeNotFound, Message: "product not found"},
+		},
+		{
+			name: "validation fields",
+			handler: func(c *gin.Context) {
+				c.Error(apperror.Validation("invalid request", apperror.Field("rating", "must be at most 5")))
+			},
+			wantStatus: http.StatusBadRequest,
+			want: &model.ErrorResponse{
+				Code:    apperror.CodeValidation,
+				Message: "invalid request",
+				Fields:  []model.FieldError{{Field: "rating", Message: "must be at most 5"}},
+			},
+		},
+		{
+			name:       "cause of an internal error is hidden",
+			handler:    func(c *gin.Context) { c.Error(errors.New("dial tcp 10.0.0.3:3306: connection refused")) },
+			wantStatus: http.StatusInternalServerError,
+			want:       &model.ErrorResponse{Code: apperror.CodeInternal, Message: "internal server error"},
+		},
+		{
+			name: "last error wins",
+			handler: func(c *gin.Context) {
+				c.Error(apperror.BadRequest("first"))
+				c.Error(apperror.Conflict("second"))
+			},
+			wantStatus: http.StatusConflict,
+			want:       &model.ErrorResponse{Code: apperror.CodeConflict, Message: "second"},
+		},
+		{
+			name: "written response is kept",
+			handler: func(c *gin.Context) {
+				c.Error(apperror.Internal("logged only"))
+				c.String(http.StatusAccepted, "queued")
+			},
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "panic is an internal error",
+			handler:    func(c *gin.Context) { panic("nil map") },
+			wantStatus: http.StatusInternalServerError,
+			want:       &model.ErrorResponse{Code: apperror.CodeInternal, Message: "internal server error"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(RequestID(), ErrorHandler(), Recovery())
+			r.GET("/", tt.handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(RequestIDHeader, "test-request")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.want == nil {
+				return
+			}
+			var got model.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body %q is not an error response: %v", w.Body.String(), err)
+			}
+			want := *tt.want
+			want.RequestID = "test-request"
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("body = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// RequestLogger attaches a logger carrying the request id to the request
+// context and writes one access log line per request once it is done.
+// It must run after RequestID and before ErrorHandler so the logged status
+// is the one the client got.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		l := log.With().Str("request_id", GetRequestID(c)).Logger()
+		c.Request = c.Request.WithContext(l.WithContext(c.Request.Context()))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		event := GetLogger(c).Info()
+		switch {
+		case status >= 500:
+			event = GetLogger(c).Error()
+		case status >= 400:
+			event = GetLogger(c).Warn()
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		event.
+			Str("method", c.Request.Method).
+			Str("route", route).
+			Str("path", c.Request.URL.Path).
+			Int("status", status).
+			Dur("latency", time.Since(start)).
+			Int("bytes", c.Writer.Size()).
+			Str("client_ip", c.ClientIP()).
+			Msg("request")
+	}
+}
+
+// GetLogger gets the per-request logger, which carries the request id and,
+// once authenticated, the user uid
+func GetLogger(c *gin.Context) *zerolog.Logger {
+	return zerolog.Ctx(c.Request.Context())
+}
+
+// withLogField adds a field to the per-request logger for the rest of the
+// request
+func withLogField(c *gin.Context, key, value string) {
+	l := GetLogger(c).With().Str(key, value).Logger()
+	c.Request = c.Request.WithContext(l.WithContext(c.Request.Context()))
+}
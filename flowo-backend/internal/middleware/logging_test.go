@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer func(l zerolog.Logger, level zerolog.Level) {
+		log.Logger = l
+		zerolog.SetGlobalLevel(level)
+	}(log.Logger, zerolog.GlobalLevel())
+	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+
+	tests := []struct {
+		name      string
+		path      string
+		status    int
+		wantLevel string
+		wantRoute string
+	}{
+		{"success is info", "/products/7", http.StatusOK, "info", "/products/:id"},
+		{"client error is warn", "/products/7", http.StatusNotFound, "warn", "/products/:id"},
+		{"server error is error", "/products/7", http.StatusInternalServerError, "error", "/products/:id"},
+		{"unknown route", "/nowhere", http.StatusNotFound, "warn", "unmatched"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log.Logger = zerolog.New(&buf)
+
+			r := gin.New()
+			r.Use(RequestID(), RequestLogger())
+			r.GET("/products/:id", func(c *gin.Context) {
+				withLogField(c, "firebase_uid", "uid-1")
+				GetLogger(c).Debug().Msg("handler")
+				c.Status(tt.status)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(RequestIDHeader, "test-request")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			lines := decodeLogLines(t, &buf)
+			access := lines[len(lines)-1]
+			want := map[string]interface{}{
+				"level":      tt.wantLevel,
+				"message":    "request",
+				"request_id": "test-request",
+				"method":     http.MethodGet,
+				"route":      tt.wantRoute,
+				"path":       tt.path,
+				"status":     float64(tt.status),
+			}
+			for k, v := range want {
+				if access[k] != v {
+					t.Errorf("access log %s = %v, want %v", k, access[k], v)
+				}
+			}
+
+			if tt.wantRoute == "unmatched" {
+				return
+			}
+			if len(lines) != 2 {
+				t.Fatalf("got %d log lines, want the handler's and the access log", len(lines))
+			}
+			for _, l := range lines {
+				if l["request_id"] != "test-request" || l["firebase_uid"] != "uid-1" {
+					t.Errorf("log line %v lacks the request id or uid", l)
+				}
+			}
+		})
+	}
+}
+
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, raw := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var l map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &l); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", raw, err)
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
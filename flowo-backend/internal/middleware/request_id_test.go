@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"4f9c2d7e1a6b3c8d", true},
+		{"7d0a-upstream/trace:42", true},
+		{strings.Repeat("a", 128), true},
+		{"", false},
+		{strings.Repeat("a", 129), false},
+		{"has space", false},
+		{"line\nbreak", false},
+		{"tab\t", false},
+		{"naïve", false},
+	}
+	for _, tt := range tests {
+		if got := validRequestID(tt.id); got != tt.want {
+			t.Errorf("validRequestID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		header   string
+		wantSame bool
+	}{
+		{"caller id is reused", "upstream-123", true},
+		{"missing id is generated", "", false},
+		{"invalid id is replaced", "bad id", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inContext, inRequest string
+			r := gin.New()
+			r.Use(RequestID())
+			r.GET("/", func(c *gin.Context) {
+				inContext = RequestIDFromContext(c.Request.Context())
+				inRequest = c.Request.Header.Get(RequestIDHeader)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			if tt.wantSame && got != tt.header {
+				t.Errorf("response id = %q, want %q", got, tt.header)
+			}
+			if !tt.wantSame && (got == tt.header || !validRequestID(got)) {
+				t.Errorf("response id = %q, want a new valid id", got)
+			}
+			if inContext != got || inRequest != got {
+				t.Errorf("context id %q, request header %q, want %q", inContext, inRequest, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flowo-backend/config"
+)
+
+// Timeout puts a deadline on the request context so database and cache
+// calls made on its behalf are cancelled instead of holding a connection.
+// Routes use the longest matching prefix in cfg.Routes, else cfg.Default.
+func Timeout(cfg config.TimeoutConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d := routeTimeout(cfg, c.FullPath())
+		if d <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+func routeTimeout(cfg config.TimeoutConfig, route string) time.Duration {
+	d, matched := cfg.Default, -1
+	for prefix, timeout := range cfg.Routes {
+		if strings.HasPrefix(route, prefix) && len(prefix) > matched {
+			d, matched = timeout, len(prefix)
+		}
+	}
+	return d
+}
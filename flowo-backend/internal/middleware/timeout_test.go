@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flowo-backend/config"
+)
+
+func TestRouteTimeout(t *testing.T) {
+	cfg := config.TimeoutConfig{
+		Default: 5 * time.Second,
+		Routes: map[string]time.Duration{
+			"/api/v1/products":                   2 * time.Second,
+			"/api/v1/products/search":            3 * time.Second,
+			"/api/v1/admin/recommendations/jobs": 0,
+		},
+	}
+
+	tests := []struct {
+		route string
+		want  time.Duration
+	}{
+		{"/api/v1/orders", 5 * time.Second},
+		{"/api/v1/products", 2 * time.Second},
+		{"/api/v1/products/:id", 2 * time.Second},
+		{"/api/v1/products/search", 3 * time.Second},
+		{"/api/v1/admin/recommendations/jobs/similarity", 0},
+		{"", 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := routeTimeout(cfg, tt.route); got != tt.want {
+			t.Errorf("routeTimeout(%q) = %v, want %v", tt.route, got, tt.want)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.TimeoutConfig{
+		Default: time.Minute,
+		Routes:  map[string]time.Duration{"/unbounded": 0},
+	}
+
+	tests := []struct {
+		route        string
+		wantDeadline bool
+	}{
+		{"/bounded", true},
+		{"/unbounded", false},
+	}
+	for _, tt := range tests {
+		var deadline time.Time
+		var hasDeadline bool
+		r := gin.New()
+		r.Use(Timeout(cfg))
+		r.GET(tt.route, func(c *gin.Context) {
+			deadline, hasDeadline = c.Request.Context().Deadline()
+		})
+
+		before := time.Now()
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.route, nil))
+		after := time.Now()
+
+		if hasDeadline != tt.wantDeadline {
+			t.Errorf("%s: deadline set = %v, want %v", tt.route, hasDeadline, tt.wantDeadline)
+		}
+		if hasDeadline && (deadline.Before(before.Add(time.Minute)) || deadline.After(after.Add(time.Minute))) {
+			t.Errorf("%s: deadline is %v after the request, want a minute", tt.route, deadline.Sub(before))
+		}
+	}
+}
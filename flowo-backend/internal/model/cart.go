@@ -14,6 +14,7 @@ type CartItem struct {
 	CartItemID int       `json:"cart_item_id"`
 	CartID     int       `json:"cart_id"`
 	ProductID  int       `json:"product_id"`
+	VariantID  *int      `json:"variant_id,omitempty"`
 	Quantity   int       `json:"quantity"`
 	AddedAt    time.Time `json:"added_at"`
 }
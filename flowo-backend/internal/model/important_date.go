@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// ImportantDate is a birthday, anniversary or other occasion a user buys
+// flowers for
+type ImportantDate struct {
+	DateID           int        `json:"date_id"`
+	FirebaseUID      string     `json:"firebase_uid"`
+	RecipientName    string     `json:"recipient_name"`
+	OccasionID       int        `json:"occasion_id"`
+	OccasionName     string     `json:"occasion_name"`
+	AddressID        *int       `json:"address_id,omitempty"`
+	EventDate        time.Time  `json:"event_date"`
+	Recurring        bool       `json:"recurring"`
+	RemindDaysBefore int        `json:"remind_days_before"`
+	Note             string     `json:"note"`
+	LastRemindedFor  *time.Time `json:"last_reminded_for,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+// InventoryBatch is a quantity of a product (or one of its variants) received
+// on a given date. Batches are consumed first-in-first-out and expire after
+// their shelf life.
+type InventoryBatch struct {
+	BatchID           int       `json:"batch_id"`
+	ProductID         int       `json:"product_id"`
+	VariantID         *int      `json:"variant_id,omitempty"`
+	QuantityReceived  int       `json:"quantity_received"`
+	QuantityRemaining int       `json:"quantity_remaining"`
+	ReceivedDate      time.Time `json:"received_date"`
+	ShelfLifeDays     int       `json:"shelf_life_days"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// Wastage records stock written off from a batch, e.g. because it expired
+type Wastage struct {
+	WastageID  int       `json:"wastage_id"`
+	BatchID    int       `json:"batch_id"`
+	ProductID  int       `json:"product_id"`
+	VariantID  *int      `json:"variant_id,omitempty"`
+	Quantity   int       `json:"quantity"`
+	Reason     string    `json:"reason"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// ProductFreshness is the stock and batch state used to derive a product status
+type ProductFreshness struct {
+	ProductID         int
+	Status            string
+	TotalStock        int
+	LowStockThreshold int
+	OldestExpiry      *time.Time
+}
+
+// Inventory movement types
+const (
+	MovementSale                = "sale"
+	MovementCancellationRestock = "cancellation_restock"
+	MovementManualAdjustment    = "manual_adjustment"
+	MovementReceipt             = "receipt"
+	MovementWastage             = "wastage"
+)
+
+// InventoryMovement is one append-only entry of the stock ledger
+type InventoryMovement struct {
+	MovementID     int       `json:"movement_id"`
+	ProductID      int       `json:"product_id"`
+	VariantID      *int      `json:"variant_id,omitempty"`
+	MovementType   string    `json:"movement_type"`
+	QuantityChange int       `json:"quantity_change"`
+	StockAfter     int       `json:"stock_after"`
+	Actor          string    `json:"actor"`
+	Reason         string    `json:"reason,omitempty"`
+	ReferenceID    *int      `json:"reference_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
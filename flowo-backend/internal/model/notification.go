@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type Notification struct {
+	NotificationID int       `json:"notification_id"`
+	FirebaseUID    string    `json:"firebase_uid"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title"`
+	Message        string    `json:"message"`
+	IsRead         bool      `json:"is_read"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -21,6 +21,7 @@ type OrderItem struct {
 	OrderItemID            int     `json:"order_item_id"`
 	OrderID                int     `json:"order_id"`
 	ProductID              int     `json:"product_id"`
+	VariantID              *int    `json:"variant_id,omitempty"`
 	Quantity               int     `json:"quantity"`
 	PricePerUnitAtPurchase float64 `json:"price_per_unit_at_purchase"`
 	ItemSubtotal           float64 `json:"item_subtotal"`
@@ -9,6 +9,7 @@ type PricingRule struct {
 	AdjustmentType          string     `json:"adjustment_type"`
 	AdjustmentValue         float64    `json:"adjustment_value"`
 	ApplicableProductID     *uint      `json:"applicable_product_id,omitempty"`
+	ApplicableVariantID     *uint      `json:"applicable_variant_id,omitempty"`
 	ApplicableFlowerTypeID  *int       `json:"applicable_flower_type_id,omitempty"`
 	ApplicableProductStatus *string    `json:"applicable_product_status,omitempty"`
 	TimeOfDayStart          *string    `json:"time_of_day_start,omitempty"`
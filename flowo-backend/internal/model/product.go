@@ -28,13 +28,18 @@ type Product struct {
 	Images []ProductImage `json:"images,omitempty"`
 	// Occasions this product is suitable for
 	Occasions []string `json:"occasions,omitempty"`
+	// Purchasable variants (size, color, wrapping) of the product
+	Variants []ProductVariant `json:"variants,omitempty"`
 	// Average rating from reviews
 	AverageRating float64 `json:"average_rating" example:"4.5"`
 	// Total number of reviews
 	ReviewCount int `json:"review_count" example:"23"`
-	// Best-selling rank (for sorting)
-	SalesRank int  `json:"sales_rank" example:"1"`
-	IsActive  bool `gorm:"default:true" json:"is_active"`
+	// Best-selling rank by units sold in the last 30 days (for sorting)
+	SalesRank int `json:"sales_rank" example:"1"`
+	// Units sold in completed orders over the last 7 and 30 days
+	UnitsSold7d  int  `json:"units_sold_7d" example:"12"`
+	UnitsSold30d int  `json:"units_sold_30d" example:"40"`
+	IsActive     bool `gorm:"default:true" json:"is_active"`
 }
 
 // ProductImage represents an image associated with a product
@@ -45,10 +50,48 @@ type ProductImage struct {
 	ProductID uint `json:"product_id" example:"1"`
 	// URL of the image
 	ImageURL string `json:"image_url" example:"https://example.com/images/rose.jpg"`
+	// URL of the downscaled thumbnail, empty for externally hosted images
+	ThumbnailURL string `json:"thumbnail_url,omitempty" example:"/uploads/products/1/thumb_abc.jpg"`
 	// Alt text for accessibility
 	AltText string `json:"alt_text" example:"Red Rose Bouquet"`
 	// Whether this is the primary image
 	IsPrimary bool `json:"is_primary" example:"true"`
+	// Storage keys of the uploaded original and thumbnail
+	StorageKey   string `json:"-"`
+	ThumbnailKey string `json:"-"`
+}
+
+// ProductVariant represents a purchasable variant of a product with its own SKU, price and stock
+type ProductVariant struct {
+	// Unique identifier of the variant
+	VariantID uint `json:"variant_id" example:"1"`
+	// Product ID this variant belongs to
+	ProductID uint `json:"product_id" example:"1"`
+	// Stock keeping unit
+	SKU string `json:"sku" example:"ROSE-RED-24-KRAFT"`
+	// Display name of the variant
+	Name string `json:"name" example:"24 stems - Kraft paper"`
+	// Size attribute (e.g., 12 stems, 24 stems)
+	Size string `json:"size,omitempty" example:"24 stems"`
+	// Color attribute
+	Color string `json:"color,omitempty" example:"Red"`
+	// Wrapping attribute
+	Wrapping string `json:"wrapping,omitempty" example:"Kraft paper"`
+	// Amount added to the product base price
+	PriceDelta float64 `json:"price_delta" example:"20.00"`
+	// Stock quantity of the variant
+	StockQuantity int `json:"stock_quantity" example:"25"`
+	// Whether the variant can be purchased
+	IsActive bool `json:"is_active" example:"true"`
+	// Timestamp when the variant was created
+	CreatedAt time.Time `json:"created_at" example:"2024-03-15T08:00:00Z"`
+	// Timestamp when the variant was last updated
+	UpdatedAt time.Time `json:"updated_at" example:"2024-03-15T08:00:00Z"`
+}
+
+// BasePrice returns the variant price before dynamic pricing rules are applied
+func (v ProductVariant) BasePrice(product Product) float64 {
+	return product.BasePrice + v.PriceDelta
 }
 
 // FlowerType represents a type of flower
@@ -99,6 +142,36 @@ type ProductSearchResponse struct {
 	Pagination PaginationInfo `json:"pagination"`
 	// Filter options available
 	Filters FilterOptions `json:"filters"`
+	// Counts for the current filter set
+	Facets *SearchFacets `json:"facets,omitempty"`
+}
+
+// SearchFacets holds result counts per filter value. Each facet is counted
+// with every filter except its own applied, so the counts of a multi-select
+// facet show what selecting another value would add.
+type SearchFacets struct {
+	// Products per flower type
+	FlowerTypes []FacetCount `json:"flower_types"`
+	// Products per occasion
+	Occasions []FacetCount `json:"occasions"`
+	// Products per status
+	Statuses []FacetCount `json:"statuses"`
+	// Products per price bucket
+	PriceHistogram []PriceBucket `json:"price_histogram"`
+}
+
+// FacetCount is the number of matching products with one filter value
+type FacetCount struct {
+	Value    string `json:"value" example:"Rose"`
+	Count    int    `json:"count" example:"12"`
+	Selected bool   `json:"selected" example:"false"`
+}
+
+// PriceBucket counts matching products priced in [Min, Max)
+type PriceBucket struct {
+	Min   float64 `json:"min" example:"20"`
+	Max   float64 `json:"max" example:"40"`
+	Count int     `json:"count" example:"7"`
 }
 
 // PaginationInfo contains pagination metadata
@@ -115,6 +188,10 @@ type PaginationInfo struct {
 	HasNext bool `json:"has_next" example:"true"`
 	// Whether there's a previous page
 	HasPrev bool `json:"has_prev" example:"false"`
+	// Opaque cursor of the next page, pass it back as cursor
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJ0IjoiMjAyNC0wMy0xNVQwODowMDowMFoiLCJpZCI6IjQyIn0"`
+	// Opaque cursor of the previous page
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 // FilterOptions contains available filter options
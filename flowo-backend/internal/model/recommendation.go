@@ -54,6 +54,46 @@ type UserPreference struct {
 	PriceMax            float64   `json:"price_max" db:"price_max"`
 	AverageSpent        float64   `json:"average_spent" db:"average_spent"`
 	LastUpdated         time.Time `json:"last_updated" db:"last_updated"`
+
+	// Decayed sums the preferences above are derived from, see the
+	// preference learner
+	FlowerScores   string     `json:"-" db:"flower_scores"`   // JSON string
+	OccasionScores string     `json:"-" db:"occasion_scores"` // JSON string
+	PriceWeight    float64    `json:"-" db:"price_weight"`
+	PriceSum       float64    `json:"-" db:"price_sum"`
+	PriceSquareSum float64    `json:"-" db:"price_square_sum"`
+	LearnedAt      *time.Time `json:"-" db:"learned_at"`
+	LearnedFrom    *time.Time `json:"-" db:"learned_from"`
+}
+
+// Kinds of UserProductInteraction
+const (
+	InteractionView      = "view"
+	InteractionAddToCart = "add_to_cart"
+)
+
+// Kinds of preference signal
+const (
+	SignalPurchase = "purchase"
+	SignalReview   = "review"
+	SignalCartAdd  = "add_to_cart"
+	SignalFeedback = "feedback"
+)
+
+// PreferenceSignal is one thing a user did with a product that tells what
+// they like
+type PreferenceSignal struct {
+	Kind       string
+	ProductID  uint
+	FlowerType string
+	At         time.Time
+	// purchases
+	Quantity  int
+	UnitPrice float64
+	// reviews
+	Rating int
+	// feedback
+	Action string
 }
 
 // ProductSimilarity represents similarity between products
@@ -65,6 +105,52 @@ type ProductSimilarity struct {
 	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// Similarity types stored in ProductSimilarity
+const (
+	SimilarityContent       = "content"
+	SimilarityCollaborative = "collaborative"
+	SimilarityHybrid        = "hybrid"
+)
+
+// ItemSignal is how strongly one user (or anonymous session) engaged with a
+// product: units bought in completed orders and views
+type ItemSignal struct {
+	UserKey   string `json:"user_key"`
+	ProductID uint   `json:"product_id"`
+	UnitsSold int    `json:"units_sold"`
+	Views     int    `json:"views"`
+}
+
+// AssociationRule is the rule "baskets with the antecedent also hold the
+// consequent", mined from completed orders
+type AssociationRule struct {
+	AntecedentID uint `json:"antecedent_id"`
+	ConsequentID uint `json:"consequent_id"`
+	// orders holding both products
+	PairCount int `json:"pair_count"`
+	// orders holding the antecedent
+	AntecedentCount int `json:"antecedent_count"`
+	// all completed orders
+	BasketCount int `json:"basket_count"`
+}
+
+// Support is the share of all orders holding both products
+func (r AssociationRule) Support() float64 {
+	if r.BasketCount == 0 {
+		return 0
+	}
+	return float64(r.PairCount) / float64(r.BasketCount)
+}
+
+// Confidence is the share of orders with the antecedent that also hold the
+// consequent
+func (r AssociationRule) Confidence() float64 {
+	if r.AntecedentCount == 0 {
+		return 0
+	}
+	return float64(r.PairCount) / float64(r.AntecedentCount)
+}
+
 // TrendingProduct represents trending product information
 type TrendingProduct struct {
 	ProductID     uint      `json:"product_id" db:"product_id"`
@@ -75,6 +161,14 @@ type TrendingProduct struct {
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// ProductActivity is a product's views and units sold in one hour
+type ProductActivity struct {
+	ProductID uint      `json:"product_id"`
+	Hour      time.Time `json:"hour"`
+	Views     int       `json:"views"`
+	UnitsSold int       `json:"units_sold"`
+}
+
 // UserInteractionSummary represents aggregated user interactions
 type UserInteractionSummary struct {
 	FirebaseUID      string  `json:"firebase_uid"`
@@ -104,6 +198,12 @@ type RecommendationConfig struct {
 	CacheDuration int `json:"cache_duration"`
 	// Minimum interactions for collaborative filtering
 	MinInteractions int `json:"min_interactions"`
+	// Most items of the same flower type in a personalized list
+	MaxPerFlowerType int `json:"max_per_flower_type"`
+
+	// Admin who last changed the stored configuration, and when
+	UpdatedBy string     `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
 }
 
 // DefaultRecommendationConfig returns default configuration
@@ -117,5 +217,82 @@ func DefaultRecommendationConfig() RecommendationConfig {
 		DefaultLimit:        10,
 		CacheDuration:       60,
 		MinInteractions:     3,
+		MaxPerFlowerType:    3,
 	}
 }
+
+// Experiment statuses; at most one experiment is running at a time
+const (
+	ExperimentDraft   = "draft"
+	ExperimentRunning = "running"
+	ExperimentStopped = "stopped"
+)
+
+// RecommendationExperiment splits signed-in users between variants of the
+// recommendation engine settings
+type RecommendationExperiment struct {
+	ExperimentID uint                `json:"experiment_id"`
+	Name         string              `json:"name"`
+	Description  string              `json:"description,omitempty"`
+	Status       string              `json:"status"`
+	CreatedBy    string              `json:"created_by,omitempty"`
+	StartedAt    *time.Time          `json:"started_at,omitempty"`
+	StoppedAt    *time.Time          `json:"stopped_at,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	Variants     []ExperimentVariant `json:"variants"`
+}
+
+// ExperimentVariant overrides the engine settings for its share of users;
+// nil fields keep the global configuration
+type ExperimentVariant struct {
+	VariantID           uint     `json:"variant_id"`
+	Name                string   `json:"name"`
+	TrafficPercent      int      `json:"traffic_percent"`
+	CollaborativeWeight *float64 `json:"collaborative_weight,omitempty"`
+	ContentWeight       *float64 `json:"content_weight,omitempty"`
+	PopularityWeight    *float64 `json:"popularity_weight,omitempty"`
+	TrendingWeight      *float64 `json:"trending_weight,omitempty"`
+	MaxPerFlowerType    *int     `json:"max_per_flower_type,omitempty"`
+}
+
+// RecommendationFeedback is what a user did with a recommended product,
+// tagged with the experiment variant they were in
+type RecommendationFeedback struct {
+	FirebaseUID        string
+	ProductID          uint
+	RecommendationType string
+	Action             string
+	SessionID          string
+	ExperimentID       *uint
+	Variant            string
+}
+
+// RecommendationImpression is one recommendation list served to a user
+type RecommendationImpression struct {
+	FirebaseUID        string
+	RecommendationType string
+	ExperimentID       *uint
+	Variant            string
+	ProductCount       int
+	AverageScore       float64
+}
+
+// RecommendationTypeStats sums impressions and feedback of one
+// recommendation type
+type RecommendationTypeStats struct {
+	RecommendationType string
+	Impressions        int
+	Clicks             int
+	Purchases          int
+	AverageScore       float64
+}
+
+// VariantStats counts the users a variant reached and how many of them
+// clicked or purchased a recommended product
+type VariantStats struct {
+	Variant        string
+	Users          int
+	Impressions    int
+	ClickedUsers   int
+	PurchasedUsers int
+}
@@ -13,3 +13,20 @@ func NewResponse(message string, data interface{}) *Response {
 		Data:    data,
 	}
 }
+
+// ErrorResponse is the body of every failed API request
+type ErrorResponse struct {
+	// Machine readable error code, e.g. not_found or validation_failed
+	Code    string `json:"code" example:"not_found"`
+	Message string `json:"message" example:"product not found"`
+	// Invalid request fields, for validation errors
+	Fields []FieldError `json:"fields,omitempty"`
+	// Matches the X-Request-ID response header
+	RequestID string `json:"request_id" example:"4f9c2d7e1a6b3c8d"`
+}
+
+// FieldError describes one invalid request field
+type FieldError struct {
+	Field   string `json:"field" example:"quantity"`
+	Message string `json:"message" example:"must be at least 1"`
+}
@@ -2,6 +2,13 @@ package model
 
 import "time"
 
+// Review moderation statuses
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
 type Review struct {
 	ReviewID    int       `json:"review_id"`
 	ProductID   int       `json:"product_id"`
@@ -9,4 +16,45 @@ type Review struct {
 	Rating      int       `json:"rating"`
 	Comment     string    `json:"comment"`
 	ReviewDate  time.Time `json:"review_date"`
+	// Reviewer's full name or username, as shown on the product page
+	ReviewerName string `json:"reviewer_name"`
+	// Order item the review is for; nil for reviews from before verification
+	OrderItemID      *int          `json:"order_item_id,omitempty"`
+	Status           string        `json:"status"`
+	ModerationReason *string       `json:"moderation_reason,omitempty"`
+	ModeratedBy      *string       `json:"moderated_by,omitempty"`
+	ModeratedAt      *time.Time    `json:"moderated_at,omitempty"`
+	UpdatedAt        *time.Time    `json:"updated_at,omitempty"`
+	HelpfulCount     int           `json:"helpful_count"`
+	NotHelpfulCount  int           `json:"not_helpful_count"`
+	Photos           []ReviewPhoto `json:"photos,omitempty"`
+	Replies          []ReviewReply `json:"replies,omitempty"`
+}
+
+// ReviewPhoto is a photo attached to a review by its author
+type ReviewPhoto struct {
+	PhotoID      int       `json:"photo_id"`
+	ReviewID     int       `json:"review_id"`
+	ImageURL     string    `json:"image_url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	StorageKey   string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// VerifiedPurchase reports whether the review is tied to a completed purchase
+func (r Review) VerifiedPurchase() bool {
+	return r.OrderItemID != nil
+}
+
+// ReviewReply is a public reply by shop staff to a review or to another reply
+type ReviewReply struct {
+	ReplyID  int `json:"reply_id"`
+	ReviewID int `json:"review_id"`
+	// Reply this one answers; nil when it answers the review
+	ParentReplyID *int      `json:"parent_reply_id,omitempty"`
+	FirebaseUID   string    `json:"firebase_uid"`
+	AuthorName    string    `json:"author_name"`
+	Message       string    `json:"message"`
+	CreatedAt     time.Time `json:"created_at"`
 }
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// SearchQueryStat counts how often a query has been searched
+type SearchQueryStat struct {
+	NormalizedQuery string    `json:"normalized_query"`
+	DisplayQuery    string    `json:"display_query"`
+	SearchCount     int       `json:"search_count"`
+	LastSearchedAt  time.Time `json:"last_searched_at"`
+}
@@ -0,0 +1,155 @@
+// Package moderation screens user-written text, such as reviews, before it is
+// published. Filters only flag text; flagged text is held for an admin.
+package moderation
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"flowo-backend/config"
+	"flowo-backend/internal/search"
+)
+
+// Result is a filter's verdict on a piece of text
+type Result struct {
+	Flagged bool
+	Reasons []string
+}
+
+// Filter checks text for content that needs a moderator's review.
+// Implementations must be safe for concurrent use.
+type Filter interface {
+	Check(text string) Result
+}
+
+// NewDefaultFilter screens for blocked words, including those configured in
+// REVIEW_BLOCKED_WORDS, and for spam
+func NewDefaultFilter(cfg *config.Config) Filter {
+	return Chain(NewWordFilter(cfg.Review.BlockedWords), NewSpamFilter())
+}
+
+// Chain runs every filter and merges their verdicts
+func Chain(filters ...Filter) Filter {
+	return chain(filters)
+}
+
+type chain []Filter
+
+func (c chain) Check(text string) Result {
+	var res Result
+	for _, f := range c {
+		r := f.Check(text)
+		if r.Flagged {
+			res.Flagged = true
+			res.Reasons = append(res.Reasons, r.Reasons...)
+		}
+	}
+	return res
+}
+
+// defaultBlockedWords are matched after folding case and accents, so "Đm"
+// and "dm" are the same word
+var defaultBlockedWords = []string{
+	"fuck", "shit", "bitch", "asshole", "bastard", "cunt",
+	"dm", "dmm", "dcm", "vcl", "vkl", "clgt",
+}
+
+// WordFilter flags text containing a blocked word
+type WordFilter struct {
+	words map[string]bool
+}
+
+// NewWordFilter blocks the default words plus extra
+func NewWordFilter(extra []string) *WordFilter {
+	f := &WordFilter{words: make(map[string]bool)}
+	for _, w := range append(defaultBlockedWords, extra...) {
+		for _, token := range search.Tokenize(w) {
+			f.words[token] = true
+		}
+	}
+	return f
+}
+
+func (f *WordFilter) Check(text string) Result {
+	for _, token := range search.Tokenize(text) {
+		if f.words[token] {
+			return Result{Flagged: true, Reasons: []string{"profanity"}}
+		}
+	}
+	return Result{}
+}
+
+var (
+	linkPattern  = regexp.MustCompile(`(?i)(https?://|www\.|\b[a-z0-9-]+\.(com|net|vn|xyz|info|biz)\b)`)
+	phonePattern = regexp.MustCompile(`(\d[\s.-]?){9,}`)
+)
+
+// SpamFilter flags links, phone numbers, shouting and repetition, the usual
+// signs of advertising or junk
+type SpamFilter struct{}
+
+func NewSpamFilter() *SpamFilter {
+	return &SpamFilter{}
+}
+
+func (f *SpamFilter) Check(text string) Result {
+	var reasons []string
+	if linkPattern.MatchString(text) {
+		reasons = append(reasons, "link")
+	}
+	if phonePattern.MatchString(text) {
+		reasons = append(reasons, "phone number")
+	}
+	if isShouting(text) {
+		reasons = append(reasons, "all caps")
+	}
+	if hasRepetition(text) {
+		reasons = append(reasons, "repetition")
+	}
+	return Result{Flagged: len(reasons) > 0, Reasons: reasons}
+}
+
+// isShouting reports text of some length written almost entirely in capitals
+func isShouting(text string) bool {
+	letters, upper := 0, 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters >= 20 && upper*10 >= letters*8
+}
+
+// hasRepetition reports a character repeated many times in a row ("!!!!!!!!")
+// or a text made mostly of the same word
+func hasRepetition(text string) bool {
+	var prev rune
+	run := 0
+	for _, r := range text {
+		if r == prev {
+			run++
+			if run >= 8 {
+				return true
+			}
+		} else {
+			prev, run = r, 1
+		}
+	}
+
+	words := strings.Fields(strings.ToLower(text))
+	if len(words) < 6 {
+		return false
+	}
+	counts := make(map[string]int)
+	for _, w := range words {
+		counts[w]++
+		if counts[w]*2 > len(words) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package moderation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWordFilter(t *testing.T) {
+	f := NewWordFilter([]string{"Hoa Giả"})
+
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"Beautiful roses, fast delivery", false},
+		{"What the FUCK happened to my order", true},
+		{"Giao chậm vcl", true},
+		{"Đm shop", true},
+		// folding does not make words out of parts of others
+		{"shitake mushrooms in the bouquet?", false},
+		{"admin was helpful", false},
+		{"configured words are matched too: hoa gia", true},
+		{"", false},
+	}
+	for _, tt := range tests {
+		got := f.Check(tt.text)
+		if got.Flagged != tt.want {
+			t.Errorf("Check(%q) flagged = %v, want %v", tt.text, got.Flagged, tt.want)
+		}
+		if got.Flagged && !reflect.DeepEqual(got.Reasons, []string{"profanity"}) {
+			t.Errorf("Check(%q) reasons = %v, want [profanity]", tt.text, got.Reasons)
+		}
+	}
+}
+
+func TestSpamFilter(t *testing.T) {
+	f := NewSpamFilter()
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Lovely lilies, they lasted two weeks!", nil},
+		{"Cheaper at https://example.org/flowers", []string{"link"}},
+		{"visit www.example.org", []string{"link"}},
+		{"order from hoatuoi.vn instead", []string{"link"}},
+		{"call me 0912 345 678", []string{"phone number"}},
+		{"call me 0912.345.678", []string{"phone number"}},
+		{"ordered 2 bouquets for 350000", nil},
+		{"THE WORST FLOWERS I HAVE EVER BOUGHT", []string{"all caps"}},
+		{"GREAT", nil},
+		{"Wow!!!!!!!!", []string{"repetition"}},
+		{"buy buy buy buy now please", []string{"repetition"}},
+		{"good good good", nil},
+		{"BUY FLOWERS NOW AT WWW.SALE.XYZ!!!!!!!!", []string{"link", "all caps", "repetition"}},
+	}
+	for _, tt := range tests {
+		got := f.Check(tt.text)
+		if got.Flagged != (len(tt.want) > 0) || !reflect.DeepEqual(got.Reasons, tt.want) {
+			t.Errorf("Check(%q) = %+v, want reasons %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	f := Chain(NewWordFilter(nil), NewSpamFilter())
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Fresh and fragrant", nil},
+		{"shit service, see www.other-shop.com", []string{"profanity", "link"}},
+		{"call 0912345678", []string{"phone number"}},
+	}
+	for _, tt := range tests {
+		got := f.Check(tt.text)
+		if got.Flagged != (len(tt.want) > 0) || !reflect.DeepEqual(got.Reasons, tt.want) {
+			t.Errorf("Check(%q) = %+v, want reasons %v", tt.text, got, tt.want)
+		}
+	}
+}
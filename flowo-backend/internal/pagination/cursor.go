@@ -0,0 +1,166 @@
+// Package pagination implements keyset pagination with opaque cursors over
+// listings sorted newest first, with page/limit offset paging as a fallback.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"flowo-backend/internal/apperror"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Cursor is the position of a row in a listing sorted by (time, id)
+// descending. Clients only ever see it encoded.
+type Cursor struct {
+	Time time.Time `json:"t"`
+	ID   string    `json:"id"`
+	// Before pages towards newer rows, i.e. the previous page
+	Before bool `json:"b,omitempty"`
+}
+
+// NewCursor is the position of a row with a numeric id
+func NewCursor(t time.Time, id int) Cursor {
+	return Cursor{Time: t, ID: strconv.Itoa(id)}
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func Decode(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, apperror.Validation("invalid cursor")
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" || c.Time.IsZero() {
+		return nil, apperror.Validation("invalid cursor")
+	}
+	return &c, nil
+}
+
+// Request is the paging part of a listing request. With a cursor the page
+// number is ignored.
+type Request struct {
+	Page   int
+	Limit  int
+	Cursor *Cursor
+}
+
+// NewRequest validates the paging parameters, defaulting the page to 1 and
+// clamping the limit
+func NewRequest(cursor string, page, limit int) (Request, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	req := Request{Page: page, Limit: limit}
+	if cursor != "" {
+		c, err := Decode(cursor)
+		if err != nil {
+			return req, err
+		}
+		req.Cursor = c
+	}
+	return req, nil
+}
+
+// Offset is the OFFSET for page mode, zero with a cursor
+func (r Request) Offset() int {
+	if r.Cursor != nil {
+		return 0
+	}
+	return (r.Page - 1) * r.Limit
+}
+
+// Fetch is the LIMIT to query with: one row more than the page shows, which
+// tells Trim whether more rows follow
+func (r Request) Fetch() int {
+	return r.Limit + 1
+}
+
+// Keyset returns the condition selecting the rows past the cursor (empty
+// without a cursor), its arguments, and the ORDER BY to fetch them in
+func (r Request) Keyset(timeCol, idCol string) (string, []interface{}, string) {
+	if r.Cursor == nil {
+		return "", nil, timeCol + " DESC, " + idCol + " DESC"
+	}
+
+	args := []interface{}{r.Cursor.Time, r.Cursor.Time, r.Cursor.ID}
+	if r.Cursor.Before {
+		return "(" + timeCol + " > ? OR (" + timeCol + " = ? AND " + idCol + " > ?))", args,
+			timeCol + " ASC, " + idCol + " ASC"
+	}
+	return "(" + timeCol + " < ? OR (" + timeCol + " = ? AND " + idCol + " < ?))", args,
+		timeCol + " DESC, " + idCol + " DESC"
+}
+
+// Result describes what surrounds the returned page
+type Result struct {
+	NextCursor string
+	PrevCursor string
+	HasNext    bool
+	HasPrev    bool
+}
+
+// Trim cuts rows fetched with Fetch and Keyset down to the page, newest
+// first, and returns the cursors of the neighbouring pages. key gives the
+// position of a row.
+func Trim[T any](r Request, rows []T, key func(T) Cursor) ([]T, Result) {
+	var res Result
+	more := len(rows) > r.Limit
+	if more {
+		rows = rows[:r.Limit]
+	}
+
+	if r.Cursor != nil && r.Cursor.Before {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+		res.HasPrev = more
+		res.HasNext = true
+	} else {
+		res.HasNext = more
+		res.HasPrev = r.Cursor != nil || r.Page > 1
+	}
+
+	if len(rows) == 0 {
+		// past either end: the cursor itself leads back
+		if r.Cursor != nil {
+			back := *r.Cursor
+			back.Before = !back.Before
+			if back.Before {
+				res.PrevCursor = back.Encode()
+			} else {
+				res.NextCursor = back.Encode()
+			}
+		}
+		return rows, res
+	}
+
+	if res.HasNext {
+		next := key(rows[len(rows)-1])
+		next.Before = false
+		res.NextCursor = next.Encode()
+	}
+	if res.HasPrev {
+		prev := key(rows[0])
+		prev.Before = true
+		res.PrevCursor = prev.Encode()
+	}
+	return rows, res
+}
@@ -0,0 +1,241 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"flowo-backend/internal/apperror"
+)
+
+var base = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+type row struct {
+	id      int
+	created time.Time
+}
+
+func rowKey(r row) Cursor {
+	return NewCursor(r.created, r.id)
+}
+
+// rows returns ids in the order given, each one minute older than the last
+func rows(ids ...int) []row {
+	out := make([]row, len(ids))
+	for i, id := range ids {
+		out[i] = row{id: id, created: base.Add(-time.Duration(id) * time.Minute)}
+	}
+	return out
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := Cursor{Time: base, ID: "42", Before: true}
+	got, err := Decode(c.Encode())
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !got.Time.Equal(c.Time) || got.ID != c.ID || got.Before != c.Before {
+		t.Errorf("Decode(Encode()) = %+v, want %+v", *got, c)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"not base64", "!!!"},
+		{"not json", "bm90IGpzb24"},
+		{"missing id", Cursor{Time: base}.Encode()},
+		{"missing time", Cursor{ID: "1"}.Encode()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.in)
+			if !apperror.Is(err, apperror.CodeValidation) {
+				t.Errorf("Decode(%q) error = %v, want a validation error", tt.in, err)
+			}
+		})
+	}
+}
+
+func TestNewRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		page       int
+		limit      int
+		wantPage   int
+		wantLimit  int
+		wantOffset int
+	}{
+		{"defaults", 0, 0, 1, DefaultLimit, 0},
+		{"negative values", -3, -1, 1, DefaultLimit, 0},
+		{"limit clamped", 2, 500, 2, MaxLimit, MaxLimit},
+		{"page offset", 3, 10, 3, 10, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := NewRequest("", tt.page, tt.limit)
+			if err != nil {
+				t.Fatalf("NewRequest() error = %v", err)
+			}
+			if req.Page != tt.wantPage || req.Limit != tt.wantLimit || req.Offset() != tt.wantOffset {
+				t.Errorf("NewRequest(%d, %d) = page %d limit %d offset %d, want %d %d %d",
+					tt.page, tt.limit, req.Page, req.Limit, req.Offset(), tt.wantPage, tt.wantLimit, tt.wantOffset)
+			}
+			if req.Fetch() != req.Limit+1 {
+				t.Errorf("Fetch() = %d, want %d", req.Fetch(), req.Limit+1)
+			}
+		})
+	}
+
+	req, err := NewRequest(NewCursor(base, 7).Encode(), 5, 10)
+	if err != nil {
+		t.Fatalf("NewRequest() with cursor error = %v", err)
+	}
+	if req.Cursor == nil || req.Cursor.ID != "7" || req.Offset() != 0 {
+		t.Errorf("NewRequest() with cursor = %+v, want cursor 7 and no offset", req)
+	}
+
+	if _, err := NewRequest("garbage!", 1, 10); err == nil {
+		t.Error("NewRequest() with an invalid cursor succeeded")
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	after := NewCursor(base, 7)
+	before := after
+	before.Before = true
+
+	tests := []struct {
+		name      string
+		cursor    *Cursor
+		wantCond  string
+		wantArgs  int
+		wantOrder string
+	}{
+		{"first page", nil, "", 0, "created_at DESC, id DESC"},
+		{"next page", &after, "(created_at < ? OR (created_at = ? AND id < ?))", 3, "created_at DESC, id DESC"},
+		{"previous page", &before, "(created_at > ? OR (created_at = ? AND id > ?))", 3, "created_at ASC, id ASC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, args, order := Request{Limit: 10, Cursor: tt.cursor}.Keyset("created_at", "id")
+			if cond != tt.wantCond || len(args) != tt.wantArgs || order != tt.wantOrder {
+				t.Errorf("Keyset() = %q, %v, %q, want %q, %d args, %q",
+					cond, args, order, tt.wantCond, tt.wantArgs, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestTrim(t *testing.T) {
+	after := NewCursor(base, 3)
+	before := after
+	before.Before = true
+
+	tests := []struct {
+		name     string
+		req      Request
+		fetched  []row
+		wantIDs  []int
+		wantNext string
+		wantPrev string
+	}{
+		{
+			name:     "first page with more rows",
+			req:      Request{Page: 1, Limit: 2},
+			fetched:  rows(1, 2, 3),
+			wantIDs:  []int{1, 2},
+			wantNext: "2",
+		},
+		{
+			name:    "only page",
+			req:     Request{Page: 1, Limit: 2},
+			fetched: rows(1, 2),
+			wantIDs: []int{1, 2},
+		},
+		{
+			name:     "middle page after a cursor",
+			req:      Request{Limit: 2, Cursor: &after},
+			fetched:  rows(4, 5, 6),
+			wantIDs:  []int{4, 5},
+			wantNext: "5",
+			wantPrev: "4",
+		},
+		{
+			name:     "last page after a cursor",
+			req:      Request{Limit: 2, Cursor: &after},
+			fetched:  rows(4),
+			wantIDs:  []int{4},
+			wantPrev: "4",
+		},
+		{
+			// rows come back oldest first from the ascending query
+			name:     "previous page is reversed to newest first",
+			req:      Request{Limit: 2, Cursor: &before},
+			fetched:  rows(2, 1),
+			wantIDs:  []int{1, 2},
+			wantNext: "2",
+		},
+		{
+			name:     "previous page with more rows before it",
+			req:      Request{Limit: 1, Cursor: &before},
+			fetched:  rows(2, 1),
+			wantIDs:  []int{2},
+			wantNext: "2",
+			wantPrev: "2",
+		},
+		{
+			name:     "empty page past the end leads back",
+			req:      Request{Limit: 2, Cursor: &after},
+			fetched:  nil,
+			wantPrev: "3",
+		},
+		{
+			name:     "empty page past the start leads forward",
+			req:      Request{Limit: 2, Cursor: &before},
+			fetched:  nil,
+			wantNext: "3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, res := Trim(tt.req, tt.fetched, rowKey)
+
+			if len(page) != len(tt.wantIDs) {
+				t.Fatalf("Trim() returned %d rows, want %v", len(page), tt.wantIDs)
+			}
+			for i, r := range page {
+				if r.id != tt.wantIDs[i] {
+					t.Fatalf("Trim() row %d = %d, want %v", i, r.id, tt.wantIDs)
+				}
+			}
+
+			checkCursor(t, "next", res.NextCursor, tt.wantNext, false)
+			checkCursor(t, "prev", res.PrevCursor, tt.wantPrev, true)
+			if res.HasNext != (tt.wantNext != "") && len(page) > 0 {
+				t.Errorf("HasNext = %v with next cursor %q", res.HasNext, tt.wantNext)
+			}
+			if res.HasPrev != (tt.wantPrev != "") && len(page) > 0 {
+				t.Errorf("HasPrev = %v with prev cursor %q", res.HasPrev, tt.wantPrev)
+			}
+		})
+	}
+}
+
+func checkCursor(t *testing.T, name, encoded, wantID string, wantBefore bool) {
+	t.Helper()
+	if wantID == "" {
+		if encoded != "" {
+			t.Errorf("%s cursor = %q, want none", name, encoded)
+		}
+		return
+	}
+	c, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("%s cursor %q: %v", name, encoded, err)
+	}
+	if c.ID != wantID || c.Before != wantBefore {
+		t.Errorf("%s cursor = id %s before %v, want id %s before %v", name, c.ID, c.Before, wantID, wantBefore)
+	}
+}
@@ -1,18 +1,20 @@
 package repository
 
 import (
+	"context"
+
 	"database/sql"
-	"errors"
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/model"
 )
 
 type AddressRepository interface {
-	Create(address *model.Address) (int, error)
-	GetAllByUser(uid string) ([]model.Address, error)
-	Delete(uid string, addressID int) error
-	ClearDefault(uid string) error
-	SetDefault(uid string, addressID int) error
-	GetDefault(uid string) (*model.Address, error)
+	Create(ctx context.Context, address *model.Address) (int, error)
+	GetAllByUser(ctx context.Context, uid string) ([]model.Address, error)
+	Delete(ctx context.Context, uid string, addressID int) error
+	ClearDefault(ctx context.Context, uid string) error
+	SetDefault(ctx context.Context, uid string, addressID int) error
+	GetDefault(ctx context.Context, uid string) (*model.Address, error)
 }
 
 type addressRepository struct {
@@ -23,11 +25,11 @@ func NewAddressRepository(db *sql.DB) AddressRepository {
 	return &addressRepository{db: db}
 }
 
-func (r *addressRepository) Create(address *model.Address) (int, error) {
+func (r *addressRepository) Create(ctx context.Context, address *model.Address) (int, error) {
 	query := `INSERT INTO Address 
 		(firebase_uid, recipient_name, phone_number, street_address, city, postal_code, country, is_default_shipping) 
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	result, err := r.db.Exec(query,
+	result, err := r.db.ExecContext(ctx, query,
 		address.FirebaseUID,
 		address.RecipientName,
 		address.PhoneNumber,
@@ -44,9 +46,9 @@ func (r *addressRepository) Create(address *model.Address) (int, error) {
 	return int(id), nil
 }
 
-func (r *addressRepository) GetAllByUser(uid string) ([]model.Address, error) {
+func (r *addressRepository) GetAllByUser(ctx context.Context, uid string) ([]model.Address, error) {
 	query := `SELECT * FROM Address WHERE firebase_uid = ?`
-	rows, err := r.db.Query(query, uid)
+	rows, err := r.db.QueryContext(ctx, query, uid)
 	if err != nil {
 		return nil, err
 	}
@@ -73,41 +75,41 @@ func (r *addressRepository) GetAllByUser(uid string) ([]model.Address, error) {
 	return addresses, nil
 }
 
-func (r *addressRepository) Delete(uid string, addressID int) error {
+func (r *addressRepository) Delete(ctx context.Context, uid string, addressID int) error {
 	query := `DELETE FROM Address WHERE firebase_uid = ? AND address_id = ?`
-	result, err := r.db.Exec(query, uid, addressID)
+	result, err := r.db.ExecContext(ctx, query, uid, addressID)
 	if err != nil {
 		return err
 	}
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return errors.New("address not found or not belongs to user")
+		return apperror.NotFound("address not found or not belongs to user")
 	}
 	return nil
 }
 
 // Clear default
-func (r *addressRepository) ClearDefault(uid string) error {
-	_, err := r.db.Exec(`UPDATE Address SET is_default_shipping = false WHERE firebase_uid = ?`, uid)
+func (r *addressRepository) ClearDefault(ctx context.Context, uid string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE Address SET is_default_shipping = false WHERE firebase_uid = ?`, uid)
 	return err
 }
 
 // Set default
-func (r *addressRepository) SetDefault(uid string, addressID int) error {
+func (r *addressRepository) SetDefault(ctx context.Context, uid string, addressID int) error {
 	query := `UPDATE Address SET is_default_shipping = true WHERE firebase_uid = ? AND address_id = ?`
-	result, err := r.db.Exec(query, uid, addressID)
+	result, err := r.db.ExecContext(ctx, query, uid, addressID)
 	if err != nil {
 		return err
 	}
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return errors.New("address not found or not belongs to user")
+		return apperror.NotFound("address not found or not belongs to user")
 	}
 	return nil
 }
 
-func (r *addressRepository) GetDefault(uid string) (*model.Address, error) {
-	row := r.db.QueryRow(`
+func (r *addressRepository) GetDefault(ctx context.Context, uid string) (*model.Address, error) {
+	row := r.db.QueryRowContext(ctx, `
         SELECT address_id, firebase_uid, recipient_name, phone_number,
        			street_address, city, postal_code, country, is_default_shipping
 		FROM Address
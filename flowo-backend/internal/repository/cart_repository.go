@@ -1,19 +1,21 @@
 package repository
 
 import (
+	"context"
+
 	"database/sql"
-	"errors"
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/model"
 )
 
 type CartRepository interface {
-	GetOrCreateCart(firebaseUID string) (int, error)
-	AddOrUpdateCartItem(cartID int, productID int, quantity int) error
-	UpdateCartItemQuantity(cartID int, productID int, quantity int) error
-	RemoveCartItem(cartID int, productID int) error
-	GetCartItems(cartID int) ([]model.CartItem, error)
-	GetCartIDByUser(firebaseUID string) (int, error)
-	ClearCart(cartID int) error
+	GetOrCreateCart(ctx context.Context, firebaseUID string) (int, error)
+	AddOrUpdateCartItem(ctx context.Context, cartID int, productID int, variantID *int, quantity int) error
+	UpdateCartItemQuantity(ctx context.Context, cartID int, productID int, variantID *int, quantity int) error
+	RemoveCartItem(ctx context.Context, cartID int, productID int, variantID *int) error
+	GetCartItems(ctx context.Context, cartID int) ([]model.CartItem, error)
+	GetCartIDByUser(ctx context.Context, firebaseUID string) (int, error)
+	ClearCart(ctx context.Context, cartID int) error
 }
 
 type cartRepository struct {
@@ -24,11 +26,11 @@ func NewCartRepository(db *sql.DB) CartRepository {
 	return &cartRepository{DB: db}
 }
 
-func (r *cartRepository) GetOrCreateCart(firebaseUID string) (int, error) {
+func (r *cartRepository) GetOrCreateCart(ctx context.Context, firebaseUID string) (int, error) {
 	var cartID int
-	err := r.DB.QueryRow("SELECT cart_id FROM Cart WHERE firebase_uid = ?", firebaseUID).Scan(&cartID)
+	err := r.DB.QueryRowContext(ctx, "SELECT cart_id FROM Cart WHERE firebase_uid = ?", firebaseUID).Scan(&cartID)
 	if err == sql.ErrNoRows {
-		res, err := r.DB.Exec("INSERT INTO Cart (firebase_uid) VALUES (?)", firebaseUID)
+		res, err := r.DB.ExecContext(ctx, "INSERT INTO Cart (firebase_uid) VALUES (?)", firebaseUID)
 		if err != nil {
 			return 0, err
 		}
@@ -38,8 +40,8 @@ func (r *cartRepository) GetOrCreateCart(firebaseUID string) (int, error) {
 	return cartID, err
 }
 
-func (r *cartRepository) AddOrUpdateCartItem(cartID int, productID int, quantity int) error {
-	tx, err := r.DB.Begin()
+func (r *cartRepository) AddOrUpdateCartItem(ctx context.Context, cartID int, productID int, variantID *int, quantity int) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -52,30 +54,26 @@ func (r *cartRepository) AddOrUpdateCartItem(cartID int, productID int, quantity
 	}()
 
 	// 1. Get current stock
-	var currentStock int
-	err = tx.QueryRow(`
-        SELECT stock_quantity 
-        FROM FlowerProduct 
-        WHERE product_id = ? AND is_active = TRUE`, productID).Scan(&currentStock)
+	currentStock, err := getAvailableStock(ctx, tx, productID, variantID)
 	if err != nil {
 		return err
 	}
 
-	// 2. Check if cart already has the product
+	// 2. Check if cart already has the product (same variant)
 	var existingQty int
-	err = tx.QueryRow(`
+	err = tx.QueryRowContext(ctx, `
         SELECT quantity 
         FROM CartItem 
-        WHERE cart_id = ? AND product_id = ?`, cartID, productID).Scan(&existingQty)
+        WHERE cart_id = ? AND product_id = ? AND variant_id <=> ?`, cartID, productID, nullInt(variantID)).Scan(&existingQty)
 
 	if err == sql.ErrNoRows {
 		// If not exists -> check new quantity
 		if quantity > currentStock {
-			return errors.New("not enough stock")
+			return apperror.OutOfStock("not enough stock")
 		}
-		_, err = tx.Exec(`
-            INSERT INTO CartItem (cart_id, product_id, quantity) 
-            VALUES (?, ?, ?)`, cartID, productID, quantity)
+		_, err = tx.ExecContext(ctx, `
+            INSERT INTO CartItem (cart_id, product_id, variant_id, quantity) 
+            VALUES (?, ?, ?, ?)`, cartID, productID, nullInt(variantID), quantity)
 		return err
 
 	} else if err != nil {
@@ -85,19 +83,19 @@ func (r *cartRepository) AddOrUpdateCartItem(cartID int, productID int, quantity
 	// If exists -> check total quantity after update
 	newQty := existingQty + quantity
 	if newQty > currentStock {
-		return errors.New("not enough stock")
+		return apperror.OutOfStock("not enough stock")
 	}
 
-	_, err = tx.Exec(`
+	_, err = tx.ExecContext(ctx, `
         UPDATE CartItem 
         SET quantity = ? 
-        WHERE cart_id = ? AND product_id = ?`,
-		newQty, cartID, productID)
+        WHERE cart_id = ? AND product_id = ? AND variant_id <=> ?`,
+		newQty, cartID, productID, nullInt(variantID))
 	return err
 }
 
-func (r *cartRepository) UpdateCartItemQuantity(cartID int, productID int, newQty int) error {
-	tx, err := r.DB.Begin()
+func (r *cartRepository) UpdateCartItemQuantity(ctx context.Context, cartID int, productID int, variantID *int, newQty int) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -111,11 +109,11 @@ func (r *cartRepository) UpdateCartItemQuantity(cartID int, productID int, newQt
 
 	// 1. Get current quantity of the item in the cart
 	var currentQty int
-	err = tx.QueryRow(`
+	err = tx.QueryRowContext(ctx, `
 		SELECT quantity 
 		FROM CartItem 
-		WHERE cart_id = ? AND product_id = ?`,
-		cartID, productID).Scan(&currentQty)
+		WHERE cart_id = ? AND product_id = ? AND variant_id <=> ?`,
+		cartID, productID, nullInt(variantID)).Scan(&currentQty)
 	if err != nil {
 		return err
 	}
@@ -130,17 +128,14 @@ func (r *cartRepository) UpdateCartItemQuantity(cartID int, productID int, newQt
 	// 3. If increase quantity first we check stock
 	if diff > 0 {
 		var currentStock int
-		err = tx.QueryRow(`
-			SELECT stock_quantity 
-			FROM FlowerProduct 
-			WHERE product_id = ?`, productID).Scan(&currentStock)
+		currentStock, err = getAvailableStock(ctx, tx, productID, variantID)
 		if err != nil {
 			return err
 		}
 		if currentStock < newQty {
-			return errors.New("not enough stock")
+			return apperror.OutOfStock("not enough stock")
 		}
-		// _, err = tx.Exec(`
+		// _, err = tx.ExecContext(ctx, `
 		// 	UPDATE FlowerProduct
 		// 	SET stock_quantity = stock_quantity - ?
 		// 	WHERE product_id = ?`, diff, productID)
@@ -149,7 +144,7 @@ func (r *cartRepository) UpdateCartItemQuantity(cartID int, productID int, newQt
 		// }
 	} //else {
 	// 	// 4. If decrease quantity, we just update stock
-	// 	_, err = tx.Exec(`
+	// 	_, err = tx.ExecContext(ctx, `
 	// 		UPDATE FlowerProduct
 	// 		SET stock_quantity = stock_quantity + ?
 	// 		WHERE product_id = ?`, -diff, productID)
@@ -159,7 +154,7 @@ func (r *cartRepository) UpdateCartItemQuantity(cartID int, productID int, newQt
 	// }
 
 	// // 5. update status in Flower Product if stock is low
-	// _, err = tx.Exec(`
+	// _, err = tx.ExecContext(ctx, `
 	// UPDATE FlowerProduct
 	// SET status = CASE
 	// 	WHEN stock_quantity < 5 THEN 'LowStock'
@@ -171,16 +166,16 @@ func (r *cartRepository) UpdateCartItemQuantity(cartID int, productID int, newQt
 	// }
 
 	// 6. Update quantity in CartItem
-	_, err = tx.Exec(`
+	_, err = tx.ExecContext(ctx, `
 		UPDATE CartItem 
 		SET quantity = ? 
-		WHERE cart_id = ? AND product_id = ?`,
-		newQty, cartID, productID)
+		WHERE cart_id = ? AND product_id = ? AND variant_id <=> ?`,
+		newQty, cartID, productID, nullInt(variantID))
 	return err
 }
 
-func (r *cartRepository) RemoveCartItem(cartID, productID int) error {
-	tx, err := r.DB.Begin()
+func (r *cartRepository) RemoveCartItem(ctx context.Context, cartID, productID int, variantID *int) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -194,29 +189,29 @@ func (r *cartRepository) RemoveCartItem(cartID, productID int) error {
 
 	// 1. Get currrent quantity of the item in the cart
 	var qty int
-	err = tx.QueryRow(`
+	err = tx.QueryRowContext(ctx, `
 		SELECT quantity FROM CartItem 
-		WHERE cart_id = ? AND product_id = ?`, cartID, productID).Scan(&qty)
+		WHERE cart_id = ? AND product_id = ? AND variant_id <=> ?`, cartID, productID, nullInt(variantID)).Scan(&qty)
 	if err != nil {
 		return err
 	}
 
 	// 2. delete cart item
-	_, err = tx.Exec(`
+	_, err = tx.ExecContext(ctx, `
 		DELETE FROM CartItem 
-		WHERE cart_id = ? AND product_id = ?`, cartID, productID)
+		WHERE cart_id = ? AND product_id = ? AND variant_id <=> ?`, cartID, productID, nullInt(variantID))
 	if err != nil {
 		return err
 	}
 
 	// // 3. increase stock quantity in Flower Product
-	// _, err = tx.Exec(`
+	// _, err = tx.ExecContext(ctx, `
 	// 	UPDATE FlowerProduct
 	// 	SET stock_quantity = stock_quantity + ?
 	// 	WHERE product_id = ?`, qty, productID)
 
 	// //4. Update status
-	// _, err = tx.Exec(`
+	// _, err = tx.ExecContext(ctx, `
 	// 	UPDATE FlowerProduct
 	// 	SET status = 'NewFlower'
 	// 	WHERE product_id = ? AND stock_quantity > 5`, productID)
@@ -227,9 +222,9 @@ func (r *cartRepository) RemoveCartItem(cartID, productID int) error {
 	return err
 }
 
-func (r *cartRepository) GetCartItems(cartID int) ([]model.CartItem, error) {
-	rows, err := r.DB.Query(`
-        SELECT cart_item_id, product_id, quantity, added_at 
+func (r *cartRepository) GetCartItems(ctx context.Context, cartID int) ([]model.CartItem, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+        SELECT cart_item_id, product_id, variant_id, quantity, added_at 
         FROM CartItem 
         WHERE cart_id = ?`, cartID)
 	if err != nil {
@@ -240,18 +235,23 @@ func (r *cartRepository) GetCartItems(cartID int) ([]model.CartItem, error) {
 	var items []model.CartItem
 	for rows.Next() {
 		var item model.CartItem
+		var variantID sql.NullInt64
 		item.CartID = cartID
-		if err := rows.Scan(&item.CartItemID, &item.ProductID, &item.Quantity, &item.AddedAt); err != nil {
+		if err := rows.Scan(&item.CartItemID, &item.ProductID, &variantID, &item.Quantity, &item.AddedAt); err != nil {
 			return nil, err
 		}
+		if variantID.Valid {
+			v := int(variantID.Int64)
+			item.VariantID = &v
+		}
 		items = append(items, item)
 	}
 	return items, nil
 }
 
-func (r *cartRepository) GetCartIDByUser(firebaseUID string) (int, error) {
+func (r *cartRepository) GetCartIDByUser(ctx context.Context, firebaseUID string) (int, error) {
 	var cartID int
-	err := r.DB.QueryRow("SELECT cart_id FROM Cart WHERE firebase_uid = ?", firebaseUID).Scan(&cartID)
+	err := r.DB.QueryRowContext(ctx, "SELECT cart_id FROM Cart WHERE firebase_uid = ?", firebaseUID).Scan(&cartID)
 	if err == sql.ErrNoRows {
 		return 0, nil
 	}
@@ -261,7 +261,27 @@ func (r *cartRepository) GetCartIDByUser(firebaseUID string) (int, error) {
 	return cartID, nil
 }
 
-func (r *cartRepository) ClearCart(cartID int) error {
-	_, err := r.DB.Exec("DELETE FROM CartItem WHERE cart_id = ?", cartID)
+func (r *cartRepository) ClearCart(ctx context.Context, cartID int) error {
+	_, err := r.DB.ExecContext(ctx, "DELETE FROM CartItem WHERE cart_id = ?", cartID)
 	return err
 }
+
+// getAvailableStock returns the stock of the chosen variant, or of the product
+// itself when no variant is given.
+func getAvailableStock(ctx context.Context, tx *sql.Tx, productID int, variantID *int) (int, error) {
+	var stock int
+	if variantID != nil {
+		err := tx.QueryRowContext(ctx, `
+			SELECT pv.stock_quantity
+			FROM ProductVariant pv
+			JOIN FlowerProduct fp ON pv.product_id = fp.product_id
+			WHERE pv.variant_id = ? AND pv.product_id = ? AND pv.is_active = TRUE AND fp.is_active = TRUE`,
+			*variantID, productID).Scan(&stock)
+		return stock, err
+	}
+	err := tx.QueryRowContext(ctx, `
+		SELECT stock_quantity 
+		FROM FlowerProduct 
+		WHERE product_id = ? AND is_active = TRUE`, productID).Scan(&stock)
+	return stock, err
+}
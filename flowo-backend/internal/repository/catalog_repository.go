@@ -0,0 +1,544 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/model"
+)
+
+type CatalogRepository interface {
+	// product images
+	AddProductImage(ctx context.Context, image *model.ProductImage) error
+	GetProductImageByID(ctx context.Context, imageID uint) (*model.ProductImage, error)
+	SetPrimaryImage(ctx context.Context, productID, imageID uint) error
+	DeleteProductImage(ctx context.Context, productID, imageID uint) error
+
+	// product occasions
+	SetProductOccasions(ctx context.Context, productID uint, occasionIDs []int) error
+
+	// flower types
+	CreateFlowerType(ctx context.Context, flowerType *model.FlowerType) error
+	UpdateFlowerType(ctx context.Context, flowerType *model.FlowerType) error
+	DeleteFlowerType(ctx context.Context, flowerTypeID uint) error
+
+	// occasions
+	CreateOccasion(ctx context.Context, occasion *model.Occasion) error
+	UpdateOccasion(ctx context.Context, occasion *model.Occasion) error
+	DeleteOccasion(ctx context.Context, occasionID uint) error
+
+	// bulk import
+	ImportProducts(ctx context.Context, rows []dto.ProductImportRow, actor string) (created, updated int, err error)
+}
+
+type catalogRepository struct {
+	DB *sql.DB
+}
+
+func NewCatalogRepository(db *sql.DB) CatalogRepository {
+	return &catalogRepository{DB: db}
+}
+
+// AddProductImage inserts the image; a primary image, or the first image of
+// a product, replaces the current primary one
+func (r *catalogRepository) AddProductImage(ctx context.Context, image *model.ProductImage) (err error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var hasPrimary bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM ProductImage WHERE product_id = ? AND is_primary = TRUE)", image.ProductID).Scan(&hasPrimary)
+	if err != nil {
+		return err
+	}
+	if !hasPrimary {
+		image.IsPrimary = true
+	}
+	if image.IsPrimary && hasPrimary {
+		if _, err = tx.ExecContext(ctx, "UPDATE ProductImage SET is_primary = FALSE WHERE product_id = ?", image.ProductID); err != nil {
+			return err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO ProductImage (product_id, image_url, thumbnail_url, alt_text, is_primary, storage_key, thumbnail_key)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		image.ProductID, image.ImageURL, emptyToNull(image.ThumbnailURL), image.AltText, image.IsPrimary,
+		emptyToNull(image.StorageKey), emptyToNull(image.ThumbnailKey))
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	image.ImageID = uint(id)
+	return nil
+}
+
+func (r *catalogRepository) GetProductImageByID(ctx context.Context, imageID uint) (*model.ProductImage, error) {
+	var image model.ProductImage
+	var isPrimary sql.NullBool
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT image_id, product_id, image_url, COALESCE(thumbnail_url, ''), COALESCE(alt_text, ''), is_primary,
+			COALESCE(storage_key, ''), COALESCE(thumbnail_key, '')
+		FROM ProductImage WHERE image_id = ?`, imageID).
+		Scan(&image.ImageID, &image.ProductID, &image.ImageURL, &image.ThumbnailURL, &image.AltText, &isPrimary,
+			&image.StorageKey, &image.ThumbnailKey)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("image not found")
+		}
+		return nil, err
+	}
+	image.IsPrimary = isPrimary.Bool
+	return &image, nil
+}
+
+// SetPrimaryImage makes imageID the only primary image of the product
+func (r *catalogRepository) SetPrimaryImage(ctx context.Context, productID, imageID uint) (err error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM ProductImage WHERE image_id = ? AND product_id = ?)", imageID, productID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return apperror.NotFound("image not found")
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE ProductImage SET is_primary = (image_id = ?) WHERE product_id = ?", imageID, productID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteProductImage removes the image and promotes the oldest remaining one
+// when the primary image was removed
+func (r *catalogRepository) DeleteProductImage(ctx context.Context, productID, imageID uint) (err error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var isPrimary sql.NullBool
+	err = tx.QueryRowContext(ctx, "SELECT is_primary FROM ProductImage WHERE image_id = ? AND product_id = ? FOR UPDATE", imageID, productID).Scan(&isPrimary)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = apperror.NotFound("image not found")
+		}
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM ProductImage WHERE image_id = ?", imageID); err != nil {
+		return err
+	}
+
+	if isPrimary.Bool {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE ProductImage SET is_primary = TRUE
+			WHERE product_id = ? ORDER BY image_id ASC LIMIT 1`, productID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetProductOccasions replaces the occasions linked to a product
+func (r *catalogRepository) SetProductOccasions(ctx context.Context, productID uint, occasionIDs []int) (err error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM ProductOccasion WHERE product_id = ?", productID); err != nil {
+		return err
+	}
+
+	seen := make(map[int]bool, len(occasionIDs))
+	for _, occasionID := range occasionIDs {
+		if seen[occasionID] {
+			continue
+		}
+		seen[occasionID] = true
+
+		var exists bool
+		if err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM Occasion WHERE occasion_id = ?)", occasionID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return apperror.Validation("occasion not found", apperror.Field("occasion_ids", "contains an unknown occasion"))
+		}
+		if _, err = tx.ExecContext(ctx, "INSERT INTO ProductOccasion (product_id, occasion_id) VALUES (?, ?)", productID, occasionID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *catalogRepository) CreateFlowerType(ctx context.Context, flowerType *model.FlowerType) error {
+	taken, err := r.nameTaken(ctx, "FlowerType", "flower_type_id", flowerType.Name, 0)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return apperror.Conflict("flower type already exists")
+	}
+
+	res, err := r.DB.ExecContext(ctx, "INSERT INTO FlowerType (name, description) VALUES (?, ?)", flowerType.Name, emptyToNull(flowerType.Description))
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	flowerType.FlowerTypeID = uint(id)
+	return nil
+}
+
+func (r *catalogRepository) UpdateFlowerType(ctx context.Context, flowerType *model.FlowerType) error {
+	taken, err := r.nameTaken(ctx, "FlowerType", "flower_type_id", flowerType.Name, flowerType.FlowerTypeID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return apperror.Conflict("flower type already exists")
+	}
+
+	res, err := r.DB.ExecContext(ctx, "UPDATE FlowerType SET name = ?, description = ? WHERE flower_type_id = ?",
+		flowerType.Name, emptyToNull(flowerType.Description), flowerType.FlowerTypeID)
+	if err != nil {
+		return err
+	}
+	return r.requireRow(ctx, res, "FlowerType", "flower_type_id", flowerType.FlowerTypeID, "flower type not found")
+}
+
+// DeleteFlowerType refuses to delete a flower type that products or pricing
+// rules still refer to
+func (r *catalogRepository) DeleteFlowerType(ctx context.Context, flowerTypeID uint) error {
+	var inUse bool
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM FlowerProduct WHERE flower_type_id = ?)
+			OR EXISTS(SELECT 1 FROM PricingRule WHERE applicable_flower_type_id = ?)`,
+		flowerTypeID, flowerTypeID).Scan(&inUse)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return apperror.Conflict("flower type in use")
+	}
+
+	res, err := r.DB.ExecContext(ctx, "DELETE FROM FlowerType WHERE flower_type_id = ?", flowerTypeID)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return apperror.NotFound("flower type not found")
+	}
+	return nil
+}
+
+func (r *catalogRepository) CreateOccasion(ctx context.Context, occasion *model.Occasion) error {
+	taken, err := r.nameTaken(ctx, "Occasion", "occasion_id", occasion.Name, 0)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return apperror.Conflict("occasion already exists")
+	}
+
+	res, err := r.DB.ExecContext(ctx, "INSERT INTO Occasion (name) VALUES (?)", occasion.Name)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	occasion.OccasionID = uint(id)
+	return nil
+}
+
+func (r *catalogRepository) UpdateOccasion(ctx context.Context, occasion *model.Occasion) error {
+	taken, err := r.nameTaken(ctx, "Occasion", "occasion_id", occasion.Name, occasion.OccasionID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return apperror.Conflict("occasion already exists")
+	}
+
+	res, err := r.DB.ExecContext(ctx, "UPDATE Occasion SET name = ? WHERE occasion_id = ?", occasion.Name, occasion.OccasionID)
+	if err != nil {
+		return err
+	}
+	return r.requireRow(ctx, res, "Occasion", "occasion_id", occasion.OccasionID, "occasion not found")
+}
+
+// DeleteOccasion removes the occasion together with its product links
+func (r *catalogRepository) DeleteOccasion(ctx context.Context, occasionID uint) (err error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM ProductOccasion WHERE occasion_id = ?", occasionID); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM Occasion WHERE occasion_id = ?", occasionID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		err = apperror.NotFound("occasion not found")
+		return err
+	}
+	return nil
+}
+
+// ImportProducts applies already validated import rows in one transaction, so
+// a failing row leaves the catalog untouched. Occasions of a row replace the
+// product's occasions; image URLs are added when missing and the first one
+// becomes primary, existing (uploaded) images are kept.
+func (r *catalogRepository) ImportProducts(ctx context.Context, rows []dto.ProductImportRow, actor string) (created, updated int, err error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	flowerTypes, err := loadNameIDs(ctx, tx, "SELECT name, flower_type_id FROM FlowerType")
+	if err != nil {
+		return 0, 0, err
+	}
+	occasions, err := loadNameIDs(ctx, tx, "SELECT name, occasion_id FROM Occasion")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for i, row := range rows {
+		flowerTypeID, ok := flowerTypes[row.FlowerType]
+		if !ok {
+			err = apperror.Validation(fmt.Sprintf("row %d: flower type not found", i+1))
+			return 0, 0, err
+		}
+
+		productID := int(row.ProductID)
+		if productID == 0 {
+			var res sql.Result
+			res, err = tx.ExecContext(ctx, `
+				INSERT INTO FlowerProduct (name, description, flower_type_id, base_price, status, stock_quantity, created_at, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?, NOW(), NOW())`,
+				row.Name, row.Description, flowerTypeID, row.BasePrice, row.Status, row.StockQuantity)
+			if err != nil {
+				return 0, 0, err
+			}
+			var id int64
+			if id, err = res.LastInsertId(); err != nil {
+				return 0, 0, err
+			}
+			productID = int(id)
+
+			if row.StockQuantity > 0 {
+				_, err = recordMovement(ctx, tx, model.InventoryMovement{
+					ProductID:      productID,
+					MovementType:   model.MovementReceipt,
+					QuantityChange: row.StockQuantity,
+					Actor:          actor,
+					Reason:         "catalog import",
+				})
+				if err != nil {
+					return 0, 0, err
+				}
+			}
+			created++
+		} else {
+			var currentStock int
+			currentStock, err = lockStock(ctx, tx, productID, nil)
+			if err != nil {
+				if apperror.Is(err, apperror.CodeNotFound) {
+					err = apperror.Validation(fmt.Sprintf("row %d: product not found", i+1))
+				}
+				return 0, 0, err
+			}
+
+			_, err = tx.ExecContext(ctx, `
+				UPDATE FlowerProduct SET name = ?, description = ?, flower_type_id = ?, base_price = ?, status = ?,
+					stock_quantity = ?, updated_at = NOW()
+				WHERE product_id = ?`,
+				row.Name, row.Description, flowerTypeID, row.BasePrice, row.Status, row.StockQuantity, productID)
+			if err != nil {
+				return 0, 0, err
+			}
+
+			if change := row.StockQuantity - currentStock; change != 0 {
+				_, err = recordMovement(ctx, tx, model.InventoryMovement{
+					ProductID:      productID,
+					MovementType:   model.MovementManualAdjustment,
+					QuantityChange: change,
+					Actor:          actor,
+					Reason:         "catalog import",
+				})
+				if err != nil {
+					return 0, 0, err
+				}
+			}
+			updated++
+		}
+
+		if _, err = tx.ExecContext(ctx, "DELETE FROM ProductOccasion WHERE product_id = ?", productID); err != nil {
+			return 0, 0, err
+		}
+		for _, name := range row.Occasions {
+			occasionID, ok := occasions[name]
+			if !ok {
+				err = apperror.Validation(fmt.Sprintf("row %d: occasion %q not found", i+1, name))
+				return 0, 0, err
+			}
+			if _, err = tx.ExecContext(ctx, "INSERT INTO ProductOccasion (product_id, occasion_id) VALUES (?, ?)", productID, occasionID); err != nil {
+				return 0, 0, err
+			}
+		}
+
+		if err = importImages(ctx, tx, productID, row.Images); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return created, updated, nil
+}
+
+// importImages adds the image URLs a product does not have yet and makes the
+// first one primary
+func importImages(ctx context.Context, tx *sql.Tx, productID int, urls []string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	for _, url := range urls {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM ProductImage WHERE product_id = ? AND image_url = ?)", productID, url).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO ProductImage (product_id, image_url, is_primary) VALUES (?, ?, FALSE)", productID, url); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.ExecContext(ctx, "UPDATE ProductImage SET is_primary = (image_url = ?) WHERE product_id = ?", urls[0], productID)
+	return err
+}
+
+func loadNameIDs(ctx context.Context, tx *sql.Tx, query string) (map[string]int, error) {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int)
+	for rows.Next() {
+		var name string
+		var id int
+		if err := rows.Scan(&name, &id); err != nil {
+			return nil, err
+		}
+		ids[name] = id
+	}
+	return ids, rows.Err()
+}
+
+// nameTaken reports whether a row of table other than exceptID already uses name
+func (r *catalogRepository) nameTaken(ctx context.Context, table, idColumn, name string, exceptID uint) (bool, error) {
+	var taken bool
+	query := "SELECT EXISTS(SELECT 1 FROM " + table + " WHERE name = ? AND " + idColumn + " <> ?)"
+	err := r.DB.QueryRowContext(ctx, query, name, exceptID).Scan(&taken)
+	return taken, err
+}
+
+// requireRow tells a missing row apart from an update that changed nothing,
+// since MySQL reports zero affected rows for both
+func (r *catalogRepository) requireRow(ctx context.Context, res sql.Result, table, idColumn string, id uint, notFound string) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+	var exists bool
+	if err := r.DB.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM "+table+" WHERE "+idColumn+" = ?)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return apperror.NotFound(notFound)
+	}
+	return nil
+}
+
+func emptyToNull(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
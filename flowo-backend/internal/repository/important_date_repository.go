@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/model"
+)
+
+type ImportantDateRepository interface {
+	Create(ctx context.Context, d *model.ImportantDate) error
+	Update(ctx context.Context, d *model.ImportantDate) error
+	Delete(ctx context.Context, firebaseUID string, dateID int) error
+	GetByID(ctx context.Context, firebaseUID string, dateID int) (*model.ImportantDate, error)
+	GetByUser(ctx context.Context, firebaseUID string) ([]model.ImportantDate, error)
+	GetOccasionName(ctx context.Context, occasionID int) (string, error)
+	AddressBelongsToUser(ctx context.Context, firebaseUID string, addressID int) (bool, error)
+	GetReminderCandidates(ctx context.Context, today time.Time) ([]model.ImportantDate, error)
+	MarkReminded(ctx context.Context, dateID int, occurrence time.Time) error
+}
+
+type importantDateRepository struct {
+	DB *sql.DB
+}
+
+func NewImportantDateRepository(db *sql.DB) ImportantDateRepository {
+	return &importantDateRepository{DB: db}
+}
+
+const importantDateColumns = `
+	d.date_id, d.firebase_uid, d.recipient_name, d.occasion_id, o.name,
+	d.address_id, d.event_date, d.recurring, d.remind_days_before,
+	COALESCE(d.note, ''), d.last_reminded_for, d.created_at, d.updated_at`
+
+func scanImportantDate(row rowScanner) (*model.ImportantDate, error) {
+	var (
+		d            model.ImportantDate
+		addressID    sql.NullInt64
+		lastReminded sql.NullTime
+	)
+	if err := row.Scan(&d.DateID, &d.FirebaseUID, &d.RecipientName, &d.OccasionID, &d.OccasionName,
+		&addressID, &d.EventDate, &d.Recurring, &d.RemindDaysBefore,
+		&d.Note, &lastReminded, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if addressID.Valid {
+		id := int(addressID.Int64)
+		d.AddressID = &id
+	}
+	if lastReminded.Valid {
+		d.LastRemindedFor = &lastReminded.Time
+	}
+	return &d, nil
+}
+
+func (r *importantDateRepository) queryDates(ctx context.Context, query string, args ...interface{}) ([]model.ImportantDate, error) {
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dates []model.ImportantDate
+	for rows.Next() {
+		d, err := scanImportantDate(rows)
+		if err != nil {
+			return nil, err
+		}
+		dates = append(dates, *d)
+	}
+	return dates, rows.Err()
+}
+
+func (r *importantDateRepository) Create(ctx context.Context, d *model.ImportantDate) error {
+	res, err := r.DB.ExecContext(ctx, `
+		INSERT INTO ImportantDate
+			(firebase_uid, recipient_name, occasion_id, address_id, event_date, recurring, remind_days_before, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		d.FirebaseUID, d.RecipientName, d.OccasionID, nullInt(d.AddressID),
+		d.EventDate.Format("2006-01-02"), d.Recurring, d.RemindDaysBefore, emptyToNull(d.Note))
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	d.DateID = int(id)
+	return nil
+}
+
+// Update saves the editable fields. Moving the date or the reminder window
+// forgets the last reminder so the new occurrence is reminded again.
+func (r *importantDateRepository) Update(ctx context.Context, d *model.ImportantDate) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE ImportantDate
+		SET recipient_name = ?, occasion_id = ?, address_id = ?, recurring = ?, note = ?,
+			last_reminded_for = IF(event_date = ? AND remind_days_before = ?, last_reminded_for, NULL),
+			event_date = ?, remind_days_before = ?
+		WHERE date_id = ? AND firebase_uid = ?`,
+		d.RecipientName, d.OccasionID, nullInt(d.AddressID), d.Recurring, emptyToNull(d.Note),
+		d.EventDate.Format("2006-01-02"), d.RemindDaysBefore,
+		d.EventDate.Format("2006-01-02"), d.RemindDaysBefore,
+		d.DateID, d.FirebaseUID)
+	return err
+}
+
+func (r *importantDateRepository) Delete(ctx context.Context, firebaseUID string, dateID int) error {
+	res, err := r.DB.ExecContext(ctx, `DELETE FROM ImportantDate WHERE date_id = ? AND firebase_uid = ?`, dateID, firebaseUID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apperror.NotFound("important date not found")
+	}
+	return nil
+}
+
+func (r *importantDateRepository) GetByID(ctx context.Context, firebaseUID string, dateID int) (*model.ImportantDate, error) {
+	row := r.DB.QueryRowContext(ctx, `
+		SELECT `+importantDateColumns+`
+		FROM ImportantDate d
+		JOIN Occasion o ON o.occasion_id = d.occasion_id
+		WHERE d.date_id = ? AND d.firebase_uid = ?`, dateID, firebaseUID)
+	d, err := scanImportantDate(row)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("important date not found")
+	}
+	return d, err
+}
+
+func (r *importantDateRepository) GetByUser(ctx context.Context, firebaseUID string) ([]model.ImportantDate, error) {
+	return r.queryDates(ctx, `
+		SELECT `+importantDateColumns+`
+		FROM ImportantDate d
+		JOIN Occasion o ON o.occasion_id = d.occasion_id
+		WHERE d.firebase_uid = ?
+		ORDER BY MONTH(d.event_date), DAY(d.event_date), d.date_id`, firebaseUID)
+}
+
+func (r *importantDateRepository) GetOccasionName(ctx context.Context, occasionID int) (string, error) {
+	var name string
+	err := r.DB.QueryRowContext(ctx, `SELECT name FROM Occasion WHERE occasion_id = ?`, occasionID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", apperror.NotFound("occasion not found")
+	}
+	return name, err
+}
+
+func (r *importantDateRepository) AddressBelongsToUser(ctx context.Context, firebaseUID string, addressID int) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM Address WHERE address_id = ? AND firebase_uid = ?)`,
+		addressID, firebaseUID).Scan(&exists)
+	return exists, err
+}
+
+// GetReminderCandidates returns every date that can still come up: all
+// recurring dates and one-off dates that have not passed yet. Whether a
+// reminder is due is decided by the caller.
+func (r *importantDateRepository) GetReminderCandidates(ctx context.Context, today time.Time) ([]model.ImportantDate, error) {
+	return r.queryDates(ctx, `
+		SELECT `+importantDateColumns+`
+		FROM ImportantDate d
+		JOIN Occasion o ON o.occasion_id = d.occasion_id
+		WHERE d.recurring = TRUE OR d.event_date >= ?`, today.Format("2006-01-02"))
+}
+
+func (r *importantDateRepository) MarkReminded(ctx context.Context, dateID int, occurrence time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `UPDATE ImportantDate SET last_reminded_for = ? WHERE date_id = ?`,
+		occurrence.Format("2006-01-02"), dateID)
+	return err
+}
@@ -0,0 +1,609 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/model"
+)
+
+type InventoryRepository interface {
+	CreateBatch(ctx context.Context, batch *model.InventoryBatch, actor string) error
+	GetBatchesByProduct(ctx context.Context, productID int) ([]model.InventoryBatch, error)
+	GetExpiredBatches(ctx context.Context, now time.Time) ([]model.InventoryBatch, error)
+	WriteOffBatch(ctx context.Context, batch model.InventoryBatch, actor, reason string) (int, error)
+	GetWastageByProduct(ctx context.Context, productID int) ([]model.Wastage, error)
+	GetProductFreshness(ctx context.Context) ([]model.ProductFreshness, error)
+	UpdateProductStatus(ctx context.Context, productID int, status string) error
+
+	// ledger
+	AdjustStock(ctx context.Context, productID int, variantID *int, change int, actor, reason string) (*model.InventoryMovement, error)
+	GetMovements(ctx context.Context, productID int, variantID *int, limit, offset int) ([]model.InventoryMovement, error)
+	GetLedgerStock(ctx context.Context, productID int, variantID *int) (int, error)
+	GetCurrentStock(ctx context.Context, productID int, variantID *int) (int, error)
+	SetLowStockThreshold(ctx context.Context, productID int, threshold int) error
+}
+
+type inventoryRepository struct {
+	DB *sql.DB
+}
+
+func NewInventoryRepository(db *sql.DB) InventoryRepository {
+	return &inventoryRepository{DB: db}
+}
+
+const batchColumns = `batch_id, product_id, variant_id, quantity_received, quantity_remaining,
+		received_date, shelf_life_days, expires_at, created_at`
+
+// CreateBatch records a received batch and adds its quantity to the product (or variant) stock
+func (r *inventoryRepository) CreateBatch(ctx context.Context, batch *model.InventoryBatch, actor string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			tx.Commit()
+		}
+	}()
+
+	batch.ExpiresAt = batch.ReceivedDate.AddDate(0, 0, batch.ShelfLifeDays)
+	batch.QuantityRemaining = batch.QuantityReceived
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO InventoryBatch
+		(product_id, variant_id, quantity_received, quantity_remaining, received_date, shelf_life_days, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		batch.ProductID, nullInt(batch.VariantID), batch.QuantityReceived, batch.QuantityRemaining,
+		batch.ReceivedDate, batch.ShelfLifeDays, batch.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	batch.BatchID = int(id)
+
+	if err = adjustStock(ctx, tx, batch.ProductID, batch.VariantID, batch.QuantityReceived); err != nil {
+		return err
+	}
+
+	_, err = recordMovement(ctx, tx, model.InventoryMovement{
+		ProductID:      batch.ProductID,
+		VariantID:      batch.VariantID,
+		MovementType:   model.MovementReceipt,
+		QuantityChange: batch.QuantityReceived,
+		Actor:          actor,
+		Reason:         fmt.Sprintf("batch #%d received", batch.BatchID),
+		ReferenceID:    &batch.BatchID,
+	})
+	return err
+}
+
+func (r *inventoryRepository) GetBatchesByProduct(ctx context.Context, productID int) ([]model.InventoryBatch, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+batchColumns+" FROM InventoryBatch WHERE product_id = ? ORDER BY received_date ASC, batch_id ASC", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanBatches(rows)
+}
+
+func (r *inventoryRepository) GetExpiredBatches(ctx context.Context, now time.Time) ([]model.InventoryBatch, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+batchColumns+" FROM InventoryBatch WHERE quantity_remaining > 0 AND expires_at <= ?", now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanBatches(rows)
+}
+
+// WriteOffBatch empties a batch, removes its remaining quantity from stock and
+// records the wastage. It returns the units written off.
+func (r *inventoryRepository) WriteOffBatch(ctx context.Context, batch model.InventoryBatch, actor, reason string) (int, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			tx.Commit()
+		}
+	}()
+
+	// Lock the stock before the batch, in the same order as sales do
+	stock, err := lockStock(ctx, tx, batch.ProductID, batch.VariantID)
+	if err != nil {
+		return 0, err
+	}
+	// Re-read under lock, a concurrent sale may have consumed part of the batch
+	var remaining int
+	err = tx.QueryRowContext(ctx, "SELECT quantity_remaining FROM InventoryBatch WHERE batch_id = ? FOR UPDATE", batch.BatchID).Scan(&remaining)
+	if err != nil {
+		return 0, err
+	}
+	if remaining == 0 {
+		return 0, nil
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE InventoryBatch SET quantity_remaining = 0 WHERE batch_id = ?", batch.BatchID); err != nil {
+		return 0, err
+	}
+
+	// batches are kept within stock, but ones from before that may exceed it
+	wasted := min(remaining, stock)
+	if wasted == 0 {
+		return 0, nil
+	}
+	if err = writeOff(ctx, tx, batch, wasted, actor, reason); err != nil {
+		return 0, err
+	}
+	return wasted, nil
+}
+
+// writeOff records quantity units of a batch as wasted and removes them from
+// stock. The batch itself must already have been reduced.
+func writeOff(ctx context.Context, tx *sql.Tx, batch model.InventoryBatch, quantity int, actor, reason string) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO Wastage (batch_id, product_id, variant_id, quantity, reason)
+		VALUES (?, ?, ?, ?, ?)`,
+		batch.BatchID, batch.ProductID, nullInt(batch.VariantID), quantity, reason); err != nil {
+		return err
+	}
+
+	if err := adjustStock(ctx, tx, batch.ProductID, batch.VariantID, -quantity); err != nil {
+		return err
+	}
+
+	_, err := recordMovement(ctx, tx, model.InventoryMovement{
+		ProductID:      batch.ProductID,
+		VariantID:      batch.VariantID,
+		MovementType:   model.MovementWastage,
+		QuantityChange: -quantity,
+		Actor:          actor,
+		Reason:         fmt.Sprintf("batch #%d %s", batch.BatchID, reason),
+		ReferenceID:    &batch.BatchID,
+	})
+	return err
+}
+
+func (r *inventoryRepository) GetWastageByProduct(ctx context.Context, productID int) ([]model.Wastage, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT wastage_id, batch_id, product_id, variant_id, quantity, reason, recorded_at
+		FROM Wastage WHERE product_id = ? ORDER BY recorded_at DESC`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wastage []model.Wastage
+	for rows.Next() {
+		var w model.Wastage
+		var variantID sql.NullInt64
+		if err := rows.Scan(&w.WastageID, &w.BatchID, &w.ProductID, &variantID, &w.Quantity, &w.Reason, &w.RecordedAt); err != nil {
+			return nil, err
+		}
+		if variantID.Valid {
+			v := int(variantID.Int64)
+			w.VariantID = &v
+		}
+		wastage = append(wastage, w)
+	}
+	return wastage, rows.Err()
+}
+
+// GetProductFreshness returns, for every active product, its status, total stock
+// (product plus active variants) and the expiry of its oldest non-empty batch
+func (r *inventoryRepository) GetProductFreshness(ctx context.Context) ([]model.ProductFreshness, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT fp.product_id, fp.status, fp.low_stock_threshold,
+			fp.stock_quantity + COALESCE((
+				SELECT SUM(pv.stock_quantity) FROM ProductVariant pv
+				WHERE pv.product_id = fp.product_id AND pv.is_active = TRUE), 0) AS total_stock,
+			(SELECT MIN(b.expires_at) FROM InventoryBatch b
+				WHERE b.product_id = fp.product_id AND b.quantity_remaining > 0) AS oldest_expiry
+		FROM FlowerProduct fp
+		WHERE fp.is_active = TRUE`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.ProductFreshness
+	for rows.Next() {
+		var f model.ProductFreshness
+		var oldestExpiry sql.NullTime
+		if err := rows.Scan(&f.ProductID, &f.Status, &f.LowStockThreshold, &f.TotalStock, &oldestExpiry); err != nil {
+			return nil, err
+		}
+		if oldestExpiry.Valid {
+			f.OldestExpiry = &oldestExpiry.Time
+		}
+		result = append(result, f)
+	}
+	return result, rows.Err()
+}
+
+func (r *inventoryRepository) UpdateProductStatus(ctx context.Context, productID int, status string) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE FlowerProduct SET status = ? WHERE product_id = ?", status, productID)
+	return err
+}
+
+// AdjustStock applies a manual stock correction and records it in the ledger
+func (r *inventoryRepository) AdjustStock(ctx context.Context, productID int, variantID *int, change int, actor, reason string) (*model.InventoryMovement, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			tx.Commit()
+		}
+	}()
+
+	current, err := lockStock(ctx, tx, productID, variantID)
+	if err != nil {
+		return nil, err
+	}
+	if current+change < 0 {
+		err = apperror.OutOfStock("stock cannot go below zero")
+		return nil, err
+	}
+
+	if err = adjustStock(ctx, tx, productID, variantID, change); err != nil {
+		return nil, err
+	}
+
+	movement, err := recordMovement(ctx, tx, model.InventoryMovement{
+		ProductID:      productID,
+		VariantID:      variantID,
+		MovementType:   model.MovementManualAdjustment,
+		QuantityChange: change,
+		Actor:          actor,
+		Reason:         reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// GetMovements lists the ledger of a variant, or without one, of the
+// product-level stock, matching what GetLedgerStock sums
+func (r *inventoryRepository) GetMovements(ctx context.Context, productID int, variantID *int, limit, offset int) ([]model.InventoryMovement, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT movement_id, product_id, variant_id, movement_type, quantity_change, stock_after,
+			actor, COALESCE(reason, ''), reference_id, created_at
+		FROM InventoryMovement
+		WHERE product_id = ? AND variant_id <=> ?
+		ORDER BY created_at DESC, movement_id DESC LIMIT ? OFFSET ?`,
+		productID, nullInt(variantID), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movements []model.InventoryMovement
+	for rows.Next() {
+		var m model.InventoryMovement
+		var variant, reference sql.NullInt64
+		if err := rows.Scan(&m.MovementID, &m.ProductID, &variant, &m.MovementType, &m.QuantityChange,
+			&m.StockAfter, &m.Actor, &m.Reason, &reference, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		if variant.Valid {
+			v := int(variant.Int64)
+			m.VariantID = &v
+		}
+		if reference.Valid {
+			v := int(reference.Int64)
+			m.ReferenceID = &v
+		}
+		movements = append(movements, m)
+	}
+	return movements, rows.Err()
+}
+
+// GetLedgerStock derives the stock of a product (or variant) by summing its ledger
+func (r *inventoryRepository) GetLedgerStock(ctx context.Context, productID int, variantID *int) (int, error) {
+	var stock int
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(quantity_change), 0) FROM InventoryMovement
+		WHERE product_id = ? AND variant_id <=> ?`, productID, nullInt(variantID)).Scan(&stock)
+	return stock, err
+}
+
+func (r *inventoryRepository) GetCurrentStock(ctx context.Context, productID int, variantID *int) (int, error) {
+	var stock int
+	var err error
+	if variantID != nil {
+		err = r.DB.QueryRowContext(ctx, "SELECT stock_quantity FROM ProductVariant WHERE variant_id = ? AND product_id = ?", *variantID, productID).Scan(&stock)
+	} else {
+		err = r.DB.QueryRowContext(ctx, "SELECT stock_quantity FROM FlowerProduct WHERE product_id = ?", productID).Scan(&stock)
+	}
+	if err == sql.ErrNoRows {
+		return 0, stockNotFound(variantID)
+	}
+	return stock, err
+}
+
+func (r *inventoryRepository) SetLowStockThreshold(ctx context.Context, productID int, threshold int) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE FlowerProduct SET low_stock_threshold = ? WHERE product_id = ?", threshold, productID)
+	return err
+}
+
+func stockNotFound(variantID *int) error {
+	if variantID != nil {
+		return apperror.NotFound("variant not found")
+	}
+	return apperror.NotFound("product not found")
+}
+
+// lockStock reads the stock counter of a variant, or of the product itself, for update
+func lockStock(ctx context.Context, tx *sql.Tx, productID int, variantID *int) (int, error) {
+	var stock int
+	var err error
+	if variantID != nil {
+		err = tx.QueryRowContext(ctx, "SELECT stock_quantity FROM ProductVariant WHERE variant_id = ? AND product_id = ? FOR UPDATE", *variantID, productID).Scan(&stock)
+	} else {
+		err = tx.QueryRowContext(ctx, "SELECT stock_quantity FROM FlowerProduct WHERE product_id = ? FOR UPDATE", productID).Scan(&stock)
+	}
+	if err == sql.ErrNoRows {
+		return 0, stockNotFound(variantID)
+	}
+	return stock, err
+}
+
+// recordMovement appends a ledger entry for a stock change that has already been
+// applied in tx. A decrease the caller did not take from batches itself, such
+// as a manual correction, is taken from the oldest batches so that they never
+// hold more than the stock. When the change takes the stock below the
+// product's low-stock threshold, every admin is notified.
+func recordMovement(ctx context.Context, tx *sql.Tx, m model.InventoryMovement) (*model.InventoryMovement, error) {
+	var threshold int
+	if err := tx.QueryRowContext(ctx, "SELECT low_stock_threshold FROM FlowerProduct WHERE product_id = ?", m.ProductID).Scan(&threshold); err != nil {
+		return nil, err
+	}
+	stockAfter, err := lockStock(ctx, tx, m.ProductID, m.VariantID)
+	if err != nil {
+		return nil, err
+	}
+	m.StockAfter = stockAfter
+
+	if m.QuantityChange < 0 {
+		if err := trimBatches(ctx, tx, m.ProductID, m.VariantID, stockAfter); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO InventoryMovement
+		(product_id, variant_id, movement_type, quantity_change, stock_after, actor, reason, reference_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		m.ProductID, nullInt(m.VariantID), m.MovementType, m.QuantityChange, m.StockAfter,
+		m.Actor, m.Reason, nullInt(m.ReferenceID))
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	m.MovementID = int(id)
+	m.CreatedAt = time.Now()
+
+	stockBefore := m.StockAfter - m.QuantityChange
+	if m.QuantityChange < 0 && stockBefore >= threshold && m.StockAfter < threshold {
+		if err := notifyLowStock(ctx, tx, m, threshold); err != nil {
+			return nil, err
+		}
+	}
+
+	return &m, nil
+}
+
+func notifyLowStock(ctx context.Context, tx *sql.Tx, m model.InventoryMovement, threshold int) error {
+	var name string
+	if err := tx.QueryRowContext(ctx, "SELECT name FROM FlowerProduct WHERE product_id = ?", m.ProductID).Scan(&name); err != nil {
+		return err
+	}
+	if m.VariantID != nil {
+		var sku string
+		if err := tx.QueryRowContext(ctx, "SELECT sku FROM ProductVariant WHERE variant_id = ?", *m.VariantID).Scan(&sku); err != nil {
+			return err
+		}
+		name += " (" + sku + ")"
+	}
+
+	title := "Low stock: " + name
+	message := fmt.Sprintf("%s is down to %d units (threshold %d) after a %s.", name, m.StockAfter, threshold, m.MovementType)
+	return notifyAdminsTx(ctx, tx, "low_stock", title, message)
+}
+
+// adjustStock changes the stock counter of a variant, or of the product itself
+// when no variant is given. A change that would take the stock below zero is
+// rejected rather than clamped, so the ledger always sums to the counter.
+func adjustStock(ctx context.Context, tx *sql.Tx, productID int, variantID *int, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	var res sql.Result
+	var err error
+	if variantID != nil {
+		res, err = tx.ExecContext(ctx, "UPDATE ProductVariant SET stock_quantity = stock_quantity + ? WHERE variant_id = ? AND stock_quantity + ? >= 0", delta, *variantID, delta)
+	} else {
+		res, err = tx.ExecContext(ctx, "UPDATE FlowerProduct SET stock_quantity = stock_quantity + ? WHERE product_id = ? AND stock_quantity + ? >= 0", delta, productID, delta)
+	}
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if delta < 0 {
+			return apperror.OutOfStock("stock cannot go below zero")
+		}
+		return stockNotFound(variantID)
+	}
+	return nil
+}
+
+// consumeBatches takes quantity from the oldest non-empty batches first and
+// records which batches served the order. Stock that predates batch tracking
+// has no batch, so running out of batches is not an error.
+func consumeBatches(ctx context.Context, tx *sql.Tx, orderID, productID int, variantID *int, quantity int) error {
+	takes, err := takeFromBatches(ctx, tx, productID, variantID, quantity)
+	if err != nil {
+		return err
+	}
+	for _, t := range takes {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO BatchConsumption (order_id, batch_id, quantity) VALUES (?, ?, ?)", orderID, t.batchID, t.quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trimBatches takes from the oldest batches until they hold no more than
+// stock. Stock outside any batch (from before batch tracking, or added by a
+// manual correction) is taken to have gone first.
+func trimBatches(ctx context.Context, tx *sql.Tx, productID int, variantID *int, stock int) error {
+	var held int
+	err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(quantity_remaining), 0) FROM InventoryBatch
+		WHERE product_id = ? AND variant_id <=> ? AND quantity_remaining > 0
+		FOR UPDATE`, productID, nullInt(variantID)).Scan(&held)
+	if err != nil {
+		return err
+	}
+	if held <= stock {
+		return nil
+	}
+	_, err = takeFromBatches(ctx, tx, productID, variantID, held-stock)
+	return err
+}
+
+type batchTake struct{ batchID, quantity int }
+
+// takeFromBatches reduces the oldest non-empty batches by up to quantity in
+// total and returns what it took from each
+func takeFromBatches(ctx context.Context, tx *sql.Tx, productID int, variantID *int, quantity int) ([]batchTake, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT batch_id, quantity_remaining FROM InventoryBatch
+		WHERE product_id = ? AND variant_id <=> ? AND quantity_remaining > 0
+		ORDER BY received_date ASC, batch_id ASC
+		FOR UPDATE`, productID, nullInt(variantID))
+	if err != nil {
+		return nil, err
+	}
+
+	var takes []batchTake
+	left := quantity
+	for rows.Next() && left > 0 {
+		var batchID, remaining int
+		if err := rows.Scan(&batchID, &remaining); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		n := min(remaining, left)
+		takes = append(takes, batchTake{batchID, n})
+		left -= n
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, t := range takes {
+		if _, err := tx.ExecContext(ctx, "UPDATE InventoryBatch SET quantity_remaining = quantity_remaining - ? WHERE batch_id = ?", t.quantity, t.batchID); err != nil {
+			return nil, err
+		}
+	}
+	return takes, nil
+}
+
+// restoreBatches puts the quantities consumed by an order back into their
+// batches once the order's units are back in stock. Units of a batch that has
+// expired since cannot be sold again, so they are written off instead.
+func restoreBatches(ctx context.Context, tx *sql.Tx, orderID int, actor string) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT b.batch_id, b.product_id, b.variant_id, b.expires_at <= ?, SUM(c.quantity)
+		FROM BatchConsumption c
+		JOIN InventoryBatch b ON b.batch_id = c.batch_id
+		WHERE c.order_id = ?
+		GROUP BY b.batch_id, b.product_id, b.variant_id, b.expires_at`, time.Now(), orderID)
+	if err != nil {
+		return err
+	}
+
+	type restore struct {
+		batch    model.InventoryBatch
+		expired  bool
+		quantity int
+	}
+	var restores []restore
+	for rows.Next() {
+		var r restore
+		var variantID sql.NullInt64
+		if err := rows.Scan(&r.batch.BatchID, &r.batch.ProductID, &variantID, &r.expired, &r.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		if variantID.Valid {
+			v := int(variantID.Int64)
+			r.batch.VariantID = &v
+		}
+		restores = append(restores, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, r := range restores {
+		if r.expired {
+			if err := writeOff(ctx, tx, r.batch, r.quantity, actor, "expired before the order was cancelled"); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE InventoryBatch SET quantity_remaining = quantity_remaining + ? WHERE batch_id = ?", r.quantity, r.batch.BatchID); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM BatchConsumption WHERE order_id = ?", orderID)
+	return err
+}
+
+func scanBatches(rows *sql.Rows) ([]model.InventoryBatch, error) {
+	var batches []model.InventoryBatch
+	for rows.Next() {
+		var b model.InventoryBatch
+		var variantID sql.NullInt64
+		if err := rows.Scan(&b.BatchID, &b.ProductID, &variantID, &b.QuantityReceived, &b.QuantityRemaining,
+			&b.ReceivedDate, &b.ShelfLifeDays, &b.ExpiresAt, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		if variantID.Valid {
+			v := int(variantID.Int64)
+			b.VariantID = &v
+		}
+		batches = append(batches, b)
+	}
+	return batches, rows.Err()
+}
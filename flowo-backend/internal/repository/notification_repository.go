@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/model"
+)
+
+type NotificationRepository interface {
+	CreateNotification(ctx context.Context, n *model.Notification) error
+	NotifyAdmins(ctx context.Context, notificationType, title, message string) error
+	GetNotificationsByUser(ctx context.Context, firebaseUID string, unreadOnly bool, limit, offset int) ([]model.Notification, error)
+	MarkAsRead(ctx context.Context, notificationID int, firebaseUID string) error
+	MarkAllAsRead(ctx context.Context, firebaseUID string) error
+}
+
+type notificationRepository struct {
+	DB *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) NotificationRepository {
+	return &notificationRepository{DB: db}
+}
+
+func (r *notificationRepository) CreateNotification(ctx context.Context, n *model.Notification) error {
+	res, err := r.DB.ExecContext(ctx, `
+		INSERT INTO Notification (firebase_uid, type, title, message)
+		VALUES (?, ?, ?, ?)`, n.FirebaseUID, n.Type, n.Title, n.Message)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	n.NotificationID = int(id)
+	return nil
+}
+
+func (r *notificationRepository) NotifyAdmins(ctx context.Context, notificationType, title, message string) error {
+	_, err := r.DB.ExecContext(ctx, notifyAdminsQuery, notificationType, title, message)
+	return err
+}
+
+func (r *notificationRepository) GetNotificationsByUser(ctx context.Context, firebaseUID string, unreadOnly bool, limit, offset int) ([]model.Notification, error) {
+	query := `
+		SELECT notification_id, firebase_uid, type, title, COALESCE(message, ''), is_read, created_at
+		FROM Notification
+		WHERE firebase_uid = ?`
+	if unreadOnly {
+		query += " AND is_read = FALSE"
+	}
+	query += " ORDER BY created_at DESC, notification_id DESC LIMIT ? OFFSET ?"
+
+	rows, err := r.DB.QueryContext(ctx, query, firebaseUID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []model.Notification
+	for rows.Next() {
+		var n model.Notification
+		if err := rows.Scan(&n.NotificationID, &n.FirebaseUID, &n.Type, &n.Title, &n.Message, &n.IsRead, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+func (r *notificationRepository) MarkAsRead(ctx context.Context, notificationID int, firebaseUID string) error {
+	res, err := r.DB.ExecContext(ctx, "UPDATE Notification SET is_read = TRUE WHERE notification_id = ? AND firebase_uid = ?", notificationID, firebaseUID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		// either missing or already read; tell them apart so callers can 404
+		var exists bool
+		err := r.DB.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM Notification WHERE notification_id = ? AND firebase_uid = ?)", notificationID, firebaseUID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return apperror.NotFound("notification not found")
+		}
+	}
+	return nil
+}
+
+func (r *notificationRepository) MarkAllAsRead(ctx context.Context, firebaseUID string) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE Notification SET is_read = TRUE WHERE firebase_uid = ? AND is_read = FALSE", firebaseUID)
+	return err
+}
+
+const notifyAdminsQuery = `
+	INSERT INTO Notification (firebase_uid, type, title, message)
+	SELECT firebase_uid, ?, ?, ? FROM User
+	WHERE role = 'Admin' AND COALESCE(is_deleted, FALSE) = FALSE`
+
+// notifyAdminsTx notifies every admin as part of tx, so the notification is
+// only kept if the change that caused it commits
+func notifyAdminsTx(ctx context.Context, tx *sql.Tx, notificationType, title, message string) error {
+	_, err := tx.ExecContext(ctx, notifyAdminsQuery, notificationType, title, message)
+	return err
+}
@@ -1,23 +1,27 @@
 package repository
 
 import (
+	"context"
+
 	"database/sql"
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/model"
+	"flowo-backend/internal/pagination"
 	"fmt"
 )
 
 type OrderRepository interface {
-	GetOrdersByUser(firebaseUID string) ([]model.Order, error)
-	UpdateOrderStatus(orderID int, status string, shippingMethod *string) error
-	GetOrderByID(orderID int) (*model.Order, error)
-
-	CreateOrderWithItemsAndStock(firebaseUID string, order model.Order, items []dto.CartItemResponse) (int, error)
-	GetOrderOwnerID(orderID int) (string, error)
-	GetOrderDetailByID(orderID int) (*dto.OrderDetailResponse, error)
-	AdminGetOrders(status, userID, startDate, endDate string, limit, offset int) ([]dto.AdminOrderResponse, error)
-	GetAdminOrderDetailByID(orderID int) (*dto.AdminOrderDetailResponse, error)
-	CancelOrderAndRestoreStock(orderID int) error
+	GetOrdersByUser(ctx context.Context, firebaseUID string) ([]model.Order, error)
+	UpdateOrderStatus(ctx context.Context, orderID int, status string, shippingMethod *string) error
+	GetOrderByID(ctx context.Context, orderID int) (*model.Order, error)
+
+	CreateOrderWithItemsAndStock(ctx context.Context, firebaseUID string, order model.Order, items []dto.CartItemResponse) (int, error)
+	GetOrderOwnerID(ctx context.Context, orderID int) (string, error)
+	GetOrderDetailByID(ctx context.Context, orderID int) (*dto.OrderDetailResponse, error)
+	AdminGetOrders(ctx context.Context, status, userID, startDate, endDate string, page pagination.Request) ([]dto.AdminOrderResponse, int, error)
+	GetAdminOrderDetailByID(ctx context.Context, orderID int) (*dto.AdminOrderDetailResponse, error)
+	CancelOrderAndRestoreStock(ctx context.Context, orderID int, actor string) error
 }
 
 type orderRepository struct {
@@ -28,8 +32,8 @@ func NewOrderRepository(db *sql.DB) OrderRepository {
 	return &orderRepository{DB: db}
 }
 
-func (r *orderRepository) GetOrdersByUser(firebaseUID string) ([]model.Order, error) {
-	rows, err := r.DB.Query(
+func (r *orderRepository) GetOrdersByUser(ctx context.Context, firebaseUID string) ([]model.Order, error) {
+	rows, err := r.DB.QueryContext(ctx,
 		"SELECT order_id, firebase_uid, status, order_date, final_total_amount, shipping_method FROM `Order` WHERE firebase_uid = ? ORDER BY order_date DESC",
 		firebaseUID,
 	)
@@ -49,7 +53,7 @@ func (r *orderRepository) GetOrdersByUser(firebaseUID string) ([]model.Order, er
 	return orders, nil
 }
 
-func (r *orderRepository) UpdateOrderStatus(orderID int, status string, shippingMethod *string) error {
+func (r *orderRepository) UpdateOrderStatus(ctx context.Context, orderID int, status string, shippingMethod *string) error {
 	query := "UPDATE `Order` SET status = ?"
 	args := []interface{}{status}
 	if shippingMethod != nil {
@@ -59,13 +63,13 @@ func (r *orderRepository) UpdateOrderStatus(orderID int, status string, shipping
 	query += " WHERE order_id = ?"
 	args = append(args, orderID)
 
-	_, err := r.DB.Exec(query, args...)
+	_, err := r.DB.ExecContext(ctx, query, args...)
 	return err
 }
 
-func (r *orderRepository) GetOrderByID(orderID int) (*model.Order, error) {
+func (r *orderRepository) GetOrderByID(ctx context.Context, orderID int) (*model.Order, error) {
 	query := "SELECT order_id, firebase_uid, status, order_date, final_total_amount, shipping_method FROM `Order` WHERE order_id = ? LIMIT 1"
-	row := r.DB.QueryRow(query, orderID)
+	row := r.DB.QueryRowContext(ctx, query, orderID)
 
 	var o model.Order
 	if err := row.Scan(&o.OrderID, &o.FirebaseUID, &o.Status, &o.OrderDate, &o.FinalTotalAmount, &o.ShippingMethod); err != nil {
@@ -78,8 +82,8 @@ func (r *orderRepository) GetOrderByID(orderID int) (*model.Order, error) {
 	return &o, nil
 }
 
-func (r *orderRepository) CreateOrderWithItemsAndStock(firebaseUID string, order model.Order, items []dto.CartItemResponse) (int, error) {
-	tx, err := r.DB.Begin()
+func (r *orderRepository) CreateOrderWithItemsAndStock(ctx context.Context, firebaseUID string, order model.Order, items []dto.CartItemResponse) (int, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
@@ -91,39 +95,61 @@ func (r *orderRepository) CreateOrderWithItemsAndStock(firebaseUID string, order
 		}
 	}()
 
+	// The order is inserted first so batch consumption can reference it
+	orderID, err := r.insertOrder(ctx, tx, order)
+	if err != nil {
+		return 0, err
+	}
+
 	for _, item := range items {
-		if err := r.reduceStock(tx, item.ProductID, item.Quantity); err != nil {
+		if err = r.reduceStock(ctx, tx, orderID, firebaseUID, item.ProductID, item.VariantID, item.Quantity); err != nil {
 			return 0, err
 		}
 	}
 
-	orderID, err := r.insertOrder(tx, order)
-	if err != nil {
-		return 0, err
-	}
-
-	if err := r.insertOrderItems(tx, orderID, items); err != nil {
+	if err = r.insertOrderItems(ctx, tx, orderID, items); err != nil {
 		return 0, err
 	}
 
 	return orderID, nil
 }
 
-func (r *orderRepository) reduceStock(tx *sql.Tx, productID, quantity int) error {
-	var currentStock int
-	err := tx.QueryRow("SELECT stock_quantity FROM FlowerProduct WHERE product_id = ?", productID).Scan(&currentStock)
+// reduceStock decrements the stock counter of the product (or variant),
+// consumes its inventory batches oldest first and records the sale in the ledger
+func (r *orderRepository) reduceStock(ctx context.Context, tx *sql.Tx, orderID int, actor string, productID int, variantID *int, quantity int) error {
+	currentStock, err := lockStock(ctx, tx, productID, variantID)
 	if err != nil {
 		return err
 	}
 	if currentStock < quantity {
-		return fmt.Errorf("not enough stock for product %d", productID)
+		if variantID != nil {
+			return apperror.OutOfStock(fmt.Sprintf("not enough stock for product %d variant %d", productID, *variantID))
+		}
+		return apperror.OutOfStock(fmt.Sprintf("not enough stock for product %d", productID))
 	}
-	_, err = tx.Exec("UPDATE FlowerProduct SET stock_quantity = stock_quantity - ? WHERE product_id = ?", quantity, productID)
+
+	if err := adjustStock(ctx, tx, productID, variantID, -quantity); err != nil {
+		return err
+	}
+
+	if err := consumeBatches(ctx, tx, orderID, productID, variantID, quantity); err != nil {
+		return err
+	}
+
+	_, err = recordMovement(ctx, tx, model.InventoryMovement{
+		ProductID:      productID,
+		VariantID:      variantID,
+		MovementType:   model.MovementSale,
+		QuantityChange: -quantity,
+		Actor:          actor,
+		Reason:         fmt.Sprintf("order #%d", orderID),
+		ReferenceID:    &orderID,
+	})
 	return err
 }
 
-func (r *orderRepository) insertOrder(tx *sql.Tx, order model.Order) (int, error) {
-	res, err := tx.Exec("INSERT INTO `Order` (firebase_uid, order_date, status, shipping_address_id, billing_address_id, subtotal_amount, discount_amount, shipping_cost, final_total_amount, notes, shipping_method) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+func (r *orderRepository) insertOrder(ctx context.Context, tx *sql.Tx, order model.Order) (int, error) {
+	res, err := tx.ExecContext(ctx, "INSERT INTO `Order` (firebase_uid, order_date, status, shipping_address_id, billing_address_id, subtotal_amount, discount_amount, shipping_cost, final_total_amount, notes, shipping_method) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		order.FirebaseUID, order.OrderDate, order.Status,
 		order.ShippingAddressID, order.BillingAddressID,
 		order.SubtotalAmount, order.DiscountAmount,
@@ -136,13 +162,13 @@ func (r *orderRepository) insertOrder(tx *sql.Tx, order model.Order) (int, error
 	return int(orderID64), nil
 }
 
-func (r *orderRepository) insertOrderItems(tx *sql.Tx, orderID int, items []dto.CartItemResponse) error {
+func (r *orderRepository) insertOrderItems(ctx context.Context, tx *sql.Tx, orderID int, items []dto.CartItemResponse) error {
 	for _, item := range items {
-		_, err := tx.Exec(`
+		_, err := tx.ExecContext(ctx, `
 			INSERT INTO OrderItem 
-			(order_id, product_id, quantity, price_per_unit_at_purchase, item_subtotal)
-			VALUES (?, ?, ?, ?, ?)`,
-			orderID, item.ProductID, item.Quantity, item.EffectivePrice, item.TotalPrice)
+			(order_id, product_id, variant_id, quantity, price_per_unit_at_purchase, item_subtotal)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			orderID, item.ProductID, nullInt(item.VariantID), item.Quantity, item.EffectivePrice, item.TotalPrice)
 		if err != nil {
 			return fmt.Errorf("failed to insert order item for product %d: %v", item.ProductID, err)
 		}
@@ -150,12 +176,12 @@ func (r *orderRepository) insertOrderItems(tx *sql.Tx, orderID int, items []dto.
 	return nil
 }
 
-func (r *orderRepository) GetOrderOwnerID(orderID int) (string, error) {
+func (r *orderRepository) GetOrderOwnerID(ctx context.Context, orderID int) (string, error) {
 	var firebaseUID string
-	err := r.DB.QueryRow("SELECT firebase_uid FROM `Order` WHERE order_id = ?", orderID).Scan(&firebaseUID)
+	err := r.DB.QueryRowContext(ctx, "SELECT firebase_uid FROM `Order` WHERE order_id = ?", orderID).Scan(&firebaseUID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return "", fmt.Errorf("order not found")
+			return "", apperror.NotFound("order not found")
 		}
 		return "", err
 	}
@@ -166,7 +192,7 @@ func (r *orderRepository) GetOrderOwnerID(orderID int) (string, error) {
 // 	var order dto.OrderDetailResponse
 // 	var shippingAddrID, billingAddrID *int
 
-// 	err := r.DB.QueryRow("SELECT o.order_id, o.status, o.order_date, o.final_total_amount, o.shipping_method, o.shipping_address_id, o.billing_address_id FROM `Order` o WHERE o.order_id = ?",
+// 	err := r.DB.QueryRowContext(ctx, "SELECT o.order_id, o.status, o.order_date, o.final_total_amount, o.shipping_method, o.shipping_address_id, o.billing_address_id FROM `Order` o WHERE o.order_id = ?",
 // 		orderID,
 // 	).Scan(&order.OrderID, &order.Status, &order.OrderDate, &order.TotalAmount,
 // 		&order.ShippingMethod, &shippingAddrID, &billingAddrID)
@@ -174,7 +200,7 @@ func (r *orderRepository) GetOrderOwnerID(orderID int) (string, error) {
 // 		return nil, err
 // 	}
 
-// 	rows, err := r.DB.Query(`
+// 	rows, err := r.DB.QueryContext(ctx, `
 // 		SELECT product_id, quantity, price_per_unit_at_purchase, item_subtotal
 // 		FROM OrderItem
 // 		WHERE order_id = ?`, orderID)
@@ -195,7 +221,7 @@ func (r *orderRepository) GetOrderOwnerID(orderID int) (string, error) {
 
 // 	if shippingAddrID != nil {
 // 		var addr dto.AddressResponse
-// 		err = r.DB.QueryRow(`
+// 		err = r.DB.QueryRowContext(ctx, `
 // 			SELECT address_id, recipient_name, phone_number, street_address, city, postal_code, country
 // 			FROM Address WHERE address_id = ?`, *shippingAddrID).
 // 			Scan(&addr.AddressID, &addr.RecipientName, &addr.PhoneNumber,
@@ -208,17 +234,17 @@ func (r *orderRepository) GetOrderOwnerID(orderID int) (string, error) {
 // 	return &order, nil
 // }
 
-func (r *orderRepository) GetOrderDetailByID(orderID int) (*dto.OrderDetailResponse, error) {
+func (r *orderRepository) GetOrderDetailByID(ctx context.Context, orderID int) (*dto.OrderDetailResponse, error) {
 
 	var order dto.OrderDetailResponse
-	err := r.DB.QueryRow(" SELECT o.order_id, o.status, o.order_date, o.final_total_amount, o.shipping_method FROM `Order` o WHERE o.order_id = ?", orderID).Scan(&order.OrderID, &order.Status, &order.OrderDate, &order.TotalAmount, &order.ShippingMethod)
+	err := r.DB.QueryRowContext(ctx, " SELECT o.order_id, o.status, o.order_date, o.final_total_amount, o.shipping_method FROM `Order` o WHERE o.order_id = ?", orderID).Scan(&order.OrderID, &order.Status, &order.OrderDate, &order.TotalAmount, &order.ShippingMethod)
 
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := r.DB.Query(`
-		SELECT product_id, quantity, price_per_unit_at_purchase, item_subtotal
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT product_id, variant_id, quantity, price_per_unit_at_purchase, item_subtotal
 		FROM OrderItem
 		WHERE order_id = ?
 	`, orderID)
@@ -228,10 +254,15 @@ func (r *orderRepository) GetOrderDetailByID(orderID int) (*dto.OrderDetailRespo
 	var items []dto.OrderItemDetail
 	for rows.Next() {
 		var item dto.OrderItemDetail
-		err := rows.Scan(&item.ProductID, &item.Quantity, &item.Price, &item.Subtotal)
+		var variantID sql.NullInt64
+		err := rows.Scan(&item.ProductID, &variantID, &item.Quantity, &item.Price, &item.Subtotal)
 		if err != nil {
 			return nil, err
 		}
+		if variantID.Valid {
+			v := int(variantID.Int64)
+			item.VariantID = &v
+		}
 		items = append(items, item)
 	}
 
@@ -239,12 +270,29 @@ func (r *orderRepository) GetOrderDetailByID(orderID int) (*dto.OrderDetailRespo
 	return &order, nil
 }
 
-func (r *orderRepository) AdminGetOrders(status, firebaseUID, startDate, endDate string, limit, offset int) ([]dto.AdminOrderResponse, error) {
-	query := "SELECT order_id, firebase_uid, final_total_amount, status, order_date FROM `Order` WHERE (status = ? OR ? = '') AND (firebase_uid = ? OR ? = '') AND (order_date >= ? OR ? = '') AND (order_date <= ? OR ? = '') ORDER BY order_date DESC LIMIT ? OFFSET ?"
+// AdminGetOrders returns the total number of matching orders and up to
+// page.Fetch() of them, newest first
+func (r *orderRepository) AdminGetOrders(ctx context.Context, status, firebaseUID, startDate, endDate string, page pagination.Request) ([]dto.AdminOrderResponse, int, error) {
+	where := " WHERE (status = ? OR ? = '') AND (firebase_uid = ? OR ? = '') AND (order_date >= ? OR ? = '') AND (order_date <= ? OR ? = '')"
+	args := []interface{}{status, status, firebaseUID, firebaseUID, startDate, startDate, endDate, endDate}
+
+	var total int
+	if err := r.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM `Order`"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	keyset, keysetArgs, orderBy := page.Keyset("order_date", "order_id")
+	if keyset != "" {
+		where += " AND " + keyset
+		args = append(args, keysetArgs...)
+	}
+	query := "SELECT order_id, firebase_uid, final_total_amount, status, order_date FROM `Order`" + where +
+		" ORDER BY " + orderBy + " LIMIT ? OFFSET ?"
+	args = append(args, page.Fetch(), page.Offset())
 
-	rows, err := r.DB.Query(query, status, status, firebaseUID, firebaseUID, startDate, startDate, endDate, endDate, limit, offset)
+	rows, err := r.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
@@ -252,18 +300,18 @@ func (r *orderRepository) AdminGetOrders(status, firebaseUID, startDate, endDate
 	for rows.Next() {
 		var o dto.AdminOrderResponse
 		if err := rows.Scan(&o.OrderID, &o.FirebaseUID, &o.TotalAmount, &o.Status, &o.OrderDate); err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		orders = append(orders, o)
 	}
-	return orders, nil
+	return orders, total, rows.Err()
 }
 
-func (r *orderRepository) GetAdminOrderDetailByID(orderID int) (*dto.AdminOrderDetailResponse, error) {
+func (r *orderRepository) GetAdminOrderDetailByID(ctx context.Context, orderID int) (*dto.AdminOrderDetailResponse, error) {
 	var order dto.AdminOrderDetailResponse
 	var shippingAddrID *int
 
-	err := r.DB.QueryRow("SELECT o.order_id, o.status, o.order_date, o.final_total_amount, o.shipping_method, IFNULL(o.customer_name, ''), IFNULL(o.customer_email, ''), o.shipping_address_id FROM `Order` o WHERE o.order_id = ?", orderID).
+	err := r.DB.QueryRowContext(ctx, "SELECT o.order_id, o.status, o.order_date, o.final_total_amount, o.shipping_method, IFNULL(o.customer_name, ''), IFNULL(o.customer_email, ''), o.shipping_address_id FROM `Order` o WHERE o.order_id = ?", orderID).
 		Scan(&order.OrderID, &order.Status, &order.OrderDate,
 			&order.TotalAmount, &order.ShippingMethod,
 			&order.CustomerName, &order.CustomerEmail,
@@ -273,8 +321,8 @@ func (r *orderRepository) GetAdminOrderDetailByID(orderID int) (*dto.AdminOrderD
 		return nil, err
 	}
 
-	rows, err := r.DB.Query(`
-    	SELECT oi.product_id, fp.name, oi.quantity, oi.price_per_unit_at_purchase, oi.item_subtotal
+	rows, err := r.DB.QueryContext(ctx, `
+    	SELECT oi.product_id, fp.name, oi.variant_id, oi.quantity, oi.price_per_unit_at_purchase, oi.item_subtotal
     	FROM OrderItem oi
     	JOIN FlowerProduct fp ON oi.product_id = fp.product_id
     	WHERE oi.order_id = ?`, orderID)
@@ -286,16 +334,21 @@ func (r *orderRepository) GetAdminOrderDetailByID(orderID int) (*dto.AdminOrderD
 	var items []dto.AdminOrderItemDetail
 	for rows.Next() {
 		var item dto.AdminOrderItemDetail
-		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.Quantity, &item.Price, &item.Subtotal); err != nil {
+		var variantID sql.NullInt64
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &variantID, &item.Quantity, &item.Price, &item.Subtotal); err != nil {
 			return nil, err
 		}
+		if variantID.Valid {
+			v := int(variantID.Int64)
+			item.VariantID = &v
+		}
 		items = append(items, item)
 	}
 	order.Items = items
 
 	if shippingAddrID != nil {
 		var addr dto.AddressResponse
-		err = r.DB.QueryRow(`
+		err = r.DB.QueryRowContext(ctx, `
 			SELECT address_id, recipient_name, phone_number, street_address, city, postal_code, country
 			FROM Address WHERE address_id = ?`, *shippingAddrID).
 			Scan(&addr.AddressID, &addr.RecipientName, &addr.PhoneNumber,
@@ -308,8 +361,8 @@ func (r *orderRepository) GetAdminOrderDetailByID(orderID int) (*dto.AdminOrderD
 	return &order, nil
 }
 
-func (r *orderRepository) CancelOrderAndRestoreStock(orderID int) error {
-	tx, err := r.DB.Begin()
+func (r *orderRepository) CancelOrderAndRestoreStock(ctx context.Context, orderID int, actor string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -322,24 +375,58 @@ func (r *orderRepository) CancelOrderAndRestoreStock(orderID int) error {
 	}()
 
 	// get order items
-	rows, err := tx.Query("SELECT product_id, quantity FROM OrderItem WHERE order_id = ?", orderID)
+	rows, err := tx.QueryContext(ctx, "SELECT product_id, variant_id, quantity FROM OrderItem WHERE order_id = ?", orderID)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
 
+	type restock struct {
+		productID int
+		variantID *int
+		quantity  int
+	}
+	var restocks []restock
 	for rows.Next() {
-		var productID, qty int
-		if err := rows.Scan(&productID, &qty); err != nil {
+		var item restock
+		var variantID sql.NullInt64
+		if err = rows.Scan(&item.productID, &variantID, &item.quantity); err != nil {
+			rows.Close()
 			return err
 		}
-		if _, err := tx.Exec("UPDATE FlowerProduct SET stock_quantity = stock_quantity + ? WHERE product_id = ?", qty, productID); err != nil {
+		if variantID.Valid {
+			v := int(variantID.Int64)
+			item.variantID = &v
+		}
+		restocks = append(restocks, item)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, item := range restocks {
+		if err = adjustStock(ctx, tx, item.productID, item.variantID, item.quantity); err != nil {
 			return err
 		}
+		if _, err = recordMovement(ctx, tx, model.InventoryMovement{
+			ProductID:      item.productID,
+			VariantID:      item.variantID,
+			MovementType:   model.MovementCancellationRestock,
+			QuantityChange: item.quantity,
+			Actor:          actor,
+			Reason:         fmt.Sprintf("order #%d cancelled", orderID),
+			ReferenceID:    &orderID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err = restoreBatches(ctx, tx, orderID, actor); err != nil {
+		return err
 	}
 
 	// update order status to cancelled
-	if _, err := tx.Exec("UPDATE `Order` SET status = ? WHERE order_id = ?", "CANCELLED", orderID); err != nil {
+	if _, err = tx.ExecContext(ctx, "UPDATE `Order` SET status = ? WHERE order_id = ?", "CANCELLED", orderID); err != nil {
 		return err
 	}
 
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -8,11 +9,11 @@ import (
 )
 
 type PaymentRepository interface {
-	CreatePayment(p *model.Payment) (int, error)
-	UpdatePaymentStatus(paymentID int, status, transactionID string, amountPaid float64) error
-	UpdatePaymentRawWebhook(paymentID int, raw string) error
-	GetPaymentByOrderID(orderID int) (*model.Payment, error)
-	GetPaymentByPaymentLinkID(paymentLinkID string) (*model.Payment, error)
+	CreatePayment(ctx context.Context, p *model.Payment) (int, error)
+	UpdatePaymentStatus(ctx context.Context, paymentID int, status, transactionID string, amountPaid float64) error
+	UpdatePaymentRawWebhook(ctx context.Context, paymentID int, raw string) error
+	GetPaymentByOrderID(ctx context.Context, orderID int) (*model.Payment, error)
+	GetPaymentByPaymentLinkID(ctx context.Context, paymentLinkID string) (*model.Payment, error)
 }
 
 type paymentRepository struct {
@@ -23,8 +24,8 @@ func NewPaymentRepository(db *sql.DB) PaymentRepository {
 	return &paymentRepository{DB: db}
 }
 
-func (r *paymentRepository) CreatePayment(p *model.Payment) (int, error) {
-	res, err := r.DB.Exec(`INSERT INTO Payment (order_id, payment_method, payment_status, transaction_id, payment_link_id, checkout_url, raw_webhook, amount_paid, payment_date) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+func (r *paymentRepository) CreatePayment(ctx context.Context, p *model.Payment) (int, error) {
+	res, err := r.DB.ExecContext(ctx, `INSERT INTO Payment (order_id, payment_method, payment_status, transaction_id, payment_link_id, checkout_url, raw_webhook, amount_paid, payment_date) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		p.OrderID, p.PaymentMethod, p.PaymentStatus, p.TransactionID, p.PaymentLinkID, p.CheckoutUrl, p.RawWebhook, p.AmountPaid, time.Now())
 	if err != nil {
 		return 0, err
@@ -36,13 +37,13 @@ func (r *paymentRepository) CreatePayment(p *model.Payment) (int, error) {
 	return int(id64), nil
 }
 
-func (r *paymentRepository) UpdatePaymentStatus(paymentID int, status, transactionID string, amountPaid float64) error {
-	_, err := r.DB.Exec("UPDATE Payment SET payment_status = ?, transaction_id = ?, amount_paid = ?, payment_date = ? WHERE payment_id = ?", status, transactionID, amountPaid, time.Now(), paymentID)
+func (r *paymentRepository) UpdatePaymentStatus(ctx context.Context, paymentID int, status, transactionID string, amountPaid float64) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE Payment SET payment_status = ?, transaction_id = ?, amount_paid = ?, payment_date = ? WHERE payment_id = ?", status, transactionID, amountPaid, time.Now(), paymentID)
 	return err
 }
 
-func (r *paymentRepository) GetPaymentByOrderID(orderID int) (*model.Payment, error) {
-	row := r.DB.QueryRow("SELECT payment_id, order_id, payment_method, payment_status, transaction_id, payment_link_id, checkout_url, raw_webhook, amount_paid, payment_date FROM Payment WHERE order_id = ? LIMIT 1", orderID)
+func (r *paymentRepository) GetPaymentByOrderID(ctx context.Context, orderID int) (*model.Payment, error) {
+	row := r.DB.QueryRowContext(ctx, "SELECT payment_id, order_id, payment_method, payment_status, transaction_id, payment_link_id, checkout_url, raw_webhook, amount_paid, payment_date FROM Payment WHERE order_id = ? LIMIT 1", orderID)
 	var p model.Payment
 	var paymentDate sql.NullTime
 	var rawWebhook sql.NullString
@@ -61,13 +62,13 @@ func (r *paymentRepository) GetPaymentByOrderID(orderID int) (*model.Payment, er
 	return &p, nil
 }
 
-func (r *paymentRepository) UpdatePaymentRawWebhook(paymentID int, raw string) error {
-	_, err := r.DB.Exec("UPDATE Payment SET raw_webhook = ? WHERE payment_id = ?", raw, paymentID)
+func (r *paymentRepository) UpdatePaymentRawWebhook(ctx context.Context, paymentID int, raw string) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE Payment SET raw_webhook = ? WHERE payment_id = ?", raw, paymentID)
 	return err
 }
 
-func (r *paymentRepository) GetPaymentByPaymentLinkID(paymentLinkID string) (*model.Payment, error) {
-	row := r.DB.QueryRow("SELECT payment_id, order_id, payment_method, payment_status, transaction_id, payment_link_id, checkout_url, raw_webhook, amount_paid, payment_date FROM Payment WHERE payment_link_id = ? OR transaction_id = ? LIMIT 1", paymentLinkID, paymentLinkID)
+func (r *paymentRepository) GetPaymentByPaymentLinkID(ctx context.Context, paymentLinkID string) (*model.Payment, error) {
+	row := r.DB.QueryRowContext(ctx, "SELECT payment_id, order_id, payment_method, payment_status, transaction_id, payment_link_id, checkout_url, raw_webhook, amount_paid, payment_date FROM Payment WHERE payment_link_id = ? OR transaction_id = ? LIMIT 1", paymentLinkID, paymentLinkID)
 	var p model.Payment
 	var paymentDate sql.NullTime
 	var rawWebhook sql.NullString
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Rows per INSERT when the whole price table is rewritten
+const priceTableChunk = 500
+
+type PriceTableRepository interface {
+	ReplaceEffectivePrices(ctx context.Context, prices map[uint]float64, computedAt time.Time, validUntil *time.Time) error
+	UpsertEffectivePrice(ctx context.Context, productID uint, price float64, computedAt time.Time, validUntil *time.Time) error
+	DeleteEffectivePrice(ctx context.Context, productID uint) error
+}
+
+type priceTableRepository struct {
+	DB *sql.DB
+}
+
+func NewPriceTableRepository(db *sql.DB) PriceTableRepository {
+	return &priceTableRepository{DB: db}
+}
+
+// ReplaceEffectivePrices rewrites the whole table in one transaction, so
+// searches keep seeing the previous prices until the new ones are complete
+func (r *priceTableRepository) ReplaceEffectivePrices(ctx context.Context, prices map[uint]float64, computedAt time.Time, validUntil *time.Time) (err error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM ProductEffectivePrice"); err != nil {
+		return err
+	}
+
+	values := make([]string, 0, priceTableChunk)
+	args := make([]interface{}, 0, priceTableChunk*4)
+	flush := func() error {
+		if len(values) == 0 {
+			return nil
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO ProductEffectivePrice (product_id, effective_price, computed_at, valid_until)
+			VALUES `+strings.Join(values, ", "), args...)
+		values, args = values[:0], args[:0]
+		return err
+	}
+
+	for productID, price := range prices {
+		values = append(values, "(?, ?, ?, ?)")
+		args = append(args, productID, price, computedAt, nullTime(validUntil))
+		if len(values) == priceTableChunk {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+	err = flush()
+	return err
+}
+
+func (r *priceTableRepository) UpsertEffectivePrice(ctx context.Context, productID uint, price float64, computedAt time.Time, validUntil *time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO ProductEffectivePrice (product_id, effective_price, computed_at, valid_until)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE effective_price = VALUES(effective_price),
+			computed_at = VALUES(computed_at), valid_until = VALUES(valid_until)`,
+		productID, price, computedAt, nullTime(validUntil))
+	return err
+}
+
+func (r *priceTableRepository) DeleteEffectivePrice(ctx context.Context, productID uint) error {
+	_, err := r.DB.ExecContext(ctx, "DELETE FROM ProductEffectivePrice WHERE product_id = ?", productID)
+	return err
+}
@@ -1,27 +1,32 @@
 package repository
 
 import (
+	"context"
+
 	"database/sql"
 	"flowo-backend/internal/model"
+	"sync"
 	"time"
 )
 
 type PricingRuleRepository interface {
-	GetActiveRules() ([]model.PricingRule, error)
-	IsRuleApplicable(rule model.PricingRule, product model.Product, now time.Time) bool
-	CreatePricingRule(rule model.PricingRule) error
-	GetAllRules() ([]model.PricingRule, error)
-	UpdateRule(rule model.PricingRule) error
-	DeleteRule(id int) error
+	GetActiveRules(ctx context.Context) ([]model.PricingRule, error)
+	IsRuleApplicable(rule model.PricingRule, product model.Product, variant *model.ProductVariant, now time.Time) bool
+	CreatePricingRule(ctx context.Context, rule model.PricingRule) error
+	GetAllRules(ctx context.Context) ([]model.PricingRule, error)
+	UpdateRule(ctx context.Context, rule model.PricingRule) error
+	DeleteRule(ctx context.Context, id int) error
+	ReloadFlowerTypes(ctx context.Context) error
 }
 
 type pricingRuleRepository struct {
 	DB            *sql.DB
+	mu            sync.RWMutex
 	flowerTypeMap map[string]int
 }
 
-func GetAllFlowerTypes(db *sql.DB) (map[string]int, error) {
-	rows, err := db.Query("SELECT name ,flower_type_id FROM FlowerType")
+func GetAllFlowerTypes(ctx context.Context, db *sql.DB) (map[string]int, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name ,flower_type_id FROM FlowerType")
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +45,7 @@ func GetAllFlowerTypes(db *sql.DB) (map[string]int, error) {
 }
 
 func NewPricingRuleRepository(db *sql.DB) (PricingRuleRepository, error) {
-	flowerTypeMap, err := GetAllFlowerTypes(db)
+	flowerTypeMap, err := GetAllFlowerTypes(context.Background(), db)
 	if err != nil {
 		return nil, err
 	}
@@ -51,9 +56,21 @@ func NewPricingRuleRepository(db *sql.DB) (PricingRuleRepository, error) {
 	}, nil
 }
 
-func (r *pricingRuleRepository) GetActiveRules() ([]model.PricingRule, error) {
-	rows, err := r.DB.Query(`SELECT rule_id, rule_name, priority, adjustment_type, adjustment_value,
-		applicable_product_id, applicable_flower_type_id, applicable_product_status,
+// ReloadFlowerTypes refreshes the flower type lookup after the catalog changes
+func (r *pricingRuleRepository) ReloadFlowerTypes(ctx context.Context) error {
+	flowerTypeMap, err := GetAllFlowerTypes(ctx, r.DB)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.flowerTypeMap = flowerTypeMap
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *pricingRuleRepository) GetActiveRules(ctx context.Context) ([]model.PricingRule, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT rule_id, rule_name, priority, adjustment_type, adjustment_value,
+		applicable_product_id, applicable_variant_id, applicable_flower_type_id, applicable_product_status,
 		time_of_day_start, time_of_day_end, special_day_id,
 		valid_from, valid_to, is_active FROM PricingRule WHERE is_active = true`)
 	if err != nil {
@@ -65,12 +82,21 @@ func (r *pricingRuleRepository) GetActiveRules() ([]model.PricingRule, error) {
 	return rules, err
 }
 
-func (r *pricingRuleRepository) IsRuleApplicable(rule model.PricingRule, product model.Product, now time.Time) bool {
+// IsRuleApplicable reports whether rule applies to product at now. A rule that
+// targets a variant only applies when that variant is being priced; rules
+// without a variant target apply to the product and all of its variants.
+func (r *pricingRuleRepository) IsRuleApplicable(rule model.PricingRule, product model.Product, variant *model.ProductVariant, now time.Time) bool {
 	if rule.ApplicableProductID != nil && *rule.ApplicableProductID != product.ProductID {
 		return false
 	}
 
+	if rule.ApplicableVariantID != nil && (variant == nil || *rule.ApplicableVariantID != variant.VariantID) {
+		return false
+	}
+
+	r.mu.RLock()
 	flowerTypeID, ok := r.flowerTypeMap[product.FlowerType]
+	r.mu.RUnlock()
 	if !ok {
 		return false
 	}
@@ -101,23 +127,24 @@ func (r *pricingRuleRepository) IsRuleApplicable(rule model.PricingRule, product
 	return true
 }
 
-func (r *pricingRuleRepository) CreatePricingRule(rule model.PricingRule) error {
+func (r *pricingRuleRepository) CreatePricingRule(ctx context.Context, rule model.PricingRule) error {
 	query := `
 		INSERT INTO PricingRule (
 			rule_name, priority, adjustment_type, adjustment_value,
-			applicable_product_id, applicable_flower_type_id, applicable_product_status,
+			applicable_product_id, applicable_variant_id, applicable_flower_type_id, applicable_product_status,
 			time_of_day_start, time_of_day_end, special_day_id,
 			valid_from, valid_to, is_active
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := r.DB.Exec(
+	_, err := r.DB.ExecContext(ctx,
 		query,
 		rule.RuleName,
 		rule.Priority,
 		rule.AdjustmentType,
 		rule.AdjustmentValue,
 		nullInt(uintPtrToInt(rule.ApplicableProductID)),
+		nullInt(uintPtrToInt(rule.ApplicableVariantID)),
 		nullInt(rule.ApplicableFlowerTypeID),
 		nullString(rule.ApplicableProductStatus),
 		nullString(rule.TimeOfDayStart),
@@ -131,16 +158,16 @@ func (r *pricingRuleRepository) CreatePricingRule(rule model.PricingRule) error
 	return err
 }
 
-func (r *pricingRuleRepository) GetAllRules() ([]model.PricingRule, error) {
+func (r *pricingRuleRepository) GetAllRules(ctx context.Context) ([]model.PricingRule, error) {
 	query := `
 		SELECT 
 			rule_id, rule_name, priority, adjustment_type, adjustment_value,
-			applicable_product_id, applicable_flower_type_id, applicable_product_status,
+			applicable_product_id, applicable_variant_id, applicable_flower_type_id, applicable_product_status,
 			time_of_day_start, time_of_day_end, special_day_id,
 			valid_from, valid_to, is_active
 		FROM PricingRule
 	`
-	rows, err := r.DB.Query(query)
+	rows, err := r.DB.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -149,15 +176,15 @@ func (r *pricingRuleRepository) GetAllRules() ([]model.PricingRule, error) {
 	return scanRules(rows)
 }
 
-func (r *pricingRuleRepository) UpdateRule(rule model.PricingRule) error {
-	_, err := r.DB.Exec(`
+func (r *pricingRuleRepository) UpdateRule(ctx context.Context, rule model.PricingRule) error {
+	_, err := r.DB.ExecContext(ctx, `
 		UPDATE PricingRule SET 
 			rule_name=?, priority=?, is_active=?, adjustment_type=?, adjustment_value=?,
-			applicable_product_id=?, applicable_flower_type_id=?, applicable_product_status=?,
+			applicable_product_id=?, applicable_variant_id=?, applicable_flower_type_id=?, applicable_product_status=?,
 			time_of_day_start=?, time_of_day_end=?, special_day_id=?, valid_from=?, valid_to=?
 		WHERE rule_id=?`,
 		rule.RuleName, rule.Priority, rule.IsActive, rule.AdjustmentType, rule.AdjustmentValue,
-		rule.ApplicableProductID, rule.ApplicableFlowerTypeID, rule.ApplicableProductStatus,
+		rule.ApplicableProductID, rule.ApplicableVariantID, rule.ApplicableFlowerTypeID, rule.ApplicableProductStatus,
 		rule.TimeOfDayStart, rule.TimeOfDayEnd, rule.SpecialDayID,
 		rule.ValidFrom, rule.ValidTo,
 		rule.RuleID,
@@ -165,8 +192,8 @@ func (r *pricingRuleRepository) UpdateRule(rule model.PricingRule) error {
 	return err
 }
 
-func (r *pricingRuleRepository) DeleteRule(id int) error {
-	_, err := r.DB.Exec("DELETE FROM PricingRule WHERE rule_id = ?", id)
+func (r *pricingRuleRepository) DeleteRule(ctx context.Context, id int) error {
+	_, err := r.DB.ExecContext(ctx, "DELETE FROM PricingRule WHERE rule_id = ?", id)
 	return err
 }
 
@@ -175,12 +202,12 @@ func scanRules(rows *sql.Rows) ([]model.PricingRule, error) {
 	for rows.Next() {
 		var rule model.PricingRule
 		var validFrom, validTo sql.NullTime
-		var prodID, typeID, specialDayID sql.NullInt64
+		var prodID, variantID, typeID, specialDayID sql.NullInt64
 		var status, timeStart, timeEnd sql.NullString
 
 		err := rows.Scan(
 			&rule.RuleID, &rule.RuleName, &rule.Priority, &rule.AdjustmentType,
-			&rule.AdjustmentValue, &prodID, &typeID, &status,
+			&rule.AdjustmentValue, &prodID, &variantID, &typeID, &status,
 			&timeStart, &timeEnd, &specialDayID,
 			&validFrom, &validTo, &rule.IsActive,
 		)
@@ -192,6 +219,10 @@ func scanRules(rows *sql.Rows) ([]model.PricingRule, error) {
 			v := uint(prodID.Int64)
 			rule.ApplicableProductID = &v
 		}
+		if variantID.Valid {
+			v := uint(variantID.Int64)
+			rule.ApplicableVariantID = &v
+		}
 		if typeID.Valid {
 			v := int(typeID.Int64)
 			rule.ApplicableFlowerTypeID = &v
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/model"
+)
+
+// priceBuckets is how many buckets the price histogram aims for
+const priceBuckets = 5
+
+// GetSearchFacets counts the products matching query per flower type,
+// occasion and status, and buckets their prices. Every facet ignores its own
+// filter so that multi-select facets keep showing the other values.
+func (r *repository) GetSearchFacets(ctx context.Context, query *dto.ProductSearchQuery) (*model.SearchFacets, error) {
+	facets := &model.SearchFacets{}
+	var err error
+
+	facets.FlowerTypes, err = r.countFacet(ctx, query, facetFlowerType, "ft.name", "", query.FlowerType)
+	if err != nil {
+		return nil, err
+	}
+
+	facets.Occasions, err = r.countFacet(ctx, query, facetOccasion, "oc.name",
+		" JOIN ProductOccasion po ON po.product_id = fp.product_id JOIN Occasion oc ON po.occasion_id = oc.occasion_id",
+		query.Occasion)
+	if err != nil {
+		return nil, err
+	}
+
+	facets.Statuses, err = r.countFacet(ctx, query, facetStatus, "fp.status", "", query.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	facets.PriceHistogram, err = r.priceHistogram(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
+
+// countFacet groups the products matching every filter but skip by column.
+// Selected values are always listed, even when nothing matches them.
+func (r *repository) countFacet(ctx context.Context, query *dto.ProductSearchQuery, skip, column, join string, selected []string) ([]model.FacetCount, error) {
+	conditions, args := buildSearchConditions(query, skip)
+	sqlQuery := "SELECT " + column + ", COUNT(DISTINCT fp.product_id)" + searchFromClause + join +
+		" WHERE " + strings.Join(conditions, " AND ") +
+		" GROUP BY " + column + " ORDER BY COUNT(DISTINCT fp.product_id) DESC, " + column
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	isSelected := make(map[string]bool, len(selected))
+	for _, v := range selected {
+		isSelected[v] = true
+	}
+
+	counts := []model.FacetCount{}
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var fc model.FacetCount
+		if err := rows.Scan(&fc.Value, &fc.Count); err != nil {
+			return nil, err
+		}
+		fc.Selected = isSelected[fc.Value]
+		seen[fc.Value] = true
+		counts = append(counts, fc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, v := range selected {
+		if !seen[v] {
+			seen[v] = true
+			counts = append(counts, model.FacetCount{Value: v, Count: 0, Selected: true})
+		}
+	}
+	return counts, nil
+}
+
+// priceHistogram buckets the effective prices of the products matching
+// every filter but the price range into equal buckets with round boundaries
+func (r *repository) priceHistogram(ctx context.Context, query *dto.ProductSearchQuery) ([]model.PriceBucket, error) {
+	conditions, args := buildSearchConditions(query, facetPrice)
+	whereClause := " WHERE " + strings.Join(conditions, " AND ")
+
+	var minPrice, maxPrice *float64
+	err := r.db.QueryRowContext(ctx, "SELECT MIN("+effectivePriceExpr+"), MAX("+effectivePriceExpr+")"+searchFromClause+whereClause, args...).
+		Scan(&minPrice, &maxPrice)
+	if err != nil {
+		return nil, err
+	}
+	if minPrice == nil || maxPrice == nil {
+		return []model.PriceBucket{}, nil
+	}
+
+	width := niceBucketWidth((*maxPrice - *minPrice) / priceBuckets)
+	start := math.Floor(*minPrice/width) * width
+	n := int(math.Floor((*maxPrice-start)/width)) + 1
+
+	buckets := make([]model.PriceBucket, n)
+	for i := range buckets {
+		buckets[i].Min = start + float64(i)*width
+		buckets[i].Max = start + float64(i+1)*width
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT FLOOR(("+effectivePriceExpr+" - ?) / ?) AS bucket, COUNT(*)"+searchFromClause+whereClause+
+		" GROUP BY bucket", append([]interface{}{start, width}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket, count int
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, err
+		}
+		if bucket < 0 {
+			bucket = 0
+		}
+		if bucket >= n {
+			bucket = n - 1
+		}
+		buckets[bucket].Count += count
+	}
+	return buckets, rows.Err()
+}
+
+// niceBucketWidth rounds raw up to 1, 2, 2.5 or 5 times a power of ten
+func niceBucketWidth(raw float64) float64 {
+	if raw <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, step := range []float64{1, 2, 2.5, 5, 10} {
+		if width := step * magnitude; width >= raw {
+			return width
+		}
+	}
+	return 10 * magnitude
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Rolling windows the units sold are counted over; sales rank uses the 30 day window
+const (
+	salesWindowShort = 7 * 24 * time.Hour
+	salesWindowLong  = 30 * 24 * time.Hour
+)
+
+type ProductStatsRepository interface {
+	RefreshRatingStats(ctx context.Context, productID int) error
+	RefreshSalesStats(ctx context.Context, productIDs []int, now time.Time) error
+	GetOrderProductIDs(ctx context.Context, orderID int) ([]int, error)
+	RebuildProductStats(ctx context.Context, now time.Time) error
+}
+
+type productStatsRepository struct {
+	DB *sql.DB
+}
+
+func NewProductStatsRepository(db *sql.DB) ProductStatsRepository {
+	return &productStatsRepository{DB: db}
+}
+
+const ratingStatsQuery = `
+	INSERT INTO ProductStats (product_id, average_rating, review_count)
+	SELECT fp.product_id, COALESCE(AVG(r.rating), 0), COUNT(r.review_id)
+	FROM FlowerProduct fp
+	LEFT JOIN Review r ON r.product_id = fp.product_id AND r.status = 'approved'`
+
+const salesStatsQuery = `
+	INSERT INTO ProductStats (product_id, units_sold_7d, units_sold_30d, units_sold_total)
+	SELECT fp.product_id,
+		COALESCE(SUM(CASE WHEN sold.order_date >= ? THEN sold.quantity END), 0),
+		COALESCE(SUM(CASE WHEN sold.order_date >= ? THEN sold.quantity END), 0),
+		COALESCE(SUM(sold.quantity), 0)
+	FROM FlowerProduct fp
+	LEFT JOIN (
+		SELECT oi.product_id, oi.quantity, o.order_date
+		FROM OrderItem oi
+		JOIN ` + "`Order`" + ` o ON oi.order_id = o.order_id
+		WHERE o.status = 'Completed'
+	) sold ON sold.product_id = fp.product_id`
+
+// RefreshRatingStats recomputes the average rating and review count of one product
+func (r *productStatsRepository) RefreshRatingStats(ctx context.Context, productID int) error {
+	_, err := r.DB.ExecContext(ctx, ratingStatsQuery+`
+		WHERE fp.product_id = ?
+		GROUP BY fp.product_id
+		ON DUPLICATE KEY UPDATE average_rating = VALUES(average_rating), review_count = VALUES(review_count)`,
+		productID)
+	return err
+}
+
+// RefreshSalesStats recomputes the units sold of the given products and then
+// re-ranks every product, since their sales move the others' ranks
+func (r *productStatsRepository) RefreshSalesStats(ctx context.Context, productIDs []int, now time.Time) (err error) {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	args := []interface{}{now.Add(-salesWindowShort), now.Add(-salesWindowLong)}
+	for _, id := range productIDs {
+		args = append(args, id)
+	}
+	if _, err = tx.ExecContext(ctx, salesStatsQuery+`
+		WHERE fp.product_id IN (`+placeholders(len(productIDs))+`)
+		GROUP BY fp.product_id
+		ON DUPLICATE KEY UPDATE units_sold_7d = VALUES(units_sold_7d),
+			units_sold_30d = VALUES(units_sold_30d), units_sold_total = VALUES(units_sold_total)`,
+		args...); err != nil {
+		return err
+	}
+
+	err = rankSales(ctx, tx)
+	return err
+}
+
+func (r *productStatsRepository) GetOrderProductIDs(ctx context.Context, orderID int) ([]int, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT DISTINCT product_id FROM OrderItem WHERE order_id = ?", orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// RebuildProductStats recomputes every product's aggregates; run periodically
+// so that the rolling sales windows move on even when nothing is sold
+func (r *productStatsRepository) RebuildProductStats(ctx context.Context, now time.Time) (err error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, ratingStatsQuery+`
+		GROUP BY fp.product_id
+		ON DUPLICATE KEY UPDATE average_rating = VALUES(average_rating), review_count = VALUES(review_count)`); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, salesStatsQuery+`
+		GROUP BY fp.product_id
+		ON DUPLICATE KEY UPDATE units_sold_7d = VALUES(units_sold_7d),
+			units_sold_30d = VALUES(units_sold_30d), units_sold_total = VALUES(units_sold_total)`,
+		now.Add(-salesWindowShort), now.Add(-salesWindowLong)); err != nil {
+		return err
+	}
+
+	err = rankSales(ctx, tx)
+	return err
+}
+
+// rankSales numbers products by units sold in the last 30 days, ties broken
+// by all-time sales; products that never sold have no rank
+func rankSales(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE ProductStats ps
+		LEFT JOIN (
+			SELECT product_id,
+				ROW_NUMBER() OVER (ORDER BY units_sold_30d DESC, units_sold_total DESC, product_id ASC) AS sales_rank
+			FROM ProductStats
+			WHERE units_sold_total > 0
+		) ranked ON ranked.product_id = ps.product_id
+		SET ps.sales_rank = ranked.sales_rank`)
+	return err
+}
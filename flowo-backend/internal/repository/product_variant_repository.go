@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/model"
+)
+
+const variantColumns = `variant_id, product_id, sku, COALESCE(name, ''), COALESCE(size, ''), COALESCE(color, ''),
+		COALESCE(wrapping, ''), price_delta, stock_quantity, is_active, created_at, updated_at`
+
+func (r *repository) GetProductVariants(ctx context.Context, productID uint) ([]model.ProductVariant, error) {
+	query := "SELECT " + variantColumns + " FROM ProductVariant WHERE product_id = ? AND is_active = TRUE ORDER BY price_delta ASC, variant_id ASC"
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanVariants(rows)
+}
+
+func (r *repository) GetVariantByID(ctx context.Context, variantID uint) (*model.ProductVariant, error) {
+	query := "SELECT " + variantColumns + " FROM ProductVariant WHERE variant_id = ? AND is_active = TRUE"
+	row := r.db.QueryRowContext(ctx, query, variantID)
+
+	var v model.ProductVariant
+	if err := row.Scan(&v.VariantID, &v.ProductID, &v.SKU, &v.Name, &v.Size, &v.Color,
+		&v.Wrapping, &v.PriceDelta, &v.StockQuantity, &v.IsActive, &v.CreatedAt, &v.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("variant not found")
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *repository) GetVariantsByIDs(ctx context.Context, ids []int) (map[int]model.ProductVariant, error) {
+	if len(ids) == 0 {
+		return map[int]model.ProductVariant{}, nil
+	}
+
+	placeholders := "?" + strings.Repeat(",?", len(ids)-1)
+	query := "SELECT " + variantColumns + " FROM ProductVariant WHERE variant_id IN (" + placeholders + ") AND is_active = TRUE"
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants, err := scanVariants(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]model.ProductVariant, len(variants))
+	for _, v := range variants {
+		result[int(v.VariantID)] = v
+	}
+	return result, nil
+}
+
+func (r *repository) CreateVariant(ctx context.Context, variant *model.ProductVariant, actor string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			tx.Commit()
+		}
+	}()
+
+	query := `INSERT INTO ProductVariant (product_id, sku, name, size, color, wrapping, price_delta, stock_quantity)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := tx.ExecContext(ctx, query, variant.ProductID, variant.SKU, variant.Name, variant.Size,
+		variant.Color, variant.Wrapping, variant.PriceDelta, variant.StockQuantity)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	variant.VariantID = uint(id)
+	variant.IsActive = true
+
+	if variant.StockQuantity > 0 {
+		variantID := int(variant.VariantID)
+		_, err = recordMovement(ctx, tx, model.InventoryMovement{
+			ProductID:      int(variant.ProductID),
+			VariantID:      &variantID,
+			MovementType:   model.MovementReceipt,
+			QuantityChange: variant.StockQuantity,
+			Actor:          actor,
+			Reason:         "initial stock",
+		})
+	}
+	return err
+}
+
+func (r *repository) UpdateVariant(ctx context.Context, variantID uint, variant *dto.ProductVariantCreate) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			tx.Commit()
+		}
+	}()
+
+	var productID, currentStock int
+	err = tx.QueryRowContext(ctx, "SELECT product_id, stock_quantity FROM ProductVariant WHERE variant_id = ? FOR UPDATE", variantID).Scan(&productID, &currentStock)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE ProductVariant SET sku = ?, name = ?, size = ?, color = ?, wrapping = ?,
+		price_delta = ?, stock_quantity = ? WHERE variant_id = ?`
+	_, err = tx.ExecContext(ctx, query, variant.SKU, variant.Name, variant.Size, variant.Color,
+		variant.Wrapping, variant.PriceDelta, variant.StockQuantity, variantID)
+	if err != nil {
+		return err
+	}
+
+	if change := variant.StockQuantity - currentStock; change != 0 {
+		id := int(variantID)
+		_, err = recordMovement(ctx, tx, model.InventoryMovement{
+			ProductID:      productID,
+			VariantID:      &id,
+			MovementType:   model.MovementManualAdjustment,
+			QuantityChange: change,
+			Actor:          variant.Actor,
+			Reason:         variant.StockReason,
+		})
+	}
+	return err
+}
+
+func (r *repository) DeleteVariant(ctx context.Context, variantID uint) error {
+	query := "UPDATE ProductVariant SET is_active = FALSE WHERE variant_id = ?"
+	_, err := r.db.ExecContext(ctx, query, variantID)
+	return err
+}
+
+// buildVariantCondition returns an EXISTS clause matching products that have an
+// active variant with all requested size/color/wrapping attributes.
+func buildVariantCondition(query *dto.ProductSearchQuery) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if query.Size != "" {
+		conditions = append(conditions, "pv.size = ?")
+		args = append(args, query.Size)
+	}
+	if query.Color != "" {
+		conditions = append(conditions, "pv.color = ?")
+		args = append(args, query.Color)
+	}
+	if query.Wrapping != "" {
+		conditions = append(conditions, "pv.wrapping = ?")
+		args = append(args, query.Wrapping)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+
+	return "EXISTS (SELECT 1 FROM ProductVariant pv WHERE pv.product_id = fp.product_id AND pv.is_active = TRUE AND " +
+		strings.Join(conditions, " AND ") + ")", args
+}
+
+func scanVariants(rows *sql.Rows) ([]model.ProductVariant, error) {
+	var variants []model.ProductVariant
+	for rows.Next() {
+		var v model.ProductVariant
+		if err := rows.Scan(&v.VariantID, &v.ProductID, &v.SKU, &v.Name, &v.Size, &v.Color,
+			&v.Wrapping, &v.PriceDelta, &v.StockQuantity, &v.IsActive, &v.CreatedAt, &v.UpdatedAt); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+	return variants, rows.Err()
+}
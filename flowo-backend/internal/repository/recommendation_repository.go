@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"context"
+
 	"database/sql"
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/model"
 	"fmt"
 	"strings"
@@ -9,36 +12,61 @@ import (
 )
 
 type RecommendationRepository interface {
+	// Engine configuration
+	GetRecommendationConfig(ctx context.Context) (*model.RecommendationConfig, error)
+	SaveRecommendationConfig(ctx context.Context, config *model.RecommendationConfig) error
+
 	// User interactions and preferences
-	GetUserInteractions(firebaseUID string) ([]model.UserInteractionSummary, error)
-	GetUserPreferences(firebaseUID string) (*model.UserPreference, error)
-	SaveUserPreferences(pref *model.UserPreference) error
+	GetUserInteractions(ctx context.Context, firebaseUID string) ([]model.UserInteractionSummary, error)
+	GetUserPreferences(ctx context.Context, firebaseUID string) (*model.UserPreference, error)
+	SaveUserPreferences(ctx context.Context, pref *model.UserPreference) error
+	GetPreferenceSignals(ctx context.Context, firebaseUID string, since, until time.Time) ([]model.PreferenceSignal, error)
+	GetUsersWithSignalsSince(ctx context.Context, since time.Time) ([]string, error)
+	GetProductOccasionNames(ctx context.Context, productIDs []uint) (map[uint][]string, error)
 
 	// Product similarities
-	GetProductSimilarities(productID uint, limit int) ([]model.ProductSimilarity, error)
-	SaveProductSimilarity(similarity *model.ProductSimilarity) error
+	GetProductSimilarities(ctx context.Context, productID uint, similarityType string, minScore float64, limit int) ([]model.ProductSimilarity, error)
+	SaveProductSimilarity(ctx context.Context, similarity *model.ProductSimilarity) error
+	SaveProductSimilarities(ctx context.Context, similarities []model.ProductSimilarity) error
+	ReplaceProductSimilarities(ctx context.Context, types []string, productIDs []uint, similarities []model.ProductSimilarity) error
+	GetItemSignals(ctx context.Context) ([]model.ItemSignal, error)
+	GetProductsWithActivitySince(ctx context.Context, viewsSince, ordersSince time.Time) ([]uint, error)
+
+	// Market basket analysis
+	GetAssociationRules(ctx context.Context, antecedentIDs []uint, minPairCount int) ([]model.AssociationRule, error)
 
 	// Trending data
-	GetTrendingProducts(period string, limit int) ([]model.TrendingProduct, error)
-	UpdateTrendingProducts(products []model.TrendingProduct) error
+	GetTrendingProducts(ctx context.Context, period string, limit int) ([]model.TrendingProduct, error)
+	GetProductActivity(ctx context.Context, since time.Time) ([]model.ProductActivity, error)
+	ReplaceTrendingProducts(ctx context.Context, period string, products []model.TrendingProduct) error
 
 	// User behavior tracking
-	GetUserPurchaseHistory(firebaseUID string) ([]model.Product, error)
-	GetUserCartHistory(firebaseUID string) ([]model.Product, error)
-	GetUserViewHistory(firebaseUID string, limit int) ([]model.Product, error)
+	SaveInteraction(ctx context.Context, firebaseUID, sessionID string, productID uint, interactionType string) error
+	GetUserPurchaseHistory(ctx context.Context, firebaseUID string) ([]model.Product, error)
+	GetUserCartHistory(ctx context.Context, firebaseUID string) ([]model.Product, error)
+	GetUserViewHistory(ctx context.Context, firebaseUID string, limit int) ([]model.Product, error)
 
 	// Collaborative filtering data
-	GetSimilarUsers(firebaseUID string, limit int) ([]string, error)
-	GetUsersWhoAlsoBought(productID uint, limit int) ([]string, error)
+	GetSimilarUsers(ctx context.Context, firebaseUID string, limit int) ([]string, error)
+	GetUsersWhoAlsoBought(ctx context.Context, productID uint, limit int) ([]string, error)
 
 	// Content-based filtering data
-	GetProductsByFlowerType(flowerType string, excludeProductID uint, limit int) ([]model.Product, error)
-	GetProductsByOccasion(occasion string, excludeProductID uint, limit int) ([]model.Product, error)
-	GetProductsByPriceRange(minPrice, maxPrice float64, excludeProductID uint, limit int) ([]model.Product, error)
+	GetProductsByFlowerType(ctx context.Context, flowerType string, excludeProductID uint, limit int) ([]model.Product, error)
+	GetProductsByOccasion(ctx context.Context, occasion string, excludeProductID uint, limit int) ([]model.Product, error)
+	GetProductsByPriceRange(ctx context.Context, minPrice, maxPrice float64, excludeProductID uint, limit int) ([]model.Product, error)
 
 	// Analytics and feedback
-	SaveRecommendationFeedback(firebaseUID string, productID uint, recommendationType, action string) error
-	GetRecommendationStats(period string) (map[string]interface{}, error)
+	SaveRecommendationFeedback(ctx context.Context, feedback model.RecommendationFeedback) error
+	SaveRecommendationImpression(ctx context.Context, impression model.RecommendationImpression) error
+	GetRecommendationStats(ctx context.Context, since time.Time) ([]model.RecommendationTypeStats, error)
+
+	// A/B experiments
+	GetExperiments(ctx context.Context) ([]model.RecommendationExperiment, error)
+	GetExperimentByID(ctx context.Context, experimentID uint) (*model.RecommendationExperiment, error)
+	GetRunningExperiment(ctx context.Context) (*model.RecommendationExperiment, error)
+	CreateExperiment(ctx context.Context, experiment *model.RecommendationExperiment) error
+	SetExperimentStatus(ctx context.Context, experimentID uint, status string) error
+	GetVariantStats(ctx context.Context, experimentID uint) ([]model.VariantStats, error)
 }
 
 type recommendationRepository struct {
@@ -49,8 +77,51 @@ func NewRecommendationRepository(db *sql.DB) RecommendationRepository {
 	return &recommendationRepository{db: db}
 }
 
+// GetRecommendationConfig loads the stored engine configuration; nil when
+// none has been stored. DefaultLimit and CacheDuration are not stored.
+func (r *recommendationRepository) GetRecommendationConfig(ctx context.Context) (*model.RecommendationConfig, error) {
+	query := `SELECT collaborative_weight, content_weight, popularity_weight, trending_weight,
+			  min_similarity, min_interactions, max_per_flower_type, COALESCE(updated_by, ''), updated_at
+			  FROM RecommendationConfig WHERE config_id = 1`
+
+	var config model.RecommendationConfig
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, query).Scan(&config.CollaborativeWeight, &config.ContentWeight,
+		&config.PopularityWeight, &config.TrendingWeight, &config.MinSimilarity,
+		&config.MinInteractions, &config.MaxPerFlowerType, &config.UpdatedBy, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	config.UpdatedAt = &updatedAt
+	return &config, nil
+}
+
+// SaveRecommendationConfig stores the engine configuration
+func (r *recommendationRepository) SaveRecommendationConfig(ctx context.Context, config *model.RecommendationConfig) error {
+	query := `INSERT INTO RecommendationConfig (config_id, collaborative_weight, content_weight,
+			  popularity_weight, trending_weight, min_similarity, min_interactions, max_per_flower_type, updated_by)
+			  VALUES (1, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ON DUPLICATE KEY UPDATE
+			  collaborative_weight = VALUES(collaborative_weight),
+			  content_weight = VALUES(content_weight),
+			  popularity_weight = VALUES(popularity_weight),
+			  trending_weight = VALUES(trending_weight),
+			  min_similarity = VALUES(min_similarity),
+			  min_interactions = VALUES(min_interactions),
+			  max_per_flower_type = VALUES(max_per_flower_type),
+			  updated_by = VALUES(updated_by)`
+
+	_, err := r.db.ExecContext(ctx, query, config.CollaborativeWeight, config.ContentWeight, config.PopularityWeight,
+		config.TrendingWeight, config.MinSimilarity, config.MinInteractions, config.MaxPerFlowerType,
+		emptyToNull(config.UpdatedBy))
+	return err
+}
+
 // GetUserInteractions retrieves aggregated user interactions
-func (r *recommendationRepository) GetUserInteractions(firebaseUID string) ([]model.UserInteractionSummary, error) {
+func (r *recommendationRepository) GetUserInteractions(ctx context.Context, firebaseUID string) ([]model.UserInteractionSummary, error) {
 	query := `
 		SELECT 
 			upi.product_id,
@@ -71,7 +142,7 @@ func (r *recommendationRepository) GetUserInteractions(firebaseUID string) ([]mo
 		LEFT JOIN (
 			SELECT product_id, SUM(rating) as total_rating, COUNT(*) as review_count
 			FROM Review
-			WHERE firebase_uid = ?
+			WHERE firebase_uid = ? AND status <> 'rejected'
 			GROUP BY product_id
 		) review_data ON upi.product_id = review_data.product_id
 		WHERE upi.firebase_uid = ?
@@ -84,7 +155,7 @@ func (r *recommendationRepository) GetUserInteractions(firebaseUID string) ([]mo
 			COALESCE(review_data.review_count, 0) * 0.5
 		) DESC`
 
-	rows, err := r.db.Query(query, firebaseUID, firebaseUID, firebaseUID)
+	rows, err := r.db.QueryContext(ctx, query, firebaseUID, firebaseUID, firebaseUID)
 	if err != nil {
 		return nil, err
 	}
@@ -121,15 +192,20 @@ func (r *recommendationRepository) GetUserInteractions(firebaseUID string) ([]mo
 }
 
 // GetUserPreferences retrieves user preferences
-func (r *recommendationRepository) GetUserPreferences(firebaseUID string) (*model.UserPreference, error) {
-	query := `SELECT firebase_uid, flower_preferences, occasion_preferences, price_min, price_max, average_spent, last_updated 
+func (r *recommendationRepository) GetUserPreferences(ctx context.Context, firebaseUID string) (*model.UserPreference, error) {
+	query := `SELECT firebase_uid, COALESCE(flower_preferences, '{}'), COALESCE(occasion_preferences, '{}'),
+			  price_min, price_max, average_spent, last_updated,
+			  COALESCE(flower_scores, '{}'), COALESCE(occasion_scores, '{}'),
+			  price_weight, price_sum, price_square_sum, learned_at, learned_from
 			  FROM UserPreference WHERE firebase_uid = ?`
 
-	row := r.db.QueryRow(query, firebaseUID)
+	row := r.db.QueryRowContext(ctx, query, firebaseUID)
 
 	var pref model.UserPreference
 	err := row.Scan(&pref.FirebaseUID, &pref.FlowerPreferences, &pref.OccasionPreferences,
-		&pref.PriceMin, &pref.PriceMax, &pref.AverageSpent, &pref.LastUpdated)
+		&pref.PriceMin, &pref.PriceMax, &pref.AverageSpent, &pref.LastUpdated,
+		&pref.FlowerScores, &pref.OccasionScores, &pref.PriceWeight, &pref.PriceSum,
+		&pref.PriceSquareSum, &pref.LearnedAt, &pref.LearnedFrom)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -142,32 +218,160 @@ func (r *recommendationRepository) GetUserPreferences(firebaseUID string) (*mode
 }
 
 // SaveUserPreferences saves or updates user preferences
-func (r *recommendationRepository) SaveUserPreferences(pref *model.UserPreference) error {
-	query := `INSERT INTO UserPreference (firebase_uid, flower_preferences, occasion_preferences, price_min, price_max, average_spent, last_updated)
-			  VALUES (?, ?, ?, ?, ?, ?, ?)
+func (r *recommendationRepository) SaveUserPreferences(ctx context.Context, pref *model.UserPreference) error {
+	query := `INSERT INTO UserPreference (firebase_uid, flower_preferences, occasion_preferences, price_min, price_max, average_spent, last_updated,
+			  flower_scores, occasion_scores, price_weight, price_sum, price_square_sum, learned_at, learned_from)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 			  ON DUPLICATE KEY UPDATE
 			  flower_preferences = VALUES(flower_preferences),
 			  occasion_preferences = VALUES(occasion_preferences),
 			  price_min = VALUES(price_min),
 			  price_max = VALUES(price_max),
 			  average_spent = VALUES(average_spent),
-			  last_updated = VALUES(last_updated)`
-
-	_, err := r.db.Exec(query, pref.FirebaseUID, pref.FlowerPreferences, pref.OccasionPreferences,
-		pref.PriceMin, pref.PriceMax, pref.AverageSpent, time.Now())
+			  last_updated = VALUES(last_updated),
+			  flower_scores = VALUES(flower_scores),
+			  occasion_scores = VALUES(occasion_scores),
+			  price_weight = VALUES(price_weight),
+			  price_sum = VALUES(price_sum),
+			  price_square_sum = VALUES(price_square_sum),
+			  learned_at = VALUES(learned_at),
+			  learned_from = VALUES(learned_from)`
+
+	_, err := r.db.ExecContext(ctx, query, pref.FirebaseUID, pref.FlowerPreferences, pref.OccasionPreferences,
+		pref.PriceMin, pref.PriceMax, pref.AverageSpent, time.Now(),
+		emptyToNull(pref.FlowerScores), emptyToNull(pref.OccasionScores), pref.PriceWeight,
+		pref.PriceSum, pref.PriceSquareSum, nullTime(pref.LearnedAt), nullTime(pref.LearnedFrom))
 
 	return err
 }
 
+// GetPreferenceSignals returns what the user did with products in
+// (since, until]: items of orders that were not cancelled or refunded,
+// reviews that were not rejected, cart adds, and feedback on recommendations
+func (r *recommendationRepository) GetPreferenceSignals(ctx context.Context, firebaseUID string, since, until time.Time) ([]model.PreferenceSignal, error) {
+	query := `
+		SELECT s.kind, s.product_id, ft.name, s.at, s.quantity, s.unit_price, s.rating, s.action
+		FROM (
+			SELECT 'purchase' AS kind, oi.product_id, o.order_date AS at, oi.quantity,
+				oi.price_per_unit_at_purchase AS unit_price, 0 AS rating, '' AS action
+			FROM OrderItem oi
+			JOIN ` + "`Order`" + ` o ON oi.order_id = o.order_id
+			WHERE o.firebase_uid = ? AND o.order_date > ? AND o.order_date <= ?
+				AND o.status NOT IN ('Cancelled', 'PaymentFailed', 'Refunded')
+			UNION ALL
+			SELECT 'review', product_id, review_date, 0, 0, rating, ''
+			FROM Review
+			WHERE firebase_uid = ? AND review_date > ? AND review_date <= ? AND status <> 'rejected'
+			UNION ALL
+			SELECT 'add_to_cart', product_id, timestamp, 0, 0, 0, ''
+			FROM UserProductInteraction
+			WHERE firebase_uid = ? AND interaction_type = 'add_to_cart' AND timestamp > ? AND timestamp <= ?
+			UNION ALL
+			SELECT 'feedback', product_id, created_at, 0, 0, 0, action
+			FROM RecommendationFeedback
+			WHERE firebase_uid = ? AND created_at > ? AND created_at <= ?
+		) s
+		JOIN FlowerProduct fp ON fp.product_id = s.product_id
+		JOIN FlowerType ft ON ft.flower_type_id = fp.flower_type_id`
+
+	args := make([]interface{}, 0, 12)
+	for i := 0; i < 4; i++ {
+		args = append(args, firebaseUID, since, until)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var signals []model.PreferenceSignal
+	for rows.Next() {
+		var sig model.PreferenceSignal
+		var unitPrice sql.NullFloat64
+		var quantity, rating sql.NullInt64
+		if err := rows.Scan(&sig.Kind, &sig.ProductID, &sig.FlowerType, &sig.At, &quantity,
+			&unitPrice, &rating, &sig.Action); err != nil {
+			return nil, err
+		}
+		sig.Quantity = int(quantity.Int64)
+		sig.UnitPrice = unitPrice.Float64
+		sig.Rating = int(rating.Int64)
+		signals = append(signals, sig)
+	}
+	return signals, rows.Err()
+}
+
+// GetUsersWithSignalsSince returns the users who ordered, reviewed, added to
+// cart or gave recommendation feedback since the given time
+func (r *recommendationRepository) GetUsersWithSignalsSince(ctx context.Context, since time.Time) ([]string, error) {
+	query := `
+		SELECT firebase_uid FROM ` + "`Order`" + ` WHERE order_date > ? AND firebase_uid IS NOT NULL
+		UNION
+		SELECT firebase_uid FROM Review WHERE review_date > ? AND firebase_uid IS NOT NULL
+		UNION
+		SELECT firebase_uid FROM UserProductInteraction
+		WHERE interaction_type = 'add_to_cart' AND timestamp > ? AND firebase_uid IS NOT NULL
+		UNION
+		SELECT firebase_uid FROM RecommendationFeedback WHERE created_at > ? AND firebase_uid IS NOT NULL`
+
+	rows, err := r.db.QueryContext(ctx, query, since, since, since, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, err
+		}
+		users = append(users, uid)
+	}
+	return users, rows.Err()
+}
+
+// GetProductOccasionNames returns the occasion names of each product
+func (r *recommendationRepository) GetProductOccasionNames(ctx context.Context, productIDs []uint) (map[uint][]string, error) {
+	occasions := make(map[uint][]string)
+	if len(productIDs) == 0 {
+		return occasions, nil
+	}
+
+	ids := make([]interface{}, len(productIDs))
+	for i, id := range productIDs {
+		ids[i] = id
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT po.product_id, o.name
+		FROM ProductOccasion po
+		JOIN Occasion o ON o.occasion_id = po.occasion_id
+		WHERE po.product_id IN (`+placeholders(len(ids))+`)`, ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID uint
+		var name string
+		if err := rows.Scan(&productID, &name); err != nil {
+			return nil, err
+		}
+		occasions[productID] = append(occasions[productID], name)
+	}
+	return occasions, rows.Err()
+}
+
 // GetProductSimilarities retrieves similar products
-func (r *recommendationRepository) GetProductSimilarities(productID uint, limit int) ([]model.ProductSimilarity, error) {
+func (r *recommendationRepository) GetProductSimilarities(ctx context.Context, productID uint, similarityType string, minScore float64, limit int) ([]model.ProductSimilarity, error) {
 	query := `SELECT product_id_1, product_id_2, similarity_score, similarity_type, updated_at
 			  FROM ProductSimilarity 
-			  WHERE (product_id_1 = ? OR product_id_2 = ?) AND similarity_score >= 0.1
+			  WHERE (product_id_1 = ? OR product_id_2 = ?) AND similarity_type = ? AND similarity_score >= ?
 			  ORDER BY similarity_score DESC
 			  LIMIT ?`
 
-	rows, err := r.db.Query(query, productID, productID, limit)
+	rows, err := r.db.QueryContext(ctx, query, productID, productID, similarityType, minScore, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -187,7 +391,7 @@ func (r *recommendationRepository) GetProductSimilarities(productID uint, limit
 }
 
 // SaveProductSimilarity saves product similarity data
-func (r *recommendationRepository) SaveProductSimilarity(similarity *model.ProductSimilarity) error {
+func (r *recommendationRepository) SaveProductSimilarity(ctx context.Context, similarity *model.ProductSimilarity) error {
 	query := `INSERT INTO ProductSimilarity (product_id_1, product_id_2, similarity_score, similarity_type, updated_at)
 			  VALUES (?, ?, ?, ?, ?)
 			  ON DUPLICATE KEY UPDATE
@@ -195,21 +399,239 @@ func (r *recommendationRepository) SaveProductSimilarity(similarity *model.Produ
 			  similarity_type = VALUES(similarity_type),
 			  updated_at = VALUES(updated_at)`
 
-	_, err := r.db.Exec(query, similarity.ProductID1, similarity.ProductID2,
+	_, err := r.db.ExecContext(ctx, query, similarity.ProductID1, similarity.ProductID2,
 		similarity.SimilarityScore, similarity.SimilarityType, time.Now())
 
 	return err
 }
 
+// SaveProductSimilarities upserts similarity rows in bulk
+func (r *recommendationRepository) SaveProductSimilarities(ctx context.Context, similarities []model.ProductSimilarity) error {
+	return insertSimilarities(ctx, r.db, similarities)
+}
+
+// ReplaceProductSimilarities swaps the rows of the given similarity types
+// that involve any of productIDs, or all of them when productIDs is nil,
+// for the given rows
+func (r *recommendationRepository) ReplaceProductSimilarities(ctx context.Context, types []string, productIDs []uint, similarities []model.ProductSimilarity) (err error) {
+	if productIDs != nil && len(productIDs) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	args := make([]interface{}, 0, len(types)+2*len(productIDs))
+	for _, t := range types {
+		args = append(args, t)
+	}
+	query := `DELETE FROM ProductSimilarity WHERE similarity_type IN (` + placeholders(len(types)) + `)`
+	if productIDs != nil {
+		ids := make([]interface{}, len(productIDs))
+		for i, id := range productIDs {
+			ids[i] = id
+		}
+		query += ` AND (product_id_1 IN (` + placeholders(len(ids)) + `) OR product_id_2 IN (` + placeholders(len(ids)) + `))`
+		args = append(args, ids...)
+		args = append(args, ids...)
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+	return insertSimilarities(ctx, tx, similarities)
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertSimilarities upserts similarity rows in chunks
+func insertSimilarities(ctx context.Context, db execer, similarities []model.ProductSimilarity) error {
+	const chunkSize = 500
+	for start := 0; start < len(similarities); start += chunkSize {
+		end := start + chunkSize
+		if end > len(similarities) {
+			end = len(similarities)
+		}
+
+		valueStrings := make([]string, 0, end-start)
+		valueArgs := make([]interface{}, 0, (end-start)*4)
+		for _, sim := range similarities[start:end] {
+			valueStrings = append(valueStrings, "(?, ?, ?, ?)")
+			valueArgs = append(valueArgs, sim.ProductID1, sim.ProductID2, sim.SimilarityScore, sim.SimilarityType)
+		}
+
+		query := fmt.Sprintf(`INSERT INTO ProductSimilarity (product_id_1, product_id_2, similarity_score, similarity_type)
+							  VALUES %s
+							  ON DUPLICATE KEY UPDATE
+							  similarity_score = VALUES(similarity_score),
+							  updated_at = CURRENT_TIMESTAMP`, strings.Join(valueStrings, ","))
+		if _, err := db.ExecContext(ctx, query, valueArgs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetItemSignals returns, per user and product, the units bought in
+// completed orders and the number of views. Anonymous views are keyed by
+// session so they still link the products viewed together.
+func (r *recommendationRepository) GetItemSignals(ctx context.Context) ([]model.ItemSignal, error) {
+	query := `
+		SELECT user_key, product_id, SUM(units_sold), SUM(views)
+		FROM (
+			SELECT o.firebase_uid AS user_key, oi.product_id, SUM(oi.quantity) AS units_sold, 0 AS views
+			FROM OrderItem oi
+			JOIN ` + "`Order`" + ` o ON oi.order_id = o.order_id
+			WHERE o.status = 'Completed' AND o.firebase_uid IS NOT NULL
+			GROUP BY o.firebase_uid, oi.product_id
+			UNION ALL
+			SELECT COALESCE(firebase_uid, CONCAT('session:', session_id)) AS user_key, product_id,
+				0 AS units_sold, COUNT(*) AS views
+			FROM UserProductInteraction
+			WHERE interaction_type = 'view' AND product_id IS NOT NULL
+				AND (firebase_uid IS NOT NULL OR session_id IS NOT NULL)
+			GROUP BY user_key, product_id
+		) signals
+		GROUP BY user_key, product_id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var signals []model.ItemSignal
+	for rows.Next() {
+		var sig model.ItemSignal
+		if err := rows.Scan(&sig.UserKey, &sig.ProductID, &sig.UnitsSold, &sig.Views); err != nil {
+			return nil, err
+		}
+		signals = append(signals, sig)
+	}
+	return signals, rows.Err()
+}
+
+// GetAssociationRules mines single-product rules from completed orders for
+// each antecedent. Consequents are limited to active, in-stock products
+// other than the antecedents, and held together in at least minPairCount
+// orders.
+func (r *recommendationRepository) GetAssociationRules(ctx context.Context, antecedentIDs []uint, minPairCount int) ([]model.AssociationRule, error) {
+	if len(antecedentIDs) == 0 {
+		return nil, nil
+	}
+
+	var basketCount int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT oi.order_id)
+		FROM OrderItem oi
+		JOIN `+"`Order`"+` o ON oi.order_id = o.order_id
+		WHERE o.status = 'Completed'`).Scan(&basketCount)
+	if err != nil {
+		return nil, err
+	}
+	if basketCount == 0 {
+		return nil, nil
+	}
+
+	ids := make([]interface{}, len(antecedentIDs))
+	for i, id := range antecedentIDs {
+		ids[i] = id
+	}
+	in := placeholders(len(ids))
+	query := `
+		SELECT pairs.antecedent_id, pairs.consequent_id, pairs.pair_count, counts.antecedent_count
+		FROM (
+			SELECT a.product_id AS antecedent_id, b.product_id AS consequent_id,
+				COUNT(DISTINCT a.order_id) AS pair_count
+			FROM OrderItem a
+			JOIN ` + "`Order`" + ` o ON a.order_id = o.order_id
+			JOIN OrderItem b ON b.order_id = a.order_id
+			JOIN FlowerProduct fp ON fp.product_id = b.product_id
+			WHERE o.status = 'Completed' AND a.product_id IN (` + in + `)
+				AND b.product_id NOT IN (` + in + `)
+				AND fp.is_active = TRUE AND fp.stock_quantity > 0
+			GROUP BY a.product_id, b.product_id
+			HAVING pair_count >= ?
+		) pairs
+		JOIN (
+			SELECT oi.product_id, COUNT(DISTINCT oi.order_id) AS antecedent_count
+			FROM OrderItem oi
+			JOIN ` + "`Order`" + ` o ON oi.order_id = o.order_id
+			WHERE o.status = 'Completed' AND oi.product_id IN (` + in + `)
+			GROUP BY oi.product_id
+		) counts ON counts.product_id = pairs.antecedent_id`
+
+	args := make([]interface{}, 0, 3*len(ids)+1)
+	args = append(args, ids...)
+	args = append(args, ids...)
+	args = append(args, minPairCount)
+	args = append(args, ids...)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []model.AssociationRule
+	for rows.Next() {
+		rule := model.AssociationRule{BasketCount: basketCount}
+		if err := rows.Scan(&rule.AntecedentID, &rule.ConsequentID, &rule.PairCount, &rule.AntecedentCount); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// GetProductsWithActivitySince returns products viewed since viewsSince or
+// ordered in completed orders placed since ordersSince
+func (r *recommendationRepository) GetProductsWithActivitySince(ctx context.Context, viewsSince, ordersSince time.Time) ([]uint, error) {
+	query := `
+		SELECT product_id FROM UserProductInteraction
+		WHERE interaction_type = 'view' AND timestamp >= ? AND product_id IS NOT NULL
+		UNION
+		SELECT oi.product_id FROM OrderItem oi
+		JOIN ` + "`Order`" + ` o ON oi.order_id = o.order_id
+		WHERE o.status = 'Completed' AND o.order_date >= ?`
+
+	rows, err := r.db.QueryContext(ctx, query, viewsSince, ordersSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uint{}
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 // GetTrendingProducts retrieves trending products
-func (r *recommendationRepository) GetTrendingProducts(period string, limit int) ([]model.TrendingProduct, error) {
+func (r *recommendationRepository) GetTrendingProducts(ctx context.Context, period string, limit int) ([]model.TrendingProduct, error) {
 	query := `SELECT product_id, trend_score, view_count, purchase_count, period, updated_at
 			  FROM TrendingProduct 
 			  WHERE period = ? 
 			  ORDER BY trend_score DESC
 			  LIMIT ?`
 
-	rows, err := r.db.Query(query, period, limit)
+	rows, err := r.db.QueryContext(ctx, query, period, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -228,37 +650,93 @@ func (r *recommendationRepository) GetTrendingProducts(period string, limit int)
 	return trending, nil
 }
 
-// UpdateTrendingProducts updates trending product data
-func (r *recommendationRepository) UpdateTrendingProducts(products []model.TrendingProduct) error {
-	if len(products) == 0 {
-		return nil
+// GetProductActivity returns hourly product views and units sold in
+// completed orders since the given time
+func (r *recommendationRepository) GetProductActivity(ctx context.Context, since time.Time) ([]model.ProductActivity, error) {
+	query := `
+		SELECT product_id, hour, SUM(views), SUM(units_sold)
+		FROM (
+			SELECT product_id, DATE_FORMAT(timestamp, '%Y-%m-%d %H:00:00') AS hour,
+				COUNT(*) AS views, 0 AS units_sold
+			FROM UserProductInteraction
+			WHERE interaction_type = 'view' AND timestamp >= ? AND product_id IS NOT NULL
+			GROUP BY product_id, hour
+			UNION ALL
+			SELECT oi.product_id, DATE_FORMAT(o.order_date, '%Y-%m-%d %H:00:00') AS hour,
+				0 AS views, SUM(oi.quantity) AS units_sold
+			FROM OrderItem oi
+			JOIN ` + "`Order`" + ` o ON oi.order_id = o.order_id
+			WHERE o.status = 'Completed' AND o.order_date >= ?
+			GROUP BY oi.product_id, hour
+		) activity
+		GROUP BY product_id, hour`
+
+	rows, err := r.db.QueryContext(ctx, query, since, since)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
+
+	var activity []model.ProductActivity
+	for rows.Next() {
+		var a model.ProductActivity
+		var hour string
+		if err := rows.Scan(&a.ProductID, &hour, &a.Views, &a.UnitsSold); err != nil {
+			return nil, err
+		}
+		if a.Hour, err = time.ParseInLocation("2006-01-02 15:04:05", hour, time.Local); err != nil {
+			return nil, err
+		}
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
+}
 
-	// Build bulk insert query
-	valueStrings := make([]string, 0, len(products))
-	valueArgs := make([]interface{}, 0, len(products)*6)
+// ReplaceTrendingProducts swaps the trending products of a period for the
+// given ones, so products that stopped trending drop out
+func (r *recommendationRepository) ReplaceTrendingProducts(ctx context.Context, period string, products []model.TrendingProduct) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
 
-	for _, product := range products {
-		valueStrings = append(valueStrings, "(?, ?, ?, ?, ?, ?)")
-		valueArgs = append(valueArgs, product.ProductID, product.TrendScore,
-			product.ViewCount, product.PurchaseCount, product.Period, time.Now())
+	if _, err = tx.ExecContext(ctx, `DELETE FROM TrendingProduct WHERE period = ?`, period); err != nil {
+		return err
 	}
 
-	query := fmt.Sprintf(`INSERT INTO TrendingProduct (product_id, trend_score, view_count, purchase_count, period, updated_at)
-						  VALUES %s
-						  ON DUPLICATE KEY UPDATE
-						  trend_score = VALUES(trend_score),
-						  view_count = VALUES(view_count),
-						  purchase_count = VALUES(purchase_count),
-						  updated_at = VALUES(updated_at)`,
-		strings.Join(valueStrings, ","))
+	const chunkSize = 500
+	for start := 0; start < len(products); start += chunkSize {
+		end := start + chunkSize
+		if end > len(products) {
+			end = len(products)
+		}
 
-	_, err := r.db.Exec(query, valueArgs...)
-	return err
+		valueStrings := make([]string, 0, end-start)
+		valueArgs := make([]interface{}, 0, (end-start)*5)
+		for _, product := range products[start:end] {
+			valueStrings = append(valueStrings, "(?, ?, ?, ?, ?)")
+			valueArgs = append(valueArgs, product.ProductID, product.TrendScore,
+				product.ViewCount, product.PurchaseCount, period)
+		}
+
+		query := fmt.Sprintf(`INSERT INTO TrendingProduct (product_id, trend_score, view_count, purchase_count, period)
+							  VALUES %s`, strings.Join(valueStrings, ","))
+		if _, err = tx.ExecContext(ctx, query, valueArgs...); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // GetUserPurchaseHistory retrieves user's purchase history
-func (r *recommendationRepository) GetUserPurchaseHistory(firebaseUID string) ([]model.Product, error) {
+func (r *recommendationRepository) GetUserPurchaseHistory(ctx context.Context, firebaseUID string) ([]model.Product, error) {
 	query := `
 		SELECT DISTINCT fp.product_id, fp.name, fp.description, ft.name as flower_type, 
 			   fp.base_price, fp.base_price as current_price, fp.status, fp.stock_quantity, 
@@ -270,7 +748,7 @@ func (r *recommendationRepository) GetUserPurchaseHistory(firebaseUID string) ([
 		WHERE o.firebase_uid = ? AND o.status = 'Completed'
 		ORDER BY o.order_date DESC`
 
-	rows, err := r.db.Query(query, firebaseUID)
+	rows, err := r.db.QueryContext(ctx, query, firebaseUID)
 	if err != nil {
 		return nil, err
 	}
@@ -292,7 +770,7 @@ func (r *recommendationRepository) GetUserPurchaseHistory(firebaseUID string) ([
 }
 
 // GetUserCartHistory retrieves products user has added to cart
-func (r *recommendationRepository) GetUserCartHistory(firebaseUID string) ([]model.Product, error) {
+func (r *recommendationRepository) GetUserCartHistory(ctx context.Context, firebaseUID string) ([]model.Product, error) {
 	query := `
 		SELECT DISTINCT fp.product_id, fp.name, fp.description, ft.name as flower_type, 
 			   fp.base_price, fp.base_price as current_price, fp.status, fp.stock_quantity, 
@@ -304,7 +782,7 @@ func (r *recommendationRepository) GetUserCartHistory(firebaseUID string) ([]mod
 		WHERE c.firebase_uid = ?
 		ORDER BY ci.added_at DESC`
 
-	rows, err := r.db.Query(query, firebaseUID)
+	rows, err := r.db.QueryContext(ctx, query, firebaseUID)
 	if err != nil {
 		return nil, err
 	}
@@ -326,7 +804,7 @@ func (r *recommendationRepository) GetUserCartHistory(firebaseUID string) ([]mod
 }
 
 // GetUserViewHistory retrieves products user has viewed
-func (r *recommendationRepository) GetUserViewHistory(firebaseUID string, limit int) ([]model.Product, error) {
+func (r *recommendationRepository) GetUserViewHistory(ctx context.Context, firebaseUID string, limit int) ([]model.Product, error) {
 	query := `
 		SELECT DISTINCT fp.product_id, fp.name, fp.description, ft.name as flower_type, 
 			   fp.base_price, fp.base_price as current_price, fp.status, fp.stock_quantity, 
@@ -338,7 +816,7 @@ func (r *recommendationRepository) GetUserViewHistory(firebaseUID string, limit
 		ORDER BY upi.timestamp DESC
 		LIMIT ?`
 
-	rows, err := r.db.Query(query, firebaseUID, limit)
+	rows, err := r.db.QueryContext(ctx, query, firebaseUID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -360,7 +838,7 @@ func (r *recommendationRepository) GetUserViewHistory(firebaseUID string, limit
 }
 
 // GetSimilarUsers finds users with similar preferences
-func (r *recommendationRepository) GetSimilarUsers(firebaseUID string, limit int) ([]string, error) {
+func (r *recommendationRepository) GetSimilarUsers(ctx context.Context, firebaseUID string, limit int) ([]string, error) {
 	// Find users who have purchased similar products
 	query := `
 		SELECT DISTINCT o2.firebase_uid
@@ -374,7 +852,7 @@ func (r *recommendationRepository) GetSimilarUsers(firebaseUID string, limit int
 		ORDER BY COUNT(DISTINCT oi1.product_id) DESC
 		LIMIT ?`
 
-	rows, err := r.db.Query(query, firebaseUID, firebaseUID, limit)
+	rows, err := r.db.QueryContext(ctx, query, firebaseUID, firebaseUID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -393,7 +871,7 @@ func (r *recommendationRepository) GetSimilarUsers(firebaseUID string, limit int
 }
 
 // GetUsersWhoAlsoBought finds users who bought a specific product
-func (r *recommendationRepository) GetUsersWhoAlsoBought(productID uint, limit int) ([]string, error) {
+func (r *recommendationRepository) GetUsersWhoAlsoBought(ctx context.Context, productID uint, limit int) ([]string, error) {
 	query := `
 		SELECT DISTINCT o.firebase_uid
 		FROM ` + "`Order`" + ` o
@@ -402,7 +880,7 @@ func (r *recommendationRepository) GetUsersWhoAlsoBought(productID uint, limit i
 		ORDER BY o.order_date DESC
 		LIMIT ?`
 
-	rows, err := r.db.Query(query, productID, limit)
+	rows, err := r.db.QueryContext(ctx, query, productID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -421,7 +899,7 @@ func (r *recommendationRepository) GetUsersWhoAlsoBought(productID uint, limit i
 }
 
 // GetProductsByFlowerType retrieves products by flower type
-func (r *recommendationRepository) GetProductsByFlowerType(flowerType string, excludeProductID uint, limit int) ([]model.Product, error) {
+func (r *recommendationRepository) GetProductsByFlowerType(ctx context.Context, flowerType string, excludeProductID uint, limit int) ([]model.Product, error) {
 	query := `
 		SELECT fp.product_id, fp.name, fp.description, ft.name as flower_type, 
 			   fp.base_price, fp.base_price as current_price, fp.status, fp.stock_quantity, 
@@ -432,7 +910,7 @@ func (r *recommendationRepository) GetProductsByFlowerType(flowerType string, ex
 		ORDER BY fp.created_at DESC
 		LIMIT ?`
 
-	rows, err := r.db.Query(query, flowerType, excludeProductID, limit)
+	rows, err := r.db.QueryContext(ctx, query, flowerType, excludeProductID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -454,7 +932,7 @@ func (r *recommendationRepository) GetProductsByFlowerType(flowerType string, ex
 }
 
 // GetProductsByOccasion retrieves products by occasion
-func (r *recommendationRepository) GetProductsByOccasion(occasion string, excludeProductID uint, limit int) ([]model.Product, error) {
+func (r *recommendationRepository) GetProductsByOccasion(ctx context.Context, occasion string, excludeProductID uint, limit int) ([]model.Product, error) {
 	query := `
 		SELECT fp.product_id, fp.name, fp.description, ft.name as flower_type, 
 			   fp.base_price, fp.base_price as current_price, fp.status, fp.stock_quantity, 
@@ -467,7 +945,7 @@ func (r *recommendationRepository) GetProductsByOccasion(occasion string, exclud
 		ORDER BY fp.created_at DESC
 		LIMIT ?`
 
-	rows, err := r.db.Query(query, occasion, excludeProductID, limit)
+	rows, err := r.db.QueryContext(ctx, query, occasion, excludeProductID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -489,7 +967,7 @@ func (r *recommendationRepository) GetProductsByOccasion(occasion string, exclud
 }
 
 // GetProductsByPriceRange retrieves products within price range
-func (r *recommendationRepository) GetProductsByPriceRange(minPrice, maxPrice float64, excludeProductID uint, limit int) ([]model.Product, error) {
+func (r *recommendationRepository) GetProductsByPriceRange(ctx context.Context, minPrice, maxPrice float64, excludeProductID uint, limit int) ([]model.Product, error) {
 	query := `
 		SELECT fp.product_id, fp.name, fp.description, ft.name as flower_type, 
 			   fp.base_price, fp.base_price as current_price, fp.status, fp.stock_quantity, 
@@ -500,7 +978,7 @@ func (r *recommendationRepository) GetProductsByPriceRange(minPrice, maxPrice fl
 		ORDER BY fp.created_at DESC
 		LIMIT ?`
 
-	rows, err := r.db.Query(query, minPrice, maxPrice, excludeProductID, limit)
+	rows, err := r.db.QueryContext(ctx, query, minPrice, maxPrice, excludeProductID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -521,30 +999,294 @@ func (r *recommendationRepository) GetProductsByPriceRange(minPrice, maxPrice fl
 	return products, nil
 }
 
+// SaveInteraction records a view or cart add of a product by a signed-in
+// user or an anonymous session
+func (r *recommendationRepository) SaveInteraction(ctx context.Context, firebaseUID, sessionID string, productID uint, interactionType string) error {
+	query := `INSERT INTO UserProductInteraction (firebase_uid, session_id, product_id, interaction_type)
+			  VALUES (?, ?, ?, ?)`
+
+	_, err := r.db.ExecContext(ctx, query, emptyToNull(firebaseUID), emptyToNull(sessionID), productID, interactionType)
+	return err
+}
+
 // SaveRecommendationFeedback saves user feedback on recommendations
-func (r *recommendationRepository) SaveRecommendationFeedback(firebaseUID string, productID uint, recommendationType, action string) error {
-	query := `INSERT INTO RecommendationFeedback (firebase_uid, product_id, recommendation_type, action, created_at)
-			  VALUES (?, ?, ?, ?, ?)`
+func (r *recommendationRepository) SaveRecommendationFeedback(ctx context.Context, feedback model.RecommendationFeedback) error {
+	query := `INSERT INTO RecommendationFeedback (firebase_uid, product_id, recommendation_type, action,
+			  session_id, experiment_id, variant, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := r.db.ExecContext(ctx, query, feedback.FirebaseUID, feedback.ProductID, feedback.RecommendationType,
+		feedback.Action, emptyToNull(feedback.SessionID), feedback.ExperimentID,
+		emptyToNull(feedback.Variant), time.Now())
+	return err
+}
+
+func (r *recommendationRepository) SaveRecommendationImpression(ctx context.Context, impression model.RecommendationImpression) error {
+	query := `INSERT INTO RecommendationImpression (firebase_uid, recommendation_type, experiment_id,
+			  variant, product_count, average_score)
+			  VALUES (?, ?, ?, ?, ?, ?)`
 
-	_, err := r.db.Exec(query, firebaseUID, productID, recommendationType, action, time.Now())
+	_, err := r.db.ExecContext(ctx, query, impression.FirebaseUID, impression.RecommendationType,
+		impression.ExperimentID, emptyToNull(impression.Variant), impression.ProductCount,
+		impression.AverageScore)
 	return err
 }
 
-// GetRecommendationStats retrieves recommendation performance statistics
-func (r *recommendationRepository) GetRecommendationStats(period string) (map[string]interface{}, error) {
-	// This is a simplified version - in a real implementation you'd have more detailed analytics
-	stats := make(map[string]interface{})
+// GetRecommendationStats sums impressions, clicks and purchases per
+// recommendation type since the given time
+func (r *recommendationRepository) GetRecommendationStats(ctx context.Context, since time.Time) ([]model.RecommendationTypeStats, error) {
+	query := `
+		SELECT recommendation_type, SUM(impressions), SUM(clicks), SUM(purchases),
+			COALESCE(SUM(score_total) / NULLIF(SUM(impressions), 0), 0)
+		FROM (
+			SELECT recommendation_type, COUNT(*) AS impressions, 0 AS clicks, 0 AS purchases,
+				SUM(COALESCE(average_score, 0)) AS score_total
+			FROM RecommendationImpression
+			WHERE created_at >= ?
+			GROUP BY recommendation_type
+			UNION ALL
+			SELECT recommendation_type, 0, SUM(action = 'clicked'), SUM(action = 'purchased'), 0
+			FROM RecommendationFeedback
+			WHERE created_at >= ?
+			GROUP BY recommendation_type
+		) activity
+		GROUP BY recommendation_type
+		ORDER BY recommendation_type`
+
+	rows, err := r.db.QueryContext(ctx, query, since, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []model.RecommendationTypeStats
+	for rows.Next() {
+		var st model.RecommendationTypeStats
+		if err := rows.Scan(&st.RecommendationType, &st.Impressions, &st.Clicks, &st.Purchases, &st.AverageScore); err != nil {
+			return nil, err
+		}
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
+
+const experimentColumns = `experiment_id, name, COALESCE(description, ''), status,
+	COALESCE(created_by, ''), started_at, stopped_at, created_at`
+
+func (r *recommendationRepository) GetExperiments(ctx context.Context) ([]model.RecommendationExperiment, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+experimentColumns+` FROM RecommendationExperiment ORDER BY created_at DESC, experiment_id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var experiments []model.RecommendationExperiment
+	for rows.Next() {
+		var e model.RecommendationExperiment
+		if err := scanExperiment(rows, &e); err != nil {
+			return nil, err
+		}
+		experiments = append(experiments, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range experiments {
+		variants, err := r.getExperimentVariants(ctx, experiments[i].ExperimentID)
+		if err != nil {
+			return nil, err
+		}
+		experiments[i].Variants = variants
+	}
+	return experiments, nil
+}
+
+func (r *recommendationRepository) GetExperimentByID(ctx context.Context, experimentID uint) (*model.RecommendationExperiment, error) {
+	return r.getExperiment(ctx, `SELECT `+experimentColumns+` FROM RecommendationExperiment WHERE experiment_id = ?`, experimentID)
+}
+
+// GetRunningExperiment returns the running experiment; nil when none runs
+func (r *recommendationRepository) GetRunningExperiment(ctx context.Context) (*model.RecommendationExperiment, error) {
+	e, err := r.getExperiment(ctx, `SELECT `+experimentColumns+` FROM RecommendationExperiment WHERE status = 'running' LIMIT 1`)
+	if apperror.Is(err, apperror.CodeNotFound) {
+		return nil, nil
+	}
+	return e, err
+}
+
+func (r *recommendationRepository) getExperiment(ctx context.Context, query string, args ...interface{}) (*model.RecommendationExperiment, error) {
+	var e model.RecommendationExperiment
+	err := scanExperiment(r.db.QueryRowContext(ctx, query, args...), &e)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("experiment not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e.Variants, err = r.getExperimentVariants(ctx, e.ExperimentID)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// rowScanner is a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanExperiment(row rowScanner, e *model.RecommendationExperiment) error {
+	return row.Scan(&e.ExperimentID, &e.Name, &e.Description, &e.Status, &e.CreatedBy,
+		&e.StartedAt, &e.StoppedAt, &e.CreatedAt)
+}
+
+// getExperimentVariants returns the variants in bucketing order
+func (r *recommendationRepository) getExperimentVariants(ctx context.Context, experimentID uint) ([]model.ExperimentVariant, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT variant_id, name, traffic_percent, collaborative_weight, content_weight,
+			popularity_weight, trending_weight, max_per_flower_type
+		FROM RecommendationExperimentVariant
+		WHERE experiment_id = ?
+		ORDER BY variant_id`, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	// Get total recommendations count (placeholder)
-	var totalCount int
-	query := `SELECT COUNT(*) FROM RecommendationFeedback WHERE created_at >= DATE_SUB(NOW(), INTERVAL 1 DAY)`
-	if err := r.db.QueryRow(query).Scan(&totalCount); err != nil {
-		totalCount = 0
+	var variants []model.ExperimentVariant
+	for rows.Next() {
+		var v model.ExperimentVariant
+		if err := rows.Scan(&v.VariantID, &v.Name, &v.TrafficPercent, &v.CollaborativeWeight,
+			&v.ContentWeight, &v.PopularityWeight, &v.TrendingWeight, &v.MaxPerFlowerType); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
 	}
+	return variants, rows.Err()
+}
 
-	stats["total_recommendations"] = totalCount
-	stats["period"] = period
-	stats["generated_at"] = time.Now()
+// CreateExperiment stores a draft experiment with its variants
+func (r *recommendationRepository) CreateExperiment(ctx context.Context, experiment *model.RecommendationExperiment) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var exists int
+	if err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM RecommendationExperiment WHERE name = ?`, experiment.Name).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		return apperror.Conflict("experiment name already exists")
+	}
 
-	return stats, nil
+	res, err := tx.ExecContext(ctx, `INSERT INTO RecommendationExperiment (name, description, status, created_by)
+		VALUES (?, ?, ?, ?)`, experiment.Name, emptyToNull(experiment.Description),
+		model.ExperimentDraft, emptyToNull(experiment.CreatedBy))
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	experiment.ExperimentID = uint(id)
+	experiment.Status = model.ExperimentDraft
+
+	for i := range experiment.Variants {
+		v := &experiment.Variants[i]
+		res, err = tx.ExecContext(ctx, `INSERT INTO RecommendationExperimentVariant (experiment_id, name, traffic_percent,
+			collaborative_weight, content_weight, popularity_weight, trending_weight, max_per_flower_type)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, experiment.ExperimentID, v.Name, v.TrafficPercent,
+			v.CollaborativeWeight, v.ContentWeight, v.PopularityWeight, v.TrendingWeight, v.MaxPerFlowerType)
+		if err != nil {
+			return err
+		}
+		id, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		v.VariantID = uint(id)
+	}
+	return nil
+}
+
+// SetExperimentStatus starts or stops an experiment. Running experiments
+// are locked so two cannot be started at once.
+func (r *recommendationRepository) SetExperimentStatus(ctx context.Context, experimentID uint, status string) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if status == model.ExperimentRunning {
+		var running int
+		err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM RecommendationExperiment
+			WHERE status = 'running' AND experiment_id != ? FOR UPDATE`, experimentID).Scan(&running)
+		if err != nil {
+			return err
+		}
+		if running > 0 {
+			return apperror.Conflict("another experiment is already running")
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE RecommendationExperiment SET status = ?, started_at = NOW()
+			WHERE experiment_id = ?`, status, experimentID)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE RecommendationExperiment SET status = ?, stopped_at = NOW()
+		WHERE experiment_id = ?`, status, experimentID)
+	return err
+}
+
+// GetVariantStats counts, per variant of the experiment, the users who were
+// shown recommendations and how many of them clicked or purchased one
+func (r *recommendationRepository) GetVariantStats(ctx context.Context, experimentID uint) ([]model.VariantStats, error) {
+	query := `
+		SELECT i.variant, COUNT(DISTINCT i.firebase_uid), COUNT(*),
+			COALESCE(MAX(f.clicked_users), 0), COALESCE(MAX(f.purchased_users), 0)
+		FROM RecommendationImpression i
+		LEFT JOIN (
+			SELECT variant,
+				COUNT(DISTINCT CASE WHEN action = 'clicked' THEN firebase_uid END) AS clicked_users,
+				COUNT(DISTINCT CASE WHEN action = 'purchased' THEN firebase_uid END) AS purchased_users
+			FROM RecommendationFeedback
+			WHERE experiment_id = ? AND firebase_uid IN (
+				SELECT firebase_uid FROM RecommendationImpression WHERE experiment_id = ?)
+			GROUP BY variant
+		) f ON f.variant = i.variant
+		WHERE i.experiment_id = ?
+		GROUP BY i.variant`
+
+	rows, err := r.db.QueryContext(ctx, query, experimentID, experimentID, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []model.VariantStats
+	for rows.Next() {
+		var st model.VariantStats
+		if err := rows.Scan(&st.Variant, &st.Users, &st.Impressions, &st.ClickedUsers, &st.PurchasedUsers); err != nil {
+			return nil, err
+		}
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
 }
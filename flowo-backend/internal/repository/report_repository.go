@@ -1,15 +1,17 @@
 package repository
 
 import (
+	"context"
+
 	"database/sql"
 	"flowo-backend/internal/dto"
 	"fmt"
 )
 
 type ReportRepository interface {
-	GetSalesSummary(start, end string) (dto.SalesSummaryDTO, error)
-	GetSalesTimeseries(start, end, groupBy string) ([]dto.SalesTimeseriesDTO, error)
-	GetTopProducts(start, end, sortBy string, limit int) ([]dto.TopProductDTO, error)
+	GetSalesSummary(ctx context.Context, start, end string) (dto.SalesSummaryDTO, error)
+	GetSalesTimeseries(ctx context.Context, start, end, groupBy string) ([]dto.SalesTimeseriesDTO, error)
+	GetTopProducts(ctx context.Context, start, end, sortBy string, limit int) ([]dto.TopProductDTO, error)
 }
 
 type reportRepository struct {
@@ -23,21 +25,21 @@ func NewReportRepository(db *sql.DB) ReportRepository {
 // ============================
 // Sales Summary
 // ============================
-func (r *reportRepository) GetSalesSummary(start, end string) (dto.SalesSummaryDTO, error) {
+func (r *reportRepository) GetSalesSummary(ctx context.Context, start, end string) (dto.SalesSummaryDTO, error) {
 	q := "SELECT COUNT(*) AS orders, COALESCE(SUM(o.final_total_amount),0) AS revenue, COALESCE(AVG(o.final_total_amount),0) AS aov FROM `Order` o WHERE o.status = 'Completed' AND DATE(o.order_date) BETWEEN ? AND ?"
 
 	var res dto.SalesSummaryDTO
-	err := r.DB.QueryRow(q, start, end).Scan(&res.Orders, &res.Revenue, &res.AOV)
+	err := r.DB.QueryRowContext(ctx, q, start, end).Scan(&res.Orders, &res.Revenue, &res.AOV)
 	return res, err
 }
 
 // ============================
 // Sales Timeseries
 // ============================
-func (r *reportRepository) GetSalesTimeseries(start, end, groupBy string) ([]dto.SalesTimeseriesDTO, error) {
+func (r *reportRepository) GetSalesTimeseries(ctx context.Context, start, end, groupBy string) ([]dto.SalesTimeseriesDTO, error) {
 	q := "SELECT DATE_FORMAT(o.order_date, ?) AS period, COUNT(*) AS orders, COALESCE(SUM(o.final_total_amount),0) AS revenue FROM `Order` o WHERE o.status = 'Completed' AND DATE(o.order_date) BETWEEN ? AND ? GROUP BY period ORDER BY period"
 
-	rows, err := r.DB.Query(q, groupBy, start, end)
+	rows, err := r.DB.QueryContext(ctx, q, groupBy, start, end)
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +59,7 @@ func (r *reportRepository) GetSalesTimeseries(start, end, groupBy string) ([]dto
 // ============================
 // Top Products
 // ============================
-func (r *reportRepository) GetTopProducts(start, end, sortBy string, limit int) ([]dto.TopProductDTO, error) {
+func (r *reportRepository) GetTopProducts(ctx context.Context, start, end, sortBy string, limit int) ([]dto.TopProductDTO, error) {
 	var orderBy string
 	switch sortBy {
 	case "quantity":
@@ -68,7 +70,7 @@ func (r *reportRepository) GetTopProducts(start, end, sortBy string, limit int)
 
 	q := fmt.Sprintf("SELECT p.product_id, p.name, COALESCE(SUM(oi.quantity),0) AS quantity, COALESCE(SUM(oi.item_subtotal),0) AS revenue FROM OrderItem oi JOIN FlowerProduct p ON oi.product_id = p.product_id JOIN `Order` o ON oi.order_id = o.order_id WHERE o.status = 'Completed' AND DATE(o.order_date) BETWEEN ? AND ? GROUP BY p.product_id, p.name ORDER BY %s DESC LIMIT ?", orderBy)
 
-	rows, err := r.DB.Query(q, start, end, limit)
+	rows, err := r.DB.QueryContext(ctx, q, start, end, limit)
 	if err != nil {
 		return nil, err
 	}
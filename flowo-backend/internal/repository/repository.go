@@ -1,38 +1,51 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"errors"
+	"strconv"
 	"strings"
 
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/model"
+	"flowo-backend/internal/pagination"
 )
 
 type Repository interface {
-	Create(todo *model.Todo) error
-	FindAll() ([]model.Todo, error)
-	FindByID(id uint) (*model.Todo, error)
-	Update(todo *model.Todo) error
-	Delete(id uint) error
-	GetFlowerTypeID(flowerType string) (uint, error)
-	GetAllProducts() ([]model.Product, error)
-	GetProductByID(id uint) (*model.Product, error)
-	CreateProduct(product *model.Product) error
-	UpdateProduct(id uint, product *dto.ProductCreate) error
-	DeleteProduct(id uint) error
-	GetProductsByFlowerType(flowerType string) ([]model.Product, error)
-	GetAllFlowerTypes() ([]model.FlowerType, error)
-	GetProductsByIDs(ids []int) (map[int]model.Product, error)
+	Create(ctx context.Context, todo *model.Todo) error
+	FindAll(ctx context.Context) ([]model.Todo, error)
+	FindByID(ctx context.Context, id uint) (*model.Todo, error)
+	Update(ctx context.Context, todo *model.Todo) error
+	Delete(ctx context.Context, id uint) error
+	GetFlowerTypeID(ctx context.Context, flowerType string) (uint, error)
+	GetAllProducts(ctx context.Context) ([]model.Product, error)
+	GetProductByID(ctx context.Context, id uint) (*model.Product, error)
+	CreateProduct(ctx context.Context, product *model.Product, actor string) error
+	UpdateProduct(ctx context.Context, id uint, product *dto.ProductCreate) error
+	DeleteProduct(ctx context.Context, id uint) error
+	GetProductsByFlowerType(ctx context.Context, flowerType string) ([]model.Product, error)
+	GetAllFlowerTypes(ctx context.Context) ([]model.FlowerType, error)
+	GetProductsByIDs(ctx context.Context, ids []int) (map[int]model.Product, error)
 
 	// product search and filtering methods
-	SearchProducts(query *dto.ProductSearchQuery) ([]model.Product, int, error)
-	GetProductDetailByID(id uint) (*model.Product, error)
-	GetProductImages(productID uint) ([]model.ProductImage, error)
-	GetProductOccasions(productID uint) ([]string, error)
-	GetAllOccasions() ([]model.Occasion, error)
-	GetPriceRange() (*model.PriceRange, error)
-	GetProductStatistics(productID uint) (averageRating float64, reviewCount int, salesRank int, error error)
+	SearchProducts(ctx context.Context, query *dto.ProductSearchQuery, page pagination.Request) ([]model.Product, int, error)
+	GetSearchFacets(ctx context.Context, query *dto.ProductSearchQuery) (*model.SearchFacets, error)
+	GetProductDetailByID(ctx context.Context, id uint) (*model.Product, error)
+	GetProductImages(ctx context.Context, productID uint) ([]model.ProductImage, error)
+	GetProductOccasions(ctx context.Context, productID uint) ([]string, error)
+	GetAllOccasions(ctx context.Context) ([]model.Occasion, error)
+	GetPriceRange(ctx context.Context) (*model.PriceRange, error)
+	GetProductStatistics(ctx context.Context, productID uint) (averageRating float64, reviewCount int, salesRank int, error error)
+	GetProductsForIndex(ctx context.Context) ([]model.Product, error)
+
+	// product variant methods
+	GetProductVariants(ctx context.Context, productID uint) ([]model.ProductVariant, error)
+	GetVariantByID(ctx context.Context, variantID uint) (*model.ProductVariant, error)
+	GetVariantsByIDs(ctx context.Context, ids []int) (map[int]model.ProductVariant, error)
+	CreateVariant(ctx context.Context, variant *model.ProductVariant, actor string) error
+	UpdateVariant(ctx context.Context, variantID uint, variant *dto.ProductVariantCreate) error
+	DeleteVariant(ctx context.Context, variantID uint) error
 }
 
 type repository struct {
@@ -43,15 +56,15 @@ func NewRepository(db *sql.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) Create(todo *model.Todo) error {
+func (r *repository) Create(ctx context.Context, todo *model.Todo) error {
 	query := "INSERT INTO todos (title, description, status, created_at, updated_at) VALUES (?, ?, ?, NOW(), NOW())"
-	_, err := r.db.Exec(query, todo.Title, todo.Description, todo.Status)
+	_, err := r.db.ExecContext(ctx, query, todo.Title, todo.Description, todo.Status)
 	return err
 }
 
-func (r *repository) FindAll() ([]model.Todo, error) {
+func (r *repository) FindAll(ctx context.Context) ([]model.Todo, error) {
 	query := "SELECT id, title, description, status, created_at, updated_at FROM todos"
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -69,14 +82,14 @@ func (r *repository) FindAll() ([]model.Todo, error) {
 	return todos, nil
 }
 
-func (r *repository) FindByID(id uint) (*model.Todo, error) {
+func (r *repository) FindByID(ctx context.Context, id uint) (*model.Todo, error) {
 	query := "SELECT id, title, description, status, created_at, updated_at FROM todos WHERE id = ?"
-	row := r.db.QueryRow(query, id)
+	row := r.db.QueryRowContext(ctx, query, id)
 
 	var todo model.Todo
 	if err := row.Scan(&todo.ID, &todo.Title, &todo.Description, &todo.Status, &todo.CreatedAt, &todo.UpdatedAt); err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("not found")
+			return nil, apperror.NotFound("todo not found")
 		}
 		return nil, err
 	}
@@ -84,39 +97,49 @@ func (r *repository) FindByID(id uint) (*model.Todo, error) {
 	return &todo, nil
 }
 
-func (r *repository) Update(todo *model.Todo) error {
+func (r *repository) Update(ctx context.Context, todo *model.Todo) error {
 	query := "UPDATE todos SET title = ?, description = ?, status = ?, updated_at = NOW() WHERE id = ?"
-	_, err := r.db.Exec(query, todo.Title, todo.Description, todo.Status, todo.ID)
+	_, err := r.db.ExecContext(ctx, query, todo.Title, todo.Description, todo.Status, todo.ID)
 	return err
 }
 
-func (r *repository) Delete(id uint) error {
+func (r *repository) Delete(ctx context.Context, id uint) error {
 	query := "DELETE FROM todos WHERE id = ?"
-	_, err := r.db.Exec(query, id)
+	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
 
-func (r *repository) GetFlowerTypeID(flowerType string) (uint, error) {
+func (r *repository) GetFlowerTypeID(ctx context.Context, flowerType string) (uint, error) {
 	query := "SELECT flower_type_id FROM FlowerType WHERE name = ?"
-	row := r.db.QueryRow(query, flowerType)
+	row := r.db.QueryRowContext(ctx, query, flowerType)
 	var flowerTypeID uint
 	if err := row.Scan(&flowerTypeID); err != nil {
 		if err == sql.ErrNoRows {
-			return 0, errors.New("flower type not found")
+			return 0, apperror.NotFound("flower type not found")
 		}
 		return 0, err
 	}
 	return flowerTypeID, nil
 }
 
-func (r *repository) GetAllProducts() ([]model.Product, error) {
+// productFlowerTypeID looks up the flower type of a product being saved,
+// where an unknown flower type is invalid input rather than a missing resource
+func (r *repository) productFlowerTypeID(ctx context.Context, flowerType string) (uint, error) {
+	flowerTypeID, err := r.GetFlowerTypeID(ctx, flowerType)
+	if apperror.Is(err, apperror.CodeNotFound) {
+		return 0, apperror.Validation("invalid flower type", apperror.Field("flower_type", "not found"))
+	}
+	return flowerTypeID, err
+}
+
+func (r *repository) GetAllProducts(ctx context.Context) ([]model.Product, error) {
 	query := `SELECT fp.product_id, fp.name, fp.description, ft.name as flower_type, 
 			  fp.base_price, fp.base_price as current_price, fp.status, fp.stock_quantity, 
 			  fp.created_at, fp.updated_at
 			  FROM FlowerProduct fp 
 			  JOIN FlowerType ft ON fp.flower_type_id = ft.flower_type_id
 			  WHERE fp.is_active = TRUE`
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -135,56 +158,118 @@ func (r *repository) GetAllProducts() ([]model.Product, error) {
 	return products, nil
 }
 
-func (r *repository) GetProductByID(id uint) (*model.Product, error) {
+func (r *repository) GetProductByID(ctx context.Context, id uint) (*model.Product, error) {
 	query := `SELECT fp.product_id, fp.name, fp.description, ft.name as flower_type, 
 			  fp.base_price, fp.base_price as current_price, fp.status, fp.stock_quantity, 
 			  fp.created_at, fp.updated_at
 			  FROM FlowerProduct fp 
 			  JOIN FlowerType ft ON fp.flower_type_id = ft.flower_type_id 
 			  WHERE fp.product_id = ? AND fp.is_active = TRUE`
-	row := r.db.QueryRow(query, id)
+	row := r.db.QueryRowContext(ctx, query, id)
 
 	var product model.Product
 	if err := row.Scan(&product.ProductID, &product.Name, &product.Description,
 		&product.FlowerType, &product.BasePrice, &product.CurrentPrice,
 		&product.Status, &product.StockQuantity, &product.CreatedAt, &product.UpdatedAt); err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("not found")
+			return nil, apperror.NotFound("product not found")
 		}
 		return nil, err
 	}
 	return &product, nil
 }
 
-func (r *repository) CreateProduct(product *model.Product) error {
+func (r *repository) CreateProduct(ctx context.Context, product *model.Product, actor string) error {
 	// First, find the flower type id based on the name
-	flowerTypeID, err := r.GetFlowerTypeID(product.FlowerType)
+	flowerTypeID, err := r.productFlowerTypeID(ctx, product.FlowerType)
 	if err != nil {
 		return err
 	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			tx.Commit()
+		}
+	}()
+
 	query := "INSERT INTO FlowerProduct (name, description, flower_type_id, base_price, status, stock_quantity, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, NOW(), NOW())"
-	_, err = r.db.Exec(query, product.Name, product.Description, flowerTypeID, product.BasePrice, product.Status, product.StockQuantity)
+	res, err := tx.ExecContext(ctx, query, product.Name, product.Description, flowerTypeID, product.BasePrice, product.Status, product.StockQuantity)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	product.ProductID = uint(id)
+
+	if product.StockQuantity > 0 {
+		_, err = recordMovement(ctx, tx, model.InventoryMovement{
+			ProductID:      int(product.ProductID),
+			MovementType:   model.MovementReceipt,
+			QuantityChange: product.StockQuantity,
+			Actor:          actor,
+			Reason:         "initial stock",
+		})
+	}
 	return err
 }
 
-func (r *repository) UpdateProduct(id uint, product *dto.ProductCreate) error {
-	flowerTypeID, err := r.GetFlowerTypeID(product.FlowerType)
+func (r *repository) UpdateProduct(ctx context.Context, id uint, product *dto.ProductCreate) error {
+	flowerTypeID, err := r.productFlowerTypeID(ctx, product.FlowerType)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			tx.Commit()
+		}
+	}()
+
+	currentStock, err := lockStock(ctx, tx, int(id), nil)
 	if err != nil {
 		return err
 	}
+
 	query := "UPDATE FlowerProduct SET name = ?, description = ?, flower_type_id = ?, base_price = ?, status = ?, stock_quantity = ?, updated_at = NOW() WHERE product_id = ?"
-	_, err = r.db.Exec(query, product.Name, product.Description, flowerTypeID, product.BasePrice, product.Status, product.StockQuantity, id)
+	_, err = tx.ExecContext(ctx, query, product.Name, product.Description, flowerTypeID, product.BasePrice, product.Status, product.StockQuantity, id)
+	if err != nil {
+		return err
+	}
+
+	if change := product.StockQuantity - currentStock; change != 0 {
+		_, err = recordMovement(ctx, tx, model.InventoryMovement{
+			ProductID:      int(id),
+			MovementType:   model.MovementManualAdjustment,
+			QuantityChange: change,
+			Actor:          product.Actor,
+			Reason:         product.StockReason,
+		})
+	}
 	return err
 }
 
-func (r *repository) DeleteProduct(id uint) error {
+func (r *repository) DeleteProduct(ctx context.Context, id uint) error {
 	query := "UPDATE FlowerProduct SET is_active = FALSE WHERE product_id = ?"
-	_, err := r.db.Exec(query, id)
+	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
 
-func (r *repository) GetProductsByFlowerType(flowerType string) ([]model.Product, error) {
-	flowerTypeID, err := r.GetFlowerTypeID(flowerType)
+func (r *repository) GetProductsByFlowerType(ctx context.Context, flowerType string) ([]model.Product, error) {
+	flowerTypeID, err := r.GetFlowerTypeID(ctx, flowerType)
 	if err != nil {
 		return nil, err
 	}
@@ -195,7 +280,7 @@ func (r *repository) GetProductsByFlowerType(flowerType string) ([]model.Product
 			  FROM FlowerProduct fp 
 			  JOIN FlowerType ft ON fp.flower_type_id = ft.flower_type_id 
 			  WHERE fp.flower_type_id = ? AND fp.is_active = TRUE`
-	rows, err := r.db.Query(query, flowerTypeID)
+	rows, err := r.db.QueryContext(ctx, query, flowerTypeID)
 	if err != nil {
 		return nil, err
 	}
@@ -215,9 +300,9 @@ func (r *repository) GetProductsByFlowerType(flowerType string) ([]model.Product
 	return products, nil
 }
 
-func (r *repository) GetAllFlowerTypes() ([]model.FlowerType, error) {
+func (r *repository) GetAllFlowerTypes(ctx context.Context) ([]model.FlowerType, error) {
 	query := "SELECT flower_type_id, name, description FROM FlowerType"
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -239,7 +324,7 @@ func (r *repository) GetAllFlowerTypes() ([]model.FlowerType, error) {
 	return flowerTypes, nil
 }
 
-func (r *repository) GetProductsByIDs(ids []int) (map[int]model.Product, error) {
+func (r *repository) GetProductsByIDs(ctx context.Context, ids []int) (map[int]model.Product, error) {
 	if len(ids) == 0 {
 		return map[int]model.Product{}, nil
 	}
@@ -257,7 +342,7 @@ func (r *repository) GetProductsByIDs(ids []int) (map[int]model.Product, error)
 		args[i] = id
 	}
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -281,97 +366,46 @@ func (r *repository) GetProductsByIDs(ids []int) (map[int]model.Product, error)
 
 // Enhanced methods for advanced search and filtering
 
-func (r *repository) SearchProducts(query *dto.ProductSearchQuery) ([]model.Product, int, error) {
+// SearchProducts returns the total number of matches and up to page.Fetch()
+// products of the requested page; with the newest sort it pages by keyset
+func (r *repository) SearchProducts(ctx context.Context, query *dto.ProductSearchQuery, page pagination.Request) ([]model.Product, int, error) {
 	// Build the base query (simplified version without complex subqueries)
-	baseQuery := `
+	selectClause := `
 		SELECT fp.product_id, fp.name, fp.description, ft.name as flower_type, 
-			   fp.base_price, fp.base_price as current_price, fp.status, fp.stock_quantity, 
+			   fp.base_price, ` + effectivePriceExpr + ` as current_price, fp.status, fp.stock_quantity, 
 			   fp.created_at, fp.updated_at,
-			   0 as average_rating,
-			   0 as review_count,
-			   999999 as sales_rank
-		FROM FlowerProduct fp 
-		JOIN FlowerType ft ON fp.flower_type_id = ft.flower_type_id
-		WHERE fp.is_active = TRUE`
+			   COALESCE(ps.average_rating, 0) as average_rating,
+			   COALESCE(ps.review_count, 0) as review_count,
+			   COALESCE(ps.sales_rank, 999999) as sales_rank,
+			   COALESCE(ps.units_sold_7d, 0), COALESCE(ps.units_sold_30d, 0)`
 
-	var conditions []string
-	var args []interface{}
-
-	// Build WHERE conditions
-	if query.Query != "" {
-		conditions = append(conditions, "(fp.name LIKE ? OR fp.description LIKE ?)")
-		searchTerm := "%" + query.Query + "%"
-		args = append(args, searchTerm, searchTerm)
-	}
-
-	if query.FlowerType != "" {
-		conditions = append(conditions, "ft.name = ?")
-		args = append(args, query.FlowerType)
-	}
-
-	if query.Occasion != "" {
-		baseQuery += " JOIN ProductOccasion po ON fp.product_id = po.product_id JOIN Occasion oc ON po.occasion_id = oc.occasion_id"
-		conditions = append(conditions, "oc.name = ?")
-		args = append(args, query.Occasion)
-	}
-
-	if query.PriceMin != nil {
-		conditions = append(conditions, "fp.base_price >= ?")
-		args = append(args, *query.PriceMin)
-	}
-
-	if query.PriceMax != nil {
-		conditions = append(conditions, "fp.base_price <= ?")
-		args = append(args, *query.PriceMax)
-	}
-
-	if query.Condition != "" {
-		conditions = append(conditions, "fp.status = ?")
-		args = append(args, query.Condition)
-	}
-
-	// Add WHERE clause if there are conditions
-	if len(conditions) > 0 {
-		baseQuery += " WHERE " + strings.Join(conditions, " AND ")
-	}
-
-	// Add ORDER BY
-	orderBy := r.buildOrderByClause(query.SortBy)
-	baseQuery += " ORDER BY " + orderBy
+	conditions, args := buildSearchConditions(query, "")
+	whereClause := " WHERE " + strings.Join(conditions, " AND ")
 
 	// Count total results with a simplified count query
-	countQuery := "SELECT COUNT(*) FROM FlowerProduct fp JOIN FlowerType ft ON fp.flower_type_id = ft.flower_type_id WHERE fp.is_active = TRUE"
-	if query.Occasion != "" {
-		countQuery += " JOIN ProductOccasion po ON fp.product_id = po.product_id JOIN Occasion oc ON po.occasion_id = oc.occasion_id"
-	}
-	if len(conditions) > 0 {
-		countQuery += " WHERE " + strings.Join(conditions, " AND ")
-	}
+	countQuery := "SELECT COUNT(*)" + searchFromClause + whereClause
 
 	var total int
-	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, 0, err
 	}
 
-	// Add pagination
-	page := query.Page
-	if page < 1 {
-		page = 1
-	}
-	limit := query.Limit
-	if limit < 1 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
+	// Add ORDER BY and pagination
+	orderBy := r.buildOrderByClause(query.SortBy, query.MatchedIDs)
+	if query.SortBy == "newest" {
+		var keyset string
+		var keysetArgs []interface{}
+		keyset, keysetArgs, orderBy = page.Keyset("fp.created_at", "fp.product_id")
+		if keyset != "" {
+			whereClause += " AND " + keyset
+			args = append(args, keysetArgs...)
+		}
 	}
-
-	offset := (page - 1) * limit
-	baseQuery += " LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
+	baseQuery := selectClause + searchFromClause + whereClause + " ORDER BY " + orderBy + " LIMIT ? OFFSET ?"
+	args = append(args, page.Fetch(), page.Offset())
 
 	// Execute the query
-	rows, err := r.db.Query(baseQuery, args...)
+	rows, err := r.db.QueryContext(ctx, baseQuery, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -383,7 +417,8 @@ func (r *repository) SearchProducts(query *dto.ProductSearchQuery) ([]model.Prod
 		if err := rows.Scan(&product.ProductID, &product.Name, &product.Description,
 			&product.FlowerType, &product.BasePrice, &product.CurrentPrice,
 			&product.Status, &product.StockQuantity, &product.CreatedAt, &product.UpdatedAt,
-			&product.AverageRating, &product.ReviewCount, &product.SalesRank); err != nil {
+			&product.AverageRating, &product.ReviewCount, &product.SalesRank,
+			&product.UnitsSold7d, &product.UnitsSold30d); err != nil {
 			return nil, 0, err
 		}
 		products = append(products, product)
@@ -392,12 +427,111 @@ func (r *repository) SearchProducts(query *dto.ProductSearchQuery) ([]model.Prod
 	return products, total, nil
 }
 
-func (r *repository) buildOrderByClause(sortBy string) string {
+const searchFromClause = `
+		FROM FlowerProduct fp 
+		JOIN FlowerType ft ON fp.flower_type_id = ft.flower_type_id
+		LEFT JOIN ProductEffectivePrice pep ON pep.product_id = fp.product_id
+		LEFT JOIN ProductStats ps ON ps.product_id = fp.product_id`
+
+// effectivePriceExpr is the price after pricing rules, from the precomputed
+// table; products not priced yet fall back to their base price
+const effectivePriceExpr = "COALESCE(pep.effective_price, fp.base_price)"
+
+// Facets whose own filter buildSearchConditions can leave out
+const (
+	facetFlowerType = "flower_type"
+	facetOccasion   = "occasion"
+	facetStatus     = "status"
+	facetPrice      = "price"
+)
+
+// buildSearchConditions turns the search filters into WHERE conditions over
+// searchFromClause, leaving out the filter of the facet named by skip
+func buildSearchConditions(query *dto.ProductSearchQuery, skip string) ([]string, []interface{}) {
+	conditions := []string{"fp.is_active = TRUE"}
+	var args []interface{}
+
+	if len(query.MatchedIDs) > 0 {
+		conditions = append(conditions, "fp.product_id IN ("+placeholders(len(query.MatchedIDs))+")")
+		for _, id := range query.MatchedIDs {
+			args = append(args, id)
+		}
+	} else if query.Query != "" {
+		conditions = append(conditions, "(fp.name LIKE ? OR fp.description LIKE ?)")
+		searchTerm := "%" + query.Query + "%"
+		args = append(args, searchTerm, searchTerm)
+	}
+
+	if len(query.FlowerType) > 0 && skip != facetFlowerType {
+		conditions = append(conditions, "ft.name IN ("+placeholders(len(query.FlowerType))+")")
+		for _, name := range query.FlowerType {
+			args = append(args, name)
+		}
+	}
+
+	if len(query.Occasion) > 0 && skip != facetOccasion {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM ProductOccasion po
+			JOIN Occasion oc ON po.occasion_id = oc.occasion_id
+			WHERE po.product_id = fp.product_id AND oc.name IN (`+placeholders(len(query.Occasion))+`))`)
+		for _, name := range query.Occasion {
+			args = append(args, name)
+		}
+	}
+
+	if skip != facetPrice {
+		if query.PriceMin != nil {
+			conditions = append(conditions, effectivePriceExpr+" >= ?")
+			args = append(args, *query.PriceMin)
+		}
+		if query.PriceMax != nil {
+			conditions = append(conditions, effectivePriceExpr+" <= ?")
+			args = append(args, *query.PriceMax)
+		}
+	}
+
+	if query.MinRating != nil {
+		conditions = append(conditions, "COALESCE(ps.average_rating, 0) >= ?")
+		args = append(args, *query.MinRating)
+	}
+
+	if len(query.Condition) > 0 && skip != facetStatus {
+		conditions = append(conditions, "fp.status IN ("+placeholders(len(query.Condition))+")")
+		for _, status := range query.Condition {
+			args = append(args, status)
+		}
+	}
+
+	// Variant attributes: the product must have an active variant matching all of them
+	if variantCondition, variantArgs := buildVariantCondition(query); variantCondition != "" {
+		conditions = append(conditions, variantCondition)
+		args = append(args, variantArgs...)
+	}
+
+	return conditions, args
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// buildOrderByClause maps a sort option to ORDER BY. Relevance keeps the
+// order of rankedIDs, the full-text ranking; without a ranking it falls back
+// to newest.
+func (r *repository) buildOrderByClause(sortBy string, rankedIDs []uint) string {
 	switch sortBy {
+	case "relevance":
+		if len(rankedIDs) == 0 {
+			return "fp.created_at DESC"
+		}
+		ids := make([]string, len(rankedIDs))
+		for i, id := range rankedIDs {
+			ids[i] = strconv.FormatUint(uint64(id), 10)
+		}
+		return "FIELD(fp.product_id, " + strings.Join(ids, ", ") + "), fp.created_at DESC"
 	case "price_asc":
-		return "fp.base_price ASC"
+		return effectivePriceExpr + " ASC, fp.product_id ASC"
 	case "price_desc":
-		return "fp.base_price DESC"
+		return effectivePriceExpr + " DESC, fp.product_id DESC"
 	case "name_asc":
 		return "fp.name ASC"
 	case "name_desc":
@@ -406,67 +540,70 @@ func (r *repository) buildOrderByClause(sortBy string) string {
 		return "fp.created_at DESC"
 	case "best_selling":
 		return "sales_rank ASC, fp.created_at DESC"
+	case "top_rated":
+		return "average_rating DESC, review_count DESC, fp.created_at DESC"
 	default:
 		return "fp.created_at DESC" // Default to newest
 	}
 }
 
-func (r *repository) GetProductDetailByID(id uint) (*model.Product, error) {
+func (r *repository) GetProductDetailByID(ctx context.Context, id uint) (*model.Product, error) {
 	// Get basic product information with ratings and sales rank
 	query := `
 		SELECT fp.product_id, fp.name, fp.description, ft.name as flower_type, 
 			   fp.base_price, fp.base_price as current_price, fp.status, fp.stock_quantity, 
 			   fp.created_at, fp.updated_at,
-			   COALESCE(AVG(r.rating), 0) as average_rating,
-			   COUNT(r.review_id) as review_count,
-			   COALESCE(sales_data.sales_rank, 999999) as sales_rank
+			   COALESCE(ps.average_rating, 0) as average_rating,
+			   COALESCE(ps.review_count, 0) as review_count,
+			   COALESCE(ps.sales_rank, 999999) as sales_rank,
+			   COALESCE(ps.units_sold_7d, 0), COALESCE(ps.units_sold_30d, 0)
 		FROM FlowerProduct fp 
 		JOIN FlowerType ft ON fp.flower_type_id = ft.flower_type_id
-		LEFT JOIN Review r ON fp.product_id = r.product_id
-		LEFT JOIN (
-			SELECT oi.product_id, ROW_NUMBER() OVER (ORDER BY SUM(oi.quantity) DESC) as sales_rank
-			FROM OrderItem oi
-			JOIN ` + "`Order`" + ` o ON oi.order_id = o.order_id
-			WHERE o.status = 'Completed'
-			GROUP BY oi.product_id
-		) sales_data ON fp.product_id = sales_data.product_id
-		WHERE fp.product_id = ? AND fp.is_active = TRUE
-		GROUP BY fp.product_id, fp.name, fp.description, ft.name, fp.base_price, 
-				 fp.status, fp.stock_quantity, fp.created_at, fp.updated_at, sales_data.sales_rank`
-
-	row := r.db.QueryRow(query, id)
+		LEFT JOIN ProductStats ps ON ps.product_id = fp.product_id
+		WHERE fp.product_id = ? AND fp.is_active = TRUE`
+
+	row := r.db.QueryRowContext(ctx, query, id)
 
 	var product model.Product
 	if err := row.Scan(&product.ProductID, &product.Name, &product.Description,
 		&product.FlowerType, &product.BasePrice, &product.CurrentPrice,
 		&product.Status, &product.StockQuantity, &product.CreatedAt, &product.UpdatedAt,
-		&product.AverageRating, &product.ReviewCount, &product.SalesRank); err != nil {
+		&product.AverageRating, &product.ReviewCount, &product.SalesRank,
+		&product.UnitsSold7d, &product.UnitsSold30d); err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("not found")
+			return nil, apperror.NotFound("product not found")
 		}
 		return nil, err
 	}
 
 	// Get product images
-	images, err := r.GetProductImages(id)
+	images, err := r.GetProductImages(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	product.Images = images
 
 	// Get product occasions
-	occasions, err := r.GetProductOccasions(id)
+	occasions, err := r.GetProductOccasions(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	product.Occasions = occasions
 
+	// Get product variants
+	variants, err := r.GetProductVariants(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	product.Variants = variants
+
 	return &product, nil
 }
 
-func (r *repository) GetProductImages(productID uint) ([]model.ProductImage, error) {
-	query := "SELECT image_id, product_id, image_url, alt_text, is_primary FROM ProductImage WHERE product_id = ? ORDER BY is_primary DESC, image_id ASC"
-	rows, err := r.db.Query(query, productID)
+func (r *repository) GetProductImages(ctx context.Context, productID uint) ([]model.ProductImage, error) {
+	query := `SELECT image_id, product_id, image_url, COALESCE(thumbnail_url, ''), COALESCE(alt_text, ''), is_primary
+			  FROM ProductImage WHERE product_id = ? ORDER BY is_primary DESC, image_id ASC`
+	rows, err := r.db.QueryContext(ctx, query, productID)
 	if err != nil {
 		return nil, err
 	}
@@ -475,7 +612,7 @@ func (r *repository) GetProductImages(productID uint) ([]model.ProductImage, err
 	var images []model.ProductImage
 	for rows.Next() {
 		var image model.ProductImage
-		if err := rows.Scan(&image.ImageID, &image.ProductID, &image.ImageURL, &image.AltText, &image.IsPrimary); err != nil {
+		if err := rows.Scan(&image.ImageID, &image.ProductID, &image.ImageURL, &image.ThumbnailURL, &image.AltText, &image.IsPrimary); err != nil {
 			return nil, err
 		}
 		images = append(images, image)
@@ -484,11 +621,11 @@ func (r *repository) GetProductImages(productID uint) ([]model.ProductImage, err
 	return images, nil
 }
 
-func (r *repository) GetProductOccasions(productID uint) ([]string, error) {
+func (r *repository) GetProductOccasions(ctx context.Context, productID uint) ([]string, error) {
 	query := `SELECT o.name FROM ProductOccasion po 
 			  JOIN Occasion o ON po.occasion_id = o.occasion_id 
 			  WHERE po.product_id = ?`
-	rows, err := r.db.Query(query, productID)
+	rows, err := r.db.QueryContext(ctx, query, productID)
 	if err != nil {
 		return nil, err
 	}
@@ -506,9 +643,9 @@ func (r *repository) GetProductOccasions(productID uint) ([]string, error) {
 	return occasions, nil
 }
 
-func (r *repository) GetAllOccasions() ([]model.Occasion, error) {
+func (r *repository) GetAllOccasions(ctx context.Context) ([]model.Occasion, error) {
 	query := "SELECT occasion_id, name FROM Occasion ORDER BY name"
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -526,42 +663,61 @@ func (r *repository) GetAllOccasions() ([]model.Occasion, error) {
 	return occasions, nil
 }
 
-func (r *repository) GetPriceRange() (*model.PriceRange, error) {
-	query := "SELECT MIN(base_price), MAX(base_price) FROM FlowerProduct WHERE stock_quantity > 0"
-	row := r.db.QueryRow(query)
+// GetProductsForIndex returns all active products with their occasions, the
+// text the search index is built from
+func (r *repository) GetProductsForIndex(ctx context.Context) ([]model.Product, error) {
+	products, err := r.GetAllProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	var priceRange model.PriceRange
-	if err := row.Scan(&priceRange.Min, &priceRange.Max); err != nil {
+	rows, err := r.db.QueryContext(ctx, `SELECT po.product_id, o.name FROM ProductOccasion po
+			  JOIN Occasion o ON po.occasion_id = o.occasion_id`)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return &priceRange, nil
+	occasions := make(map[uint][]string)
+	for rows.Next() {
+		var productID uint
+		var name string
+		if err := rows.Scan(&productID, &name); err != nil {
+			return nil, err
+		}
+		occasions[productID] = append(occasions[productID], name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range products {
+		products[i].Occasions = occasions[products[i].ProductID]
+	}
+	return products, nil
 }
 
-func (r *repository) GetProductStatistics(productID uint) (averageRating float64, reviewCount int, salesRank int, error error) {
-	// Get rating statistics
-	ratingQuery := "SELECT COALESCE(AVG(rating), 0), COUNT(*) FROM Review WHERE product_id = ?"
-	if err := r.db.QueryRow(ratingQuery, productID).Scan(&averageRating, &reviewCount); err != nil {
-		return 0, 0, 0, err
+func (r *repository) GetPriceRange(ctx context.Context) (*model.PriceRange, error) {
+	query := "SELECT MIN(" + effectivePriceExpr + "), MAX(" + effectivePriceExpr + ")" +
+		searchFromClause + " WHERE fp.stock_quantity > 0"
+	row := r.db.QueryRowContext(ctx, query)
+
+	var priceRange model.PriceRange
+	if err := row.Scan(&priceRange.Min, &priceRange.Max); err != nil {
+		return nil, err
 	}
 
-	// Get sales rank
-	salesQuery := `
-		SELECT COALESCE(ranking.rank, 999999) FROM (
-			SELECT oi.product_id, ROW_NUMBER() OVER (ORDER BY SUM(oi.quantity) DESC) as rank
-			FROM OrderItem oi
-			JOIN ` + "`Order`" + ` o ON oi.order_id = o.order_id
-			WHERE o.status = 'Completed'
-			GROUP BY oi.product_id
-		) ranking WHERE ranking.product_id = ?`
+	return &priceRange, nil
+}
 
-	if err := r.db.QueryRow(salesQuery, productID).Scan(&salesRank); err != nil {
+func (r *repository) GetProductStatistics(ctx context.Context, productID uint) (averageRating float64, reviewCount int, salesRank int, error error) {
+	query := `SELECT COALESCE(average_rating, 0), COALESCE(review_count, 0), COALESCE(sales_rank, 999999)
+		FROM ProductStats WHERE product_id = ?`
+	if err := r.db.QueryRowContext(ctx, query, productID).Scan(&averageRating, &reviewCount, &salesRank); err != nil {
 		if err == sql.ErrNoRows {
-			salesRank = 999999 // No sales yet
-		} else {
-			return 0, 0, 0, err
+			return 0, 0, 999999, nil // No reviews or sales yet
 		}
+		return 0, 0, 0, err
 	}
-
 	return averageRating, reviewCount, salesRank, nil
 }
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"flowo-backend/internal/dto"
+)
+
+func TestBuildSearchConditions(t *testing.T) {
+	value := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name           string
+		query          dto.ProductSearchQuery
+		skip           string
+		wantConditions []string
+		wantArgs       []interface{}
+	}{
+		{
+			name:           "no filters",
+			wantConditions: []string{"fp.is_active = TRUE"},
+		},
+		{
+			name:           "minimum rating counts unrated products as zero",
+			query:          dto.ProductSearchQuery{MinRating: value(4)},
+			wantConditions: []string{"fp.is_active = TRUE", "COALESCE(ps.average_rating, 0) >= ?"},
+			wantArgs:       []interface{}{4.0},
+		},
+		{
+			name:           "minimum rating applies to every facet",
+			query:          dto.ProductSearchQuery{MinRating: value(3.5), PriceMin: value(10), Condition: []string{"NewFlower"}},
+			skip:           facetPrice,
+			wantConditions: []string{"fp.is_active = TRUE", "COALESCE(ps.average_rating, 0) >= ?", "fp.status IN (?)"},
+			wantArgs:       []interface{}{3.5, "NewFlower"},
+		},
+		{
+			name:           "matched ids replace the text filter",
+			query:          dto.ProductSearchQuery{Query: "rose", MatchedIDs: []uint{3, 1}, FlowerType: []string{"Rose", "Lily"}},
+			skip:           facetFlowerType,
+			wantConditions: []string{"fp.is_active = TRUE", "fp.product_id IN (?,?)"},
+			wantArgs:       []interface{}{uint(3), uint(1)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions, args := buildSearchConditions(&tt.query, tt.skip)
+			if !reflect.DeepEqual(conditions, tt.wantConditions) || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("buildSearchConditions() = %q %v, want %q %v", conditions, args, tt.wantConditions, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildOrderByClause(t *testing.T) {
+	r := &repository{}
+
+	tests := []struct {
+		sortBy    string
+		rankedIDs []uint
+		want      string
+	}{
+		{"top_rated", nil, "average_rating DESC, review_count DESC, fp.created_at DESC"},
+		{"best_selling", nil, "sales_rank ASC, fp.created_at DESC"},
+		{"relevance", []uint{7, 2}, "FIELD(fp.product_id, 7, 2), fp.created_at DESC"},
+		{"relevance", nil, "fp.created_at DESC"},
+		{"", nil, "fp.created_at DESC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			if got := r.buildOrderByClause(tt.sortBy, tt.rankedIDs); got != tt.want {
+				t.Errorf("buildOrderByClause(%q) = %s, want %s", tt.sortBy, got, tt.want)
+			}
+		})
+	}
+
+	if got := r.buildOrderByClause("price_asc", nil); !strings.HasPrefix(got, effectivePriceExpr) {
+		t.Errorf("buildOrderByClause(\"price_asc\") = %s, want the effective price first", got)
+	}
+}
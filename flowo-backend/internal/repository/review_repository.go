@@ -1,16 +1,42 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	//"errors"
+	"time"
+
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/model"
+	"flowo-backend/internal/pagination"
 )
 
 type ReviewRepository interface {
-	CreateReview(review *model.Review) error
-	GetAllReviews() ([]model.Review, error)
-	GetReviewsByProductID(productID int) ([]model.Review, error)
-	DeleteReview(reviewID int) error
+	CreateReview(ctx context.Context, review *model.Review, orderItemID *int) error
+	GetAllReviews(ctx context.Context) ([]model.Review, error)
+	GetReviewByID(ctx context.Context, reviewID int) (*model.Review, error)
+	GetReviewsByProductID(ctx context.Context, productID int, sort string, page pagination.Request) ([]model.Review, int, error)
+	GetReviewsByStatus(ctx context.Context, status string, page pagination.Request) ([]model.Review, int, error)
+	GetUnansweredReviews(ctx context.Context, maxRating int, page pagination.Request) ([]model.Review, int, error)
+	GetRatingDistribution(ctx context.Context, productID int) (map[int]int, error)
+	UpdateReview(ctx context.Context, review *model.Review) error
+	ModerateReview(ctx context.Context, reviewID int, status, reason, moderator string) error
+	DeleteReview(ctx context.Context, reviewID int) error
+
+	// photos
+	AddReviewPhoto(ctx context.Context, photo *model.ReviewPhoto, maxPhotos int) error
+	GetReviewPhotoByID(ctx context.Context, photoID int) (*model.ReviewPhoto, error)
+	GetReviewPhotos(ctx context.Context, reviewIDs []int) (map[int][]model.ReviewPhoto, error)
+	DeleteReviewPhoto(ctx context.Context, photoID int) error
+
+	// staff replies
+	CreateReviewReply(ctx context.Context, reply *model.ReviewReply) error
+	GetReviewReplyByID(ctx context.Context, replyID int) (*model.ReviewReply, error)
+	GetReviewReplies(ctx context.Context, reviewIDs []int) (map[int][]model.ReviewReply, error)
+	DeleteReviewReply(ctx context.Context, replyID int) error
+
+	// helpfulness votes
+	SetReviewVote(ctx context.Context, reviewID int, firebaseUID string, helpful bool) (helpfulCount, notHelpfulCount int, err error)
+	DeleteReviewVote(ctx context.Context, reviewID int, firebaseUID string) (helpfulCount, notHelpfulCount int, err error)
 }
 
 type reviewRepository struct {
@@ -21,53 +47,492 @@ func NewReviewRepository(db *sql.DB) ReviewRepository {
 	return &reviewRepository{db: db}
 }
 
-func (r *reviewRepository) CreateReview(review *model.Review) error {
-	query := `INSERT INTO Review (product_id, firebase_uid, rating, comment, review_date)
-	          VALUES (?, ?, ?, ?, NOW())`
-	_, err := r.db.Exec(query, review.ProductID, review.FirebaseUID, review.Rating, review.Comment)
+const reviewColumns = `r.review_id, r.product_id, r.firebase_uid, r.rating, COALESCE(r.comment, ''), r.review_date,
+	COALESCE(NULLIF(u.full_name, ''), NULLIF(u.username, ''), 'Anonymous'),
+	r.order_item_id, r.status, r.moderation_reason, r.moderated_by, r.moderated_at, r.updated_at,
+	r.helpful_count, r.not_helpful_count`
+
+const reviewFrom = ` FROM Review r LEFT JOIN User u ON u.firebase_uid = r.firebase_uid`
+
+// reviewSortOrders are the orders besides newest first; they page by offset
+var reviewSortOrders = map[string]string{
+	"highest":      "r.rating DESC, r.review_date DESC, r.review_id DESC",
+	"lowest":       "r.rating ASC, r.review_date DESC, r.review_id DESC",
+	"most_helpful": "r.helpful_count DESC, r.review_date DESC, r.review_id DESC",
+}
+
+// CreateReview ties the review to one of the reviewer's completed order items
+// for the product, orderItemID if given, else the oldest one not reviewed
+// yet, and inserts it. The order items are locked so that concurrent
+// reviews cannot claim the same one.
+func (r *reviewRepository) CreateReview(ctx context.Context, review *model.Review, orderItemID *int) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT oi.order_item_id,
+			EXISTS (SELECT 1 FROM Review rv WHERE rv.order_item_id = oi.order_item_id)
+		FROM OrderItem oi
+		JOIN `+"`Order`"+` o ON oi.order_id = o.order_id
+		WHERE o.firebase_uid = ? AND oi.product_id = ? AND o.status = 'Completed'
+		ORDER BY o.order_date ASC, oi.order_item_id ASC
+		FOR UPDATE`, review.FirebaseUID, review.ProductID)
+	if err != nil {
+		return err
+	}
+
+	purchased, chosen, requestedReviewed := false, 0, false
+	for rows.Next() {
+		var itemID int
+		var reviewed bool
+		if err = rows.Scan(&itemID, &reviewed); err != nil {
+			rows.Close()
+			return err
+		}
+		purchased = true
+		if orderItemID != nil {
+			if itemID == *orderItemID {
+				chosen, requestedReviewed = itemID, reviewed
+			}
+			continue
+		}
+		if !reviewed && chosen == 0 {
+			chosen = itemID
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	switch {
+	case !purchased:
+		err = apperror.Forbidden("review requires a completed purchase of this product")
+	case orderItemID != nil && chosen == 0:
+		err = apperror.Forbidden("order item not eligible for review")
+	case requestedReviewed:
+		err = apperror.Conflict("order item already reviewed")
+	case chosen == 0:
+		err = apperror.Conflict("product already reviewed for every purchase")
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO Review (product_id, firebase_uid, rating, comment, review_date, order_item_id, status, moderation_reason)
+		VALUES (?, ?, ?, ?, NOW(), ?, ?, ?)`,
+		review.ProductID, review.FirebaseUID, review.Rating, review.Comment, chosen, review.Status,
+		nullString(review.ModerationReason))
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	review.ReviewID = int(id)
+	review.OrderItemID = &chosen
+	review.ReviewDate = time.Now()
+	return nil
+}
+
+func (r *reviewRepository) GetAllReviews(ctx context.Context) ([]model.Review, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+reviewColumns+reviewFrom)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanReviews(rows)
+}
+
+func (r *reviewRepository) GetReviewByID(ctx context.Context, reviewID int) (*model.Review, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+reviewColumns+reviewFrom+` WHERE r.review_id = ?`, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews, err := scanReviews(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(reviews) == 0 {
+		return nil, apperror.NotFound("review not found")
+	}
+	return &reviews[0], nil
+}
+
+// GetReviewsByProductID returns the number of published reviews of a product
+// and up to page.Fetch() of them in the given sort order, newest first by
+// default
+func (r *reviewRepository) GetReviewsByProductID(ctx context.Context, productID int, sort string, page pagination.Request) ([]model.Review, int, error) {
+	return r.listReviews(ctx, "r.product_id = ? AND r.status = 'approved'", []interface{}{productID}, sort, page)
+}
+
+// GetReviewsByStatus pages through the reviews in a moderation status, newest first
+func (r *reviewRepository) GetReviewsByStatus(ctx context.Context, status string, page pagination.Request) ([]model.Review, int, error) {
+	return r.listReviews(ctx, "r.status = ?", []interface{}{status}, "", page)
+}
+
+// GetUnansweredReviews pages through published reviews rated maxRating or
+// lower that no staff member has replied to yet, newest first
+func (r *reviewRepository) GetUnansweredReviews(ctx context.Context, maxRating int, page pagination.Request) ([]model.Review, int, error) {
+	return r.listReviews(ctx, `r.status = 'approved' AND r.rating <= ?
+		AND NOT EXISTS (SELECT 1 FROM ReviewReply rr WHERE rr.review_id = r.review_id)`,
+		[]interface{}{maxRating}, "", page)
+}
+
+func (r *reviewRepository) listReviews(ctx context.Context, where string, args []interface{}, sort string, page pagination.Request) ([]model.Review, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM Review r WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	orderBy, ok := reviewSortOrders[sort]
+	if !ok {
+		var keyset string
+		var keysetArgs []interface{}
+		keyset, keysetArgs, orderBy = page.Keyset("r.review_date", "r.review_id")
+		if keyset != "" {
+			where += " AND " + keyset
+			args = append(args, keysetArgs...)
+		}
+	}
+	query := `SELECT ` + reviewColumns + reviewFrom + ` WHERE ` + where +
+		` ORDER BY ` + orderBy + ` LIMIT ? OFFSET ?`
+	args = append(args, page.Fetch(), page.Offset())
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	reviews, err := scanReviews(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+// GetRatingDistribution counts the published reviews of a product per star rating
+func (r *reviewRepository) GetRatingDistribution(ctx context.Context, productID int) (map[int]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT rating, COUNT(*) FROM Review
+		WHERE product_id = ? AND status = 'approved'
+		GROUP BY rating`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int]int)
+	for rows.Next() {
+		var rating, count int
+		if err := rows.Scan(&rating, &count); err != nil {
+			return nil, err
+		}
+		counts[rating] = count
+	}
+	return counts, rows.Err()
+}
+
+// UpdateReview saves an edit by the author; the status and reason come from
+// re-screening the text, and any earlier moderation decision is cleared
+func (r *reviewRepository) UpdateReview(ctx context.Context, review *model.Review) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE Review SET rating = ?, comment = ?, status = ?, moderation_reason = ?,
+			moderated_by = NULL, moderated_at = NULL, updated_at = NOW()
+		WHERE review_id = ?`,
+		review.Rating, review.Comment, review.Status, nullString(review.ModerationReason), review.ReviewID)
+	return err
+}
+
+func (r *reviewRepository) ModerateReview(ctx context.Context, reviewID int, status, reason, moderator string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE Review SET status = ?, moderation_reason = ?, moderated_by = ?, moderated_at = NOW()
+		WHERE review_id = ?`,
+		status, emptyToNull(reason), moderator, reviewID)
 	return err
 }
 
-func (r *reviewRepository) GetAllReviews() ([]model.Review, error) {
-	query := `SELECT review_id, product_id, firebase_uid, rating, comment, review_date FROM Review`
-	rows, err := r.db.Query(query)
+func (r *reviewRepository) DeleteReview(ctx context.Context, reviewID int) error {
+	query := `DELETE FROM Review WHERE review_id = ?`
+	_, err := r.db.ExecContext(ctx, query, reviewID)
+	return err
+}
+
+// AddReviewPhoto attaches the photo unless the review already has maxPhotos.
+// The review row is locked so concurrent uploads cannot exceed the limit.
+func (r *reviewRepository) AddReviewPhoto(ctx context.Context, photo *model.ReviewPhoto, maxPhotos int) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var reviewID int
+	err = tx.QueryRowContext(ctx, `SELECT review_id FROM Review WHERE review_id = ? FOR UPDATE`, photo.ReviewID).Scan(&reviewID)
+	if err == sql.ErrNoRows {
+		return apperror.NotFound("review not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	var count int
+	if err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM ReviewPhoto WHERE review_id = ?`, photo.ReviewID).Scan(&count); err != nil {
+		return err
+	}
+	if count >= maxPhotos {
+		return apperror.Conflict("review already has the maximum number of photos")
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO ReviewPhoto (review_id, image_url, thumbnail_url, storage_key, thumbnail_key)
+		VALUES (?, ?, ?, ?, ?)`,
+		photo.ReviewID, photo.ImageURL, photo.ThumbnailURL, photo.StorageKey, photo.ThumbnailKey)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	photo.PhotoID = int(id)
+	photo.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *reviewRepository) GetReviewPhotoByID(ctx context.Context, photoID int) (*model.ReviewPhoto, error) {
+	var p model.ReviewPhoto
+	err := r.db.QueryRowContext(ctx, `
+		SELECT photo_id, review_id, image_url, thumbnail_url, storage_key, thumbnail_key, created_at
+		FROM ReviewPhoto WHERE photo_id = ?`, photoID).
+		Scan(&p.PhotoID, &p.ReviewID, &p.ImageURL, &p.ThumbnailURL, &p.StorageKey, &p.ThumbnailKey, &p.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, apperror.NotFound("photo not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetReviewPhotos loads the photos of several reviews at once, keyed by review
+func (r *reviewRepository) GetReviewPhotos(ctx context.Context, reviewIDs []int) (map[int][]model.ReviewPhoto, error) {
+	photos := make(map[int][]model.ReviewPhoto)
+	if len(reviewIDs) == 0 {
+		return photos, nil
+	}
+
+	args := make([]interface{}, len(reviewIDs))
+	for i, id := range reviewIDs {
+		args[i] = id
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT photo_id, review_id, image_url, thumbnail_url, storage_key, thumbnail_key, created_at
+		FROM ReviewPhoto WHERE review_id IN (`+placeholders(len(reviewIDs))+`)
+		ORDER BY review_id, photo_id`, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var reviews []model.Review
 	for rows.Next() {
-		var review model.Review
-		if err := rows.Scan(&review.ReviewID, &review.ProductID, &review.FirebaseUID, &review.Rating, &review.Comment, &review.ReviewDate); err != nil {
+		var p model.ReviewPhoto
+		if err := rows.Scan(&p.PhotoID, &p.ReviewID, &p.ImageURL, &p.ThumbnailURL, &p.StorageKey, &p.ThumbnailKey, &p.CreatedAt); err != nil {
 			return nil, err
 		}
-		reviews = append(reviews, review)
+		photos[p.ReviewID] = append(photos[p.ReviewID], p)
+	}
+	return photos, rows.Err()
+}
+
+func (r *reviewRepository) DeleteReviewPhoto(ctx context.Context, photoID int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM ReviewPhoto WHERE photo_id = ?`, photoID)
+	return err
+}
+
+const replyColumns = `rr.reply_id, rr.review_id, rr.parent_reply_id, rr.firebase_uid,
+	COALESCE(NULLIF(u.full_name, ''), NULLIF(u.username, ''), 'Shop staff'), rr.message, rr.created_at
+	FROM ReviewReply rr LEFT JOIN User u ON u.firebase_uid = rr.firebase_uid`
+
+func (r *reviewRepository) CreateReviewReply(ctx context.Context, reply *model.ReviewReply) error {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO ReviewReply (review_id, parent_reply_id, firebase_uid, message)
+		VALUES (?, ?, ?, ?)`,
+		reply.ReviewID, reply.ParentReplyID, reply.FirebaseUID, reply.Message)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	reply.ReplyID = int(id)
+	reply.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *reviewRepository) GetReviewReplyByID(ctx context.Context, replyID int) (*model.ReviewReply, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+replyColumns+` WHERE rr.reply_id = ?`, replyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	replies, err := scanReviewReplies(rows)
+	if err != nil {
+		return nil, err
 	}
-	return reviews, nil
+	if len(replies) == 0 {
+		return nil, apperror.NotFound("reply not found")
+	}
+	return &replies[0], nil
 }
 
-func (r *reviewRepository) GetReviewsByProductID(productID int) ([]model.Review, error) {
-	query := `SELECT review_id, product_id, firebase_uid, rating, comment, review_date FROM Review WHERE product_id = ?`
-	rows, err := r.db.Query(query, productID)
+// GetReviewReplies loads the replies of several reviews at once, keyed by
+// review, oldest first
+func (r *reviewRepository) GetReviewReplies(ctx context.Context, reviewIDs []int) (map[int][]model.ReviewReply, error) {
+	replies := make(map[int][]model.ReviewReply)
+	if len(reviewIDs) == 0 {
+		return replies, nil
+	}
+
+	args := make([]interface{}, len(reviewIDs))
+	for i, id := range reviewIDs {
+		args[i] = id
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT `+replyColumns+`
+		WHERE rr.review_id IN (`+placeholders(len(reviewIDs))+`)
+		ORDER BY rr.review_id, rr.created_at, rr.reply_id`, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	list, err := scanReviewReplies(rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, reply := range list {
+		replies[reply.ReviewID] = append(replies[reply.ReviewID], reply)
+	}
+	return replies, nil
+}
+
+// DeleteReviewReply removes the reply together with the replies answering it
+func (r *reviewRepository) DeleteReviewReply(ctx context.Context, replyID int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM ReviewReply WHERE reply_id = ?`, replyID)
+	return err
+}
+
+func scanReviewReplies(rows *sql.Rows) ([]model.ReviewReply, error) {
+	var replies []model.ReviewReply
+	for rows.Next() {
+		var reply model.ReviewReply
+		var parentID sql.NullInt64
+		if err := rows.Scan(&reply.ReplyID, &reply.ReviewID, &parentID, &reply.FirebaseUID,
+			&reply.AuthorName, &reply.Message, &reply.CreatedAt); err != nil {
+			return nil, err
+		}
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			reply.ParentReplyID = &id
+		}
+		replies = append(replies, reply)
+	}
+	return replies, rows.Err()
+}
+
+// SetReviewVote records or changes the user's vote and returns the review's new counts
+func (r *reviewRepository) SetReviewVote(ctx context.Context, reviewID int, firebaseUID string, helpful bool) (int, int, error) {
+	return r.changeVote(ctx, reviewID, `
+		INSERT INTO ReviewVote (review_id, firebase_uid, helpful) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE helpful = VALUES(helpful)`, reviewID, firebaseUID, helpful)
+}
+
+// DeleteReviewVote withdraws the user's vote and returns the review's new counts
+func (r *reviewRepository) DeleteReviewVote(ctx context.Context, reviewID int, firebaseUID string) (int, int, error) {
+	return r.changeVote(ctx, reviewID, `DELETE FROM ReviewVote WHERE review_id = ? AND firebase_uid = ?`, reviewID, firebaseUID)
+}
+
+// changeVote applies a vote change and recounts the review's votes in the
+// same transaction, so the denormalized counts never drift
+func (r *reviewRepository) changeVote(ctx context.Context, reviewID int, query string, args ...interface{}) (helpful, notHelpful int, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return 0, 0, err
+	}
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE Review SET
+			helpful_count = (SELECT COUNT(*) FROM ReviewVote WHERE review_id = ? AND helpful = TRUE),
+			not_helpful_count = (SELECT COUNT(*) FROM ReviewVote WHERE review_id = ? AND helpful = FALSE)
+		WHERE review_id = ?`, reviewID, reviewID, reviewID); err != nil {
+		return 0, 0, err
+	}
+	err = tx.QueryRowContext(ctx, `SELECT helpful_count, not_helpful_count FROM Review WHERE review_id = ?`, reviewID).
+		Scan(&helpful, &notHelpful)
+	return helpful, notHelpful, err
+}
+
+func scanReviews(rows *sql.Rows) ([]model.Review, error) {
 	var reviews []model.Review
 	for rows.Next() {
 		var review model.Review
-		if err := rows.Scan(&review.ReviewID, &review.ProductID, &review.FirebaseUID, &review.Rating, &review.Comment, &review.ReviewDate); err != nil {
+		var orderItemID sql.NullInt64
+		var reason, moderatedBy sql.NullString
+		var moderatedAt, updatedAt sql.NullTime
+		if err := rows.Scan(&review.ReviewID, &review.ProductID, &review.FirebaseUID, &review.Rating,
+			&review.Comment, &review.ReviewDate, &review.ReviewerName, &orderItemID, &review.Status, &reason,
+			&moderatedBy, &moderatedAt, &updatedAt, &review.HelpfulCount, &review.NotHelpfulCount); err != nil {
 			return nil, err
 		}
+		if orderItemID.Valid {
+			id := int(orderItemID.Int64)
+			review.OrderItemID = &id
+		}
+		if reason.Valid {
+			review.ModerationReason = &reason.String
+		}
+		if moderatedBy.Valid {
+			review.ModeratedBy = &moderatedBy.String
+		}
+		if moderatedAt.Valid {
+			review.ModeratedAt = &moderatedAt.Time
+		}
+		if updatedAt.Valid {
+			review.UpdatedAt = &updatedAt.Time
+		}
 		reviews = append(reviews, review)
 	}
-	return reviews, nil
-}
-
-func (r *reviewRepository) DeleteReview(reviewID int) error {
-	query := `DELETE FROM Review WHERE review_id = ?`
-	_, err := r.db.Exec(query, reviewID)
-	return err
+	return reviews, rows.Err()
 }
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"flowo-backend/internal/model"
+)
+
+type SearchRepository interface {
+	RecordSearchQuery(ctx context.Context, normalized, display string) error
+	GetPopularSearchQueries(ctx context.Context, minCount, limit int) ([]model.SearchQueryStat, error)
+}
+
+type searchRepository struct {
+	DB *sql.DB
+}
+
+func NewSearchRepository(db *sql.DB) SearchRepository {
+	return &searchRepository{DB: db}
+}
+
+func (r *searchRepository) RecordSearchQuery(ctx context.Context, normalized, display string) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO SearchQuery (normalized_query, display_query, search_count)
+		VALUES (?, ?, 1)
+		ON DUPLICATE KEY UPDATE search_count = search_count + 1, display_query = VALUES(display_query)`,
+		normalized, display)
+	return err
+}
+
+func (r *searchRepository) GetPopularSearchQueries(ctx context.Context, minCount, limit int) ([]model.SearchQueryStat, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT normalized_query, display_query, search_count, last_searched_at
+		FROM SearchQuery
+		WHERE search_count >= ?
+		ORDER BY search_count DESC
+		LIMIT ?`, minCount, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []model.SearchQueryStat
+	for rows.Next() {
+		var s model.SearchQueryStat
+		if err := rows.Scan(&s.NormalizedQuery, &s.DisplayQuery, &s.SearchCount, &s.LastSearchedAt); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
@@ -1,19 +1,22 @@
 package repository
 
 import (
+	"context"
+
 	"database/sql"
 	"flowo-backend/internal/model"
+	"flowo-backend/internal/pagination"
 	"fmt"
 )
 
 type UserRepository interface {
-	CreateUser(user *model.User) error
-	GetUserByFirebaseUID(firebaseUID string) (*model.User, error)
-	GetUserByEmail(email string) (*model.User, error)
-	UpdateUser(user *model.User) error
-	CheckUserExists(firebaseUID string) (bool, error)
-	GetAllUsers() ([]*model.UserWithAddress, error)
-	SoftDeleteUser(firebaseUID string) error
+	CreateUser(ctx context.Context, user *model.User) error
+	GetUserByFirebaseUID(ctx context.Context, firebaseUID string) (*model.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	UpdateUser(ctx context.Context, user *model.User) error
+	CheckUserExists(ctx context.Context, firebaseUID string) (bool, error)
+	GetAllUsers(ctx context.Context, page pagination.Request) ([]*model.UserWithAddress, int, error)
+	SoftDeleteUser(ctx context.Context, firebaseUID string) error
 }
 
 type userRepository struct {
@@ -25,12 +28,12 @@ func NewUserRepository(db *sql.DB) UserRepository {
 }
 
 // CreateUser
-func (r *userRepository) CreateUser(user *model.User) error {
+func (r *userRepository) CreateUser(ctx context.Context, user *model.User) error {
 	query := `
 		INSERT INTO User (firebase_uid, username, email, full_name, gender, role, created_at, updated_at, is_deleted) 
 		VALUES (?, ?, ?, ?, ?, ?, NOW(), NOW(), FALSE)
 	`
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, query,
 		user.FirebaseUID,
 		user.Username,
 		user.Email,
@@ -45,14 +48,14 @@ func (r *userRepository) CreateUser(user *model.User) error {
 }
 
 // GetUserByFirebaseUID
-func (r *userRepository) GetUserByFirebaseUID(firebaseUID string) (*model.User, error) {
+func (r *userRepository) GetUserByFirebaseUID(ctx context.Context, firebaseUID string) (*model.User, error) {
 	user := &model.User{}
 	query := `
 		SELECT firebase_uid, username, email, full_name, gender, role, created_at, updated_at 
 		FROM User 
 		WHERE firebase_uid = ? AND is_deleted = FALSE
 	`
-	err := r.db.QueryRow(query, firebaseUID).Scan(
+	err := r.db.QueryRowContext(ctx, query, firebaseUID).Scan(
 		&user.FirebaseUID,
 		&user.Username,
 		&user.Email,
@@ -72,14 +75,14 @@ func (r *userRepository) GetUserByFirebaseUID(firebaseUID string) (*model.User,
 }
 
 // GetUserByEmail
-func (r *userRepository) GetUserByEmail(email string) (*model.User, error) {
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	user := &model.User{}
 	query := `
 		SELECT firebase_uid, username, email, full_name, gender, role, created_at, updated_at 
 		FROM User 
 		WHERE email = ? AND is_deleted = FALSE
 	`
-	err := r.db.QueryRow(query, email).Scan(
+	err := r.db.QueryRowContext(ctx, query, email).Scan(
 		&user.FirebaseUID,
 		&user.Username,
 		&user.Email,
@@ -99,13 +102,13 @@ func (r *userRepository) GetUserByEmail(email string) (*model.User, error) {
 }
 
 // UpdateUser
-func (r *userRepository) UpdateUser(user *model.User) error {
+func (r *userRepository) UpdateUser(ctx context.Context, user *model.User) error {
 	query := `
 		UPDATE User 
 		SET username = ?, email = ?, full_name = ?, gender = ?, role = ?, updated_at = NOW() 
 		WHERE firebase_uid = ? AND is_deleted = FALSE
 	`
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, query,
 		user.Username,
 		user.Email,
 		user.FullName,
@@ -120,10 +123,10 @@ func (r *userRepository) UpdateUser(user *model.User) error {
 }
 
 // CheckUserExists
-func (r *userRepository) CheckUserExists(firebaseUID string) (bool, error) {
+func (r *userRepository) CheckUserExists(ctx context.Context, firebaseUID string) (bool, error) {
 	var count int
 	query := "SELECT COUNT(*) FROM User WHERE firebase_uid = ? AND is_deleted = FALSE"
-	err := r.db.QueryRow(query, firebaseUID).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, firebaseUID).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("failed to check user existence: %w", err)
 	}
@@ -131,21 +134,32 @@ func (r *userRepository) CheckUserExists(firebaseUID string) (bool, error) {
 }
 
 // SoftDeleteUser
-func (r *userRepository) SoftDeleteUser(firebaseUID string) error {
+func (r *userRepository) SoftDeleteUser(ctx context.Context, firebaseUID string) error {
 	query := `
 		UPDATE User 
 		SET is_deleted = TRUE, updated_at = NOW() 
 		WHERE firebase_uid = ?
 	`
-	_, err := r.db.Exec(query, firebaseUID)
+	_, err := r.db.ExecContext(ctx, query, firebaseUID)
 	if err != nil {
 		return fmt.Errorf("failed to soft delete user: %w", err)
 	}
 	return nil
 }
 
-// GetAllUsers
-func (r *userRepository) GetAllUsers() ([]*model.UserWithAddress, error) {
+// GetAllUsers returns the number of users and up to page.Fetch() of them,
+// newest first
+func (r *userRepository) GetAllUsers(ctx context.Context, page pagination.Request) ([]*model.UserWithAddress, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM User WHERE is_deleted = FALSE").Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	where := "WHERE u.is_deleted = FALSE"
+	keyset, args, orderBy := page.Keyset("u.created_at", "u.firebase_uid")
+	if keyset != "" {
+		where += " AND " + keyset
+	}
 	query := `
 		SELECT 
 			u.firebase_uid, u.username, u.email, u.full_name, u.gender, u.role, u.created_at, u.updated_at,
@@ -153,11 +167,14 @@ func (r *userRepository) GetAllUsers() ([]*model.UserWithAddress, error) {
 		FROM User u
 		LEFT JOIN Address a 
 			ON u.firebase_uid = a.firebase_uid AND a.is_default_shipping = true
-		WHERE u.is_deleted = FALSE
+		` + where + `
+		ORDER BY ` + orderBy + `
+		LIMIT ? OFFSET ?
 	`
-	rows, err := r.db.Query(query)
+	args = append(args, page.Fetch(), page.Offset())
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query users with addresses: %w", err)
+		return nil, 0, fmt.Errorf("failed to query users with addresses: %w", err)
 	}
 	defer rows.Close()
 
@@ -186,7 +203,7 @@ func (r *userRepository) GetAllUsers() ([]*model.UserWithAddress, error) {
 			&country,
 		)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
 		if username.Valid {
@@ -211,5 +228,5 @@ func (r *userRepository) GetAllUsers() ([]*model.UserWithAddress, error) {
 
 		users = append(users, &user)
 	}
-	return users, nil
+	return users, total, rows.Err()
 }
@@ -0,0 +1,248 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Field weights: a match in the name counts three times a match in the
+// description, flower type and occasions count twice
+const (
+	nameWeight        = 3
+	flowerTypeWeight  = 2
+	occasionWeight    = 2
+	descriptionWeight = 1
+)
+
+// BM25 parameters
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Weights of terms that only match a query token approximately
+const (
+	prefixMatchWeight = 0.7
+	typoMatchWeight   = 0.6
+)
+
+// Document is the searchable text of one product
+type Document struct {
+	ProductID   uint
+	Name        string
+	Description string
+	FlowerType  string
+	Occasions   []string
+}
+
+// Hit is a matching product with its relevance score
+type Hit struct {
+	ProductID uint
+	Score     float64
+}
+
+type indexedDoc struct {
+	length int
+	terms  map[string]int
+}
+
+// Index is an in-memory inverted index over product text with BM25 ranking.
+// It is safe for concurrent use.
+type Index struct {
+	mu          sync.RWMutex
+	docs        map[uint]indexedDoc
+	postings    map[string]map[uint]int
+	totalLength int
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[uint]indexedDoc),
+		postings: make(map[string]map[uint]int),
+	}
+}
+
+// Replace drops the whole index and indexes docs
+func (idx *Index) Replace(docs []Document) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.docs = make(map[uint]indexedDoc, len(docs))
+	idx.postings = make(map[string]map[uint]int)
+	idx.totalLength = 0
+	for _, doc := range docs {
+		idx.add(doc)
+	}
+}
+
+// Upsert indexes doc, replacing any previous version of the product
+func (idx *Index) Upsert(doc Document) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(doc.ProductID)
+	idx.add(doc)
+}
+
+func (idx *Index) Remove(productID uint) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(productID)
+}
+
+// Len returns the number of indexed products
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Terms returns every indexed term with the number of products containing it
+func (idx *Index) Terms() map[string]int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	terms := make(map[string]int, len(idx.postings))
+	for term, posting := range idx.postings {
+		terms[term] = len(posting)
+	}
+	return terms
+}
+
+func (idx *Index) add(doc Document) {
+	terms := make(map[string]int)
+	length := 0
+	addField := func(text string, weight int) {
+		for _, token := range Tokenize(text) {
+			terms[token] += weight
+			length += weight
+		}
+	}
+	addField(doc.Name, nameWeight)
+	addField(doc.FlowerType, flowerTypeWeight)
+	addField(strings.Join(doc.Occasions, " "), occasionWeight)
+	addField(doc.Description, descriptionWeight)
+
+	idx.docs[doc.ProductID] = indexedDoc{length: length, terms: terms}
+	idx.totalLength += length
+	for term, tf := range terms {
+		posting, ok := idx.postings[term]
+		if !ok {
+			posting = make(map[uint]int)
+			idx.postings[term] = posting
+		}
+		posting[doc.ProductID] = tf
+	}
+}
+
+func (idx *Index) remove(productID uint) {
+	doc, ok := idx.docs[productID]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		posting := idx.postings[term]
+		delete(posting, productID)
+		if len(posting) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	idx.totalLength -= doc.length
+	delete(idx.docs, productID)
+}
+
+// Search ranks products against query. Every query token is matched exactly,
+// by prefix (only the last token, which may still be being typed) and with
+// typos; a product's score for a token is its best match, and token scores
+// add up, so products matching more of the query rank higher.
+func (idx *Index) Search(query string) []Hit {
+	tokens := Tokenize(query)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if len(idx.docs) == 0 {
+		return nil
+	}
+	avgLength := float64(idx.totalLength) / float64(len(idx.docs))
+
+	scores := make(map[uint]float64)
+	for i, token := range tokens {
+		best := make(map[uint]float64)
+		for term, weight := range idx.expand(token, i == len(tokens)-1) {
+			posting := idx.postings[term]
+			idf := idx.idf(len(posting))
+			for productID, tf := range posting {
+				doc := idx.docs[productID]
+				norm := float64(tf) * (k1 + 1) /
+					(float64(tf) + k1*(1-b+b*float64(doc.length)/avgLength))
+				if score := weight * idf * norm; score > best[productID] {
+					best[productID] = score
+				}
+			}
+		}
+		for productID, score := range best {
+			scores[productID] += score
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for productID, score := range scores {
+		hits = append(hits, Hit{ProductID: productID, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ProductID < hits[j].ProductID
+	})
+	return hits
+}
+
+// expand returns the indexed terms a query token matches with their weight
+func (idx *Index) expand(token string, allowPrefix bool) map[string]float64 {
+	matches := make(map[string]float64)
+	if _, ok := idx.postings[token]; ok {
+		matches[token] = 1
+	}
+
+	tokenLen := len([]rune(token))
+	maxTypos := 0
+	switch {
+	case tokenLen >= 8:
+		maxTypos = 2
+	case tokenLen >= 4:
+		maxTypos = 1
+	}
+
+	for term := range idx.postings {
+		if term == token {
+			continue
+		}
+		weight := 0.0
+		if allowPrefix && tokenLen >= 2 && strings.HasPrefix(term, token) {
+			weight = prefixMatchWeight
+		}
+		if maxTypos > 0 && weight < typoMatchWeight {
+			if d := editDistance(token, term, maxTypos); d <= maxTypos {
+				weight = typoMatchWeight / float64(d)
+			}
+		}
+		if weight > 0 {
+			matches[term] = weight
+		}
+	}
+	return matches
+}
+
+func (idx *Index) idf(docFreq int) float64 {
+	n := float64(len(idx.docs))
+	df := float64(docFreq)
+	return math.Log(1 + (n-df+0.5)/(df+0.5))
+}
@@ -0,0 +1,76 @@
+package search
+
+import "testing"
+
+func testIndex() *Index {
+	idx := NewIndex()
+	idx.Replace([]Document{
+		{ProductID: 1, Name: "Red Rose Bouquet", Description: "Twelve red roses", FlowerType: "Rose", Occasions: []string{"Valentine"}},
+		{ProductID: 2, Name: "White Lily", Description: "Fresh lilies with a red ribbon", FlowerType: "Lily", Occasions: []string{"Funeral"}},
+		{ProductID: 3, Name: "Hoa hồng Đà Lạt", Description: "Pink roses from Da Lat", FlowerType: "Rose"},
+		{ProductID: 4, Name: "Orchid Pot", Description: "A purple orchid", FlowerType: "Orchid", Occasions: []string{"Birthday"}},
+	})
+	return idx
+}
+
+func hitIDs(hits []Hit) []uint {
+	ids := make([]uint, len(hits))
+	for i, h := range hits {
+		ids[i] = h.ProductID
+	}
+	return ids
+}
+
+func TestIndexSearch(t *testing.T) {
+	idx := testIndex()
+
+	tests := []struct {
+		name  string
+		query string
+		// expected ids in rank order; nil means no hits
+		want []uint
+	}{
+		{"name match ranks above description match", "red", []uint{1, 2}},
+		{"accents are ignored", "hoa hong", []uint{3}},
+		{"accented query matches folded text", "orchíd", []uint{4}},
+		{"last token matches by prefix", "orch", []uint{4}},
+		{"typo within one edit", "orhcid", []uint{4}},
+		{"occasion is searchable", "valentine", []uint{1}},
+		{"more matched tokens rank higher", "white lily ribbon", []uint{2}},
+		{"no match", "sunflower", nil},
+		{"only noise tokens", "a", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hitIDs(idx.Search(tt.query))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexUpsertAndRemove(t *testing.T) {
+	idx := testIndex()
+
+	idx.Upsert(Document{ProductID: 4, Name: "Sunflower Basket", FlowerType: "Sunflower"})
+	if hits := idx.Search("orchid"); len(hits) != 0 {
+		t.Errorf("old text of an upserted product still matches: %v", hitIDs(hits))
+	}
+	if got := hitIDs(idx.Search("sunflower")); len(got) != 1 || got[0] != 4 {
+		t.Errorf("Search(sunflower) = %v, want [4]", got)
+	}
+
+	idx.Remove(4)
+	if idx.Len() != 3 {
+		t.Errorf("Len() = %d after Remove, want 3", idx.Len())
+	}
+	if _, ok := idx.Terms()["sunflower"]; ok {
+		t.Error("terms of a removed product are still indexed")
+	}
+}
@@ -0,0 +1,280 @@
+package search
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Suggestion kinds
+const (
+	KindProduct    = "product"
+	KindFlowerType = "flower_type"
+	KindOccasion   = "occasion"
+	KindQuery      = "query"
+)
+
+// Base weights per kind; popular queries weigh their search count instead
+var kindWeights = map[string]float64{
+	KindFlowerType: 30,
+	KindOccasion:   20,
+	KindProduct:    10,
+}
+
+const (
+	// candidates kept per trie node, more than a response needs so that
+	// duplicates can be dropped
+	nodeTopK = 24
+	// a phrase matched from a later word ranks below one matched from its start
+	innerWordFactor = 0.5
+)
+
+// Entry is one completion the suggester can return
+type Entry struct {
+	Kind      string
+	Text      string
+	ProductID uint
+	// Weight adds to the kind weight; for queries it is the search count
+	Weight float64
+}
+
+func (e Entry) key() string {
+	if e.Kind == KindProduct {
+		return KindProduct + ":" + strconv.FormatUint(uint64(e.ProductID), 10)
+	}
+	return e.Kind + ":" + normalizePhrase(e.Text)
+}
+
+type candidate struct {
+	entry *Entry
+	score float64
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	// entries whose phrase (or a word suffix of it) ends exactly here
+	terminal []candidate
+	// best candidates in this subtree, score descending
+	top []candidate
+}
+
+// Suggester is an in-memory prefix trie of product names, flower types,
+// occasions and popular queries. Every phrase is reachable from the start of
+// each of its words, and each node keeps its best completions so a lookup
+// only walks the prefix. It is safe for concurrent use.
+type Suggester struct {
+	mu      sync.RWMutex
+	root    *trieNode
+	entries map[string]*Entry
+}
+
+func NewSuggester() *Suggester {
+	return &Suggester{
+		root:    &trieNode{},
+		entries: make(map[string]*Entry),
+	}
+}
+
+// Upsert adds the entry or replaces the previous entry with the same identity
+func (s *Suggester) Upsert(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(e.key())
+	s.add(&e)
+}
+
+// Remove drops the entry with the identity of e
+func (s *Suggester) Remove(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(e.key())
+}
+
+// ReplaceKinds drops every entry of the given kinds and adds entries,
+// leaving other kinds untouched
+func (s *Suggester) ReplaceKinds(kinds []string, entries []Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	drop := make(map[string]bool, len(kinds))
+	for _, k := range kinds {
+		drop[k] = true
+	}
+	for key, e := range s.entries {
+		if drop[e.Kind] {
+			s.remove(key)
+		}
+	}
+	for i := range entries {
+		e := entries[i]
+		s.remove(e.key())
+		s.add(&e)
+	}
+}
+
+// Suggest returns up to limit completions of prefix, best first
+func (s *Suggester) Suggest(prefix string, limit int) []Entry {
+	prefix = normalizePhrase(prefix)
+	if prefix == "" || limit <= 0 {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	node := s.root
+	for _, r := range prefix {
+		node = node.children[r]
+		if node == nil {
+			return nil
+		}
+	}
+
+	seenText := make(map[string]bool)
+	var out []Entry
+	for _, c := range node.top {
+		// a query that equals a catalog name adds nothing
+		text := normalizePhrase(c.entry.Text)
+		if seenText[text] {
+			continue
+		}
+		seenText[text] = true
+		out = append(out, *c.entry)
+		if len(out) == limit {
+			break
+		}
+	}
+	return out
+}
+
+func (s *Suggester) add(e *Entry) {
+	phrase := normalizePhrase(e.Text)
+	if phrase == "" {
+		return
+	}
+	s.entries[e.key()] = e
+
+	base := kindWeights[e.Kind] + e.Weight
+	for i, start := range wordStarts(phrase) {
+		score := base
+		if i > 0 {
+			score *= innerWordFactor
+		}
+		c := candidate{entry: e, score: score}
+
+		node := s.root
+		path := []*trieNode{node}
+		for _, r := range phrase[start:] {
+			if node.children == nil {
+				node.children = make(map[rune]*trieNode)
+			}
+			child := node.children[r]
+			if child == nil {
+				child = &trieNode{}
+				node.children[r] = child
+			}
+			node = child
+			path = append(path, node)
+		}
+		node.terminal = append(node.terminal, c)
+		for _, n := range path {
+			n.top = insertTop(n.top, c)
+		}
+	}
+}
+
+func (s *Suggester) remove(key string) {
+	e, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	delete(s.entries, key)
+
+	phrase := normalizePhrase(e.Text)
+	for _, start := range wordStarts(phrase) {
+		node := s.root
+		path := []*trieNode{node}
+		for _, r := range phrase[start:] {
+			node = node.children[r]
+			if node == nil {
+				break
+			}
+			path = append(path, node)
+		}
+		if node == nil {
+			continue
+		}
+
+		node.terminal = withoutEntry(node.terminal, e)
+		// recompute the best candidates bottom-up along the path
+		for i := len(path) - 1; i >= 0; i-- {
+			path[i].top = recomputeTop(path[i])
+		}
+	}
+}
+
+func insertTop(top []candidate, c candidate) []candidate {
+	i := sort.Search(len(top), func(i int) bool { return top[i].score < c.score })
+	if i >= nodeTopK {
+		return top
+	}
+	top = append(top, candidate{})
+	copy(top[i+1:], top[i:])
+	top[i] = c
+	if len(top) > nodeTopK {
+		top = top[:nodeTopK]
+	}
+	return top
+}
+
+func recomputeTop(n *trieNode) []candidate {
+	var top []candidate
+	for _, c := range n.terminal {
+		top = insertTop(top, c)
+	}
+	for _, child := range n.children {
+		for _, c := range child.top {
+			top = insertTop(top, c)
+		}
+	}
+	return top
+}
+
+func withoutEntry(cs []candidate, e *Entry) []candidate {
+	out := cs[:0]
+	for _, c := range cs {
+		if c.entry != e {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// wordStarts returns the byte offsets where the words of a normalized phrase begin
+func wordStarts(phrase string) []int {
+	starts := []int{0}
+	for i := 0; i < len(phrase); i++ {
+		if phrase[i] == ' ' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// normalizePhrase folds s and reduces every run of non-alphanumerics to a
+// single space, keeping partial words intact for prefix lookups
+func normalizePhrase(s string) string {
+	fields := strings.FieldsFunc(Fold(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// NormalizeQuery is the form popular queries are counted under
+func NormalizeQuery(s string) string {
+	return normalizePhrase(s)
+}
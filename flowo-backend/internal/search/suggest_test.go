@@ -0,0 +1,102 @@
+package search
+
+import "testing"
+
+func testSuggester() *Suggester {
+	s := NewSuggester()
+	s.ReplaceKinds([]string{KindProduct, KindFlowerType, KindOccasion}, []Entry{
+		{Kind: KindProduct, Text: "Red Rose Bouquet", ProductID: 1},
+		{Kind: KindProduct, Text: "Rainbow Tulips", ProductID: 2},
+		{Kind: KindFlowerType, Text: "Rose"},
+		{Kind: KindOccasion, Text: "Valentine's Day"},
+		{Kind: KindProduct, Text: "Hoa hồng Đà Lạt", ProductID: 3},
+	})
+	s.ReplaceKinds([]string{KindQuery}, []Entry{
+		{Kind: KindQuery, Text: "roses for mom", Weight: 50},
+		{Kind: KindQuery, Text: "rose", Weight: 100},
+	})
+	return s
+}
+
+func suggestionTexts(entries []Entry) []string {
+	texts := make([]string, len(entries))
+	for i, e := range entries {
+		texts[i] = e.Text
+	}
+	return texts
+}
+
+func TestSuggest(t *testing.T) {
+	s := testSuggester()
+
+	tests := []struct {
+		name   string
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{
+			name:   "popular query outranks catalog, duplicate text dropped",
+			prefix: "ros",
+			limit:  10,
+			want:   []string{"rose", "roses for mom", "Red Rose Bouquet"},
+		},
+		{"limit is applied", "r", 2, []string{"rose", "roses for mom"}},
+		{"phrase start ranks above inner word", "r", 10, []string{"rose", "roses for mom", "Red Rose Bouquet", "Rainbow Tulips"}},
+		{"accents are folded", "hoa hô", 10, []string{"Hoa hồng Đà Lạt"}},
+		{"inner word matches", "da la", 10, []string{"Hoa hồng Đà Lạt"}},
+		{"punctuation is ignored", "valentine s", 10, []string{"Valentine's Day"}},
+		{"no match", "sunf", 10, nil},
+		{"empty prefix", "  ", 10, nil},
+		{"zero limit", "r", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suggestionTexts(s.Suggest(tt.prefix, tt.limit))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Suggest(%q, %d) = %q, want %q", tt.prefix, tt.limit, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Suggest(%q, %d) = %q, want %q", tt.prefix, tt.limit, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSuggesterUpsertAndRemove(t *testing.T) {
+	s := testSuggester()
+
+	// renaming a product replaces its old phrase
+	s.Upsert(Entry{Kind: KindProduct, Text: "Sunflower Basket", ProductID: 2})
+	if got := s.Suggest("rainb", 10); len(got) != 0 {
+		t.Errorf("old product name still suggested: %q", suggestionTexts(got))
+	}
+	if got := suggestionTexts(s.Suggest("sun", 10)); len(got) != 1 || got[0] != "Sunflower Basket" {
+		t.Errorf("Suggest(sun) = %q, want [Sunflower Basket]", got)
+	}
+
+	// queries are identified by their normalized text
+	s.Remove(Entry{Kind: KindQuery, Text: "  ROSE "})
+	got := suggestionTexts(s.Suggest("ros", 10))
+	if len(got) != 3 || got[1] != "Rose" {
+		t.Errorf("Suggest(ros) after removing the query = %q, want the flower type in its place", got)
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"  Hoa   Hồng! ", "hoa hong"},
+		{"valentine's day", "valentine s day"},
+		{"---", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeQuery(tt.in); got != tt.want {
+			t.Errorf("NormalizeQuery(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
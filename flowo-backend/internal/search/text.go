@@ -0,0 +1,110 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// foldTable maps Vietnamese (and common Latin) accented letters to their
+// base letter, so "hoa hồng" and "hoa hong" index to the same tokens
+var foldTable = buildFoldTable(map[rune]string{
+	'a': "àáảãạăằắẳẵặâầấẩẫậäå",
+	'e': "èéẻẽẹêềếểễệë",
+	'i': "ìíỉĩịîï",
+	'o': "òóỏõọôồốổỗộơờớởỡợö",
+	'u': "ùúủũụưừứửữựûü",
+	'y': "ỳýỷỹỵÿ",
+	'd': "đ",
+	'c': "ç",
+	'n': "ñ",
+})
+
+func buildFoldTable(groups map[rune]string) map[rune]rune {
+	table := make(map[rune]rune)
+	for base, accented := range groups {
+		for _, r := range accented {
+			table[r] = base
+		}
+	}
+	return table
+}
+
+// Fold lowercases s and strips diacritics
+func Fold(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		r = unicode.ToLower(r)
+		if unicode.Is(unicode.Mn, r) {
+			// combining marks of decomposed input
+			continue
+		}
+		if base, ok := foldTable[r]; ok {
+			r = base
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Tokenize folds s and splits it into words. Single letters are dropped as
+// noise, single digits are kept ("5 roses").
+func Tokenize(s string) []string {
+	fields := strings.FieldsFunc(Fold(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := fields[:0]
+	for _, f := range fields {
+		if len([]rune(f)) == 1 && !unicode.IsDigit([]rune(f)[0]) {
+			continue
+		}
+		tokens = append(tokens, f)
+	}
+	return tokens
+}
+
+// editDistance is the optimal string alignment distance between a and b
+// (insertions, deletions, substitutions and adjacent transpositions). It
+// gives up and returns max+1 once the distance is known to exceed max.
+func editDistance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > max {
+		return max + 1
+	}
+
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
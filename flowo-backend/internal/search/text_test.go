@@ -0,0 +1,67 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Hoa Hồng", "hoa hong"},
+		{"ĐÀ LẠT", "da lat"},
+		{"crème brûlée", "creme brulee"},
+		// decomposed input: e followed by a combining acute accent
+		{"café", "cafe"},
+		{"plain", "plain"},
+	}
+	for _, tt := range tests {
+		if got := Fold(tt.in); got != tt.want {
+			t.Errorf("Fold(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Hoa hồng đỏ", []string{"hoa", "hong", "do"}},
+		{"5 roses, a bouquet!", []string{"5", "roses", "bouquet"}},
+		{"tulip-lily/orchid", []string{"tulip", "lily", "orchid"}},
+		{"  ", []string{}},
+	}
+	for _, tt := range tests {
+		got := Tokenize(tt.in)
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"rose", "rose", 2, 0},
+		{"rose", "rise", 2, 1},
+		{"rose", "roses", 2, 1},
+		{"rose", "orse", 2, 1}, // adjacent transposition
+		{"tulip", "tlupi", 2, 2},
+		{"orchid", "lily", 2, 3}, // gives up past max
+		{"a", "abcd", 1, 2},      // length difference alone exceeds max
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b, tt.max); got != tt.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.max, got, tt.want)
+		}
+	}
+}
@@ -1,17 +1,19 @@
 package service
 
 import (
+	"context"
+
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/model"
 	"flowo-backend/internal/repository"
 )
 
 type AddressService interface {
-	CreateAddress(uid string, req dto.CreateAddressRequest) (*dto.AddressResponse, error)
-	GetAddresses(uid string) ([]dto.AddressResponse, error)
-	DeleteAddress(uid string, addressID int) error
-	SetDefaultAddress(uid string, addressID int) error
-	GetDefaultAddress(uid string) (*dto.AddressResponse, error)
+	CreateAddress(ctx context.Context, uid string, req dto.CreateAddressRequest) (*dto.AddressResponse, error)
+	GetAddresses(ctx context.Context, uid string) ([]dto.AddressResponse, error)
+	DeleteAddress(ctx context.Context, uid string, addressID int) error
+	SetDefaultAddress(ctx context.Context, uid string, addressID int) error
+	GetDefaultAddress(ctx context.Context, uid string) (*dto.AddressResponse, error)
 }
 
 type addressService struct {
@@ -22,9 +24,9 @@ func NewAddressService(repo repository.AddressRepository) AddressService {
 	return &addressService{repo: repo}
 }
 
-func (s *addressService) CreateAddress(uid string, req dto.CreateAddressRequest) (*dto.AddressResponse, error) {
+func (s *addressService) CreateAddress(ctx context.Context, uid string, req dto.CreateAddressRequest) (*dto.AddressResponse, error) {
 	if req.IsDefault {
-		if err := s.repo.ClearDefault(uid); err != nil {
+		if err := s.repo.ClearDefault(ctx, uid); err != nil {
 			return nil, err
 		}
 	}
@@ -39,7 +41,7 @@ func (s *addressService) CreateAddress(uid string, req dto.CreateAddressRequest)
 		Country:           req.Country,
 		IsDefaultShipping: req.IsDefault,
 	}
-	id, err := s.repo.Create(&addr)
+	id, err := s.repo.Create(ctx, &addr)
 	if err != nil {
 		return nil, err
 	}
@@ -57,8 +59,8 @@ func (s *addressService) CreateAddress(uid string, req dto.CreateAddressRequest)
 	}, nil
 }
 
-func (s *addressService) GetAddresses(uid string) ([]dto.AddressResponse, error) {
-	addresses, err := s.repo.GetAllByUser(uid)
+func (s *addressService) GetAddresses(ctx context.Context, uid string) ([]dto.AddressResponse, error) {
+	addresses, err := s.repo.GetAllByUser(ctx, uid)
 	if err != nil {
 		return nil, err
 	}
@@ -78,19 +80,19 @@ func (s *addressService) GetAddresses(uid string) ([]dto.AddressResponse, error)
 	return res, nil
 }
 
-func (s *addressService) DeleteAddress(uid string, addressID int) error {
-	return s.repo.Delete(uid, addressID)
+func (s *addressService) DeleteAddress(ctx context.Context, uid string, addressID int) error {
+	return s.repo.Delete(ctx, uid, addressID)
 }
 
-func (s *addressService) SetDefaultAddress(uid string, addressID int) error {
-	if err := s.repo.ClearDefault(uid); err != nil {
+func (s *addressService) SetDefaultAddress(ctx context.Context, uid string, addressID int) error {
+	if err := s.repo.ClearDefault(ctx, uid); err != nil {
 		return err
 	}
-	return s.repo.SetDefault(uid, addressID)
+	return s.repo.SetDefault(ctx, uid, addressID)
 }
 
-func (s *addressService) GetDefaultAddress(uid string) (*dto.AddressResponse, error) {
-	addr, err := s.repo.GetDefault(uid)
+func (s *addressService) GetDefaultAddress(ctx context.Context, uid string) (*dto.AddressResponse, error) {
+	addr, err := s.repo.GetDefault(ctx, uid)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/model"
+)
+
+const (
+	// a product pair must share this many orders before it makes a rule
+	cartRuleMinPairCount = 2
+	// rules below this confidence are too weak to suggest
+	cartRuleMinConfidence = 0.05
+)
+
+// GetCartRecommendations suggests add-ons for a cart from association rules
+// over past orders. Each suggestion keeps the strongest rule leading to it,
+// scored by confidence; products already in the cart or out of stock are
+// never suggested.
+func (s *recommendationService) GetCartRecommendations(ctx context.Context, cart []dto.CartItemResponse, limit int) (*dto.RecommendationResponseDTO, error) {
+	// the reasons name the cart lines, so variants are part of the key
+	lines := make([]string, len(cart))
+	for i, item := range cart {
+		lines[i] = strconv.Itoa(item.ProductID)
+		if item.VariantID != nil {
+			lines[i] += "/" + strconv.Itoa(*item.VariantID)
+		}
+	}
+	sort.Strings(lines)
+	key := recommendationCacheKey("", "frequently_bought_together", strings.Join(lines, ","), limit)
+	return s.cached(ctx, key, func() (*dto.RecommendationResponseDTO, error) {
+		return s.cartRecommendations(ctx, cart, limit)
+	})
+}
+
+func (s *recommendationService) cartRecommendations(ctx context.Context, cart []dto.CartItemResponse, limit int) (*dto.RecommendationResponseDTO, error) {
+	if limit <= 0 {
+		limit = s.GetConfig().DefaultLimit
+	}
+
+	names := make(map[uint]string, len(cart))
+	var cartIDs []uint
+	for _, item := range cart {
+		id := uint(item.ProductID)
+		if _, ok := names[id]; ok {
+			continue
+		}
+		names[id] = item.Name
+		cartIDs = append(cartIDs, id)
+	}
+
+	rules, err := s.recommendationRepo.GetAssociationRules(ctx, cartIDs, cartRuleMinPairCount)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations := []dto.RecommendedProductDTO{}
+	for _, rule := range rankAssociationRules(rules) {
+		if len(recommendations) >= limit {
+			break
+		}
+		product, err := s.productRepo.GetProductByID(ctx, rule.ConsequentID)
+		if err != nil {
+			continue
+		}
+		recommendations = append(recommendations, dto.RecommendedProductDTO{
+			Product: ToProductResponse(*product, product.BasePrice),
+			Score:   math.Round(rule.Confidence()*10000) / 10000,
+			Reason: fmt.Sprintf("Often bought with %s (confidence %.0f%%, support %.1f%%)",
+				names[rule.AntecedentID], rule.Confidence()*100, rule.Support()*100),
+			Category: "frequently_bought_together",
+		})
+	}
+
+	return &dto.RecommendationResponseDTO{
+		RecommendationType: "frequently_bought_together",
+		Recommendations:    recommendations,
+		Explanation:        "Products often bought with the items in your cart",
+		GeneratedAt:        time.Now().Format(time.RFC3339),
+		Total:              len(recommendations),
+	}, nil
+}
+
+// rankAssociationRules keeps the strongest rule per consequent, dropping
+// weak ones, and orders them by confidence, then support, then product id
+func rankAssociationRules(rules []model.AssociationRule) []model.AssociationRule {
+	best := make(map[uint]model.AssociationRule)
+	for _, rule := range rules {
+		if rule.Confidence() < cartRuleMinConfidence {
+			continue
+		}
+		current, ok := best[rule.ConsequentID]
+		if !ok || rule.Confidence() > current.Confidence() ||
+			(rule.Confidence() == current.Confidence() && rule.Support() > current.Support()) {
+			best[rule.ConsequentID] = rule
+		}
+	}
+
+	ranked := make([]model.AssociationRule, 0, len(best))
+	for _, rule := range best {
+		ranked = append(ranked, rule)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Confidence() != ranked[j].Confidence() {
+			return ranked[i].Confidence() > ranked[j].Confidence()
+		}
+		if ranked[i].Support() != ranked[j].Support() {
+			return ranked[i].Support() > ranked[j].Support()
+		}
+		return ranked[i].ConsequentID < ranked[j].ConsequentID
+	})
+	return ranked
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+
+	"flowo-backend/internal/model"
+)
+
+func TestRankAssociationRules(t *testing.T) {
+	// rule from antecedent to consequent over 100 completed orders
+	rule := func(antecedent, consequent uint, pairs, antecedentOrders int) model.AssociationRule {
+		return model.AssociationRule{
+			AntecedentID:    antecedent,
+			ConsequentID:    consequent,
+			PairCount:       pairs,
+			AntecedentCount: antecedentOrders,
+			BasketCount:     100,
+		}
+	}
+	type pick struct{ antecedent, consequent uint }
+
+	tests := []struct {
+		name  string
+		rules []model.AssociationRule
+		want  []pick
+	}{
+		{
+			name:  "ordered by confidence",
+			rules: []model.AssociationRule{rule(1, 10, 2, 20), rule(1, 11, 6, 20), rule(1, 12, 4, 20)},
+			want:  []pick{{1, 11}, {1, 12}, {1, 10}},
+		},
+		{
+			name:  "equal confidence is ordered by support",
+			rules: []model.AssociationRule{rule(1, 10, 2, 10), rule(2, 11, 6, 30)},
+			want:  []pick{{2, 11}, {1, 10}},
+		},
+		{
+			name:  "then by product id",
+			rules: []model.AssociationRule{rule(1, 12, 3, 10), rule(1, 10, 3, 10)},
+			want:  []pick{{1, 10}, {1, 12}},
+		},
+		{
+			name:  "strongest rule per suggestion is kept",
+			rules: []model.AssociationRule{rule(1, 10, 2, 20), rule(2, 10, 5, 10), rule(3, 10, 3, 20)},
+			want:  []pick{{2, 10}},
+		},
+		{
+			name:  "higher support breaks a confidence tie for the same suggestion",
+			rules: []model.AssociationRule{rule(1, 10, 2, 10), rule(2, 10, 4, 20)},
+			want:  []pick{{2, 10}},
+		},
+		{
+			name:  "weak rules are dropped",
+			rules: []model.AssociationRule{rule(1, 10, 2, 100), rule(1, 11, 5, 100)},
+			want:  []pick{{1, 11}},
+		},
+		{
+			name:  "antecedent without orders",
+			rules: []model.AssociationRule{rule(1, 10, 2, 0)},
+			want:  []pick{},
+		},
+		{
+			name: "no rules",
+			want: []pick{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rankAssociationRules(tt.rules)
+			if len(got) != len(tt.want) {
+				t.Fatalf("rankAssociationRules() = %+v, want %v", got, tt.want)
+			}
+			for i, w := range tt.want {
+				if got[i].AntecedentID != w.antecedent || got[i].ConsequentID != w.consequent {
+					t.Errorf("rank %d = %d -> %d, want %d -> %d",
+						i, got[i].AntecedentID, got[i].ConsequentID, w.antecedent, w.consequent)
+				}
+			}
+		})
+	}
+}
@@ -1,10 +1,13 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/model"
 	"flowo-backend/internal/repository"
-	"fmt"
 	"time"
 )
 
@@ -12,68 +15,83 @@ type CartService struct {
 	Repo        repository.CartRepository
 	ProductRepo repository.Repository
 	PricingSvc  *PricingService
+	// Recommendations suggests add-ons for the cart
+	Recommendations RecommendationService
 }
 
-func NewCartService(repo repository.CartRepository, productRepo repository.Repository, pricingSvc *PricingService) *CartService {
+func NewCartService(repo repository.CartRepository, productRepo repository.Repository, pricingSvc *PricingService, recommendations RecommendationService) *CartService {
 	return &CartService{
-		Repo:        repo,
-		ProductRepo: productRepo,
-		PricingSvc:  pricingSvc,
+		Repo:            repo,
+		ProductRepo:     productRepo,
+		PricingSvc:      pricingSvc,
+		Recommendations: recommendations,
 	}
 }
 
-func (s *CartService) AddToCart(req dto.AddToCartRequest) error {
-	cartID, err := s.Repo.GetOrCreateCart(req.FirebaseUID)
+func (s *CartService) AddToCart(ctx context.Context, req dto.AddToCartRequest) error {
+	cartID, err := s.Repo.GetOrCreateCart(ctx, req.FirebaseUID)
 	if err != nil {
 		return err
 	}
-	return s.Repo.AddOrUpdateCartItem(cartID, req.ProductID, req.Quantity)
+	if err := s.Repo.AddOrUpdateCartItem(ctx, cartID, req.ProductID, req.VariantID, req.Quantity); err != nil {
+		return err
+	}
+	s.Recommendations.RecordInteraction(ctx, req.FirebaseUID, "", uint(req.ProductID), model.InteractionAddToCart)
+	return nil
 }
 
-func (s *CartService) UpdateCartItem(req dto.UpdateCartItemRequest) error {
-	cartID, err := s.Repo.GetOrCreateCart(req.FirebaseUID)
+func (s *CartService) UpdateCartItem(ctx context.Context, req dto.UpdateCartItemRequest) error {
+	cartID, err := s.Repo.GetOrCreateCart(ctx, req.FirebaseUID)
 	if err != nil {
 		return err
 	}
-	return s.Repo.UpdateCartItemQuantity(cartID, req.ProductID, req.Quantity)
+	return s.Repo.UpdateCartItemQuantity(ctx, cartID, req.ProductID, req.VariantID, req.Quantity)
 }
 
-func (s *CartService) RemoveCartItem(FirebaseUID string, productID int) error {
-	cartID, err := s.Repo.GetCartIDByUser(FirebaseUID)
+func (s *CartService) RemoveCartItem(ctx context.Context, FirebaseUID string, productID int, variantID *int) error {
+	cartID, err := s.Repo.GetCartIDByUser(ctx, FirebaseUID)
 	if err != nil {
 		return err
 	}
 	if cartID == 0 {
-		return fmt.Errorf("cart does not exist")
+		return apperror.NotFound("cart does not exist")
 	}
-	return s.Repo.RemoveCartItem(cartID, productID)
+	return s.Repo.RemoveCartItem(ctx, cartID, productID, variantID)
 }
 
-func (s *CartService) GetCartItems(FirebaseUID string) ([]model.CartItem, error) {
-	cartID, err := s.Repo.GetOrCreateCart(FirebaseUID)
+func (s *CartService) GetCartItems(ctx context.Context, FirebaseUID string) ([]model.CartItem, error) {
+	cartID, err := s.Repo.GetOrCreateCart(ctx, FirebaseUID)
 	if err != nil {
 		return nil, err
 	}
-	return s.Repo.GetCartItems(cartID)
+	return s.Repo.GetCartItems(ctx, cartID)
 }
 
-func (s *CartService) GetCartWithPrices(FirebaseUID string) ([]dto.CartItemResponse, error) {
-	cartID, err := s.Repo.GetOrCreateCart(FirebaseUID)
+func (s *CartService) GetCartWithPrices(ctx context.Context, FirebaseUID string) ([]dto.CartItemResponse, error) {
+	cartID, err := s.Repo.GetOrCreateCart(ctx, FirebaseUID)
 	if err != nil {
 		return nil, err
 	}
 
-	cartItems, err := s.Repo.GetCartItems(cartID)
+	cartItems, err := s.Repo.GetCartItems(ctx, cartID)
 	if err != nil {
 		return nil, err
 	}
 
-	var productIDs []int
+	var productIDs, variantIDs []int
 	for _, item := range cartItems {
 		productIDs = append(productIDs, item.ProductID)
+		if item.VariantID != nil {
+			variantIDs = append(variantIDs, *item.VariantID)
+		}
+	}
+
+	productsMap, err := s.ProductRepo.GetProductsByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
 	}
 
-	productsMap, err := s.ProductRepo.GetProductsByIDs(productIDs)
+	variantsMap, err := s.ProductRepo.GetVariantsByIDs(ctx, variantIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -87,12 +105,21 @@ func (s *CartService) GetCartWithPrices(FirebaseUID string) ([]dto.CartItemRespo
 			continue
 		}
 
-		price, err := s.PricingSvc.GetEffectivePrice(product, now)
+		var variant *model.ProductVariant
+		if item.VariantID != nil {
+			v, ok := variantsMap[*item.VariantID]
+			if !ok {
+				continue
+			}
+			variant = &v
+		}
+
+		price, err := s.PricingSvc.GetVariantEffectivePrice(ctx, product, variant, now)
 		if err != nil {
 			return nil, err
 		}
 
-		responses = append(responses, dto.CartItemResponse{
+		response := dto.CartItemResponse{
 			ProductID:      int(product.ProductID),
 			Name:           product.Name,
 			Description:    product.Description,
@@ -100,8 +127,69 @@ func (s *CartService) GetCartWithPrices(FirebaseUID string) ([]dto.CartItemRespo
 			Price:          product.BasePrice,
 			EffectivePrice: price,
 			TotalPrice:     price * float64(item.Quantity),
-		})
+		}
+		if variant != nil {
+			response.VariantID = item.VariantID
+			response.SKU = variant.SKU
+			response.Name = product.Name + " - " + variant.Name
+			response.Price = variant.BasePrice(product)
+		}
+
+		responses = append(responses, response)
 	}
 
 	return responses, nil
 }
+
+// GetCartSuggestions returns "complete your order" add-ons for the user's
+// current cart
+func (s *CartService) GetCartSuggestions(ctx context.Context, FirebaseUID string, limit int) (*dto.RecommendationResponseDTO, error) {
+	items, err := s.GetCartWithPrices(ctx, FirebaseUID)
+	if err != nil {
+		return nil, err
+	}
+	suggestions, err := s.Recommendations.GetCartRecommendations(ctx, items, limit)
+	if err != nil {
+		return nil, err
+	}
+	s.Recommendations.RecordImpression(ctx, FirebaseUID, suggestions)
+	return suggestions, nil
+}
+
+// PrefillCart adds one of each product that is not in the cart yet, as
+// used by the links in occasion reminders. Products that cannot be added
+// are reported back instead of failing the whole request.
+func (s *CartService) PrefillCart(ctx context.Context, req dto.PrefillCartRequest) (*dto.PrefillCartResponse, error) {
+	cartID, err := s.Repo.GetOrCreateCart(ctx, req.FirebaseUID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.Repo.GetCartItems(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	inCart := make(map[int]bool, len(items))
+	for _, item := range items {
+		inCart[item.ProductID] = true
+	}
+
+	result := &dto.PrefillCartResponse{Added: []int{}, Skipped: []int{}}
+	for _, productID := range req.ProductIDs {
+		if inCart[productID] {
+			result.Skipped = append(result.Skipped, productID)
+			continue
+		}
+		err := s.Repo.AddOrUpdateCartItem(ctx, cartID, productID, nil, 1)
+		if errors.Is(err, sql.ErrNoRows) || apperror.Is(err, apperror.CodeOutOfStock) {
+			result.Skipped = append(result.Skipped, productID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		inCart[productID] = true
+		result.Added = append(result.Added, productID)
+		s.Recommendations.RecordInteraction(ctx, req.FirebaseUID, "", uint(productID), model.InteractionAddToCart)
+	}
+	return result, nil
+}
@@ -0,0 +1,396 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/dto"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	maxImportRows  = 5000
+	importListSep  = "|"
+	FormatCSV      = "csv"
+	FormatJSON     = "json"
+	errInvalidRows = "import has invalid rows"
+)
+
+var importColumns = []string{
+	"product_id", "name", "description", "flower_type", "base_price",
+	"status", "stock_quantity", "occasions", "images",
+}
+
+var requiredImportColumns = []string{
+	"name", "description", "flower_type", "base_price", "status", "stock_quantity",
+}
+
+// ImportProducts validates every row of a CSV or JSON catalog file and, unless
+// dryRun is set or a row is invalid, applies all rows in one transaction. The
+// result lists row-level errors; rows are numbered from 1, not counting the
+// CSV header.
+func (s *catalogService) ImportProducts(ctx context.Context, format string, data []byte, dryRun bool, actor string) (*dto.ProductImportResult, error) {
+	rows, rowErrors, err := parseProductImport(format, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, apperror.Validation("import file has no rows")
+	}
+	if len(rows) > maxImportRows {
+		return nil, apperror.Validation(fmt.Sprintf("import file has more than %d rows", maxImportRows))
+	}
+
+	validationErrors, err := s.validateImportRows(ctx, rows, rowErrors)
+	if err != nil {
+		return nil, err
+	}
+	rowErrors = append(rowErrors, validationErrors...)
+
+	result := &dto.ProductImportResult{
+		DryRun:    dryRun,
+		TotalRows: len(rows),
+		Errors:    rowErrors,
+	}
+	if len(rowErrors) > 0 {
+		if dryRun {
+			return result, nil
+		}
+		return result, apperror.Validation(errInvalidRows)
+	}
+
+	if dryRun {
+		for _, row := range rows {
+			if row.ProductID == 0 {
+				result.Created++
+			} else {
+				result.Updated++
+			}
+		}
+		return result, nil
+	}
+
+	created, updated, err := s.repo.ImportProducts(ctx, rows, actor)
+	if err != nil {
+		return nil, err
+	}
+	result.Created, result.Updated = created, updated
+	result.Errors = []dto.ImportRowError{}
+
+	if err := s.indexer.Rebuild(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to rebuild search index after import")
+	}
+	s.priceTable.Invalidate()
+	return result, nil
+}
+
+// ExportProducts writes all active products in the import format
+func (s *catalogService) ExportProducts(ctx context.Context, format string) ([]byte, error) {
+	if format != FormatCSV && format != FormatJSON {
+		return nil, apperror.Validation("unsupported format", apperror.Field("format", "must be csv or json"))
+	}
+
+	products, err := s.productRepo.GetAllProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]dto.ProductImportRow, 0, len(products))
+	for _, p := range products {
+		occasions, err := s.productRepo.GetProductOccasions(ctx, p.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		images, err := s.productRepo.GetProductImages(ctx, p.ProductID)
+		if err != nil {
+			return nil, err
+		}
+
+		row := dto.ProductImportRow{
+			ProductID:     p.ProductID,
+			Name:          p.Name,
+			Description:   p.Description,
+			FlowerType:    p.FlowerType,
+			BasePrice:     p.BasePrice,
+			Status:        p.Status,
+			StockQuantity: p.StockQuantity,
+			Occasions:     occasions,
+			Images:        []string{},
+		}
+		if row.Occasions == nil {
+			row.Occasions = []string{}
+		}
+		// images come primary first, which is what the importer expects
+		for _, img := range images {
+			row.Images = append(row.Images, img.ImageURL)
+		}
+		rows = append(rows, row)
+	}
+
+	if format == FormatJSON {
+		return json.MarshalIndent(rows, "", "  ")
+	}
+	return encodeProductCSV(rows)
+}
+
+// validateImportRows applies the single-product rules plus the references a
+// row makes to flower types, occasions and existing products. Rows that
+// already failed to parse are skipped.
+func (s *catalogService) validateImportRows(ctx context.Context, rows []dto.ProductImportRow, parseErrors []dto.ImportRowError) ([]dto.ImportRowError, error) {
+	flowerTypes, err := s.productRepo.GetAllFlowerTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	occasions, err := s.productRepo.GetAllOccasions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	products, err := s.productRepo.GetAllProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	knownFlowerTypes := make(map[string]bool, len(flowerTypes))
+	for _, ft := range flowerTypes {
+		knownFlowerTypes[ft.Name] = true
+	}
+	knownOccasions := make(map[string]bool, len(occasions))
+	for _, o := range occasions {
+		knownOccasions[o.Name] = true
+	}
+	knownProducts := make(map[uint]bool, len(products))
+	for _, p := range products {
+		knownProducts[p.ProductID] = true
+	}
+	unparsable := make(map[int]bool, len(parseErrors))
+	for _, e := range parseErrors {
+		unparsable[e.Row] = true
+	}
+
+	var rowErrors []dto.ImportRowError
+	seenProducts := make(map[uint]int)
+
+	for i := range rows {
+		rowNum := i + 1
+		if unparsable[rowNum] {
+			continue
+		}
+		row := &rows[i]
+		fail := func(field, message string) {
+			rowErrors = append(rowErrors, dto.ImportRowError{Row: rowNum, Field: field, Message: message})
+		}
+
+		input := &dto.ProductCreate{
+			Name:          row.Name,
+			Description:   row.Description,
+			FlowerType:    row.FlowerType,
+			BasePrice:     row.BasePrice,
+			Status:        row.Status,
+			StockQuantity: row.StockQuantity,
+		}
+		if err := validateProductInput(input); err != nil {
+			fail("", err.Error())
+			continue
+		}
+
+		if !knownFlowerTypes[row.FlowerType] {
+			fail("flower_type", fmt.Sprintf("flower type %q not found", row.FlowerType))
+		}
+
+		if row.ProductID != 0 {
+			if !knownProducts[row.ProductID] {
+				fail("product_id", "product not found")
+			} else if first, ok := seenProducts[row.ProductID]; ok {
+				fail("product_id", fmt.Sprintf("product already updated by row %d", first))
+			} else {
+				seenProducts[row.ProductID] = rowNum
+			}
+		}
+
+		row.Occasions = uniqueNonEmpty(row.Occasions)
+		for _, name := range row.Occasions {
+			if !knownOccasions[name] {
+				fail("occasions", fmt.Sprintf("occasion %q not found", name))
+			}
+		}
+
+		row.Images = uniqueNonEmpty(row.Images)
+		for _, img := range row.Images {
+			if !isImageURL(img) {
+				fail("images", fmt.Sprintf("invalid image url %q", img))
+			}
+		}
+	}
+
+	return rowErrors, nil
+}
+
+func parseProductImport(format string, data []byte) ([]dto.ProductImportRow, []dto.ImportRowError, error) {
+	switch format {
+	case FormatJSON:
+		var rows []dto.ProductImportRow
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return nil, nil, apperror.Validation(fmt.Sprintf("invalid JSON: %v", err))
+		}
+		for i := range rows {
+			trimImportRow(&rows[i])
+		}
+		return rows, nil, nil
+	case FormatCSV:
+		return parseProductCSV(data)
+	default:
+		return nil, nil, apperror.Validation("unsupported format", apperror.Field("format", "must be csv or json"))
+	}
+}
+
+func parseProductCSV(data []byte) ([]dto.ProductImportRow, []dto.ImportRowError, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil, apperror.Validation("import file has no rows")
+		}
+		return nil, nil, apperror.Validation(fmt.Sprintf("invalid CSV: %v", err))
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range requiredImportColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, apperror.Validation(fmt.Sprintf("invalid CSV: missing column %q", name))
+		}
+	}
+
+	var rows []dto.ProductImportRow
+	var rowErrors []dto.ImportRowError
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, apperror.Validation(fmt.Sprintf("invalid CSV: %v", err))
+		}
+
+		rowNum := len(rows) + 1
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		fail := func(field, message string) {
+			rowErrors = append(rowErrors, dto.ImportRowError{Row: rowNum, Field: field, Message: message})
+		}
+
+		row := dto.ProductImportRow{
+			Name:        get("name"),
+			Description: get("description"),
+			FlowerType:  get("flower_type"),
+			Status:      get("status"),
+			Occasions:   splitImportList(get("occasions")),
+			Images:      splitImportList(get("images")),
+		}
+		if v := get("product_id"); v != "" {
+			id, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				fail("product_id", "product_id must be a positive integer")
+			}
+			row.ProductID = uint(id)
+		}
+		if price, err := strconv.ParseFloat(get("base_price"), 64); err != nil {
+			fail("base_price", "base_price must be a number")
+		} else {
+			row.BasePrice = price
+		}
+		if stock, err := strconv.Atoi(get("stock_quantity")); err != nil {
+			fail("stock_quantity", "stock_quantity must be an integer")
+		} else {
+			row.StockQuantity = stock
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+func encodeProductCSV(rows []dto.ProductImportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(importColumns); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		record := []string{
+			strconv.FormatUint(uint64(row.ProductID), 10),
+			row.Name,
+			row.Description,
+			row.FlowerType,
+			strconv.FormatFloat(row.BasePrice, 'f', -1, 64),
+			row.Status,
+			strconv.Itoa(row.StockQuantity),
+			strings.Join(row.Occasions, importListSep),
+			strings.Join(row.Images, importListSep),
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func trimImportRow(row *dto.ProductImportRow) {
+	row.Name = strings.TrimSpace(row.Name)
+	row.Description = strings.TrimSpace(row.Description)
+	row.FlowerType = strings.TrimSpace(row.FlowerType)
+	row.Status = strings.TrimSpace(row.Status)
+}
+
+func splitImportList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, importListSep)
+}
+
+// uniqueNonEmpty trims the values and drops blanks and repeats, keeping order
+func uniqueNonEmpty(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return out
+}
+
+// isImageURL accepts absolute http(s) URLs and paths served by this backend
+func isImageURL(value string) bool {
+	if strings.HasPrefix(value, "/") {
+		return true
+	}
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/dto"
+)
+
+func TestParseProductImport(t *testing.T) {
+	header := "product_id,name,description,flower_type,base_price,status,stock_quantity,occasions,images\n"
+
+	tests := []struct {
+		name       string
+		format     string
+		data       string
+		wantRows   []dto.ProductImportRow
+		wantErrors []dto.ImportRowError
+		wantErr    bool
+	}{
+		{
+			name:   "csv rows",
+			format: FormatCSV,
+			data: header +
+				`7,Red Rose,"Twelve roses, wrapped",Rose,45.5,NewFlower,20,Birthday|Anniversary,https://cdn.example.com/rose.jpg` + "\n" +
+				`,  Lily ,White lilies,Lily,30,NewFlower,5,,` + "\n",
+			wantRows: []dto.ProductImportRow{
+				{
+					ProductID: 7, Name: "Red Rose", Description: "Twelve roses, wrapped", FlowerType: "Rose",
+					BasePrice: 45.5, Status: "NewFlower", StockQuantity: 20,
+					Occasions: []string{"Birthday", "Anniversary"}, Images: []string{"https://cdn.example.com/rose.jpg"},
+				},
+				{Name: "Lily", Description: "White lilies", FlowerType: "Lily", BasePrice: 30, Status: "NewFlower", StockQuantity: 5},
+			},
+		},
+		{
+			name:   "csv columns in any order and case, with a BOM",
+			format: FormatCSV,
+			data:   "\ufeffStatus,Name,Description,Flower_Type,Base_Price,Stock_Quantity\nNewFlower,Tulip,Pink tulips,Tulip,12,3\n",
+			wantRows: []dto.ProductImportRow{
+				{Name: "Tulip", Description: "Pink tulips", FlowerType: "Tulip", BasePrice: 12, Status: "NewFlower", StockQuantity: 3},
+			},
+		},
+		{
+			name:   "csv values that do not parse are row errors",
+			format: FormatCSV,
+			data:   header + "x,Rose,Red,Rose,cheap,NewFlower,1.5,,\n",
+			wantRows: []dto.ProductImportRow{
+				{Name: "Rose", Description: "Red", FlowerType: "Rose", Status: "NewFlower"},
+			},
+			wantErrors: []dto.ImportRowError{
+				{Row: 1, Field: "product_id", Message: "product_id must be a positive integer"},
+				{Row: 1, Field: "base_price", Message: "base_price must be a number"},
+				{Row: 1, Field: "stock_quantity", Message: "stock_quantity must be an integer"},
+			},
+		},
+		{name: "csv missing a required column", format: FormatCSV, data: "name,description\nRose,Red\n", wantErr: true},
+		{name: "empty csv", format: FormatCSV, data: "", wantErr: true},
+		{name: "broken csv", format: FormatCSV, data: header + `1,"Rose,Red` + "\n", wantErr: true},
+		{
+			name:   "json rows are trimmed",
+			format: FormatJSON,
+			data:   `[{"name":" Rose ","description":"Red","flower_type":"Rose ","base_price":10,"status":"NewFlower","stock_quantity":2,"occasions":["Birthday"]}]`,
+			wantRows: []dto.ProductImportRow{
+				{Name: "Rose", Description: "Red", FlowerType: "Rose", BasePrice: 10, Status: "NewFlower", StockQuantity: 2, Occasions: []string{"Birthday"}},
+			},
+		},
+		{name: "broken json", format: FormatJSON, data: `[{"name":`, wantErr: true},
+		{name: "unsupported format", format: "xlsx", data: "anything", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, rowErrors, err := parseProductImport(tt.format, []byte(tt.data))
+			if tt.wantErr {
+				if appErr := apperror.From(err); appErr.Code != apperror.CodeValidation {
+					t.Errorf("parseProductImport() error = %+v, want a validation error", appErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseProductImport() error = %v", err)
+			}
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("parseProductImport() rows = %+v, want %+v", rows, tt.wantRows)
+			}
+			if !reflect.DeepEqual(rowErrors, tt.wantErrors) {
+				t.Errorf("parseProductImport() row errors = %+v, want %+v", rowErrors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestEncodeProductCSV(t *testing.T) {
+	// an export can be imported again unchanged
+	rows := []dto.ProductImportRow{
+		{
+			ProductID: 3, Name: `Rose "Deluxe"`, Description: "Roses, lilies\nand ribbon", FlowerType: "Rose",
+			BasePrice: 59.99, Status: "NewFlower", StockQuantity: 12,
+			Occasions: []string{"Birthday", "Wedding"}, Images: []string{"/uploads/a.jpg", "https://cdn.example.com/b.jpg"},
+		},
+		{ProductID: 4, Name: "Lily", Description: "White", FlowerType: "Lily", BasePrice: 20, Status: "LowStock"},
+	}
+
+	data, err := encodeProductCSV(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, rowErrors, err := parseProductCSV(data)
+	if err != nil || len(rowErrors) != 0 {
+		t.Fatalf("parseProductCSV() of an export = %v, %+v", err, rowErrors)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("round trip = %+v, want %+v", got, rows)
+	}
+}
+
+func TestImportHelpers(t *testing.T) {
+	uniqueTests := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{"nil", nil, []string{}},
+		{"blanks and repeats are dropped", []string{" Rose", "", "Lily", "Rose ", "  "}, []string{"Rose", "Lily"}},
+	}
+	for _, tt := range uniqueTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uniqueNonEmpty(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("uniqueNonEmpty() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	imageTests := []struct {
+		value string
+		want  bool
+	}{
+		{"https://cdn.example.com/rose.jpg", true},
+		{"http://localhost:8081/uploads/rose.jpg", true},
+		{"/uploads/products/1/rose.jpg", true},
+		{"ftp://example.com/rose.jpg", false},
+		{"https://", false},
+		{"rose.jpg", false},
+		{"", false},
+	}
+	for _, tt := range imageTests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := isImageURL(tt.value); got != tt.want {
+				t.Errorf("isImageURL(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
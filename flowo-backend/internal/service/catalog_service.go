@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"flowo-backend/config"
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/model"
+	"flowo-backend/internal/repository"
+	"flowo-backend/internal/storage"
+
+	"github.com/rs/zerolog/log"
+)
+
+type CatalogService interface {
+	UploadProductImage(ctx context.Context, productID uint, data []byte, req dto.ProductImageUpload) (*model.ProductImage, error)
+	SetPrimaryImage(ctx context.Context, productID, imageID uint) error
+	DeleteProductImage(ctx context.Context, productID, imageID uint) error
+	SetProductOccasions(ctx context.Context, productID uint, occasionIDs []int) error
+
+	CreateFlowerType(ctx context.Context, req dto.FlowerTypeRequest) (*model.FlowerType, error)
+	UpdateFlowerType(ctx context.Context, flowerTypeID uint, req dto.FlowerTypeRequest) (*model.FlowerType, error)
+	DeleteFlowerType(ctx context.Context, flowerTypeID uint) error
+
+	CreateOccasion(ctx context.Context, req dto.OccasionRequest) (*model.Occasion, error)
+	UpdateOccasion(ctx context.Context, occasionID uint, req dto.OccasionRequest) (*model.Occasion, error)
+	DeleteOccasion(ctx context.Context, occasionID uint) error
+
+	ImportProducts(ctx context.Context, format string, data []byte, dryRun bool, actor string) (*dto.ProductImportResult, error)
+	ExportProducts(ctx context.Context, format string) ([]byte, error)
+}
+
+type catalogService struct {
+	repo        repository.CatalogRepository
+	productRepo repository.Repository
+	pricingRepo repository.PricingRuleRepository
+	storage     storage.Storage
+	indexer     *ProductIndexer
+	priceTable  *PriceTableService
+	cfg         config.StorageConfig
+}
+
+func NewCatalogService(
+	repo repository.CatalogRepository,
+	productRepo repository.Repository,
+	pricingRepo repository.PricingRuleRepository,
+	store storage.Storage,
+	indexer *ProductIndexer,
+	priceTable *PriceTableService,
+	cfg *config.Config,
+) CatalogService {
+	return &catalogService{
+		repo:        repo,
+		productRepo: productRepo,
+		pricingRepo: pricingRepo,
+		storage:     store,
+		indexer:     indexer,
+		priceTable:  priceTable,
+		cfg:         cfg.Storage,
+	}
+}
+
+// UploadProductImage validates the image, stores it with a thumbnail and
+// attaches both to the product
+func (s *catalogService) UploadProductImage(ctx context.Context, productID uint, data []byte, req dto.ProductImageUpload) (*model.ProductImage, error) {
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	ext, err := storage.ValidateImage(data, storage.ImageLimits{
+		MaxBytes:     s.cfg.MaxImageBytes,
+		MinDimension: s.cfg.MinImageSize,
+		MaxDimension: s.cfg.MaxImageSize,
+	})
+	if err != nil {
+		return nil, apperror.Validation(fmt.Sprintf("invalid image: %v", err))
+	}
+
+	thumb, err := storage.Thumbnail(data, s.cfg.ThumbnailSize)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("products/%d/%s%s", productID, name, ext)
+	thumbKey := fmt.Sprintf("products/%d/thumb_%s.jpg", productID, name)
+
+	url, err := s.storage.Save(key, data)
+	if err != nil {
+		return nil, err
+	}
+	thumbURL, err := s.storage.Save(thumbKey, thumb)
+	if err != nil {
+		s.removeFiles(key)
+		return nil, err
+	}
+
+	image := &model.ProductImage{
+		ProductID:    productID,
+		ImageURL:     url,
+		ThumbnailURL: thumbURL,
+		AltText:      req.AltText,
+		IsPrimary:    req.IsPrimary,
+		StorageKey:   key,
+		ThumbnailKey: thumbKey,
+	}
+	if err := s.repo.AddProductImage(ctx, image); err != nil {
+		s.removeFiles(key, thumbKey)
+		return nil, err
+	}
+	return image, nil
+}
+
+func (s *catalogService) SetPrimaryImage(ctx context.Context, productID, imageID uint) error {
+	return s.repo.SetPrimaryImage(ctx, productID, imageID)
+}
+
+// DeleteProductImage removes the image row first and then its stored files,
+// so a failed file removal never leaves a row pointing at nothing
+func (s *catalogService) DeleteProductImage(ctx context.Context, productID, imageID uint) error {
+	image, err := s.repo.GetProductImageByID(ctx, imageID)
+	if err != nil {
+		return err
+	}
+	if image.ProductID != productID {
+		return apperror.NotFound("image not found")
+	}
+
+	if err := s.repo.DeleteProductImage(ctx, productID, imageID); err != nil {
+		return err
+	}
+	s.removeFiles(image.StorageKey, image.ThumbnailKey)
+	return nil
+}
+
+func (s *catalogService) SetProductOccasions(ctx context.Context, productID uint, occasionIDs []int) error {
+	if err := s.checkProduct(ctx, productID); err != nil {
+		return err
+	}
+	if err := s.repo.SetProductOccasions(ctx, productID, occasionIDs); err != nil {
+		return err
+	}
+	s.indexer.IndexProduct(ctx, productID)
+	return nil
+}
+
+func (s *catalogService) CreateFlowerType(ctx context.Context, req dto.FlowerTypeRequest) (*model.FlowerType, error) {
+	flowerType := &model.FlowerType{
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+	}
+	if flowerType.Name == "" {
+		return nil, apperror.Validation("name is required", apperror.Field("name", "is required"))
+	}
+	if err := s.repo.CreateFlowerType(ctx, flowerType); err != nil {
+		return nil, err
+	}
+	s.reloadFlowerTypes(ctx)
+	s.indexer.RebuildAsync(ctx)
+	return flowerType, nil
+}
+
+func (s *catalogService) UpdateFlowerType(ctx context.Context, flowerTypeID uint, req dto.FlowerTypeRequest) (*model.FlowerType, error) {
+	flowerType := &model.FlowerType{
+		FlowerTypeID: flowerTypeID,
+		Name:         strings.TrimSpace(req.Name),
+		Description:  strings.TrimSpace(req.Description),
+	}
+	if flowerType.Name == "" {
+		return nil, apperror.Validation("name is required", apperror.Field("name", "is required"))
+	}
+	if err := s.repo.UpdateFlowerType(ctx, flowerType); err != nil {
+		return nil, err
+	}
+	s.reloadFlowerTypes(ctx)
+	s.indexer.RebuildAsync(ctx)
+	return flowerType, nil
+}
+
+func (s *catalogService) DeleteFlowerType(ctx context.Context, flowerTypeID uint) error {
+	if err := s.repo.DeleteFlowerType(ctx, flowerTypeID); err != nil {
+		return err
+	}
+	s.reloadFlowerTypes(ctx)
+	s.indexer.RebuildAsync(ctx)
+	return nil
+}
+
+func (s *catalogService) CreateOccasion(ctx context.Context, req dto.OccasionRequest) (*model.Occasion, error) {
+	occasion := &model.Occasion{Name: strings.TrimSpace(req.Name)}
+	if occasion.Name == "" {
+		return nil, apperror.Validation("name is required", apperror.Field("name", "is required"))
+	}
+	if err := s.repo.CreateOccasion(ctx, occasion); err != nil {
+		return nil, err
+	}
+	s.indexer.RebuildAsync(ctx)
+	return occasion, nil
+}
+
+func (s *catalogService) UpdateOccasion(ctx context.Context, occasionID uint, req dto.OccasionRequest) (*model.Occasion, error) {
+	occasion := &model.Occasion{OccasionID: occasionID, Name: strings.TrimSpace(req.Name)}
+	if occasion.Name == "" {
+		return nil, apperror.Validation("name is required", apperror.Field("name", "is required"))
+	}
+	if err := s.repo.UpdateOccasion(ctx, occasion); err != nil {
+		return nil, err
+	}
+	s.indexer.RebuildAsync(ctx)
+	return occasion, nil
+}
+
+func (s *catalogService) DeleteOccasion(ctx context.Context, occasionID uint) error {
+	if err := s.repo.DeleteOccasion(ctx, occasionID); err != nil {
+		return err
+	}
+	s.indexer.RebuildAsync(ctx)
+	return nil
+}
+
+func (s *catalogService) checkProduct(ctx context.Context, productID uint) error {
+	if _, err := s.productRepo.GetProductByID(ctx, productID); err != nil {
+		if apperror.Is(err, apperror.CodeNotFound) {
+			return apperror.NotFound("product not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// reloadFlowerTypes keeps flower-type pricing rules matching renamed and new
+// flower types and reprices accordingly; a failure only delays that, so it is
+// logged and not returned
+func (s *catalogService) reloadFlowerTypes(ctx context.Context) {
+	if err := s.pricingRepo.ReloadFlowerTypes(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to reload flower types for pricing")
+	}
+	s.priceTable.Invalidate()
+}
+
+func (s *catalogService) removeFiles(keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := s.storage.Delete(key); err != nil {
+			log.Warn().Err(err).Str("key", key).Msg("Failed to remove stored file")
+		}
+	}
+}
+
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
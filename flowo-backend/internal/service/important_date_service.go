@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"flowo-backend/config"
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/model"
+	"flowo-backend/internal/repository"
+)
+
+const (
+	defaultRemindDaysBefore = 7
+	maxRemindDaysBefore     = 60
+	defaultCalendarDays     = 30
+	maxCalendarDays         = 366
+	// products suggested in a reminder and prefilled into the cart link
+	reminderSuggestionLimit = 3
+)
+
+type ImportantDateService interface {
+	CreateDate(ctx context.Context, firebaseUID string, req dto.ImportantDateRequest) (*dto.ImportantDateResponse, error)
+	UpdateDate(ctx context.Context, firebaseUID string, dateID int, req dto.ImportantDateRequest) (*dto.ImportantDateResponse, error)
+	DeleteDate(ctx context.Context, firebaseUID string, dateID int) error
+	GetDates(ctx context.Context, firebaseUID string) ([]dto.ImportantDateResponse, error)
+	GetUpcoming(ctx context.Context, firebaseUID string, days int) ([]dto.ImportantDateResponse, error)
+	SendDueReminders(ctx context.Context) error
+}
+
+type importantDateService struct {
+	repo            repository.ImportantDateRepository
+	recommendations RecommendationService
+	notifications   NotificationService
+	domain          string
+}
+
+func NewImportantDateService(repo repository.ImportantDateRepository, recommendations RecommendationService, notifications NotificationService, cfg *config.Config) ImportantDateService {
+	return &importantDateService{
+		repo:            repo,
+		recommendations: recommendations,
+		notifications:   notifications,
+		domain:          strings.TrimRight(cfg.Domain, "/"),
+	}
+}
+
+func (s *importantDateService) CreateDate(ctx context.Context, firebaseUID string, req dto.ImportantDateRequest) (*dto.ImportantDateResponse, error) {
+	d := &model.ImportantDate{FirebaseUID: firebaseUID}
+	if err := s.apply(ctx, d, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, d); err != nil {
+		return nil, err
+	}
+	return s.response(ctx, firebaseUID, d.DateID)
+}
+
+func (s *importantDateService) UpdateDate(ctx context.Context, firebaseUID string, dateID int, req dto.ImportantDateRequest) (*dto.ImportantDateResponse, error) {
+	d, err := s.repo.GetByID(ctx, firebaseUID, dateID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(ctx, d, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, d); err != nil {
+		return nil, err
+	}
+	return s.response(ctx, firebaseUID, dateID)
+}
+
+func (s *importantDateService) DeleteDate(ctx context.Context, firebaseUID string, dateID int) error {
+	return s.repo.Delete(ctx, firebaseUID, dateID)
+}
+
+func (s *importantDateService) GetDates(ctx context.Context, firebaseUID string) ([]dto.ImportantDateResponse, error) {
+	dates, err := s.repo.GetByUser(ctx, firebaseUID)
+	if err != nil {
+		return nil, err
+	}
+	today := startOfDay(time.Now())
+	responses := make([]dto.ImportantDateResponse, 0, len(dates))
+	for _, d := range dates {
+		responses = append(responses, toImportantDateResponse(d, today))
+	}
+	return responses, nil
+}
+
+// GetUpcoming is the gifting calendar: dates coming up within the next
+// days, soonest first
+func (s *importantDateService) GetUpcoming(ctx context.Context, firebaseUID string, days int) ([]dto.ImportantDateResponse, error) {
+	if days <= 0 || days > maxCalendarDays {
+		days = defaultCalendarDays
+	}
+	dates, err := s.repo.GetByUser(ctx, firebaseUID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := startOfDay(time.Now())
+	until := today.AddDate(0, 0, days)
+	upcoming := []dto.ImportantDateResponse{}
+	for _, d := range dates {
+		next, ok := nextOccurrence(d, today)
+		if !ok || next.After(until) {
+			continue
+		}
+		upcoming = append(upcoming, toImportantDateResponse(d, today))
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		return upcoming[i].NextOccurrence < upcoming[j].NextOccurrence
+	})
+	return upcoming, nil
+}
+
+// SendDueReminders notifies users whose dates are within their reminder
+// window. Each occurrence is reminded once.
+func (s *importantDateService) SendDueReminders(ctx context.Context) error {
+	today := startOfDay(time.Now())
+	dates, err := s.repo.GetReminderCandidates(ctx, today)
+	if err != nil {
+		return err
+	}
+
+	sent := 0
+	for _, d := range dates {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		next, ok := nextOccurrence(d, today)
+		if !ok || today.Before(next.AddDate(0, 0, -d.RemindDaysBefore)) {
+			continue
+		}
+		if d.LastRemindedFor != nil && !d.LastRemindedFor.Before(next) {
+			continue
+		}
+
+		title, message := s.reminderMessage(ctx, d, next, today)
+		if err := s.notifications.Notify(ctx, d.FirebaseUID, "occasion_reminder", title, message); err != nil {
+			log.Error().Err(err).Int("date_id", d.DateID).Msg("Failed to send occasion reminder")
+			continue
+		}
+		if err := s.repo.MarkReminded(ctx, d.DateID, next); err != nil {
+			return err
+		}
+		sent++
+	}
+	if sent > 0 {
+		log.Info().Int("sent", sent).Msg("Occasion reminders sent")
+	}
+	return nil
+}
+
+func (s *importantDateService) reminderMessage(ctx context.Context, d model.ImportantDate, next, today time.Time) (string, string) {
+	title := fmt.Sprintf("%s's %s is coming up", d.RecipientName, d.OccasionName)
+
+	var when string
+	switch days := daysBetween(today, next); days {
+	case 0:
+		when = "today"
+	case 1:
+		when = "tomorrow"
+	default:
+		when = fmt.Sprintf("in %d days", days)
+	}
+	message := fmt.Sprintf("%s's %s is %s (%s).", d.RecipientName, d.OccasionName, when, next.Format("2006-01-02"))
+
+	// A reminder without suggestions is still worth sending
+	suggestions, err := s.recommendations.GetOccasionBasedRecommendations(ctx, d.OccasionName, reminderSuggestionLimit)
+	if err != nil {
+		log.Warn().Err(err).Str("occasion", d.OccasionName).Msg("Failed to get occasion recommendations for reminder")
+		return title, message
+	}
+	if suggestions == nil || len(suggestions.Recommendations) == 0 {
+		return title, message
+	}
+
+	names := make([]string, 0, len(suggestions.Recommendations))
+	ids := make([]string, 0, len(suggestions.Recommendations))
+	for _, rec := range suggestions.Recommendations {
+		names = append(names, rec.Product.Name)
+		ids = append(ids, strconv.FormatUint(uint64(rec.Product.ProductID), 10))
+	}
+	message += fmt.Sprintf(" Suggested for the occasion: %s. Add them to your cart: %s/cart?add=%s",
+		strings.Join(names, ", "), s.domain, strings.Join(ids, ","))
+	return title, message
+}
+
+// apply validates the request and copies it onto the date
+func (s *importantDateService) apply(ctx context.Context, d *model.ImportantDate, req dto.ImportantDateRequest) error {
+	recipient := strings.TrimSpace(req.RecipientName)
+	if recipient == "" {
+		return apperror.Validation("recipient name is required", apperror.Field("recipient_name", "is required"))
+	}
+	eventDate, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+	if err != nil {
+		return apperror.Validation("invalid date", apperror.Field("date", "must be a date in YYYY-MM-DD format"))
+	}
+	remindDays := defaultRemindDaysBefore
+	if req.RemindDaysBefore != nil {
+		remindDays = *req.RemindDaysBefore
+	}
+	if remindDays < 0 || remindDays > maxRemindDaysBefore {
+		return apperror.Validation("remind days must be between 0 and 60", apperror.Field("remind_days_before", "must be between 0 and 60"))
+	}
+
+	occasionName, err := s.repo.GetOccasionName(ctx, req.OccasionID)
+	if apperror.Is(err, apperror.CodeNotFound) {
+		return apperror.Validation("occasion not found", apperror.Field("occasion_id", "not found"))
+	}
+	if err != nil {
+		return err
+	}
+	if req.AddressID != nil {
+		ok, err := s.repo.AddressBelongsToUser(ctx, d.FirebaseUID, *req.AddressID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return apperror.Validation("address not found", apperror.Field("address_id", "not found in your address book"))
+		}
+	}
+
+	d.RecipientName = recipient
+	d.OccasionID = req.OccasionID
+	d.OccasionName = occasionName
+	d.AddressID = req.AddressID
+	d.EventDate = eventDate
+	d.Recurring = req.Recurring == nil || *req.Recurring
+	d.RemindDaysBefore = remindDays
+	d.Note = strings.TrimSpace(req.Note)
+	return nil
+}
+
+func (s *importantDateService) response(ctx context.Context, firebaseUID string, dateID int) (*dto.ImportantDateResponse, error) {
+	d, err := s.repo.GetByID(ctx, firebaseUID, dateID)
+	if err != nil {
+		return nil, err
+	}
+	resp := toImportantDateResponse(*d, startOfDay(time.Now()))
+	return &resp, nil
+}
+
+func toImportantDateResponse(d model.ImportantDate, today time.Time) dto.ImportantDateResponse {
+	resp := dto.ImportantDateResponse{
+		DateID:           d.DateID,
+		RecipientName:    d.RecipientName,
+		OccasionID:       d.OccasionID,
+		OccasionName:     d.OccasionName,
+		AddressID:        d.AddressID,
+		Date:             d.EventDate.Format("2006-01-02"),
+		Recurring:        d.Recurring,
+		RemindDaysBefore: d.RemindDaysBefore,
+		Note:             d.Note,
+	}
+	if next, ok := nextOccurrence(d, today); ok {
+		days := daysBetween(today, next)
+		resp.NextOccurrence = next.Format("2006-01-02")
+		resp.DaysUntil = &days
+	}
+	return resp
+}
+
+// nextOccurrence returns the first occurrence of the date on or after
+// today. Recurring dates on Feb 29 fall on Feb 28 in other years. One-off
+// dates that have passed have no next occurrence.
+func nextOccurrence(d model.ImportantDate, today time.Time) (time.Time, bool) {
+	event := startOfDay(d.EventDate)
+	if !d.Recurring {
+		return event, !event.Before(today)
+	}
+	year := today.Year()
+	if event.Year() > year {
+		year = event.Year()
+	}
+	for ; ; year++ {
+		day := event.Day()
+		if event.Month() == time.February && day == 29 && !isLeapYear(year) {
+			day = 28
+		}
+		next := time.Date(year, event.Month(), day, 0, 0, 0, 0, today.Location())
+		if !next.Before(today) {
+			return next, true
+		}
+	}
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// daysBetween counts calendar days, so DST changes don't shorten a day
+func daysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
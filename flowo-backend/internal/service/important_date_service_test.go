@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"flowo-backend/internal/model"
+)
+
+func TestNextOccurrence(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name      string
+		event     time.Time
+		recurring bool
+		today     time.Time
+		want      time.Time
+		wantOK    bool
+	}{
+		{"recurring later this year", date(2020, 6, 15), true, date(2024, 5, 1), date(2024, 6, 15), true},
+		{"recurring today", date(2020, 5, 1), true, date(2024, 5, 1), date(2024, 5, 1), true},
+		{"recurring passed this year", date(2020, 3, 10), true, date(2024, 5, 1), date(2025, 3, 10), true},
+		{"recurring first occurrence in a later year", date(2026, 8, 1), true, date(2024, 5, 1), date(2026, 8, 1), true},
+		{"Feb 29 in a leap year", date(2020, 2, 29), true, date(2024, 1, 10), date(2024, 2, 29), true},
+		{"Feb 29 falls on Feb 28 otherwise", date(2020, 2, 29), true, date(2023, 1, 10), date(2023, 2, 28), true},
+		{"Feb 29 passed in a leap year", date(2020, 2, 29), true, date(2024, 3, 1), date(2025, 2, 28), true},
+		{"Feb 29 on the Feb 28 stand-in", date(2020, 2, 29), true, date(2023, 2, 28), date(2023, 2, 28), true},
+		{"Feb 29 after the stand-in", date(2020, 2, 29), true, date(2023, 3, 1), date(2024, 2, 29), true},
+		{"Feb 29 skips century non-leap year", date(2096, 2, 29), true, date(2100, 1, 1), date(2100, 2, 28), true},
+		{"one-off upcoming", date(2024, 7, 1), false, date(2024, 5, 1), date(2024, 7, 1), true},
+		{"one-off today", date(2024, 5, 1), false, date(2024, 5, 1), date(2024, 5, 1), true},
+		{"one-off passed", date(2024, 4, 30), false, date(2024, 5, 1), date(2024, 4, 30), false},
+		{"one-off Feb 29 passed", date(2020, 2, 29), false, date(2024, 5, 1), date(2020, 2, 29), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := model.ImportantDate{EventDate: tt.event, Recurring: tt.recurring}
+			got, ok := nextOccurrence(d, tt.today)
+			if !got.Equal(tt.want) || ok != tt.wantOK {
+				t.Errorf("nextOccurrence() = %s, %v, want %s, %v",
+					got.Format("2006-01-02"), ok, tt.want.Format("2006-01-02"), tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	tests := []struct {
+		from, to time.Time
+		want     int
+	}{
+		{time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC), time.Date(2024, 5, 2, 1, 0, 0, 0, time.UTC), 1},
+		{time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 2},
+		{time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 7, 0, 0, 0, 0, time.UTC), 7},
+		{time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), -2},
+	}
+	for _, tt := range tests {
+		if got := daysBetween(tt.from, tt.to); got != tt.want {
+			t.Errorf("daysBetween(%s, %s) = %d, want %d", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"flowo-backend/config"
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/model"
+	"flowo-backend/internal/repository"
+)
+
+type InventoryService interface {
+	ReceiveBatch(ctx context.Context, productID int, actor string, req dto.ReceiveBatchRequest) (*model.InventoryBatch, error)
+	GetBatches(ctx context.Context, productID int) ([]model.InventoryBatch, error)
+	GetWastage(ctx context.Context, productID int) ([]model.Wastage, error)
+	RefreshFreshness(ctx context.Context, now time.Time, actor string) (*dto.FreshnessReport, error)
+
+	AdjustStock(ctx context.Context, productID int, actor string, req dto.StockAdjustmentRequest) (*model.InventoryMovement, error)
+	GetMovements(ctx context.Context, productID int, variantID *int, page, limit int) (*dto.InventoryMovementsResponse, error)
+	SetLowStockThreshold(ctx context.Context, productID int, threshold int) error
+}
+
+type inventoryService struct {
+	repo        repository.InventoryRepository
+	productRepo repository.Repository
+	priceTable  *PriceTableService
+	cfg         config.InventoryConfig
+}
+
+func NewInventoryService(repo repository.InventoryRepository, productRepo repository.Repository, priceTable *PriceTableService, cfg *config.Config) InventoryService {
+	return &inventoryService{
+		repo:        repo,
+		productRepo: productRepo,
+		priceTable:  priceTable,
+		cfg:         cfg.Inventory,
+	}
+}
+
+func (s *inventoryService) ReceiveBatch(ctx context.Context, productID int, actor string, req dto.ReceiveBatchRequest) (*model.InventoryBatch, error) {
+	if err := s.checkProductVariant(ctx, productID, req.VariantID); err != nil {
+		return nil, err
+	}
+
+	receivedDate := time.Now()
+	if req.ReceivedDate != nil {
+		receivedDate = *req.ReceivedDate
+	}
+
+	batch := &model.InventoryBatch{
+		ProductID:        productID,
+		VariantID:        req.VariantID,
+		QuantityReceived: req.Quantity,
+		ReceivedDate:     receivedDate,
+		ShelfLifeDays:    req.ShelfLifeDays,
+	}
+	if err := s.repo.CreateBatch(ctx, batch, actor); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func (s *inventoryService) GetBatches(ctx context.Context, productID int) ([]model.InventoryBatch, error) {
+	return s.repo.GetBatchesByProduct(ctx, productID)
+}
+
+func (s *inventoryService) GetWastage(ctx context.Context, productID int) ([]model.Wastage, error) {
+	return s.repo.GetWastageByProduct(ctx, productID)
+}
+
+// RefreshFreshness writes off expired batches and then re-derives every
+// product status from its stock and oldest batch
+func (s *inventoryService) RefreshFreshness(ctx context.Context, now time.Time, actor string) (*dto.FreshnessReport, error) {
+	report := &dto.FreshnessReport{}
+
+	expired, err := s.repo.GetExpiredBatches(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, batch := range expired {
+		wasted, err := s.repo.WriteOffBatch(ctx, batch, actor, "expired")
+		if err != nil {
+			return report, err
+		}
+		report.WrittenOffBatches++
+		report.WastedUnits += wasted
+	}
+
+	products, err := s.repo.GetProductFreshness(ctx)
+	if err != nil {
+		return report, err
+	}
+	for _, p := range products {
+		status := s.deriveStatus(p, now)
+		if status == p.Status {
+			continue
+		}
+		if err := s.repo.UpdateProductStatus(ctx, p.ProductID, status); err != nil {
+			return report, err
+		}
+		report.StatusChanges++
+	}
+	// status-based pricing rules may now apply to different products
+	if report.StatusChanges > 0 {
+		s.priceTable.Invalidate()
+	}
+
+	return report, nil
+}
+
+// deriveStatus picks OldFlower when the oldest batch is close to expiry, so
+// that status-based discount rules can clear it, then LowStock under the
+// product's threshold, otherwise NewFlower
+func (s *inventoryService) deriveStatus(p model.ProductFreshness, now time.Time) string {
+	nearExpiry := now.AddDate(0, 0, s.cfg.NearExpiryDays)
+	if p.OldestExpiry != nil && !p.OldestExpiry.After(nearExpiry) {
+		return "OldFlower"
+	}
+	if p.TotalStock < p.LowStockThreshold {
+		return "LowStock"
+	}
+	return "NewFlower"
+}
+
+func (s *inventoryService) AdjustStock(ctx context.Context, productID int, actor string, req dto.StockAdjustmentRequest) (*model.InventoryMovement, error) {
+	if req.QuantityChange == 0 {
+		return nil, apperror.Validation("quantity change cannot be zero", apperror.Field("quantity_change", "cannot be zero"))
+	}
+	if err := s.checkProductVariant(ctx, productID, req.VariantID); err != nil {
+		return nil, err
+	}
+	return s.repo.AdjustStock(ctx, productID, req.VariantID, req.QuantityChange, actor, req.Reason)
+}
+
+// GetMovements returns a page of the ledger together with the stock derived
+// from it, so drift against the stock counter is visible
+func (s *inventoryService) GetMovements(ctx context.Context, productID int, variantID *int, page, limit int) (*dto.InventoryMovementsResponse, error) {
+	if err := s.checkProductVariant(ctx, productID, variantID); err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	movements, err := s.repo.GetMovements(ctx, productID, variantID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+	ledgerStock, err := s.repo.GetLedgerStock(ctx, productID, variantID)
+	if err != nil {
+		return nil, err
+	}
+	currentStock, err := s.repo.GetCurrentStock(ctx, productID, variantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.InventoryMovementsResponse{
+		ProductID:    productID,
+		VariantID:    variantID,
+		CurrentStock: currentStock,
+		LedgerStock:  ledgerStock,
+		Movements:    movements,
+	}, nil
+}
+
+func (s *inventoryService) SetLowStockThreshold(ctx context.Context, productID int, threshold int) error {
+	if threshold < 0 {
+		return apperror.Validation("threshold cannot be negative", apperror.Field("threshold", "cannot be negative"))
+	}
+	if err := s.checkProductVariant(ctx, productID, nil); err != nil {
+		return err
+	}
+	return s.repo.SetLowStockThreshold(ctx, productID, threshold)
+}
+
+// checkProductVariant verifies the product exists and, when given, that the variant belongs to it
+func (s *inventoryService) checkProductVariant(ctx context.Context, productID int, variantID *int) error {
+	if _, err := s.productRepo.GetProductByID(ctx, uint(productID)); err != nil {
+		if apperror.Is(err, apperror.CodeNotFound) {
+			return apperror.NotFound("product not found")
+		}
+		return err
+	}
+
+	if variantID != nil {
+		variant, err := s.productRepo.GetVariantByID(ctx, uint(*variantID))
+		if err != nil {
+			if apperror.Is(err, apperror.CodeNotFound) {
+				return apperror.NotFound("variant not found")
+			}
+			return err
+		}
+		if int(variant.ProductID) != productID {
+			return apperror.NotFound("variant not found")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"flowo-backend/config"
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/model"
+)
+
+func TestDeriveStatus(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	in := func(days int) *time.Time {
+		t := now.AddDate(0, 0, days)
+		return &t
+	}
+	s := &inventoryService{cfg: config.InventoryConfig{NearExpiryDays: 2}}
+
+	tests := []struct {
+		name      string
+		freshness model.ProductFreshness
+		want      string
+	}{
+		{"fresh stock", model.ProductFreshness{TotalStock: 20, LowStockThreshold: 5, OldestExpiry: in(5)}, "NewFlower"},
+		{"no batches", model.ProductFreshness{TotalStock: 20, LowStockThreshold: 5}, "NewFlower"},
+		{"oldest batch near expiry", model.ProductFreshness{TotalStock: 20, LowStockThreshold: 5, OldestExpiry: in(1)}, "OldFlower"},
+		{"expiring on the cut-off", model.ProductFreshness{TotalStock: 20, LowStockThreshold: 5, OldestExpiry: in(2)}, "OldFlower"},
+		{"near expiry beats low stock", model.ProductFreshness{TotalStock: 1, LowStockThreshold: 5, OldestExpiry: in(0)}, "OldFlower"},
+		{"under the threshold", model.ProductFreshness{TotalStock: 4, LowStockThreshold: 5, OldestExpiry: in(5)}, "LowStock"},
+		{"at the threshold", model.ProductFreshness{TotalStock: 5, LowStockThreshold: 5}, "NewFlower"},
+		{"sold out", model.ProductFreshness{TotalStock: 0, LowStockThreshold: 5}, "LowStock"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.deriveStatus(tt.freshness, now); got != tt.want {
+				t.Errorf("deriveStatus() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInventoryValidation(t *testing.T) {
+	// invalid requests are rejected before the product is looked up
+	s := &inventoryService{}
+	ctx := context.Background()
+
+	_, err := s.AdjustStock(ctx, 1, "admin", dto.StockAdjustmentRequest{QuantityChange: 0, Reason: "recount"})
+	if appErr := apperror.From(err); appErr.Code != apperror.CodeValidation || appErr.Fields[0].Field != "quantity_change" {
+		t.Errorf("AdjustStock() of zero = %+v, want a validation error on quantity_change", appErr)
+	}
+
+	err = s.SetLowStockThreshold(ctx, 1, -1)
+	if appErr := apperror.From(err); appErr.Code != apperror.CodeValidation || appErr.Fields[0].Field != "threshold" {
+		t.Errorf("SetLowStockThreshold(-1) = %+v, want a validation error on threshold", appErr)
+	}
+}
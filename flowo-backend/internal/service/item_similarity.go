@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"flowo-backend/internal/model"
+)
+
+const (
+	// similar products kept per product and similarity type
+	similarityTopK = 50
+	// a purchase links products more strongly than a view
+	similarityPurchaseWeight = 3.0
+	// users touching more products than this are skipped as noise (crawlers,
+	// shared sessions); they would add a pair for every two products
+	similarityMaxItemsPerUser = 500
+	// incremental runs only recompute recently active products; a full
+	// rebuild this often picks up everything else
+	fullSimilarityRebuildInterval = 24 * time.Hour
+	// orders are matched by order date, so look back far enough to catch
+	// orders completed some days after they were placed
+	orderCompletionLookback = 72 * time.Hour
+)
+
+// productPair is an unordered pair of products, lower id first
+type productPair struct {
+	a, b uint
+}
+
+func newProductPair(x, y uint) productPair {
+	if x > y {
+		x, y = y, x
+	}
+	return productPair{a: x, b: y}
+}
+
+// UpdateSimilarityMatrix rebuilds the collaborative and hybrid rows of
+// ProductSimilarity. The first run and one run a day rebuild every product;
+// other runs only rewrite the pairs of products viewed or bought since the
+// last run, since only their co-occurrence vectors changed. Such a pair is
+// kept when it is in the top-K of either product, so the neighbours of
+// those products are ranked again too.
+func (s *recommendationService) UpdateSimilarityMatrix(ctx context.Context) error {
+	s.similarityMu.Lock()
+	defer s.similarityMu.Unlock()
+
+	now := time.Now()
+	full := s.lastFullSimilarityRun.IsZero() || now.Sub(s.lastFullSimilarityRun) >= fullSimilarityRebuildInterval
+
+	var scope map[uint]bool
+	var scopeIDs []uint
+	if !full {
+		ids, err := s.recommendationRepo.GetProductsWithActivitySince(ctx, s.lastSimilarityRun, s.lastSimilarityRun.Add(-orderCompletionLookback))
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			s.lastSimilarityRun = now
+			return nil
+		}
+		scope = make(map[uint]bool, len(ids))
+		for _, id := range ids {
+			scope[id] = true
+		}
+		scopeIDs = ids
+	}
+
+	signals, err := s.recommendationRepo.GetItemSignals(ctx)
+	if err != nil {
+		return err
+	}
+	products, err := s.productRepo.GetAllProducts(ctx)
+	if err != nil {
+		return err
+	}
+
+	collaborative := cosineSimilarities(signals, withNeighbours(signals, scope))
+	rows := s.similarityRows(products, collaborative, scope, s.GetConfig())
+	types := []string{model.SimilarityCollaborative, model.SimilarityHybrid}
+	if err := s.recommendationRepo.ReplaceProductSimilarities(ctx, types, scopeIDs, rows); err != nil {
+		return err
+	}
+
+	s.lastSimilarityRun = now
+	if full {
+		s.lastFullSimilarityRun = now
+	}
+	return nil
+}
+
+// weightedItem is one product a user engaged with
+type weightedItem struct {
+	productID uint
+	weight    float64
+}
+
+// itemsByUser turns signals into per-user engagement weights, leaving out
+// users with too many products to be meaningful
+func itemsByUser(signals []model.ItemSignal) map[string][]weightedItem {
+	byUser := make(map[string][]weightedItem)
+	for _, sig := range signals {
+		var w float64
+		if sig.UnitsSold > 0 {
+			w += similarityPurchaseWeight
+		}
+		if sig.Views > 0 {
+			w++
+		}
+		if w == 0 {
+			continue
+		}
+		byUser[sig.UserKey] = append(byUser[sig.UserKey], weightedItem{sig.ProductID, w})
+	}
+	for user, items := range byUser {
+		if len(items) > similarityMaxItemsPerUser {
+			delete(byUser, user)
+		}
+	}
+	return byUser
+}
+
+// withNeighbours adds to scope every product sharing a user with a product
+// in it, i.e. every product with a collaborative score against the scope.
+// Their whole similarity vectors are needed to rank their neighbours.
+func withNeighbours(signals []model.ItemSignal, scope map[uint]bool) map[uint]bool {
+	if scope == nil {
+		return nil
+	}
+	expanded := make(map[uint]bool, len(scope))
+	for id := range scope {
+		expanded[id] = true
+	}
+	for _, items := range itemsByUser(signals) {
+		touches := false
+		for _, it := range items {
+			if scope[it.productID] {
+				touches = true
+				break
+			}
+		}
+		if !touches {
+			continue
+		}
+		for _, it := range items {
+			expanded[it.productID] = true
+		}
+	}
+	return expanded
+}
+
+// cosineSimilarities computes the item-item cosine similarity of products
+// over users: each product is a vector of per-user engagement weights. Only
+// pairs with a product in scope are computed, all pairs when scope is nil.
+func cosineSimilarities(signals []model.ItemSignal, scope map[uint]bool) map[productPair]float64 {
+	norms := make(map[uint]float64)
+	dots := make(map[productPair]float64)
+	for _, items := range itemsByUser(signals) {
+		for i, x := range items {
+			norms[x.productID] += x.weight * x.weight
+			for _, y := range items[i+1:] {
+				if scope != nil && !scope[x.productID] && !scope[y.productID] {
+					continue
+				}
+				dots[newProductPair(x.productID, y.productID)] += x.weight * y.weight
+			}
+		}
+	}
+
+	similarities := make(map[productPair]float64, len(dots))
+	for pair, dot := range dots {
+		similarities[pair] = math.Min(dot/math.Sqrt(norms[pair.a]*norms[pair.b]), 1)
+	}
+	return similarities
+}
+
+// similarityRows keeps the pairs with a product in scope (all pairs when
+// scope is nil) that are among the top collaborative or hybrid neighbours
+// above MinSimilarity of either product. Hybrid blends collaborative and
+// content similarity in the ratio of their configured weights. Products far
+// from the scope may lack collaborative scores here; at worst that keeps a
+// pair the daily full rebuild drops again.
+func (s *recommendationService) similarityRows(
+	products []model.Product,
+	collaborative map[productPair]float64,
+	scope map[uint]bool,
+	config model.RecommendationConfig,
+) []model.ProductSimilarity {
+	alpha := 0.5
+	if total := config.CollaborativeWeight + config.ContentWeight; total > 0 {
+		alpha = config.CollaborativeWeight / total
+	}
+
+	type neighbour struct {
+		pair  productPair
+		score float64
+	}
+	topK := func(list []neighbour) []neighbour {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].score != list[j].score {
+				return list[i].score > list[j].score
+			}
+			return list[i].pair.a+list[i].pair.b < list[j].pair.a+list[j].pair.b
+		})
+		if len(list) > similarityTopK {
+			list = list[:similarityTopK]
+		}
+		return list
+	}
+
+	inScope := func(pair productPair) bool {
+		return scope == nil || scope[pair.a] || scope[pair.b]
+	}
+
+	collabRows := make(map[productPair]float64)
+	hybridRows := make(map[productPair]float64)
+	for _, p := range products {
+		var collab, hybrid []neighbour
+		for _, q := range products {
+			if q.ProductID == p.ProductID {
+				continue
+			}
+			pair := newProductPair(p.ProductID, q.ProductID)
+			c := collaborative[pair]
+			h := alpha*c + (1-alpha)*s.calculateContentSimilarity(p, q)
+			if c > 0 && c >= config.MinSimilarity {
+				collab = append(collab, neighbour{pair, c})
+			}
+			if h >= config.MinSimilarity {
+				hybrid = append(hybrid, neighbour{pair, h})
+			}
+		}
+		for _, n := range topK(collab) {
+			if inScope(n.pair) {
+				collabRows[n.pair] = n.score
+			}
+		}
+		for _, n := range topK(hybrid) {
+			if inScope(n.pair) {
+				hybridRows[n.pair] = n.score
+			}
+		}
+	}
+
+	rows := make([]model.ProductSimilarity, 0, len(collabRows)+len(hybridRows))
+	for pair, score := range collabRows {
+		rows = append(rows, similarityRow(pair, score, model.SimilarityCollaborative))
+	}
+	for pair, score := range hybridRows {
+		rows = append(rows, similarityRow(pair, score, model.SimilarityHybrid))
+	}
+	return rows
+}
+
+func similarityRow(pair productPair, score float64, similarityType string) model.ProductSimilarity {
+	return model.ProductSimilarity{
+		ProductID1:      pair.a,
+		ProductID2:      pair.b,
+		SimilarityScore: math.Round(score*10000) / 10000,
+		SimilarityType:  similarityType,
+	}
+}
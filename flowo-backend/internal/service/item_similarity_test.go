@@ -0,0 +1,215 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"flowo-backend/internal/model"
+)
+
+func TestCosineSimilarities(t *testing.T) {
+	heavyUser := make([]model.ItemSignal, similarityMaxItemsPerUser+1)
+	for i := range heavyUser {
+		heavyUser[i] = model.ItemSignal{UserKey: "crawler", ProductID: uint(i + 1), Views: 1}
+	}
+
+	tests := []struct {
+		name    string
+		signals []model.ItemSignal
+		scope   map[uint]bool
+		want    map[productPair]float64
+	}{
+		{
+			name: "products seen by the same users are identical",
+			signals: []model.ItemSignal{
+				{UserKey: "u1", ProductID: 1, Views: 2},
+				{UserKey: "u1", ProductID: 2, Views: 1},
+				{UserKey: "u2", ProductID: 2, Views: 5},
+				{UserKey: "u2", ProductID: 1, Views: 1},
+			},
+			want: map[productPair]float64{{1, 2}: 1},
+		},
+		{
+			name: "a purchase weighs more than a view",
+			signals: []model.ItemSignal{
+				{UserKey: "u1", ProductID: 1, UnitsSold: 1},
+				{UserKey: "u1", ProductID: 2, Views: 1},
+				{UserKey: "u2", ProductID: 1, Views: 1},
+				{UserKey: "u2", ProductID: 3, Views: 1},
+			},
+			// p1 = (3, 1), p2 = (1, 0), p3 = (0, 1)
+			want: map[productPair]float64{
+				{1, 2}: 3 / math.Sqrt(10),
+				{1, 3}: 1 / math.Sqrt(10),
+			},
+		},
+		{
+			name: "signals without views or sales are ignored",
+			signals: []model.ItemSignal{
+				{UserKey: "u1", ProductID: 1, Views: 1},
+				{UserKey: "u1", ProductID: 2},
+			},
+			want: map[productPair]float64{},
+		},
+		{
+			name:    "users with too many products are skipped",
+			signals: heavyUser,
+			want:    map[productPair]float64{},
+		},
+		{
+			name: "only pairs touching the scope",
+			signals: []model.ItemSignal{
+				{UserKey: "u1", ProductID: 1, Views: 1},
+				{UserKey: "u1", ProductID: 2, Views: 1},
+				{UserKey: "u1", ProductID: 3, Views: 1},
+			},
+			scope: map[uint]bool{1: true},
+			want: map[productPair]float64{
+				{1, 2}: 1,
+				{1, 3}: 1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkScores(t, cosineSimilarities(tt.signals, tt.scope), tt.want)
+		})
+	}
+}
+
+func TestWithNeighbours(t *testing.T) {
+	signals := []model.ItemSignal{
+		{UserKey: "u1", ProductID: 1, Views: 1},
+		{UserKey: "u1", ProductID: 2, Views: 1},
+		{UserKey: "u2", ProductID: 2, Views: 1},
+		{UserKey: "u2", ProductID: 3, Views: 1},
+		{UserKey: "u3", ProductID: 4, Views: 1},
+		{UserKey: "u3", ProductID: 5, Views: 1},
+	}
+
+	tests := []struct {
+		name  string
+		scope map[uint]bool
+		want  []uint
+	}{
+		{"full run stays unscoped", nil, nil},
+		{"direct co-occurrences only", map[uint]bool{1: true}, []uint{1, 2}},
+		{"scope without signals", map[uint]bool{9: true}, []uint{9}},
+		{"several scoped products", map[uint]bool{3: true, 4: true}, []uint{2, 3, 4, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := withNeighbours(signals, tt.scope)
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("withNeighbours() = %v, want nil", got)
+				}
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("withNeighbours() = %v, want %v", got, tt.want)
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Fatalf("withNeighbours() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSimilarityRows(t *testing.T) {
+	// different flower types, statuses and no prices: no content similarity
+	unrelated := []model.Product{
+		{ProductID: 1, FlowerType: "Rose", Status: "NewFlower"},
+		{ProductID: 2, FlowerType: "Lily", Status: "OldFlower"},
+		{ProductID: 3, FlowerType: "Tulip", Status: "LowStock"},
+	}
+	collaborative := map[productPair]float64{
+		{1, 2}: 0.8,
+		{2, 3}: 0.3,
+		{1, 3}: 0.05,
+	}
+	balanced := model.RecommendationConfig{CollaborativeWeight: 1, ContentWeight: 1, MinSimilarity: 0.1}
+
+	tests := []struct {
+		name          string
+		products      []model.Product
+		collaborative map[productPair]float64
+		scope         map[uint]bool
+		config        model.RecommendationConfig
+		wantCollab    map[productPair]float64
+		wantHybrid    map[productPair]float64
+	}{
+		{
+			name:          "pairs below the minimum are dropped",
+			products:      unrelated,
+			collaborative: collaborative,
+			config:        balanced,
+			wantCollab:    map[productPair]float64{{1, 2}: 0.8, {2, 3}: 0.3},
+			wantHybrid:    map[productPair]float64{{1, 2}: 0.4, {2, 3}: 0.15},
+		},
+		{
+			name:          "incremental run keeps only pairs touching the scope",
+			products:      unrelated,
+			collaborative: collaborative,
+			scope:         map[uint]bool{1: true},
+			config:        balanced,
+			wantCollab:    map[productPair]float64{{1, 2}: 0.8},
+			wantHybrid:    map[productPair]float64{{1, 2}: 0.4},
+		},
+		{
+			name: "content similarity alone makes hybrid rows",
+			products: []model.Product{
+				{ProductID: 1, FlowerType: "Rose", Status: "NewFlower", BasePrice: 20},
+				{ProductID: 2, FlowerType: "Rose", Status: "NewFlower", BasePrice: 20},
+			},
+			collaborative: map[productPair]float64{},
+			config:        model.RecommendationConfig{ContentWeight: 1, MinSimilarity: 0.1},
+			wantCollab:    map[productPair]float64{},
+			wantHybrid:    map[productPair]float64{{1, 2}: 0.9},
+		},
+	}
+	s := &recommendationService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collab := make(map[productPair]float64)
+			hybrid := make(map[productPair]float64)
+			for _, r := range s.similarityRows(tt.products, tt.collaborative, tt.scope, tt.config) {
+				pair := productPair{r.ProductID1, r.ProductID2}
+				switch r.SimilarityType {
+				case model.SimilarityCollaborative:
+					collab[pair] = r.SimilarityScore
+				case model.SimilarityHybrid:
+					hybrid[pair] = r.SimilarityScore
+				default:
+					t.Fatalf("unexpected similarity type %q", r.SimilarityType)
+				}
+			}
+			checkScores(t, collab, tt.wantCollab)
+			checkScores(t, hybrid, tt.wantHybrid)
+		})
+	}
+}
+
+func checkScores(t *testing.T, got, want map[productPair]float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %s, want %s", formatScores(got), formatScores(want))
+	}
+	for pair, w := range want {
+		g, ok := got[pair]
+		if !ok || math.Abs(g-w) > 1e-4 {
+			t.Fatalf("got %s, want %s", formatScores(got), formatScores(want))
+		}
+	}
+}
+
+func formatScores(scores map[productPair]float64) string {
+	s := "{"
+	for pair, score := range scores {
+		s += fmt.Sprintf(" %d-%d:%.4f", pair.a, pair.b, score)
+	}
+	return s + " }"
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+
+	"flowo-backend/internal/model"
+	"flowo-backend/internal/repository"
+)
+
+type NotificationService interface {
+	Notify(ctx context.Context, firebaseUID, notificationType, title, message string) error
+	NotifyAdmins(ctx context.Context, notificationType, title, message string) error
+	GetNotifications(ctx context.Context, firebaseUID string, unreadOnly bool, page, limit int) ([]model.Notification, error)
+	MarkAsRead(ctx context.Context, notificationID int, firebaseUID string) error
+	MarkAllAsRead(ctx context.Context, firebaseUID string) error
+}
+
+type notificationService struct {
+	repo repository.NotificationRepository
+}
+
+func NewNotificationService(repo repository.NotificationRepository) NotificationService {
+	return &notificationService{repo: repo}
+}
+
+func (s *notificationService) Notify(ctx context.Context, firebaseUID, notificationType, title, message string) error {
+	return s.repo.CreateNotification(ctx, &model.Notification{
+		FirebaseUID: firebaseUID,
+		Type:        notificationType,
+		Title:       title,
+		Message:     message,
+	})
+}
+
+func (s *notificationService) NotifyAdmins(ctx context.Context, notificationType, title, message string) error {
+	return s.repo.NotifyAdmins(ctx, notificationType, title, message)
+}
+
+func (s *notificationService) GetNotifications(ctx context.Context, firebaseUID string, unreadOnly bool, page, limit int) ([]model.Notification, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.repo.GetNotificationsByUser(ctx, firebaseUID, unreadOnly, limit, (page-1)*limit)
+}
+
+func (s *notificationService) MarkAsRead(ctx context.Context, notificationID int, firebaseUID string) error {
+	return s.repo.MarkAsRead(ctx, notificationID, firebaseUID)
+}
+
+func (s *notificationService) MarkAllAsRead(ctx context.Context, firebaseUID string) error {
+	return s.repo.MarkAllAsRead(ctx, firebaseUID)
+}
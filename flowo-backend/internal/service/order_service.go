@@ -1,9 +1,13 @@
 package service
 
 import (
-	"errors"
+	"context"
+	"strings"
+
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/model"
+	"flowo-backend/internal/pagination"
 	"flowo-backend/internal/repository"
 	"time"
 )
@@ -13,19 +17,39 @@ type OrderService struct {
 	CartRepo    repository.CartRepository
 	CartService *CartService
 	AddressRepo repository.AddressRepository
+	Stats       *ProductStatsService
+	RecCache    *RecommendationCache
+	// Recommendations relearns preferences when an order stops counting
+	Recommendations RecommendationService
 }
 
-func NewOrderService(orderRepo repository.OrderRepository, cartRepo repository.CartRepository, cartService *CartService, addressRepo repository.AddressRepository) *OrderService {
+func NewOrderService(orderRepo repository.OrderRepository, cartRepo repository.CartRepository, cartService *CartService, addressRepo repository.AddressRepository, stats *ProductStatsService, recCache *RecommendationCache, recommendations RecommendationService) *OrderService {
 	return &OrderService{
-		OrderRepo:   orderRepo,
-		CartRepo:    cartRepo,
-		CartService: cartService,
-		AddressRepo: addressRepo,
+		OrderRepo:       orderRepo,
+		CartRepo:        cartRepo,
+		CartService:     cartService,
+		AddressRepo:     addressRepo,
+		Stats:           stats,
+		RecCache:        recCache,
+		Recommendations: recommendations,
 	}
 }
 
-func (s *OrderService) GetUserOrders(FirebaseUID string) ([]dto.OrderResponse, error) {
-	orders, err := s.OrderRepo.GetOrdersByUser(FirebaseUID)
+// undonePurchaseStatuses are the order statuses whose items no longer count
+// as purchases for preference learning
+var undonePurchaseStatuses = []string{"Cancelled", "PaymentFailed", "Refunded"}
+
+func purchaseUndone(status string) bool {
+	for _, s := range undonePurchaseStatuses {
+		if strings.EqualFold(status, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *OrderService) GetUserOrders(ctx context.Context, FirebaseUID string) ([]dto.OrderResponse, error) {
+	orders, err := s.OrderRepo.GetOrdersByUser(ctx, FirebaseUID)
 	if err != nil {
 		return nil, err
 	}
@@ -43,7 +67,7 @@ func (s *OrderService) GetUserOrders(FirebaseUID string) ([]dto.OrderResponse, e
 	return res, nil
 }
 
-func (s *OrderService) UpdateStatus(orderID int, req dto.UpdateOrderStatusRequest, FirebaseUID string) error {
+func (s *OrderService) UpdateStatus(ctx context.Context, orderID int, req dto.UpdateOrderStatusRequest, FirebaseUID string) error {
 	// order, err := s.OrderRepo.GetOrderByID(orderID)
 	// if err != nil {
 	// 	return err
@@ -58,21 +82,30 @@ func (s *OrderService) UpdateStatus(orderID int, req dto.UpdateOrderStatusReques
 		methodPtr = &req.ShippingMethod
 	}
 
-	return s.OrderRepo.UpdateOrderStatus(orderID, req.Status, methodPtr)
+	if err := s.OrderRepo.UpdateOrderStatus(ctx, orderID, req.Status, methodPtr); err != nil {
+		return err
+	}
+	s.Stats.OrderStatusChanged(ctx, orderID)
+	if purchaseUndone(req.Status) {
+		if order, err := s.OrderRepo.GetOrderByID(ctx, orderID); err == nil && order != nil {
+			s.Recommendations.RelearnUserPreferences(ctx, order.FirebaseUID)
+		}
+	}
+	return nil
 }
 
-func (s *OrderService) CreateOrder(FirebaseUID string, req dto.CreateOrderRequest) (int, error) {
-	items, err := s.CartService.GetCartWithPrices(FirebaseUID)
+func (s *OrderService) CreateOrder(ctx context.Context, FirebaseUID string, req dto.CreateOrderRequest) (int, error) {
+	items, err := s.CartService.GetCartWithPrices(ctx, FirebaseUID)
 	if err != nil || len(items) == 0 {
-		return 0, errors.New("cart is empty or error getting cart prices")
+		return 0, apperror.Validation("cart is empty or error getting cart prices")
 	}
 
-	defaultAddr, err := s.AddressRepo.GetDefault(FirebaseUID)
+	defaultAddr, err := s.AddressRepo.GetDefault(ctx, FirebaseUID)
 	if err != nil {
 		return 0, err
 	}
 	if defaultAddr == nil {
-		return 0, errors.New("no default shipping address found")
+		return 0, apperror.Validation("no default shipping address found")
 	}
 
 	var subtotal float64
@@ -98,10 +131,11 @@ func (s *OrderService) CreateOrder(FirebaseUID string, req dto.CreateOrderReques
 		ShippingMethod:    req.ShippingMethod,
 	}
 
-	orderID, err := s.OrderRepo.CreateOrderWithItemsAndStock(FirebaseUID, order, items)
+	orderID, err := s.OrderRepo.CreateOrderWithItemsAndStock(ctx, FirebaseUID, order, items)
 	if err != nil {
 		return 0, err
 	}
+	s.RecCache.InvalidateUser(ctx, FirebaseUID)
 
 	// remove clear cart
 	// if cartID, _ := s.CartRepo.GetCartIDByUser(FirebaseUID); cartID != 0 {
@@ -118,30 +152,43 @@ func getBillingAddressID(billing *int, shipping int) int {
 	return shipping
 }
 
-func (s *OrderService) GetOrderDetailByID(orderID int) (*dto.OrderDetailResponse, error) {
-	return s.OrderRepo.GetOrderDetailByID(orderID)
+func (s *OrderService) GetOrderDetailByID(ctx context.Context, orderID int) (*dto.OrderDetailResponse, error) {
+	return s.OrderRepo.GetOrderDetailByID(ctx, orderID)
 }
 
-func (s *OrderService) GetOrderOwnerID(orderID int) (string, error) {
-	return s.OrderRepo.GetOrderOwnerID(orderID)
+func (s *OrderService) GetOrderOwnerID(ctx context.Context, orderID int) (string, error) {
+	return s.OrderRepo.GetOrderOwnerID(ctx, orderID)
 }
 
 // GetOrderByID returns a minimal order model used by controllers for status checks
-func (s *OrderService) GetOrderByID(orderID int) (*model.Order, error) {
-	return s.OrderRepo.GetOrderByID(orderID)
+func (s *OrderService) GetOrderByID(ctx context.Context, orderID int) (*model.Order, error) {
+	return s.OrderRepo.GetOrderByID(ctx, orderID)
 }
 
-func (s *OrderService) AdminGetOrders(status, userID, startDate, endDate string, page, limit int) ([]dto.AdminOrderResponse, error) {
-	if page < 1 {
-		page = 1
+// AdminGetOrders lists orders newest first, by cursor or by page
+func (s *OrderService) AdminGetOrders(ctx context.Context, status, userID, startDate, endDate, cursor string, page, limit int) (*dto.AdminOrderListResponse, error) {
+	req, err := pagination.NewRequest(cursor, page, limit)
+	if err != nil {
+		return nil, err
 	}
-	if limit <= 0 {
-		limit = 20
+
+	orders, total, err := s.OrderRepo.AdminGetOrders(ctx, status, userID, startDate, endDate, req)
+	if err != nil {
+		return nil, err
+	}
+	orders, cursors := pagination.Trim(req, orders, func(o dto.AdminOrderResponse) pagination.Cursor {
+		return pagination.NewCursor(o.OrderDate, o.OrderID)
+	})
+	if orders == nil {
+		orders = []dto.AdminOrderResponse{}
 	}
-	offset := (page - 1) * limit
-	return s.OrderRepo.AdminGetOrders(status, userID, startDate, endDate, limit, offset)
+
+	return &dto.AdminOrderListResponse{
+		Orders:     orders,
+		Pagination: *buildPaginationInfo(req, total, cursors),
+	}, nil
 }
 
-func (s *OrderService) GetAdminOrderDetailByID(orderID int) (*dto.AdminOrderDetailResponse, error) {
-	return s.OrderRepo.GetAdminOrderDetailByID(orderID)
+func (s *OrderService) GetAdminOrderDetailByID(ctx context.Context, orderID int) (*dto.AdminOrderDetailResponse, error) {
+	return s.OrderRepo.GetAdminOrderDetailByID(ctx, orderID)
 }
@@ -1,14 +1,15 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"flowo-backend/config"
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/model"
 	"flowo-backend/internal/repository"
@@ -19,32 +20,34 @@ import (
 )
 
 type PaymentService interface {
-	CreatePaymentLink(req dto.CreatePaymentLinkRequest, userID string) (*dto.PaymentLinkResponse, error)
-	HandleWebhook(webhook payos.WebhookType) error
-	CancelOrder(orderID int, userID string) error
+	CreatePaymentLink(ctx context.Context, req dto.CreatePaymentLinkRequest, userID string) (*dto.PaymentLinkResponse, error)
+	HandleWebhook(ctx context.Context, webhook payos.WebhookType) error
+	CancelOrder(ctx context.Context, orderID int, userID string) error
 }
 
 type paymentService struct {
-	cfg       *config.Config
-	repo      repository.PaymentRepository
-	orderRepo repository.OrderRepository
-	client    *http.Client
+	cfg             *config.Config
+	repo            repository.PaymentRepository
+	orderRepo       repository.OrderRepository
+	stats           *ProductStatsService
+	recommendations RecommendationService
+	client          *http.Client
 }
 
-func NewPaymentService(cfg *config.Config, repo repository.PaymentRepository, orderRepo repository.OrderRepository) PaymentService {
-	return &paymentService{cfg: cfg, repo: repo, orderRepo: orderRepo, client: &http.Client{Timeout: 10 * time.Second}}
+func NewPaymentService(cfg *config.Config, repo repository.PaymentRepository, orderRepo repository.OrderRepository, stats *ProductStatsService, recommendations RecommendationService) PaymentService {
+	return &paymentService{cfg: cfg, repo: repo, orderRepo: orderRepo, stats: stats, recommendations: recommendations, client: &http.Client{Timeout: 10 * time.Second}}
 }
 
 // signCreatePayload moved to internal/payos
 
-func (s *paymentService) CreatePaymentLink(req dto.CreatePaymentLinkRequest, userID string) (*dto.PaymentLinkResponse, error) {
+func (s *paymentService) CreatePaymentLink(ctx context.Context, req dto.CreatePaymentLinkRequest, userID string) (*dto.PaymentLinkResponse, error) {
 	// ensure order exists and belongs to user
-	order, err := s.orderRepo.GetOrderByID(req.OrderID)
+	order, err := s.orderRepo.GetOrderByID(ctx, req.OrderID)
 	if err != nil || order == nil {
-		return nil, errors.New("order not found")
+		return nil, apperror.NotFound("order not found")
 	}
 	if order.FirebaseUID != userID {
-		return nil, errors.New("unauthorized")
+		return nil, apperror.Forbidden("forbidden")
 	}
 
 	// build PayOS CheckoutRequestType and call library helper
@@ -79,7 +82,7 @@ func (s *paymentService) CreatePaymentLink(req dto.CreatePaymentLinkRequest, use
 		AmountPaid:    0,
 		PaymentDate:   time.Now(),
 	}
-	_, err = s.repo.CreatePayment(p)
+	_, err = s.repo.CreatePayment(ctx, p)
 	if err != nil {
 		return nil, err
 	}
@@ -87,14 +90,14 @@ func (s *paymentService) CreatePaymentLink(req dto.CreatePaymentLinkRequest, use
 	return &dto.PaymentLinkResponse{CheckoutUrl: checkoutUrl, PaymentLinkId: paymentLinkId}, nil
 }
 
-func (s *paymentService) HandleWebhook(webhook payos.WebhookType) error {
+func (s *paymentService) HandleWebhook(ctx context.Context, webhook payos.WebhookType) error {
 	// Unmarshal into PayOS webhook type and verify signature using library
 	verified, err := payos.VerifyPaymentWebhookData(webhook)
 	if err != nil {
 		return err
 	}
 	if verified == nil {
-		return errors.New("invalid webhook data")
+		return apperror.BadRequest("invalid webhook data")
 	}
 
 	// persist raw webhook JSON into payment record (for audit) and parse fields
@@ -170,17 +173,17 @@ func (s *paymentService) HandleWebhook(webhook payos.WebhookType) error {
 	var p *model.Payment
 	var err2 error
 	if orderID != 0 {
-		p, err2 = s.repo.GetPaymentByOrderID(orderID)
+		p, err2 = s.repo.GetPaymentByOrderID(ctx, orderID)
 		if err2 != nil {
 			return err2
 		}
 	}
 	if p == nil {
-		return errors.New("payment not found")
+		return apperror.NotFound("payment not found")
 	}
 
 	// save raw webhook payload for auditing
-	if err := s.repo.UpdatePaymentRawWebhook(p.PaymentID, string(rawData)); err != nil {
+	if err := s.repo.UpdatePaymentRawWebhook(ctx, p.PaymentID, string(rawData)); err != nil {
 		// log but do not fail processing
 		// fmt.Println("failed to save raw webhook:", err)
 	}
@@ -194,54 +197,59 @@ func (s *paymentService) HandleWebhook(webhook payos.WebhookType) error {
 	}
 
 	if success {
-		if err := s.repo.UpdatePaymentStatus(p.PaymentID, "Completed", paymentLinkId, amountFloat); err != nil {
+		if err := s.repo.UpdatePaymentStatus(ctx, p.PaymentID, "Completed", paymentLinkId, amountFloat); err != nil {
 			return err
 		}
-		if err := s.orderRepo.UpdateOrderStatus(orderID, "COMPLETED", nil); err != nil {
+		if err := s.orderRepo.UpdateOrderStatus(ctx, orderID, "COMPLETED", nil); err != nil {
 			return err
 		}
+		s.stats.OrderStatusChanged(ctx, orderID)
 	} else {
-		if err := s.repo.UpdatePaymentStatus(p.PaymentID, "Cancelled", paymentLinkId, 0); err != nil {
+		if err := s.repo.UpdatePaymentStatus(ctx, p.PaymentID, "Cancelled", paymentLinkId, 0); err != nil {
 			return err
 		}
-		if err := s.orderRepo.CancelOrderAndRestoreStock(orderID); err != nil {
+		if err := s.orderRepo.CancelOrderAndRestoreStock(ctx, orderID, "system:payos-webhook"); err != nil {
 			return err
 		}
+		if order, err := s.orderRepo.GetOrderByID(ctx, orderID); err == nil && order != nil {
+			s.recommendations.RelearnUserPreferences(ctx, order.FirebaseUID)
+		}
 	}
 
 	return nil
 }
 
 // CancelOrder cancels an order (owner or admin) and updates payment status to Cancelled.
-func (s *paymentService) CancelOrder(orderID int, userID string) error {
+func (s *paymentService) CancelOrder(ctx context.Context, orderID int, userID string) error {
 	// verify owner
-	owner, err := s.orderRepo.GetOrderByID(orderID)
+	owner, err := s.orderRepo.GetOrderByID(ctx, orderID)
 	if err != nil {
 		return err
 	}
 	if owner == nil {
-		return errors.New("order not found")
+		return apperror.NotFound("order not found")
 	}
 	// allow admin bypass if needed — simple owner check here
 	if owner.FirebaseUID != userID {
-		return errors.New("forbidden")
+		return apperror.Forbidden("forbidden")
 	}
 
 	// update payment record if exists
-	p, err := s.repo.GetPaymentByOrderID(orderID)
+	p, err := s.repo.GetPaymentByOrderID(ctx, orderID)
 	if err != nil {
 		return err
 	}
 	if p != nil {
-		if err := s.repo.UpdatePaymentStatus(p.PaymentID, "Cancelled", p.TransactionID, 0); err != nil {
+		if err := s.repo.UpdatePaymentStatus(ctx, p.PaymentID, "Cancelled", p.TransactionID, 0); err != nil {
 			return err
 		}
 	}
 
 	// cancel order and restore stock
-	if err := s.orderRepo.CancelOrderAndRestoreStock(orderID); err != nil {
+	if err := s.orderRepo.CancelOrderAndRestoreStock(ctx, orderID, userID); err != nil {
 		return err
 	}
+	s.recommendations.RelearnUserPreferences(ctx, owner.FirebaseUID)
 
 	return nil
 }
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// a signal counts half as much after this long
+	preferenceHalfLife = 90 * 24 * time.Hour
+	// decayed affinities below this are dropped from the profile
+	minPreferenceScore = 0.01
+)
+
+// preference signal weights; a review counts (rating - 3), so 1 and 2 star
+// reviews are negative
+const (
+	purchaseSignalWeight  = 3.0
+	cartAddSignalWeight   = 1.0
+	clickedSignalWeight   = 0.5
+	likedSignalWeight     = 1.5
+	dismissedSignalWeight = -1.5
+)
+
+// signalWeight is how much a signal says the user likes its product.
+// Purchases reported as feedback are skipped, the order already counts.
+func signalWeight(sig model.PreferenceSignal) float64 {
+	switch sig.Kind {
+	case model.SignalPurchase:
+		return purchaseSignalWeight
+	case model.SignalReview:
+		return float64(sig.Rating - 3)
+	case model.SignalCartAdd:
+		return cartAddSignalWeight
+	case model.SignalFeedback:
+		switch sig.Action {
+		case "clicked":
+			return clickedSignalWeight
+		case "liked":
+			return likedSignalWeight
+		case "dismissed":
+			return dismissedSignalWeight
+		}
+	}
+	return 0
+}
+
+// decay is the weight left of a signal this old
+func decay(age time.Duration) float64 {
+	if age <= 0 {
+		return 1
+	}
+	return math.Exp2(-float64(age) / float64(preferenceHalfLife))
+}
+
+// learnPreferences folds signals into the profile. The stored sums are
+// decayed to now first, so each refresh only needs the signals since the
+// last one.
+func learnPreferences(pref *model.UserPreference, signals []model.PreferenceSignal, occasions map[uint][]string, now time.Time) {
+	flowerScores := decodeScores(pref.FlowerScores)
+	occasionScores := decodeScores(pref.OccasionScores)
+	if pref.LearnedAt != nil {
+		factor := decay(now.Sub(*pref.LearnedAt))
+		for k := range flowerScores {
+			flowerScores[k] *= factor
+		}
+		for k := range occasionScores {
+			occasionScores[k] *= factor
+		}
+		pref.PriceWeight *= factor
+		pref.PriceSum *= factor
+		pref.PriceSquareSum *= factor
+	}
+
+	for _, sig := range signals {
+		d := decay(now.Sub(sig.At))
+		if w := signalWeight(sig) * d; w != 0 {
+			flowerScores[sig.FlowerType] += w
+			for _, occasion := range occasions[sig.ProductID] {
+				occasionScores[occasion] += w
+			}
+		}
+		if sig.Kind == model.SignalPurchase && sig.Quantity > 0 && sig.UnitPrice > 0 {
+			w := float64(sig.Quantity) * d
+			pref.PriceWeight += w
+			pref.PriceSum += w * sig.UnitPrice
+			pref.PriceSquareSum += w * sig.UnitPrice * sig.UnitPrice
+		}
+	}
+
+	pref.FlowerScores = encodeScores(pruneScores(flowerScores))
+	pref.OccasionScores = encodeScores(pruneScores(occasionScores))
+	pref.FlowerPreferences = encodeScores(normalizeScores(flowerScores))
+	pref.OccasionPreferences = encodeScores(normalizeScores(occasionScores))
+
+	// the price band is one standard deviation around the decayed mean
+	pref.AverageSpent, pref.PriceMin, pref.PriceMax = 0, 0, 0
+	if pref.PriceWeight > 0 {
+		mean := pref.PriceSum / pref.PriceWeight
+		std := math.Sqrt(math.Max(0, pref.PriceSquareSum/pref.PriceWeight-mean*mean))
+		pref.AverageSpent = math.Round(mean*100) / 100
+		pref.PriceMin = math.Round(math.Max(0, mean-std)*100) / 100
+		pref.PriceMax = math.Round((mean+std)*100) / 100
+	}
+	pref.LearnedAt = &now
+	pref.LastUpdated = now
+}
+
+func pruneScores(scores map[string]float64) map[string]float64 {
+	for k, v := range scores {
+		if math.Abs(v) < minPreferenceScore {
+			delete(scores, k)
+		}
+	}
+	return scores
+}
+
+// normalizeScores scales affinities into [-1, 1] by the strongest one
+func normalizeScores(scores map[string]float64) map[string]float64 {
+	var strongest float64
+	for _, v := range scores {
+		strongest = math.Max(strongest, math.Abs(v))
+	}
+	normalized := make(map[string]float64, len(scores))
+	for k, v := range scores {
+		normalized[k] = math.Round(v/strongest*10000) / 10000
+	}
+	return normalized
+}
+
+func decodeScores(raw string) map[string]float64 {
+	scores := make(map[string]float64)
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &scores); err != nil {
+			return make(map[string]float64)
+		}
+	}
+	return scores
+}
+
+func encodeScores(scores map[string]float64) string {
+	data, _ := json.Marshal(scores)
+	return string(data)
+}
+
+// RecordInteraction logs a view or cart add for trending, item similarity
+// and preference learning. Logging failures never fail the request.
+func (s *recommendationService) RecordInteraction(ctx context.Context, firebaseUID, sessionID string, productID uint, interactionType string) {
+	if firebaseUID == "" && sessionID == "" {
+		return
+	}
+	if err := s.recommendationRepo.SaveInteraction(ctx, firebaseUID, sessionID, productID, interactionType); err != nil {
+		log.Warn().Err(err).Uint("product_id", productID).Str("interaction_type", interactionType).Msg("Failed to record product interaction")
+	}
+}
+
+// UpdateUserPreferences folds the user's signals since the last refresh
+// into their preference profile
+func (s *recommendationService) UpdateUserPreferences(ctx context.Context, firebaseUID string) error {
+	// serialized with the refresh job so no signal is folded in twice
+	s.preferenceMu.Lock()
+	defer s.preferenceMu.Unlock()
+	return s.updateUserPreferences(ctx, firebaseUID, false)
+}
+
+// RelearnUserPreferences learns the user's profile again from their whole
+// history, after a signal already folded in was undone: an order cancelled
+// or refunded, or a review edited, deleted or moderated. Failures are
+// logged and never fail the change that undid the signal.
+func (s *recommendationService) RelearnUserPreferences(ctx context.Context, firebaseUID string) {
+	if firebaseUID == "" {
+		return
+	}
+	s.preferenceMu.Lock()
+	defer s.preferenceMu.Unlock()
+	if err := s.updateUserPreferences(ctx, firebaseUID, true); err != nil {
+		log.Warn().Err(err).Str("firebase_uid", firebaseUID).Msg("Failed to relearn user preferences")
+	}
+}
+
+// updateUserPreferences folds in the signals since the last refresh, or
+// with relearn drops the stored sums and folds in every signal since the
+// user last reset their preferences
+func (s *recommendationService) updateUserPreferences(ctx context.Context, firebaseUID string, relearn bool) error {
+	pref, err := s.recommendationRepo.GetUserPreferences(ctx, firebaseUID)
+	if err != nil {
+		return err
+	}
+	if pref == nil {
+		pref = &model.UserPreference{FirebaseUID: firebaseUID}
+	}
+	if relearn {
+		pref = &model.UserPreference{FirebaseUID: firebaseUID, LearnedFrom: pref.LearnedFrom}
+	}
+
+	var since time.Time
+	switch {
+	case pref.LearnedAt != nil:
+		since = *pref.LearnedAt
+	case pref.LearnedFrom != nil:
+		since = *pref.LearnedFrom
+	}
+	now := time.Now()
+	signals, err := s.recommendationRepo.GetPreferenceSignals(ctx, firebaseUID, since, now)
+	if err != nil {
+		return err
+	}
+	if len(signals) == 0 && pref.LearnedAt != nil {
+		return nil
+	}
+
+	productIDs := make([]uint, 0, len(signals))
+	seen := make(map[uint]bool)
+	for _, sig := range signals {
+		if !seen[sig.ProductID] {
+			seen[sig.ProductID] = true
+			productIDs = append(productIDs, sig.ProductID)
+		}
+	}
+	occasions, err := s.recommendationRepo.GetProductOccasionNames(ctx, productIDs)
+	if err != nil {
+		return err
+	}
+
+	learnPreferences(pref, signals, occasions, now)
+	if err := s.recommendationRepo.SaveUserPreferences(ctx, pref); err != nil {
+		return err
+	}
+	s.cache.InvalidateUser(ctx, firebaseUID)
+	return nil
+}
+
+// RefreshPreferences updates the profiles of users with new signals since
+// the previous run; the first run after start covers everyone.
+func (s *recommendationService) RefreshPreferences(ctx context.Context) error {
+	s.preferenceMu.Lock()
+	defer s.preferenceMu.Unlock()
+
+	started := time.Now()
+	users, err := s.recommendationRepo.GetUsersWithSignalsSince(ctx, s.lastPreferenceRun)
+	if err != nil {
+		return err
+	}
+	for _, uid := range users {
+		if err := s.updateUserPreferences(ctx, uid, false); err != nil {
+			log.Warn().Err(err).Str("firebase_uid", uid).Msg("Failed to update user preferences")
+		}
+	}
+	s.lastPreferenceRun = started
+	return nil
+}
+
+// GetUserPreferences returns the user's learned profile; empty until there
+// is something to learn from
+func (s *recommendationService) GetUserPreferences(ctx context.Context, firebaseUID string) (*dto.UserPreferenceDTO, error) {
+	pref, err := s.recommendationRepo.GetUserPreferences(ctx, firebaseUID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &dto.UserPreferenceDTO{
+		FirebaseUID:        firebaseUID,
+		PreferredFlowers:   map[string]float64{},
+		PreferredOccasions: map[string]float64{},
+	}
+	if pref == nil {
+		return profile, nil
+	}
+	profile.PreferredFlowers = decodeScores(pref.FlowerPreferences)
+	profile.PreferredOccasions = decodeScores(pref.OccasionPreferences)
+	profile.PriceRange = dto.PriceRangeDTO{
+		PreferredMin: pref.PriceMin,
+		PreferredMax: pref.PriceMax,
+		AverageSpent: pref.AverageSpent,
+	}
+	profile.LastUpdated = pref.LastUpdated.Format(time.RFC3339)
+	return profile, nil
+}
+
+// ResetUserPreferences clears the profile. Learning restarts from now, so
+// earlier behavior is not learned again.
+func (s *recommendationService) ResetUserPreferences(ctx context.Context, firebaseUID string) error {
+	s.preferenceMu.Lock()
+	defer s.preferenceMu.Unlock()
+
+	now := time.Now()
+	pref := &model.UserPreference{
+		FirebaseUID:         firebaseUID,
+		FlowerPreferences:   "{}",
+		OccasionPreferences: "{}",
+		FlowerScores:        "{}",
+		OccasionScores:      "{}",
+		LearnedAt:           &now,
+		LearnedFrom:         &now,
+	}
+	if err := s.recommendationRepo.SaveUserPreferences(ctx, pref); err != nil {
+		return err
+	}
+	s.cache.InvalidateUser(ctx, firebaseUID)
+	return nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"flowo-backend/internal/model"
+)
+
+func TestNormalizeScores(t *testing.T) {
+	tests := []struct {
+		name string
+		in   map[string]float64
+		want map[string]float64
+	}{
+		{"empty", map[string]float64{}, map[string]float64{}},
+		{"scaled by the strongest", map[string]float64{"Rose": 4, "Lily": 1}, map[string]float64{"Rose": 1, "Lily": 0.25}},
+		{"negative strongest", map[string]float64{"Rose": -4, "Lily": 2}, map[string]float64{"Rose": -1, "Lily": 0.5}},
+		{"rounded to four places", map[string]float64{"Rose": 3, "Lily": 1}, map[string]float64{"Rose": 1, "Lily": 0.3333}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkPreferenceScores(t, "normalizeScores()", normalizeScores(tt.in), tt.want)
+		})
+	}
+}
+
+func TestLearnPreferences(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	halfLifeAgo := now.Add(-preferenceHalfLife)
+	occasions := map[uint][]string{
+		1: {"Birthday"},
+		2: {"Birthday", "Funeral"},
+	}
+
+	tests := []struct {
+		name          string
+		pref          model.UserPreference
+		signals       []model.PreferenceSignal
+		wantFlowers   map[string]float64
+		wantOccasions map[string]float64
+		// flower preferences after normalizing
+		wantFlowerPrefs map[string]float64
+		wantPrice       [3]float64 // average, min, max
+	}{
+		{
+			name: "purchases count for, low ratings against",
+			signals: []model.PreferenceSignal{
+				{Kind: model.SignalPurchase, ProductID: 1, FlowerType: "Rose", At: now, Quantity: 2, UnitPrice: 10},
+				{Kind: model.SignalReview, ProductID: 2, FlowerType: "Lily", At: now, Rating: 1},
+			},
+			wantFlowers:     map[string]float64{"Rose": 3, "Lily": -2},
+			wantOccasions:   map[string]float64{"Birthday": 1, "Funeral": -2},
+			wantFlowerPrefs: map[string]float64{"Rose": 1, "Lily": -0.6667},
+			wantPrice:       [3]float64{10, 10, 10},
+		},
+		{
+			name: "feedback weights, purchase feedback is not counted again",
+			signals: []model.PreferenceSignal{
+				{Kind: model.SignalFeedback, ProductID: 1, FlowerType: "Rose", At: now, Action: "liked"},
+				{Kind: model.SignalFeedback, ProductID: 1, FlowerType: "Rose", At: now, Action: "clicked"},
+				{Kind: model.SignalFeedback, ProductID: 3, FlowerType: "Tulip", At: now, Action: "dismissed"},
+				{Kind: model.SignalFeedback, ProductID: 3, FlowerType: "Orchid", At: now, Action: "purchased"},
+				{Kind: model.SignalCartAdd, ProductID: 3, FlowerType: "Orchid", At: now},
+			},
+			wantFlowers:     map[string]float64{"Rose": 2, "Tulip": -1.5, "Orchid": 1},
+			wantOccasions:   map[string]float64{"Birthday": 2},
+			wantFlowerPrefs: map[string]float64{"Rose": 1, "Tulip": -0.75, "Orchid": 0.5},
+		},
+		{
+			name: "older signals count less",
+			signals: []model.PreferenceSignal{
+				{Kind: model.SignalPurchase, ProductID: 3, FlowerType: "Rose", At: halfLifeAgo, Quantity: 1, UnitPrice: 10},
+				{Kind: model.SignalPurchase, ProductID: 3, FlowerType: "Lily", At: now, Quantity: 1, UnitPrice: 40},
+			},
+			wantFlowers:     map[string]float64{"Rose": 1.5, "Lily": 3},
+			wantOccasions:   map[string]float64{},
+			wantFlowerPrefs: map[string]float64{"Rose": 0.5, "Lily": 1},
+			// weighted mean (0.5*10 + 40) / 1.5, one deviation either side
+			wantPrice: [3]float64{30, 15.86, 44.14},
+		},
+		{
+			name: "stored sums decay before new signals are added",
+			pref: model.UserPreference{
+				FlowerScores:   `{"Rose":4,"Lily":0.015}`,
+				OccasionScores: `{"Birthday":2}`,
+				PriceWeight:    2,
+				PriceSum:       40,
+				PriceSquareSum: 800,
+				LearnedAt:      &halfLifeAgo,
+			},
+			signals: []model.PreferenceSignal{
+				{Kind: model.SignalCartAdd, ProductID: 3, FlowerType: "Rose", At: now},
+			},
+			// Lily decays below the minimum and is dropped
+			wantFlowers:     map[string]float64{"Rose": 3},
+			wantOccasions:   map[string]float64{"Birthday": 1},
+			wantFlowerPrefs: map[string]float64{"Rose": 1},
+			wantPrice:       [3]float64{20, 20, 20},
+		},
+		{
+			name:            "no signals leaves an empty profile",
+			wantFlowers:     map[string]float64{},
+			wantOccasions:   map[string]float64{},
+			wantFlowerPrefs: map[string]float64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pref := tt.pref
+			learnPreferences(&pref, tt.signals, occasions, now)
+
+			checkPreferenceScores(t, "FlowerScores", decodeScores(pref.FlowerScores), tt.wantFlowers)
+			checkPreferenceScores(t, "OccasionScores", decodeScores(pref.OccasionScores), tt.wantOccasions)
+			checkPreferenceScores(t, "FlowerPreferences", decodeScores(pref.FlowerPreferences), tt.wantFlowerPrefs)
+
+			gotPrice := [3]float64{pref.AverageSpent, pref.PriceMin, pref.PriceMax}
+			if gotPrice != tt.wantPrice {
+				t.Errorf("average, min, max = %v, want %v", gotPrice, tt.wantPrice)
+			}
+			if pref.LearnedAt == nil || !pref.LearnedAt.Equal(now) {
+				t.Errorf("LearnedAt = %v, want %v", pref.LearnedAt, now)
+			}
+		})
+	}
+}
+
+func checkPreferenceScores(t *testing.T, name string, got, want map[string]float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s = %v, want %v", name, got, want)
+	}
+	for k, w := range want {
+		if g, ok := got[k]; !ok || math.Abs(g-w) > 1e-4 {
+			t.Fatalf("%s = %v, want %v", name, got, want)
+		}
+	}
+}
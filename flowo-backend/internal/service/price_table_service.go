@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/repository"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+)
+
+// Prices are recomputed at least this often even when no rule boundary is
+// due, which also picks up rules edited directly in the database
+const maxPriceTableAge = time.Hour
+
+// PriceTableService keeps ProductEffectivePrice, the precomputed price of
+// every active product after pricing rules, which search filters and sorts
+// on. The table goes stale when a rule, a product's price or its status
+// changes, and when a time-bound rule starts or stops applying.
+type PriceTableService struct {
+	pricing   *PricingService
+	repo      repository.Repository
+	priceRepo repository.PriceTableRepository
+
+	// mu serializes refreshes and guards validUntil
+	mu         sync.Mutex
+	validUntil time.Time
+	stale      atomic.Bool
+}
+
+func NewPriceTableService(lifecycle fx.Lifecycle, pricing *PricingService, repo repository.Repository, priceRepo repository.PriceTableRepository) *PriceTableService {
+	s := &PriceTableService{
+		pricing:   pricing,
+		repo:      repo,
+		priceRepo: priceRepo,
+	}
+	s.stale.Store(true)
+	pricing.OnRulesChanged(s.Invalidate)
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := s.EnsureFresh(ctx, time.Now()); err != nil {
+				log.Error().Err(err).Msg("Failed to build effective price table")
+			}
+			return nil
+		},
+	})
+	return s
+}
+
+// Invalidate marks every price stale; the next search or job run recomputes them
+func (s *PriceTableService) Invalidate() {
+	s.stale.Store(true)
+}
+
+// EnsureFresh recomputes the whole table if it was invalidated or a rule
+// boundary has passed, and is a no-op otherwise
+func (s *PriceTableService) EnsureFresh(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.stale.Load() && now.Before(s.validUntil) {
+		return nil
+	}
+
+	// cleared first so an invalidation during the refresh is not lost
+	s.stale.Store(false)
+	if err := s.refresh(ctx, now); err != nil {
+		s.stale.Store(true)
+		return err
+	}
+	return nil
+}
+
+func (s *PriceTableService) refresh(ctx context.Context, now time.Time) error {
+	products, err := s.repo.GetAllProducts(ctx)
+	if err != nil {
+		return err
+	}
+
+	prices, next, err := s.pricing.EffectivePrices(ctx, products, now)
+	if err != nil {
+		return err
+	}
+
+	validUntil := now.Add(maxPriceTableAge)
+	if !next.IsZero() && next.Before(validUntil) {
+		validUntil = next
+	}
+	if err := s.priceRepo.ReplaceEffectivePrices(ctx, prices, now, &validUntil); err != nil {
+		return err
+	}
+	s.validUntil = validUntil
+
+	log.Info().Int("products", len(prices)).Time("valid_until", validUntil).Msg("Effective price table refreshed")
+	return nil
+}
+
+// RefreshProduct reprices one product after it was created or edited, or
+// drops its row when it no longer exists. On failure the whole table is
+// invalidated instead.
+func (s *PriceTableService) RefreshProduct(ctx context.Context, productID uint) {
+	now := time.Now()
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		if apperror.Is(err, apperror.CodeNotFound) {
+			err = s.priceRepo.DeleteEffectivePrice(ctx, productID)
+		}
+		if err != nil {
+			log.Error().Err(err).Uint("product_id", productID).Msg("Failed to reprice product")
+			s.Invalidate()
+		}
+		return
+	}
+
+	price, err := s.pricing.GetEffectivePrice(ctx, *product, now)
+	if err == nil {
+		s.mu.Lock()
+		var validUntil *time.Time
+		if !s.validUntil.IsZero() {
+			v := s.validUntil
+			validUntil = &v
+		}
+		err = s.priceRepo.UpsertEffectivePrice(ctx, productID, price, now, validUntil)
+		s.mu.Unlock()
+	}
+	if err != nil {
+		log.Error().Err(err).Uint("product_id", productID).Msg("Failed to reprice product")
+		s.Invalidate()
+	}
+}
@@ -1,81 +1,118 @@
 package service
 
 import (
+	"context"
+
 	"flowo-backend/cache"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/model"
 	"flowo-backend/internal/repository"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 )
 
 type PricingService struct {
 	Repo  repository.PricingRuleRepository
 	Cache *cache.RedisCache
+
+	mu        sync.Mutex
+	listeners []func()
 }
 
 func NewPricingService(repo repository.PricingRuleRepository, cache *cache.RedisCache) *PricingService {
 	return &PricingService{Repo: repo, Cache: cache}
 }
-func (s *PricingService) GetEffectivePrice(product model.Product, now time.Time) (float64, error) {
-	rules, err := s.Repo.GetActiveRules()
-	if err != nil {
-		return product.BasePrice, err
-	}
-
-	var matched *model.PricingRule
-	var highestPriority = -1
-
-	for _, rule := range rules {
-		if s.Repo.IsRuleApplicable(rule, product, now) && rule.Priority > highestPriority {
+func (s *PricingService) GetEffectivePrice(ctx context.Context, product model.Product, now time.Time) (float64, error) {
+	return s.GetVariantEffectivePrice(ctx, product, nil, now)
+}
 
-			matched = &rule
-			highestPriority = rule.Priority
-		}
+// GetVariantEffectivePrice prices a product variant: the product base price plus
+// the variant price delta, adjusted by the highest priority applicable rule.
+// A nil variant prices the product itself.
+func (s *PricingService) GetVariantEffectivePrice(ctx context.Context, product model.Product, variant *model.ProductVariant, now time.Time) (float64, error) {
+	basePrice := product.BasePrice
+	if variant != nil {
+		basePrice = variant.BasePrice(product)
 	}
 
-	price := product.BasePrice
-	if matched != nil {
-		switch matched.AdjustmentType {
-		case "percentage_discount":
-			price -= price * matched.AdjustmentValue / 100
-		case "fixed_discount":
-			price -= matched.AdjustmentValue
-		case "override_price":
-			price = matched.AdjustmentValue
-		}
+	rules, err := s.Repo.GetActiveRules(ctx)
+	if err != nil {
+		return basePrice, err
 	}
 
-	return price, nil
+	return s.applyRules(rules, product, variant, basePrice, now), nil
 }
 
-func (s *PricingService) GetEffectivePriceCache(product model.Product, now time.Time) (float64, error) {
+func (s *PricingService) GetEffectivePriceCache(ctx context.Context, product model.Product, now time.Time) (float64, error) {
 	cacheKey := fmt.Sprintf("price:product:%d", product.ProductID)
 
 	// Check cache
-	if val, err := s.Cache.Get(cacheKey); err == nil {
+	if val, err := s.Cache.Get(ctx, cacheKey); err == nil {
 		if price, err := strconv.ParseFloat(val, 64); err == nil {
 			return price, nil
 		}
 	}
-	rules, err := s.Repo.GetActiveRules()
+	rules, err := s.Repo.GetActiveRules(ctx)
 	if err != nil {
 		return product.BasePrice, err
 	}
 
+	price := s.applyRules(rules, product, nil, product.BasePrice, now)
+
+	// Cache for 5 minutes
+	_ = s.Cache.Set(ctx, cacheKey, fmt.Sprintf("%.2f", price), 5*time.Minute)
+
+	return price, nil
+}
+
+// EffectivePrices prices every product with a single rule lookup. It also
+// returns when the next rule starts or stops applying, the point after which
+// the prices are stale; it is zero when no rule is time-bound.
+func (s *PricingService) EffectivePrices(ctx context.Context, products []model.Product, now time.Time) (map[uint]float64, time.Time, error) {
+	rules, err := s.Repo.GetActiveRules(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	prices := make(map[uint]float64, len(products))
+	for _, p := range products {
+		prices[p.ProductID] = s.applyRules(rules, p, nil, p.BasePrice, now)
+	}
+	return prices, nextRuleChange(rules, now), nil
+}
+
+// OnRulesChanged registers fn to be called after a pricing rule is created,
+// updated or deleted
+func (s *PricingService) OnRulesChanged(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+func (s *PricingService) rulesChanged() {
+	s.mu.Lock()
+	listeners := append([]func(){}, s.listeners...)
+	s.mu.Unlock()
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+// applyRules adjusts basePrice by the highest priority rule applicable at now
+func (s *PricingService) applyRules(rules []model.PricingRule, product model.Product, variant *model.ProductVariant, basePrice float64, now time.Time) float64 {
 	var matched *model.PricingRule
 	var highestPriority = -1
 
-	for _, rule := range rules {
-		if s.Repo.IsRuleApplicable(rule, product, now) && rule.Priority > highestPriority {
-
-			matched = &rule
-			highestPriority = rule.Priority
+	for i := range rules {
+		if rules[i].Priority > highestPriority && s.Repo.IsRuleApplicable(rules[i], product, variant, now) {
+			matched = &rules[i]
+			highestPriority = rules[i].Priority
 		}
 	}
 
-	price := product.BasePrice
+	price := basePrice
 	if matched != nil {
 		switch matched.AdjustmentType {
 		case "percentage_discount":
@@ -86,14 +123,49 @@ func (s *PricingService) GetEffectivePriceCache(product model.Product, now time.
 			price = matched.AdjustmentValue
 		}
 	}
+	return price
+}
 
-	// Cache for 5 minutes
-	_ = s.Cache.Set(cacheKey, fmt.Sprintf("%.2f", price), 5*time.Minute)
+// nextRuleChange returns the first moment after now at which a rule's
+// validity window or time-of-day window opens or closes, or zero if none does
+func nextRuleChange(rules []model.PricingRule, now time.Time) time.Time {
+	var next time.Time
+	consider := func(t time.Time) {
+		if t.After(now) && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
 
-	return price, nil
+	for _, rule := range rules {
+		if rule.ValidFrom != nil {
+			consider(*rule.ValidFrom)
+		}
+		if rule.ValidTo != nil {
+			// rules still apply at valid_to itself
+			consider(rule.ValidTo.Add(time.Second))
+		}
+		if rule.TimeOfDayStart != nil && rule.TimeOfDayEnd != nil {
+			for _, clock := range []string{*rule.TimeOfDayStart, *rule.TimeOfDayEnd} {
+				t, err := time.Parse("15:04:05", clock)
+				if err != nil {
+					continue
+				}
+				at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, now.Location())
+				if clock == *rule.TimeOfDayEnd {
+					// the end second is still inside the window
+					at = at.Add(time.Second)
+				}
+				if !at.After(now) {
+					at = at.AddDate(0, 0, 1)
+				}
+				consider(at)
+			}
+		}
+	}
+	return next
 }
 
-func (s *PricingService) CreatePricingRule(req dto.CreatePricingRuleRequest) error {
+func (s *PricingService) CreatePricingRule(ctx context.Context, req dto.CreatePricingRuleRequest) error {
 	rule := model.PricingRule{
 		RuleName:                req.RuleName,
 		Priority:                req.Priority,
@@ -101,6 +173,7 @@ func (s *PricingService) CreatePricingRule(req dto.CreatePricingRuleRequest) err
 		AdjustmentType:          req.AdjustmentType,
 		AdjustmentValue:         req.AdjustmentValue,
 		ApplicableProductID:     intPtrToUint(req.ApplicableProductID),
+		ApplicableVariantID:     intPtrToUint(req.ApplicableVariantID),
 		ApplicableFlowerTypeID:  req.ApplicableFlowerTypeID,
 		ApplicableProductStatus: req.ApplicableProductStatus,
 		SpecialDayID:            req.SpecialDayID,
@@ -126,19 +199,31 @@ func (s *PricingService) CreatePricingRule(req dto.CreatePricingRuleRequest) err
 		}
 	}
 
-	return s.Repo.CreatePricingRule(rule)
+	if err := s.Repo.CreatePricingRule(ctx, rule); err != nil {
+		return err
+	}
+	s.rulesChanged()
+	return nil
 }
 
-func (s *PricingService) GetAllRules() ([]model.PricingRule, error) {
-	return s.Repo.GetAllRules()
+func (s *PricingService) GetAllRules(ctx context.Context) ([]model.PricingRule, error) {
+	return s.Repo.GetAllRules(ctx)
 }
 
-func (s *PricingService) UpdateRule(rule model.PricingRule) error {
-	return s.Repo.UpdateRule(rule)
+func (s *PricingService) UpdateRule(ctx context.Context, rule model.PricingRule) error {
+	if err := s.Repo.UpdateRule(ctx, rule); err != nil {
+		return err
+	}
+	s.rulesChanged()
+	return nil
 }
 
-func (s *PricingService) DeleteRule(id int) error {
-	return s.Repo.DeleteRule(id)
+func (s *PricingService) DeleteRule(ctx context.Context, id int) error {
+	if err := s.Repo.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+	s.rulesChanged()
+	return nil
 }
 
 func intPtrToUint(ptr *int) *uint {
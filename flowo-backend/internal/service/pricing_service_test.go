@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"flowo-backend/internal/model"
+)
+
+func TestNextRuleChange(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(day, hour, min, sec int) time.Time {
+		return time.Date(2024, 5, day, hour, min, sec, 0, time.UTC)
+	}
+	ptr := func(t time.Time) *time.Time { return &t }
+	clock := func(s string) *string { return &s }
+
+	tests := []struct {
+		name  string
+		rules []model.PricingRule
+		want  time.Time
+	}{
+		{"no rules", nil, time.Time{}},
+		{"rule without windows", []model.PricingRule{{RuleID: 1}}, time.Time{}},
+		{"rule starting later", []model.PricingRule{{ValidFrom: ptr(at(3, 8, 0, 0))}}, at(3, 8, 0, 0)},
+		{"rule that already started", []model.PricingRule{{ValidFrom: ptr(at(1, 12, 0, 0))}}, time.Time{}},
+		{"rule ends a second after valid_to", []model.PricingRule{{ValidFrom: ptr(at(1, 0, 0, 0)), ValidTo: ptr(at(2, 23, 59, 59))}}, at(3, 0, 0, 0)},
+		{"ended rule", []model.PricingRule{{ValidTo: ptr(at(1, 11, 0, 0))}}, time.Time{}},
+		{"happy hour later today", []model.PricingRule{{TimeOfDayStart: clock("17:00:00"), TimeOfDayEnd: clock("19:00:00")}}, at(1, 17, 0, 0)},
+		{"inside a time window", []model.PricingRule{{TimeOfDayStart: clock("08:00:00"), TimeOfDayEnd: clock("13:29:59")}}, at(1, 13, 30, 0)},
+		{"time window over for today", []model.PricingRule{{TimeOfDayStart: clock("06:00:00"), TimeOfDayEnd: clock("09:00:00")}}, at(2, 6, 0, 0)},
+		{"window opening at now starts tomorrow", []model.PricingRule{{TimeOfDayStart: clock("12:00:00"), TimeOfDayEnd: clock("23:00:00")}}, at(1, 23, 0, 1)},
+		{"start without end is ignored", []model.PricingRule{{TimeOfDayStart: clock("17:00:00")}}, time.Time{}},
+		{"unparseable clock is ignored", []model.PricingRule{{TimeOfDayStart: clock("5pm"), TimeOfDayEnd: clock("19:00:00")}}, at(1, 19, 0, 1)},
+		{
+			name: "earliest change across rules",
+			rules: []model.PricingRule{
+				{ValidFrom: ptr(at(4, 0, 0, 0))},
+				{TimeOfDayStart: clock("18:00:00"), TimeOfDayEnd: clock("20:00:00")},
+				{ValidTo: ptr(at(1, 15, 0, 0))},
+			},
+			want: at(1, 15, 0, 1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextRuleChange(tt.rules, now); !got.Equal(tt.want) {
+				t.Errorf("nextRuleChange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"flowo-backend/internal/repository"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+)
+
+// ProductStatsService maintains the denormalized rating and sales aggregates
+// shown in product listings. Updates follow the event that caused them; a
+// failed update only leaves the listing stale until the next rebuild, so it is
+// logged rather than failing the review or payment.
+type ProductStatsService struct {
+	repo repository.ProductStatsRepository
+}
+
+func NewProductStatsService(lifecycle fx.Lifecycle, repo repository.ProductStatsRepository) *ProductStatsService {
+	s := &ProductStatsService{repo: repo}
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := s.Rebuild(ctx, time.Now()); err != nil {
+				log.Error().Err(err).Msg("Failed to build product statistics")
+			}
+			return nil
+		},
+	})
+	return s
+}
+
+// ReviewChanged updates a product's rating after one of its reviews changed
+func (s *ProductStatsService) ReviewChanged(ctx context.Context, productID int) {
+	if err := s.repo.RefreshRatingStats(ctx, productID); err != nil {
+		log.Error().Err(err).Int("product_id", productID).Msg("Failed to update product rating")
+	}
+}
+
+// OrderStatusChanged recounts the units sold of an order's products after
+// the order was completed, or moved out of completed, and re-ranks sales
+func (s *ProductStatsService) OrderStatusChanged(ctx context.Context, orderID int) {
+	productIDs, err := s.repo.GetOrderProductIDs(ctx, orderID)
+	if err == nil {
+		err = s.repo.RefreshSalesStats(ctx, productIDs, time.Now())
+	}
+	if err != nil {
+		log.Error().Err(err).Int("order_id", orderID).Msg("Failed to update product sales")
+	}
+}
+
+// Rebuild recomputes the aggregates of every product
+func (s *ProductStatsService) Rebuild(ctx context.Context, now time.Time) error {
+	return s.repo.RebuildProductStats(ctx, now)
+}
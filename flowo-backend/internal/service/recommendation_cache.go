@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"flowo-backend/cache"
+	"flowo-backend/internal/dto"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	recommendationCachePrefix = "recommendations:"
+	// responses kept in process per instance
+	recommendationLocalCapacity = 1000
+	// invalidations only reach this instance's memory, so other instances
+	// may serve a stale local entry for at most this long
+	recommendationLocalMaxAge = time.Minute
+)
+
+// RecommendationCache keeps recommendation responses in a two-tier cache:
+// an in-process LRU in front of Redis. Keys are scoped to a user or shared
+// by everyone, so one user's entries can be dropped without touching the
+// rest.
+type RecommendationCache struct {
+	local *cache.LRU[[]byte]
+	redis *cache.RedisCache
+}
+
+func NewRecommendationCache(redis *cache.RedisCache) *RecommendationCache {
+	return &RecommendationCache{
+		local: cache.NewLRU[[]byte](recommendationLocalCapacity),
+		redis: redis,
+	}
+}
+
+// recommendationCacheKey builds the key for a response; firebaseUID is empty
+// for responses that are the same for everyone
+func recommendationCacheKey(firebaseUID, recommendationType string, params ...interface{}) string {
+	scope := "shared"
+	if firebaseUID != "" {
+		scope = "user:" + firebaseUID
+	}
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = fmt.Sprint(p)
+	}
+	return recommendationCachePrefix + scope + ":" + recommendationType + ":" + strings.Join(parts, "|")
+}
+
+// Get looks in process first, then in Redis; a Redis hit is copied into the
+// local tier
+func (c *RecommendationCache) Get(ctx context.Context, key string) (*dto.RecommendationResponseDTO, bool) {
+	data, ok := c.local.Get(key)
+	if !ok {
+		raw, err := c.redis.Get(ctx, key)
+		if err != nil {
+			return nil, false
+		}
+		data = []byte(raw)
+		c.local.Set(key, data, recommendationLocalMaxAge)
+	}
+
+	var response dto.RecommendationResponseDTO
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, false
+	}
+	return &response, true
+}
+
+func (c *RecommendationCache) Set(ctx context.Context, key string, response *dto.RecommendationResponseDTO, ttl time.Duration) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	c.local.Set(key, data, min(ttl, recommendationLocalMaxAge))
+	if err := c.redis.Set(ctx, key, string(data), ttl); err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Failed to cache recommendations")
+	}
+}
+
+// InvalidateUser drops every response cached for the user
+func (c *RecommendationCache) InvalidateUser(ctx context.Context, firebaseUID string) {
+	if firebaseUID == "" {
+		return
+	}
+	c.invalidate(ctx, recommendationCachePrefix+"user:"+firebaseUID+":")
+}
+
+// InvalidateAll drops every cached response, for changes such as a product
+// leaving the catalog that affect everyone's recommendations
+func (c *RecommendationCache) InvalidateAll(ctx context.Context) {
+	c.invalidate(ctx, recommendationCachePrefix)
+}
+
+func (c *RecommendationCache) invalidate(ctx context.Context, prefix string) {
+	c.local.DeletePrefix(prefix)
+	if err := c.redis.DeletePrefix(ctx, prefix); err != nil {
+		log.Warn().Err(err).Str("prefix", prefix).Msg("Failed to invalidate cached recommendations")
+	}
+}
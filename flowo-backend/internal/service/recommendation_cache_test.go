@@ -0,0 +1,33 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecommendationCacheKey(t *testing.T) {
+	tests := []struct {
+		name               string
+		firebaseUID        string
+		recommendationType string
+		params             []interface{}
+		want               string
+	}{
+		{"shared", "", "trending", []interface{}{10, "7d"}, "recommendations:shared:trending:10|7d"},
+		{"per user", "uid-1", "personalized", []interface{}{20}, "recommendations:user:uid-1:personalized:20"},
+		{"no params", "uid-1", "cart", nil, "recommendations:user:uid-1:cart:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recommendationCacheKey(tt.firebaseUID, tt.recommendationType, tt.params...); got != tt.want {
+				t.Errorf("recommendationCacheKey() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// invalidating one user must not reach another whose uid extends theirs
+	prefix := recommendationCachePrefix + "user:uid-1:"
+	if key := recommendationCacheKey("uid-12", "personalized", 20); strings.HasPrefix(key, prefix) {
+		t.Errorf("key %s is dropped by the invalidation prefix %s of another user", key, prefix)
+	}
+}
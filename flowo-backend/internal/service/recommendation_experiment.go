@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"time"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+// z-score of the 95% confidence intervals in experiment reports
+const confidenceZ = 1.96
+
+// loadExperiment picks up the running experiment, if any
+func (s *recommendationService) loadExperiment(ctx context.Context) error {
+	experiment, err := s.recommendationRepo.GetRunningExperiment(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.experiment = experiment
+	s.mu.Unlock()
+	return nil
+}
+
+// assignVariant buckets a signed-in user into a variant of the running
+// experiment. The bucket is a hash of experiment and user, so a user stays
+// in the same variant for the whole experiment without storing assignments.
+func (s *recommendationService) assignVariant(firebaseUID string) (*model.RecommendationExperiment, *model.ExperimentVariant) {
+	s.mu.RLock()
+	experiment := s.experiment
+	s.mu.RUnlock()
+	if experiment == nil || firebaseUID == "" {
+		return nil, nil
+	}
+
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%d:%s", experiment.ExperimentID, firebaseUID)
+	bucket := int(h.Sum32() % 100)
+	for i := range experiment.Variants {
+		bucket -= experiment.Variants[i].TrafficPercent
+		if bucket < 0 {
+			return experiment, &experiment.Variants[i]
+		}
+	}
+	return nil, nil
+}
+
+// applyVariant returns the configuration with the variant's overrides
+func applyVariant(config model.RecommendationConfig, variant *model.ExperimentVariant) model.RecommendationConfig {
+	if variant == nil {
+		return config
+	}
+	setIfPresent(&config.CollaborativeWeight, variant.CollaborativeWeight)
+	setIfPresent(&config.ContentWeight, variant.ContentWeight)
+	setIfPresent(&config.PopularityWeight, variant.PopularityWeight)
+	setIfPresent(&config.TrendingWeight, variant.TrendingWeight)
+	setIfPresent(&config.MaxPerFlowerType, variant.MaxPerFlowerType)
+	return config
+}
+
+// RecordImpression tags a response served to a signed-in user with their
+// experiment variant and logs it for the CTR and conversion reports.
+// Logging failures never fail the request.
+func (s *recommendationService) RecordImpression(ctx context.Context, firebaseUID string, response *dto.RecommendationResponseDTO) {
+	if firebaseUID == "" || response == nil {
+		return
+	}
+
+	impression := model.RecommendationImpression{
+		FirebaseUID:        firebaseUID,
+		RecommendationType: response.RecommendationType,
+		ProductCount:       len(response.Recommendations),
+	}
+	if experiment, variant := s.assignVariant(firebaseUID); variant != nil {
+		response.Experiment = experiment.Name
+		response.Variant = variant.Name
+		impression.ExperimentID = &experiment.ExperimentID
+		impression.Variant = variant.Name
+	}
+	if len(response.Recommendations) > 0 {
+		var total float64
+		for _, rec := range response.Recommendations {
+			total += rec.Score
+		}
+		impression.AverageScore = math.Round(total/float64(len(response.Recommendations))*10000) / 10000
+	}
+
+	if err := s.recommendationRepo.SaveRecommendationImpression(ctx, impression); err != nil {
+		log.Warn().Err(err).Str("firebase_uid", firebaseUID).Msg("Failed to record recommendation impression")
+	}
+}
+
+// GetExperiments lists all experiments, newest first
+func (s *recommendationService) GetExperiments(ctx context.Context) ([]model.RecommendationExperiment, error) {
+	return s.recommendationRepo.GetExperiments(ctx)
+}
+
+// CreateExperiment stores a draft experiment; it takes effect once started
+func (s *recommendationService) CreateExperiment(ctx context.Context, req dto.CreateExperimentRequest, actor string) (*model.RecommendationExperiment, error) {
+	experiment := model.RecommendationExperiment{
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		CreatedBy:   actor,
+		CreatedAt:   time.Now(),
+	}
+	if experiment.Name == "" {
+		return nil, apperror.Validation("experiment name is required", apperror.Field("name", "is required"))
+	}
+	if len(req.Variants) < 2 {
+		return nil, apperror.Validation("an experiment needs at least two variants", apperror.Field("variants", "needs at least two variants"))
+	}
+
+	config := s.GetConfig()
+	names := make(map[string]bool, len(req.Variants))
+	traffic := 0
+	for _, v := range req.Variants {
+		variant := model.ExperimentVariant{
+			Name:                strings.TrimSpace(v.Name),
+			TrafficPercent:      v.TrafficPercent,
+			CollaborativeWeight: v.CollaborativeWeight,
+			ContentWeight:       v.ContentWeight,
+			PopularityWeight:    v.PopularityWeight,
+			TrendingWeight:      v.TrendingWeight,
+			MaxPerFlowerType:    v.MaxPerFlowerType,
+		}
+		if variant.Name == "" || names[variant.Name] {
+			return nil, apperror.Validation("variant names must be unique", apperror.Field("variants", "names must be unique"))
+		}
+		names[variant.Name] = true
+		traffic += variant.TrafficPercent
+		if err := validateRecommendationConfig(applyVariant(config, &variant)); err != nil {
+			return nil, err
+		}
+		experiment.Variants = append(experiment.Variants, variant)
+	}
+	if traffic != 100 {
+		return nil, apperror.Validation("variant traffic must add up to 100", apperror.Field("variants", "traffic_percent must add up to 100"))
+	}
+
+	if err := s.recommendationRepo.CreateExperiment(ctx, &experiment); err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// UpdateExperimentStatus starts a draft experiment or stops a running one.
+// Stopped experiments cannot be restarted, so their results stay clean.
+func (s *recommendationService) UpdateExperimentStatus(ctx context.Context, experimentID uint, status string) (*model.RecommendationExperiment, error) {
+	experiment, err := s.recommendationRepo.GetExperimentByID(ctx, experimentID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := (experiment.Status == model.ExperimentDraft && status == model.ExperimentRunning) ||
+		(experiment.Status == model.ExperimentRunning && status == model.ExperimentStopped)
+	if !allowed {
+		return nil, apperror.Conflict("invalid experiment status change")
+	}
+
+	if err := s.recommendationRepo.SetExperimentStatus(ctx, experimentID, status); err != nil {
+		return nil, err
+	}
+	if err := s.loadExperiment(ctx); err != nil {
+		return nil, err
+	}
+	return s.recommendationRepo.GetExperimentByID(ctx, experimentID)
+}
+
+// GetExperimentReport compares click-through and conversion of the
+// experiment's variants
+func (s *recommendationService) GetExperimentReport(ctx context.Context, experimentID uint) (*dto.ExperimentReportDTO, error) {
+	experiment, err := s.recommendationRepo.GetExperimentByID(ctx, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.experimentReport(ctx, experiment)
+}
+
+func (s *recommendationService) experimentReport(ctx context.Context, experiment *model.RecommendationExperiment) (*dto.ExperimentReportDTO, error) {
+	stats, err := s.recommendationRepo.GetVariantStats(ctx, experiment.ExperimentID)
+	if err != nil {
+		return nil, err
+	}
+	byVariant := make(map[string]model.VariantStats, len(stats))
+	for _, st := range stats {
+		byVariant[st.Variant] = st
+	}
+
+	report := &dto.ExperimentReportDTO{
+		ExperimentID: experiment.ExperimentID,
+		Name:         experiment.Name,
+		Status:       experiment.Status,
+		Variants:     []dto.VariantReportDTO{},
+	}
+	if experiment.StartedAt != nil {
+		report.StartedAt = experiment.StartedAt.Format(time.RFC3339)
+	}
+	if experiment.StoppedAt != nil {
+		report.StoppedAt = experiment.StoppedAt.Format(time.RFC3339)
+	}
+	for _, v := range experiment.Variants {
+		st := byVariant[v.Name]
+		report.Variants = append(report.Variants, dto.VariantReportDTO{
+			Variant:          v.Name,
+			TrafficPercent:   v.TrafficPercent,
+			Users:            st.Users,
+			Impressions:      st.Impressions,
+			ClickedUsers:     st.ClickedUsers,
+			PurchasedUsers:   st.PurchasedUsers,
+			ClickThroughRate: wilsonInterval(st.ClickedUsers, st.Users),
+			ConversionRate:   wilsonInterval(st.PurchasedUsers, st.Users),
+		})
+	}
+	return report, nil
+}
+
+// wilsonInterval is the proportion successes/n with its 95% Wilson score
+// interval, which stays within [0, 1] even for small samples
+func wilsonInterval(successes, n int) dto.RateDTO {
+	if n == 0 {
+		return dto.RateDTO{}
+	}
+	p := math.Min(float64(successes)/float64(n), 1)
+	total := float64(n)
+	z2 := confidenceZ * confidenceZ
+	denominator := 1 + z2/total
+	center := (p + z2/(2*total)) / denominator
+	margin := confidenceZ * math.Sqrt(p*(1-p)/total+z2/(4*total*total)) / denominator
+	return dto.RateDTO{
+		Rate:  roundRate(p),
+		Lower: roundRate(math.Max(0, center-margin)),
+		Upper: roundRate(math.Min(1, center+margin)),
+	}
+}
+
+func roundRate(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
+
+// GetRecommendationStats reports how recommendations performed over the
+// period, per type and for the running experiment
+func (s *recommendationService) GetRecommendationStats(ctx context.Context, period string) (*dto.RecommendationStatsDTO, error) {
+	window, ok := trendingWindows[period]
+	if !ok {
+		return nil, apperror.Validation("invalid period", apperror.Field("period", "is invalid"))
+	}
+
+	typeStats, err := s.recommendationRepo.GetRecommendationStats(ctx, time.Now().Add(-window))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &dto.RecommendationStatsDTO{Period: period, Types: []dto.RecommendationTypeStatsDTO{}}
+	var clicks, purchases int
+	var scoreTotal, bestCTR float64
+	for _, st := range typeStats {
+		typeDTO := dto.RecommendationTypeStatsDTO{
+			RecommendationType: st.RecommendationType,
+			Impressions:        st.Impressions,
+			Clicks:             st.Clicks,
+			Purchases:          st.Purchases,
+			AverageScore:       roundRate(st.AverageScore),
+		}
+		if st.Impressions > 0 {
+			typeDTO.ClickThroughRate = roundRate(float64(st.Clicks) / float64(st.Impressions))
+			typeDTO.ConversionRate = roundRate(float64(st.Purchases) / float64(st.Impressions))
+			if typeDTO.ClickThroughRate > bestCTR {
+				bestCTR = typeDTO.ClickThroughRate
+				stats.TopPerformingType = st.RecommendationType
+			}
+		}
+		stats.Types = append(stats.Types, typeDTO)
+
+		stats.TotalRecommendations += st.Impressions
+		clicks += st.Clicks
+		purchases += st.Purchases
+		scoreTotal += st.AverageScore * float64(st.Impressions)
+	}
+	if stats.TotalRecommendations > 0 {
+		total := float64(stats.TotalRecommendations)
+		stats.ClickThroughRate = roundRate(float64(clicks) / total)
+		stats.ConversionRate = roundRate(float64(purchases) / total)
+		stats.AverageScore = roundRate(scoreTotal / total)
+	}
+
+	s.mu.RLock()
+	experiment := s.experiment
+	s.mu.RUnlock()
+	if experiment != nil {
+		stats.Experiment, err = s.experimentReport(ctx, experiment)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/model"
+)
+
+func TestWilsonInterval(t *testing.T) {
+	tests := []struct {
+		successes, n int
+		want         dto.RateDTO
+	}{
+		{0, 0, dto.RateDTO{}},
+		{0, 10, dto.RateDTO{Rate: 0, Lower: 0, Upper: 0.2775}},
+		{5, 10, dto.RateDTO{Rate: 0.5, Lower: 0.2366, Upper: 0.7634}},
+		{50, 100, dto.RateDTO{Rate: 0.5, Lower: 0.4038, Upper: 0.5962}},
+		{1, 3, dto.RateDTO{Rate: 0.3333, Lower: 0.0615, Upper: 0.7923}},
+		{10, 10, dto.RateDTO{Rate: 1, Lower: 0.7225, Upper: 1}},
+		// more successes than users is clamped to a rate of 1
+		{12, 10, dto.RateDTO{Rate: 1, Lower: 0.7225, Upper: 1}},
+	}
+	for _, tt := range tests {
+		if got := wilsonInterval(tt.successes, tt.n); got != tt.want {
+			t.Errorf("wilsonInterval(%d, %d) = %+v, want %+v", tt.successes, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestAssignVariant(t *testing.T) {
+	experiment := func(percents ...int) *model.RecommendationExperiment {
+		e := &model.RecommendationExperiment{ExperimentID: 7, Status: "running"}
+		for i, p := range percents {
+			e.Variants = append(e.Variants, model.ExperimentVariant{
+				VariantID:      uint(i + 1),
+				Name:           fmt.Sprintf("variant-%d", i+1),
+				TrafficPercent: p,
+			})
+		}
+		return e
+	}
+	const users = 2000
+
+	tests := []struct {
+		name       string
+		experiment *model.RecommendationExperiment
+		// expected share of users per variant id, in percent; unassigned
+		// users are counted under 0
+		want map[uint]int
+	}{
+		{"no running experiment", nil, map[uint]int{0: 100}},
+		{"single variant takes everyone", experiment(100), map[uint]int{1: 100}},
+		{"even split", experiment(50, 50), map[uint]int{1: 50, 2: 50}},
+		{"uneven split", experiment(80, 20), map[uint]int{1: 80, 2: 20}},
+		{"zero traffic variant is never picked", experiment(0, 100), map[uint]int{2: 100}},
+		{"users outside the traffic get no variant", experiment(30), map[uint]int{1: 30, 0: 70}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &recommendationService{experiment: tt.experiment}
+
+			counts := make(map[uint]int)
+			for i := 0; i < users; i++ {
+				uid := fmt.Sprintf("user-%d", i)
+				e, v := s.assignVariant(uid)
+				if (e == nil) != (v == nil) {
+					t.Fatalf("assignVariant(%q) = %v, %v, want both or neither", uid, e, v)
+				}
+				var id uint
+				if v != nil {
+					id = v.VariantID
+					if _, again := s.assignVariant(uid); again.VariantID != id {
+						t.Fatalf("assignVariant(%q) is not stable", uid)
+					}
+				}
+				counts[id]++
+			}
+
+			for id, count := range counts {
+				if _, ok := tt.want[id]; !ok {
+					t.Errorf("%d users got variant %d, want none", count, id)
+				}
+			}
+			for id, percent := range tt.want {
+				// within five points of the configured share
+				got := counts[id] * 100 / users
+				if got < percent-5 || got > percent+5 {
+					t.Errorf("variant %d got %d%% of users, want about %d%%", id, got, percent)
+				}
+			}
+		})
+	}
+
+	s := &recommendationService{experiment: experiment(100)}
+	if e, v := s.assignVariant(""); e != nil || v != nil {
+		t.Error("anonymous user was assigned a variant")
+	}
+}
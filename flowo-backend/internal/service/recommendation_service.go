@@ -4,40 +4,210 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/model"
 	"flowo-backend/internal/repository"
 	"math"
 	"sort"
+	"sync"
 	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
 )
 
 type RecommendationService interface {
 	GetPersonalizedRecommendations(ctx context.Context, req *dto.RecommendationRequestDTO) (*dto.RecommendationResponseDTO, error)
-	GetSimilarProducts(ctx context.Context, productID uint, limit int) (*dto.RecommendationResponseDTO, error)
+	GetSimilarProducts(ctx context.Context, productID uint, similarityType string, limit int) (*dto.RecommendationResponseDTO, error)
 	GetTrendingProducts(ctx context.Context, period string, limit int) (*dto.RecommendationResponseDTO, error)
 	GetOccasionBasedRecommendations(ctx context.Context, occasion string, limit int) (*dto.RecommendationResponseDTO, error)
 	GetPriceBasedRecommendations(ctx context.Context, minPrice, maxPrice float64, limit int) (*dto.RecommendationResponseDTO, error)
+	GetCartRecommendations(ctx context.Context, cart []dto.CartItemResponse, limit int) (*dto.RecommendationResponseDTO, error)
 	UpdateUserPreferences(ctx context.Context, firebaseUID string) error
+	RefreshPreferences(ctx context.Context) error
+	GetUserPreferences(ctx context.Context, firebaseUID string) (*dto.UserPreferenceDTO, error)
+	ResetUserPreferences(ctx context.Context, firebaseUID string) error
+	RelearnUserPreferences(ctx context.Context, firebaseUID string)
+	RecordFeedback(ctx context.Context, firebaseUID string, feedback dto.RecommendationFeedbackDTO) error
+	RecordImpression(ctx context.Context, firebaseUID string, response *dto.RecommendationResponseDTO)
+	RecordInteraction(ctx context.Context, firebaseUID, sessionID string, productID uint, interactionType string)
 	CalculateProductSimilarities(ctx context.Context, productID uint) error
+	UpdateSimilarityMatrix(ctx context.Context) error
 	UpdateTrendingProducts(ctx context.Context) error
+	GetConfig() model.RecommendationConfig
+	UpdateConfig(ctx context.Context, req dto.UpdateRecommendationConfigRequest, actor string) (*model.RecommendationConfig, error)
+	GetRecommendationStats(ctx context.Context, period string) (*dto.RecommendationStatsDTO, error)
+
+	// A/B experiments
+	GetExperiments(ctx context.Context) ([]model.RecommendationExperiment, error)
+	CreateExperiment(ctx context.Context, req dto.CreateExperimentRequest, actor string) (*model.RecommendationExperiment, error)
+	UpdateExperimentStatus(ctx context.Context, experimentID uint, status string) (*model.RecommendationExperiment, error)
+	GetExperimentReport(ctx context.Context, experimentID uint) (*dto.ExperimentReportDTO, error)
 }
 
 type recommendationService struct {
 	recommendationRepo repository.RecommendationRepository
 	productRepo        repository.Repository
-	config             model.RecommendationConfig
+	cache              *RecommendationCache
+
+	mu         sync.RWMutex
+	config     model.RecommendationConfig
+	experiment *model.RecommendationExperiment
+
+	// similarity matrix runs, see UpdateSimilarityMatrix
+	similarityMu          sync.Mutex
+	lastSimilarityRun     time.Time
+	lastFullSimilarityRun time.Time
+
+	// preference learner runs, see RefreshPreferences
+	preferenceMu      sync.Mutex
+	lastPreferenceRun time.Time
 }
 
+// NewRecommendationService starts with the default configuration; on start
+// it loads the stored one and computes trending products
 func NewRecommendationService(
+	lifecycle fx.Lifecycle,
 	recommendationRepo repository.RecommendationRepository,
 	productRepo repository.Repository,
+	cache *RecommendationCache,
 ) RecommendationService {
-	return &recommendationService{
+	s := &recommendationService{
 		recommendationRepo: recommendationRepo,
 		productRepo:        productRepo,
+		cache:              cache,
 		config:             model.DefaultRecommendationConfig(),
 	}
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := s.loadConfig(ctx); err != nil {
+				log.Warn().Err(err).Msg("Failed to load recommendation config, using defaults")
+			}
+			if err := s.loadExperiment(ctx); err != nil {
+				log.Warn().Err(err).Msg("Failed to load running recommendation experiment")
+			}
+			if err := s.UpdateTrendingProducts(ctx); err != nil {
+				log.Warn().Err(err).Msg("Failed to compute trending products")
+			}
+			// the first matrix build scans every product pair, so keep it off
+			// the startup path
+			go func() {
+				if err := s.UpdateSimilarityMatrix(context.Background()); err != nil {
+					log.Warn().Err(err).Msg("Failed to build product similarity matrix")
+				}
+			}()
+			return nil
+		},
+	})
+	return s
+}
+
+// GetConfig returns the configuration currently in use
+func (s *recommendationService) GetConfig() model.RecommendationConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// UpdateConfig changes the given settings, stores the result and applies it
+// to subsequent recommendations
+func (s *recommendationService) UpdateConfig(ctx context.Context, req dto.UpdateRecommendationConfigRequest, actor string) (*model.RecommendationConfig, error) {
+	config := s.GetConfig()
+	setIfPresent(&config.CollaborativeWeight, req.CollaborativeWeight)
+	setIfPresent(&config.ContentWeight, req.ContentWeight)
+	setIfPresent(&config.PopularityWeight, req.PopularityWeight)
+	setIfPresent(&config.TrendingWeight, req.TrendingWeight)
+	setIfPresent(&config.MinSimilarity, req.MinSimilarity)
+	setIfPresent(&config.MinInteractions, req.MinInteractions)
+	setIfPresent(&config.MaxPerFlowerType, req.MaxPerFlowerType)
+
+	if err := validateRecommendationConfig(config); err != nil {
+		return nil, err
+	}
+
+	config.UpdatedBy = actor
+	if err := s.recommendationRepo.SaveRecommendationConfig(ctx, &config); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	config.UpdatedAt = &now
+
+	s.mu.Lock()
+	s.config = config
+	s.mu.Unlock()
+	// cached responses were ranked with the old weights
+	s.cache.InvalidateAll(ctx)
+	return &config, nil
+}
+
+func (s *recommendationService) loadConfig(ctx context.Context) error {
+	stored, err := s.recommendationRepo.GetRecommendationConfig(ctx)
+	if err != nil || stored == nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defaults := model.DefaultRecommendationConfig()
+	stored.DefaultLimit = defaults.DefaultLimit
+	stored.CacheDuration = defaults.CacheDuration
+	s.config = *stored
+	return nil
+}
+
+func validateRecommendationConfig(config model.RecommendationConfig) error {
+	weights := []float64{config.CollaborativeWeight, config.ContentWeight, config.PopularityWeight, config.TrendingWeight}
+	var total float64
+	for _, w := range weights {
+		if w < 0 || w > 1 {
+			return apperror.Validation("weights must be between 0 and 1")
+		}
+		total += w
+	}
+	if total == 0 {
+		return apperror.Validation("at least one weight must be positive")
+	}
+	if config.MinSimilarity < 0 || config.MinSimilarity > 1 {
+		return apperror.Validation("min_similarity must be between 0 and 1", apperror.Field("min_similarity", "must be between 0 and 1"))
+	}
+	if config.MinInteractions < 0 {
+		return apperror.Validation("min_interactions cannot be negative", apperror.Field("min_interactions", "cannot be negative"))
+	}
+	if config.MaxPerFlowerType < 1 {
+		return apperror.Validation("max_per_flower_type must be at least 1", apperror.Field("max_per_flower_type", "must be at least 1"))
+	}
+	return nil
+}
+
+func setIfPresent[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// cached serves a response from the recommendation cache, building and
+// caching it on a miss. CacheDuration of zero disables caching.
+func (s *recommendationService) cached(ctx context.Context, key string, build func() (*dto.RecommendationResponseDTO, error)) (*dto.RecommendationResponseDTO, error) {
+	ttl := time.Duration(s.GetConfig().CacheDuration) * time.Minute
+	if ttl <= 0 {
+		return build()
+	}
+	if response, ok := s.cache.Get(ctx, key); ok {
+		return response, nil
+	}
+	response, err := build()
+	if err != nil {
+		return nil, err
+	}
+	// builders skip products they fail to load, so a request that timed out
+	// or was cancelled may have built a short or empty list; don't keep it
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.cache.Set(ctx, key, response, ttl)
+	return response, nil
 }
 
 // GetPersonalizedRecommendations provides personalized recommendations using hybrid approach
@@ -46,52 +216,88 @@ func (s *recommendationService) GetPersonalizedRecommendations(ctx context.Conte
 		return s.getAnonymousRecommendations(ctx, req)
 	}
 
+	// users in an experiment get their variant's settings
+	experiment, variant := s.assignVariant(*req.FirebaseUID)
+	config := applyVariant(s.GetConfig(), variant)
+	arm := ""
+	if variant != nil {
+		arm = fmt.Sprintf("%d/%s", experiment.ExperimentID, variant.Name)
+	}
+
+	key := recommendationCacheKey(*req.FirebaseUID, "personalized", arm, req.Limit)
+	response, err := s.cached(ctx, key, func() (*dto.RecommendationResponseDTO, error) {
+		return s.personalizedRecommendations(ctx, req, config)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.RecordImpression(ctx, *req.FirebaseUID, response)
+	return response, nil
+}
+
+func (s *recommendationService) personalizedRecommendations(ctx context.Context, req *dto.RecommendationRequestDTO, config model.RecommendationConfig) (*dto.RecommendationResponseDTO, error) {
+
 	firebaseUID := *req.FirebaseUID
 	limit := req.Limit
 	if limit <= 0 {
-		limit = s.config.DefaultLimit
+		limit = config.DefaultLimit
 	}
 
 	// Get user interactions and preferences
-	interactions, err := s.recommendationRepo.GetUserInteractions(firebaseUID)
+	interactions, err := s.recommendationRepo.GetUserInteractions(ctx, firebaseUID)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(interactions) < s.config.MinInteractions {
+	if len(interactions) < config.MinInteractions {
 		// Fallback to trending products for users with minimal interactions
 		return s.GetTrendingProducts(ctx, "weekly", limit)
 	}
 
-	// Calculate hybrid recommendations
-	recommendations := make(map[uint]*dto.RecommendedProductDTO)
-
-	// 1. Collaborative Filtering
-	collaborativeRecs, err := s.getCollaborativeRecommendations(firebaseUID, limit*2)
-	if err == nil {
-		s.mergeRecommendations(recommendations, collaborativeRecs, s.config.CollaborativeWeight, "collaborative")
+	// Each strategy offers more candidates than needed, so that the blend
+	// can still fill the list after capping items per flower type
+	candidates := limit * 3
+	sources := []blendSource{
+		{weight: config.CollaborativeWeight, category: "collaborative"},
+		{weight: config.ContentWeight, category: "content_based"},
+		{weight: config.PopularityWeight, category: "popularity"},
+		{weight: config.TrendingWeight, category: "trending"},
 	}
-
-	// 2. Content-Based Filtering
-	contentRecs, err := s.getContentBasedRecommendations(firebaseUID, limit*2)
-	if err == nil {
-		s.mergeRecommendations(recommendations, contentRecs, s.config.ContentWeight, "content_based")
+	strategies := []func() ([]*dto.RecommendedProductDTO, error){
+		func() ([]*dto.RecommendedProductDTO, error) {
+			return s.getCollaborativeRecommendations(ctx, firebaseUID, candidates)
+		},
+		func() ([]*dto.RecommendedProductDTO, error) {
+			return s.getContentBasedRecommendations(ctx, firebaseUID, candidates)
+		},
+		func() ([]*dto.RecommendedProductDTO, error) {
+			return s.getPopularityRecommendations(ctx, candidates)
+		},
+		func() ([]*dto.RecommendedProductDTO, error) {
+			return s.getTrendingRecommendations(ctx, "weekly", candidates)
+		},
 	}
-
-	// 3. Popularity-Based
-	popularRecs, err := s.getPopularityRecommendations(limit)
-	if err == nil {
-		s.mergeRecommendations(recommendations, popularRecs, s.config.PopularityWeight, "popularity")
+	// a strategy without candidates for this user is skipped, but when all
+	// of them fail the list would be empty for the wrong reason
+	var failed int
+	var lastErr error
+	for i, strategy := range strategies {
+		recs, err := strategy()
+		if err != nil {
+			failed++
+			lastErr = err
+			continue
+		}
+		sources[i].recs = recs
 	}
-
-	// 4. Trending products
-	trendingRecs, err := s.getTrendingRecommendations("weekly", limit)
-	if err == nil {
-		s.mergeRecommendations(recommendations, trendingRecs, s.config.TrendingWeight, "trending")
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if failed == len(strategies) {
+		return nil, lastErr
 	}
 
-	// Convert to slice and sort by score
-	finalRecs := s.sortAndLimitRecommendations(recommendations, limit)
+	finalRecs := diversifyRecommendations(blendRecommendations(sources), config.MaxPerFlowerType, limit)
 
 	return &dto.RecommendationResponseDTO{
 		RecommendationType: "personalized",
@@ -102,14 +308,38 @@ func (s *recommendationService) GetPersonalizedRecommendations(ctx context.Conte
 	}, nil
 }
 
-// GetSimilarProducts finds products similar to a given product
-func (s *recommendationService) GetSimilarProducts(ctx context.Context, productID uint, limit int) (*dto.RecommendationResponseDTO, error) {
+// similarityReasons explains each similarity type to the customer
+var similarityReasons = map[string]string{
+	model.SimilarityContent:       "Similar product features",
+	model.SimilarityCollaborative: "Customers who viewed or bought this also chose this",
+	model.SimilarityHybrid:        "Similar and often chosen together",
+}
+
+// GetSimilarProducts finds products similar to a given product by the given
+// similarity type, hybrid by default
+func (s *recommendationService) GetSimilarProducts(ctx context.Context, productID uint, similarityType string, limit int) (*dto.RecommendationResponseDTO, error) {
+	if similarityType == "" {
+		similarityType = model.SimilarityHybrid
+	}
+	reason, ok := similarityReasons[similarityType]
+	if !ok {
+		return nil, apperror.Validation("invalid similarity type", apperror.Field("similarity_type", "is invalid"))
+	}
+	key := recommendationCacheKey("", "similar", productID, similarityType, limit)
+	return s.cached(ctx, key, func() (*dto.RecommendationResponseDTO, error) {
+		return s.similarProducts(ctx, productID, similarityType, reason, limit)
+	})
+}
+
+func (s *recommendationService) similarProducts(ctx context.Context, productID uint, similarityType, reason string, limit int) (*dto.RecommendationResponseDTO, error) {
+
+	config := s.GetConfig()
 	if limit <= 0 {
-		limit = s.config.DefaultLimit
+		limit = config.DefaultLimit
 	}
 
 	// Get target product
-	targetProduct, err := s.productRepo.GetProductByID(productID)
+	targetProduct, err := s.productRepo.GetProductByID(ctx, productID)
 	if err != nil {
 		return nil, err
 	}
@@ -117,7 +347,7 @@ func (s *recommendationService) GetSimilarProducts(ctx context.Context, productI
 	recommendations := make(map[uint]*dto.RecommendedProductDTO)
 
 	// 1. Get precomputed similarities
-	similarities, err := s.recommendationRepo.GetProductSimilarities(productID, limit*2)
+	similarities, err := s.recommendationRepo.GetProductSimilarities(ctx, productID, similarityType, config.MinSimilarity, limit*2)
 	if err == nil && len(similarities) > 0 {
 		for _, sim := range similarities {
 			otherProductID := sim.ProductID1
@@ -125,7 +355,7 @@ func (s *recommendationService) GetSimilarProducts(ctx context.Context, productI
 				otherProductID = sim.ProductID2
 			}
 
-			product, err := s.productRepo.GetProductByID(otherProductID)
+			product, err := s.productRepo.GetProductByID(ctx, otherProductID)
 			if err != nil {
 				continue
 			}
@@ -134,7 +364,7 @@ func (s *recommendationService) GetSimilarProducts(ctx context.Context, productI
 			recommendations[otherProductID] = &dto.RecommendedProductDTO{
 				Product:  productResponse,
 				Score:    sim.SimilarityScore,
-				Reason:   "Similar product features",
+				Reason:   reason,
 				Category: "similar_products",
 			}
 		}
@@ -143,7 +373,7 @@ func (s *recommendationService) GetSimilarProducts(ctx context.Context, productI
 	// 2. Fallback: Content-based similarity
 	if len(recommendations) < limit {
 		// Same flower type
-		similarByType, err := s.recommendationRepo.GetProductsByFlowerType(targetProduct.FlowerType, productID, limit)
+		similarByType, err := s.recommendationRepo.GetProductsByFlowerType(ctx, targetProduct.FlowerType, productID, limit)
 		if err == nil {
 			for _, product := range similarByType {
 				if _, exists := recommendations[product.ProductID]; !exists {
@@ -161,7 +391,7 @@ func (s *recommendationService) GetSimilarProducts(ctx context.Context, productI
 		// Similar price range
 		priceMin := targetProduct.BasePrice * 0.8
 		priceMax := targetProduct.BasePrice * 1.2
-		similarByPrice, err := s.recommendationRepo.GetProductsByPriceRange(priceMin, priceMax, productID, limit)
+		similarByPrice, err := s.recommendationRepo.GetProductsByPriceRange(ctx, priceMin, priceMax, productID, limit)
 		if err == nil {
 			for _, product := range similarByPrice {
 				if _, exists := recommendations[product.ProductID]; !exists {
@@ -190,18 +420,25 @@ func (s *recommendationService) GetSimilarProducts(ctx context.Context, productI
 
 // GetTrendingProducts returns trending products
 func (s *recommendationService) GetTrendingProducts(ctx context.Context, period string, limit int) (*dto.RecommendationResponseDTO, error) {
+	key := recommendationCacheKey("", "trending", period, limit)
+	return s.cached(ctx, key, func() (*dto.RecommendationResponseDTO, error) {
+		return s.trendingProducts(ctx, period, limit)
+	})
+}
+
+func (s *recommendationService) trendingProducts(ctx context.Context, period string, limit int) (*dto.RecommendationResponseDTO, error) {
 	if limit <= 0 {
-		limit = s.config.DefaultLimit
+		limit = s.GetConfig().DefaultLimit
 	}
 
-	trendingProducts, err := s.recommendationRepo.GetTrendingProducts(period, limit)
+	trendingProducts, err := s.recommendationRepo.GetTrendingProducts(ctx, period, limit)
 	if err != nil {
 		return nil, err
 	}
 
 	var recommendations []dto.RecommendedProductDTO
 	for _, trending := range trendingProducts {
-		product, err := s.productRepo.GetProductByID(trending.ProductID)
+		product, err := s.productRepo.GetProductByID(ctx, trending.ProductID)
 		if err != nil {
 			continue
 		}
@@ -217,7 +454,7 @@ func (s *recommendationService) GetTrendingProducts(ctx context.Context, period
 
 	// Fallback to popular products if no trending data
 	if len(recommendations) == 0 {
-		popularRecs, err := s.getPopularityRecommendations(limit)
+		popularRecs, err := s.getPopularityRecommendations(ctx, limit)
 		if err != nil {
 			return nil, err
 		}
@@ -242,11 +479,18 @@ func (s *recommendationService) GetTrendingProducts(ctx context.Context, period
 
 // GetOccasionBasedRecommendations returns products for specific occasions
 func (s *recommendationService) GetOccasionBasedRecommendations(ctx context.Context, occasion string, limit int) (*dto.RecommendationResponseDTO, error) {
+	key := recommendationCacheKey("", "occasion_based", occasion, limit)
+	return s.cached(ctx, key, func() (*dto.RecommendationResponseDTO, error) {
+		return s.occasionBasedRecommendations(ctx, occasion, limit)
+	})
+}
+
+func (s *recommendationService) occasionBasedRecommendations(ctx context.Context, occasion string, limit int) (*dto.RecommendationResponseDTO, error) {
 	if limit <= 0 {
-		limit = s.config.DefaultLimit
+		limit = s.GetConfig().DefaultLimit
 	}
 
-	products, err := s.recommendationRepo.GetProductsByOccasion(occasion, 0, limit)
+	products, err := s.recommendationRepo.GetProductsByOccasion(ctx, occasion, 0, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -283,11 +527,18 @@ func (s *recommendationService) GetOccasionBasedRecommendations(ctx context.Cont
 
 // GetPriceBasedRecommendations returns products within price range
 func (s *recommendationService) GetPriceBasedRecommendations(ctx context.Context, minPrice, maxPrice float64, limit int) (*dto.RecommendationResponseDTO, error) {
+	key := recommendationCacheKey("", "price_based", minPrice, maxPrice, limit)
+	return s.cached(ctx, key, func() (*dto.RecommendationResponseDTO, error) {
+		return s.priceBasedRecommendations(ctx, minPrice, maxPrice, limit)
+	})
+}
+
+func (s *recommendationService) priceBasedRecommendations(ctx context.Context, minPrice, maxPrice float64, limit int) (*dto.RecommendationResponseDTO, error) {
 	if limit <= 0 {
-		limit = s.config.DefaultLimit
+		limit = s.GetConfig().DefaultLimit
 	}
 
-	products, err := s.recommendationRepo.GetProductsByPriceRange(minPrice, maxPrice, 0, limit)
+	products, err := s.recommendationRepo.GetProductsByPriceRange(ctx, minPrice, maxPrice, 0, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -328,23 +579,23 @@ func (s *recommendationService) getAnonymousRecommendations(ctx context.Context,
 	return s.GetTrendingProducts(ctx, "weekly", req.Limit)
 }
 
-func (s *recommendationService) getCollaborativeRecommendations(firebaseUID string, limit int) ([]*dto.RecommendedProductDTO, error) {
+func (s *recommendationService) getCollaborativeRecommendations(ctx context.Context, firebaseUID string, limit int) ([]*dto.RecommendedProductDTO, error) {
 	// Find similar users
-	similarUsers, err := s.recommendationRepo.GetSimilarUsers(firebaseUID, 10)
+	similarUsers, err := s.recommendationRepo.GetSimilarUsers(ctx, firebaseUID, 10)
 	if err != nil || len(similarUsers) == 0 {
 		return nil, errors.New("no similar users found")
 	}
 
 	// Get products purchased by similar users
 	productScores := make(map[uint]float64)
-	userPurchases, _ := s.recommendationRepo.GetUserPurchaseHistory(firebaseUID)
+	userPurchases, _ := s.recommendationRepo.GetUserPurchaseHistory(ctx, firebaseUID)
 	purchasedProducts := make(map[uint]bool)
 	for _, product := range userPurchases {
 		purchasedProducts[product.ProductID] = true
 	}
 
 	for _, similarFirebaseUserID := range similarUsers {
-		purchases, err := s.recommendationRepo.GetUserPurchaseHistory(similarFirebaseUserID)
+		purchases, err := s.recommendationRepo.GetUserPurchaseHistory(ctx, similarFirebaseUserID)
 		if err != nil {
 			continue
 		}
@@ -359,7 +610,7 @@ func (s *recommendationService) getCollaborativeRecommendations(firebaseUID stri
 	// Convert to recommendations
 	var recommendations []*dto.RecommendedProductDTO
 	for productID, score := range productScores {
-		product, err := s.productRepo.GetProductByID(productID)
+		product, err := s.productRepo.GetProductByID(ctx, productID)
 		if err != nil {
 			continue
 		}
@@ -385,12 +636,12 @@ func (s *recommendationService) getCollaborativeRecommendations(firebaseUID stri
 	return recommendations, nil
 }
 
-func (s *recommendationService) getContentBasedRecommendations(firebaseUID string, limit int) ([]*dto.RecommendedProductDTO, error) {
+func (s *recommendationService) getContentBasedRecommendations(ctx context.Context, firebaseUID string, limit int) ([]*dto.RecommendedProductDTO, error) {
 	// Get user preferences
-	userPrefs, err := s.recommendationRepo.GetUserPreferences(firebaseUID)
+	userPrefs, err := s.recommendationRepo.GetUserPreferences(ctx, firebaseUID)
 	if err != nil || userPrefs == nil {
 		// Fallback to purchase history
-		return s.getContentBasedFromHistory(firebaseUID, limit)
+		return s.getContentBasedFromHistory(ctx, firebaseUID, limit)
 	}
 
 	// Parse preferences
@@ -409,12 +660,12 @@ func (s *recommendationService) getContentBasedRecommendations(firebaseUID strin
 	// Recommend based on preferred flower types
 	if len(flowerPrefs) == 0 {
 		// Fallback to purchase history if no flower preferences
-		return s.getContentBasedFromHistory(firebaseUID, limit)
+		return s.getContentBasedFromHistory(ctx, firebaseUID, limit)
 	}
 
 	for flowerType, pref := range flowerPrefs {
 		if pref > 0.5 {
-			products, err := s.recommendationRepo.GetProductsByFlowerType(flowerType, 0, limit/len(flowerPrefs)+1)
+			products, err := s.recommendationRepo.GetProductsByFlowerType(ctx, flowerType, 0, limit/len(flowerPrefs)+1)
 			if err != nil {
 				continue
 			}
@@ -434,8 +685,8 @@ func (s *recommendationService) getContentBasedRecommendations(firebaseUID strin
 	return recommendations, nil
 }
 
-func (s *recommendationService) getContentBasedFromHistory(firebaseUID string, limit int) ([]*dto.RecommendedProductDTO, error) {
-	purchaseHistory, err := s.recommendationRepo.GetUserPurchaseHistory(firebaseUID)
+func (s *recommendationService) getContentBasedFromHistory(ctx context.Context, firebaseUID string, limit int) ([]*dto.RecommendedProductDTO, error) {
+	purchaseHistory, err := s.recommendationRepo.GetUserPurchaseHistory(ctx, firebaseUID)
 	if err != nil || len(purchaseHistory) == 0 {
 		return nil, errors.New("no purchase history found")
 	}
@@ -455,7 +706,7 @@ func (s *recommendationService) getContentBasedFromHistory(firebaseUID string, l
 	for flowerType, count := range flowerTypeCount {
 		score := float64(count) / float64(len(purchaseHistory))
 		if score > 0.2 { // Only recommend if user has shown interest
-			products, err := s.recommendationRepo.GetProductsByFlowerType(flowerType, 0, limit/len(flowerTypeCount)+1)
+			products, err := s.recommendationRepo.GetProductsByFlowerType(ctx, flowerType, 0, limit/len(flowerTypeCount)+1)
 			if err != nil {
 				continue
 			}
@@ -481,9 +732,9 @@ type scoredProduct struct {
 	score   float64
 }
 
-func (s *recommendationService) getPopularityRecommendations(limit int) ([]*dto.RecommendedProductDTO, error) {
+func (s *recommendationService) getPopularityRecommendations(ctx context.Context, limit int) ([]*dto.RecommendedProductDTO, error) {
 	// Get all products and sort by popularity metrics
-	products, err := s.productRepo.GetAllProducts()
+	products, err := s.productRepo.GetAllProducts(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -530,15 +781,15 @@ func (s *recommendationService) getPopularityRecommendations(limit int) ([]*dto.
 	return recommendations, nil
 }
 
-func (s *recommendationService) getTrendingRecommendations(period string, limit int) ([]*dto.RecommendedProductDTO, error) {
-	trendingProducts, err := s.recommendationRepo.GetTrendingProducts(period, limit)
+func (s *recommendationService) getTrendingRecommendations(ctx context.Context, period string, limit int) ([]*dto.RecommendedProductDTO, error) {
+	trendingProducts, err := s.recommendationRepo.GetTrendingProducts(ctx, period, limit)
 	if err != nil {
 		return nil, err
 	}
 
 	var recommendations []*dto.RecommendedProductDTO
 	for _, trending := range trendingProducts {
-		product, err := s.productRepo.GetProductByID(trending.ProductID)
+		product, err := s.productRepo.GetProductByID(ctx, trending.ProductID)
 		if err != nil {
 			continue
 		}
@@ -555,26 +806,99 @@ func (s *recommendationService) getTrendingRecommendations(period string, limit
 	return recommendations, nil
 }
 
-func (s *recommendationService) mergeRecommendations(
-	target map[uint]*dto.RecommendedProductDTO,
-	source []*dto.RecommendedProductDTO,
-	weight float64,
-	category string,
-) {
-	for _, rec := range source {
-		productID := rec.Product.ProductID
-		if existing, exists := target[productID]; exists {
-			// Combine scores
-			existing.Score += rec.Score * weight
-			existing.Reason += ", " + rec.Reason
-		} else {
-			// Add new recommendation
-			newRec := *rec
-			newRec.Score *= weight
-			newRec.Category = category
-			target[productID] = &newRec
+// blendSource is one strategy's candidates for a personalized blend
+type blendSource struct {
+	weight   float64
+	category string
+	recs     []*dto.RecommendedProductDTO
+}
+
+// blendRecommendations scales each strategy's scores to 0..1 by its best
+// score and averages them with the strategy weights, so the result stays in
+// 0..1 and a product found by several strategies outranks one found by a
+// single strategy. Reason and category come from the strategy contributing
+// most. The result is sorted by score.
+func blendRecommendations(sources []blendSource) []dto.RecommendedProductDTO {
+	var totalWeight float64
+	for _, src := range sources {
+		if src.weight > 0 {
+			totalWeight += src.weight
+		}
+	}
+	if totalWeight == 0 {
+		return nil
+	}
+
+	type blended struct {
+		rec  dto.RecommendedProductDTO
+		best float64
+	}
+	byProduct := make(map[uint]*blended)
+	for _, src := range sources {
+		if src.weight <= 0 {
+			continue
+		}
+		// a strategy may list a product more than once; keep its best score
+		bestRec := make(map[uint]*dto.RecommendedProductDTO)
+		var maxScore float64
+		for _, rec := range src.recs {
+			id := rec.Product.ProductID
+			if prev, ok := bestRec[id]; !ok || rec.Score > prev.Score {
+				bestRec[id] = rec
+			}
+			maxScore = math.Max(maxScore, rec.Score)
+		}
+		if maxScore <= 0 {
+			continue
+		}
+
+		for id, rec := range bestRec {
+			contribution := src.weight / totalWeight * rec.Score / maxScore
+			b, ok := byProduct[id]
+			if !ok {
+				b = &blended{rec: *rec}
+				b.rec.Score = 0
+				byProduct[id] = b
+			}
+			b.rec.Score += contribution
+			if contribution > b.best {
+				b.best = contribution
+				b.rec.Reason = rec.Reason
+				b.rec.Category = src.category
+			}
+		}
+	}
+
+	recs := make([]dto.RecommendedProductDTO, 0, len(byProduct))
+	for _, b := range byProduct {
+		b.rec.Score = math.Round(b.rec.Score*10000) / 10000
+		recs = append(recs, b.rec)
+	}
+	sort.Slice(recs, func(i, j int) bool {
+		if recs[i].Score != recs[j].Score {
+			return recs[i].Score > recs[j].Score
+		}
+		return recs[i].Product.ProductID < recs[j].Product.ProductID
+	})
+	return recs
+}
+
+// diversifyRecommendations takes recommendations in order, skipping those
+// whose flower type already has maxPerType items, until limit is reached
+func diversifyRecommendations(recs []dto.RecommendedProductDTO, maxPerType, limit int) []dto.RecommendedProductDTO {
+	perType := make(map[string]int)
+	result := make([]dto.RecommendedProductDTO, 0, limit)
+	for _, rec := range recs {
+		if len(result) == limit {
+			break
+		}
+		if maxPerType > 0 && perType[rec.Product.FlowerType] >= maxPerType {
+			continue
 		}
+		perType[rec.Product.FlowerType]++
+		result = append(result, rec)
 	}
+	return result
 }
 
 func (s *recommendationService) sortAndLimitRecommendations(
@@ -600,128 +924,59 @@ func (s *recommendationService) sortAndLimitRecommendations(
 	return recs
 }
 
-// UpdateUserPreferences analyzes user behavior and updates preferences
-func (s *recommendationService) UpdateUserPreferences(ctx context.Context, firebaseUID string) error {
-	// Get user interactions
-	interactions, err := s.recommendationRepo.GetUserInteractions(firebaseUID)
-	if err != nil {
-		return err
+// RecordFeedback stores what the user did with a recommended product; their
+// cached recommendations are dropped so the next request reflects it
+func (s *recommendationService) RecordFeedback(ctx context.Context, firebaseUID string, feedback dto.RecommendationFeedbackDTO) error {
+	row := model.RecommendationFeedback{
+		FirebaseUID:        firebaseUID,
+		ProductID:          feedback.ProductID,
+		RecommendationType: feedback.RecommendationType,
+		Action:             feedback.Action,
+		SessionID:          feedback.SessionID,
 	}
-
-	if len(interactions) == 0 {
-		return nil // No interactions to analyze
-	}
-
-	// Analyze flower type preferences
-	flowerPrefs := make(map[string]float64)
-	occasionPrefs := make(map[string]float64)
-	var totalSpent, minPrice, maxPrice float64
-	priceCount := 0
-
-	for _, interaction := range interactions {
-		product, err := s.productRepo.GetProductByID(interaction.ProductID)
-		if err != nil {
-			continue
-		}
-
-		// Calculate preference score based on interaction strength
-		prefScore := interaction.InteractionScore / 10.0 // Normalize
-		if prefScore > 1.0 {
-			prefScore = 1.0
-		}
-
-		flowerPrefs[product.FlowerType] += prefScore
-
-		// Analyze price preferences from purchases
-		if interaction.PurchaseCount > 0 {
-			totalSpent += product.BasePrice * float64(interaction.PurchaseCount)
-			priceCount += interaction.PurchaseCount
-			
-			if minPrice == 0 || product.BasePrice < minPrice {
-				minPrice = product.BasePrice
-			}
-			if product.BasePrice > maxPrice {
-				maxPrice = product.BasePrice
-			}
-		}
-
-		// Get product occasions (simplified - would need to implement occasion fetching)
-		// This is a placeholder for occasion preference analysis
-	}
-
-	// Normalize flower preferences
-	var maxFlowerPref float64
-	for _, pref := range flowerPrefs {
-		if pref > maxFlowerPref {
-			maxFlowerPref = pref
-		}
-	}
-	if maxFlowerPref > 0 {
-		for flowerType := range flowerPrefs {
-			flowerPrefs[flowerType] /= maxFlowerPref
-		}
-	}
-
-	// Calculate average spent
-	var avgSpent float64
-	if priceCount > 0 {
-		avgSpent = totalSpent / float64(priceCount)
+	if experiment, variant := s.assignVariant(firebaseUID); variant != nil {
+		row.ExperimentID = &experiment.ExperimentID
+		row.Variant = variant.Name
 	}
-
-	// Convert to JSON
-	flowerPrefsJSON, _ := json.Marshal(flowerPrefs)
-	occasionPrefsJSON, _ := json.Marshal(occasionPrefs)
-
-	// Save preferences
-	userPref := &model.UserPreference{
-		FirebaseUID:       firebaseUID,
-		FlowerPreferences: string(flowerPrefsJSON),
-		OccasionPreferences: string(occasionPrefsJSON),
-		PriceMin:            minPrice,
-		PriceMax:            maxPrice,
-		AverageSpent:        avgSpent,
-		LastUpdated:         time.Now(),
+	if err := s.recommendationRepo.SaveRecommendationFeedback(ctx, row); err != nil {
+		return err
 	}
-
-	return s.recommendationRepo.SaveUserPreferences(userPref)
+	s.cache.InvalidateUser(ctx, firebaseUID)
+	return nil
 }
 
-// CalculateProductSimilarities computes and stores product similarities
+// CalculateProductSimilarities computes and stores the content similarity
+// of one product to every other product
 func (s *recommendationService) CalculateProductSimilarities(ctx context.Context, productID uint) error {
-	targetProduct, err := s.productRepo.GetProductByID(productID)
+	targetProduct, err := s.productRepo.GetProductByID(ctx, productID)
 	if err != nil {
 		return err
 	}
 
-	allProducts, err := s.productRepo.GetAllProducts()
+	allProducts, err := s.productRepo.GetAllProducts(ctx)
 	if err != nil {
 		return err
 	}
 
+	minSimilarity := s.GetConfig().MinSimilarity
+	var similarities []model.ProductSimilarity
 	for _, product := range allProducts {
 		if product.ProductID == targetProduct.ProductID {
 			continue
 		}
 
-		// Calculate content-based similarity
 		similarity := s.calculateContentSimilarity(*targetProduct, product)
-		
-		if similarity >= s.config.MinSimilarity {
-			productSim := &model.ProductSimilarity{
+		if similarity >= minSimilarity {
+			similarities = append(similarities, model.ProductSimilarity{
 				ProductID1:      targetProduct.ProductID,
 				ProductID2:      product.ProductID,
-				SimilarityScore: similarity,
-				SimilarityType:  "content",
-				UpdatedAt:       time.Now(),
-			}
-			
-			if err := s.recommendationRepo.SaveProductSimilarity(productSim); err != nil {
-				continue // Continue with other products
-			}
+				SimilarityScore: math.Round(similarity*10000) / 10000,
+				SimilarityType:  model.SimilarityContent,
+			})
 		}
 	}
 
-	return nil
+	return s.recommendationRepo.SaveProductSimilarities(ctx, similarities)
 }
 
 func (s *recommendationService) calculateContentSimilarity(product1, product2 model.Product) float64 {
@@ -755,48 +1010,102 @@ func (s *recommendationService) calculateContentSimilarity(product1, product2 mo
 	return similarity
 }
 
-// UpdateTrendingProducts calculates and updates trending product data
+// trendingWindows are the periods trend scores are computed for
+var trendingWindows = map[string]time.Duration{
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+}
+
+const (
+	// a unit sold counts as much as this many views
+	trendPurchaseWeight = 5.0
+	// activity loses half its weight over this fraction of the window
+	trendHalfLifeFraction = 0.25
+	// how much growth over the previous window raises or lowers the score
+	trendVelocityWeight = 0.5
+)
+
+// UpdateTrendingProducts recomputes the trending products of every period
+// from recent views and sales
 func (s *recommendationService) UpdateTrendingProducts(ctx context.Context) error {
-	// This would typically be run as a background job
-	// For now, it's a simplified implementation
-	
-	products, err := s.productRepo.GetAllProducts()
+	now := time.Now()
+	activity, err := s.recommendationRepo.GetProductActivity(ctx, now.Add(-2*trendingWindows["monthly"]))
 	if err != nil {
 		return err
 	}
 
-	var trendingProducts []model.TrendingProduct
-	
-	for _, product := range products {
-		// Calculate trend score (simplified)
-		trendScore := 0.0
-		
-		// Factor in recent sales (placeholder - would need actual sales data)
-		if product.SalesRank > 0 && product.SalesRank <= 100 {
-			trendScore += (100.0 - float64(product.SalesRank)) / 100.0 * 0.5
+	for _, period := range []string{"daily", "weekly", "monthly"} {
+		trending := computeTrendScores(activity, trendingWindows[period], now)
+		for i := range trending {
+			trending[i].Period = period
 		}
-		
-		// Factor in ratings
-		if product.AverageRating > 0 {
-			trendScore += (product.AverageRating / 5.0) * 0.3
+		if err := s.recommendationRepo.ReplaceTrendingProducts(ctx, period, trending); err != nil {
+			return err
 		}
-		
-		// Factor in review velocity (placeholder)
-		if product.ReviewCount > 0 {
-			trendScore += math.Min(float64(product.ReviewCount)/50.0, 1.0) * 0.2
+	}
+	return nil
+}
+
+// computeTrendScores scores products active in the window before now.
+// Activity (views plus weighted units sold) decays exponentially with age,
+// and the decayed sum is raised or lowered by the growth against the
+// window before it. Scores are scaled so the top product scores 1.
+func computeTrendScores(activity []model.ProductActivity, window time.Duration, now time.Time) []model.TrendingProduct {
+	type totals struct {
+		views, units      int
+		decayed, previous float64
+	}
+	start, prevStart := now.Add(-window), now.Add(-2*window)
+	halfLife := window.Hours() * trendHalfLifeFraction
+
+	byProduct := make(map[uint]*totals)
+	for _, a := range activity {
+		if a.Hour.Before(prevStart) || a.Hour.After(now) {
+			continue
 		}
-		
-		if trendScore > 0.1 { // Only include products with meaningful trend scores
-			trendingProducts = append(trendingProducts, model.TrendingProduct{
-				ProductID:     product.ProductID,
-				TrendScore:    trendScore,
-				ViewCount:     0, // Would be populated from actual view data
-				PurchaseCount: 0, // Would be populated from actual purchase data
-				Period:        "weekly",
-				UpdatedAt:     time.Now(),
-			})
+		t, ok := byProduct[a.ProductID]
+		if !ok {
+			t = &totals{}
+			byProduct[a.ProductID] = t
+		}
+		signal := float64(a.Views) + float64(a.UnitsSold)*trendPurchaseWeight
+		if a.Hour.Before(start) {
+			t.previous += signal
+			continue
 		}
+		t.views += a.Views
+		t.units += a.UnitsSold
+		age := now.Sub(a.Hour).Hours()
+		t.decayed += signal * math.Exp2(-age/halfLife)
 	}
-	
-	return s.recommendationRepo.UpdateTrendingProducts(trendingProducts)
-}
\ No newline at end of file
+
+	var trending []model.TrendingProduct
+	var maxScore float64
+	for productID, t := range byProduct {
+		current := float64(t.views) + float64(t.units)*trendPurchaseWeight
+		if current == 0 {
+			continue
+		}
+		growth := (current - t.previous) / (current + t.previous)
+		score := t.decayed * (1 + trendVelocityWeight*growth)
+		maxScore = math.Max(maxScore, score)
+		trending = append(trending, model.TrendingProduct{
+			ProductID:     productID,
+			TrendScore:    score,
+			ViewCount:     t.views,
+			PurchaseCount: t.units,
+		})
+	}
+
+	for i := range trending {
+		trending[i].TrendScore = math.Round(trending[i].TrendScore/maxScore*10000) / 10000
+	}
+	sort.Slice(trending, func(i, j int) bool {
+		if trending[i].TrendScore != trending[j].TrendScore {
+			return trending[i].TrendScore > trending[j].TrendScore
+		}
+		return trending[i].ProductID < trending[j].ProductID
+	})
+	return trending
+}
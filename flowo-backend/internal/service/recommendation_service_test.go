@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"flowo-backend/internal/model"
+)
+
+func TestComputeTrendScores(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	window := 24 * time.Hour
+	hoursAgo := func(h int) time.Time { return now.Add(-time.Duration(h) * time.Hour) }
+
+	type want struct {
+		productID uint
+		views     int
+		units     int
+	}
+	tests := []struct {
+		name     string
+		activity []model.ProductActivity
+		want     []want
+	}{
+		{
+			name: "recent activity outranks older activity",
+			activity: []model.ProductActivity{
+				{ProductID: 1, Hour: hoursAgo(20), Views: 10},
+				{ProductID: 2, Hour: hoursAgo(1), Views: 10},
+			},
+			want: []want{{2, 10, 0}, {1, 10, 0}},
+		},
+		{
+			name: "units sold weigh more than views",
+			activity: []model.ProductActivity{
+				{ProductID: 1, Hour: hoursAgo(2), Views: 4},
+				{ProductID: 2, Hour: hoursAgo(2), UnitsSold: 1},
+			},
+			want: []want{{2, 0, 1}, {1, 4, 0}},
+		},
+		{
+			name: "decline against the previous window lowers the score",
+			activity: []model.ProductActivity{
+				{ProductID: 1, Hour: hoursAgo(2), Views: 10},
+				{ProductID: 1, Hour: hoursAgo(30), Views: 30},
+				{ProductID: 2, Hour: hoursAgo(2), Views: 8},
+			},
+			want: []want{{2, 8, 0}, {1, 10, 0}},
+		},
+		{
+			name: "equal scores are ordered by product id",
+			activity: []model.ProductActivity{
+				{ProductID: 7, Hour: hoursAgo(3), Views: 5},
+				{ProductID: 3, Hour: hoursAgo(3), Views: 5},
+			},
+			want: []want{{3, 5, 0}, {7, 5, 0}},
+		},
+		{
+			name: "activity outside the current window is not counted",
+			activity: []model.ProductActivity{
+				{ProductID: 1, Hour: hoursAgo(1), Views: 2},
+				{ProductID: 1, Hour: hoursAgo(60), Views: 100},
+				{ProductID: 1, Hour: now.Add(time.Hour), Views: 100},
+				// only active in the previous window
+				{ProductID: 2, Hour: hoursAgo(30), Views: 50},
+			},
+			want: []want{{1, 2, 0}},
+		},
+		{
+			name: "no activity",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeTrendScores(tt.activity, window, now)
+			if len(got) != len(tt.want) {
+				t.Fatalf("computeTrendScores() = %+v, want %d products", got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.ProductID != w.productID || g.ViewCount != w.views || g.PurchaseCount != w.units {
+					t.Errorf("rank %d = product %d (%d views, %d units), want product %d (%d views, %d units)",
+						i, g.ProductID, g.ViewCount, g.PurchaseCount, w.productID, w.views, w.units)
+				}
+			}
+			if len(got) > 0 && got[0].TrendScore != 1 {
+				t.Errorf("top score = %v, want 1", got[0].TrendScore)
+			}
+			for i := 1; i < len(got); i++ {
+				if got[i].TrendScore > got[i-1].TrendScore {
+					t.Errorf("scores not descending: %+v", got)
+				}
+			}
+		})
+	}
+}
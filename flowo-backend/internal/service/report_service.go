@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"time"
 
 	"flowo-backend/internal/dto"
@@ -8,8 +9,8 @@ import (
 )
 
 type ReportService interface {
-	GetSalesReport(start, end, groupBy string) (dto.AdminSalesReportResponse, error)
-	GetTopProducts(start, end, sortBy string, limit int) ([]dto.TopProductDTO, error)
+	GetSalesReport(ctx context.Context, start, end, groupBy string) (dto.AdminSalesReportResponse, error)
+	GetTopProducts(ctx context.Context, start, end, sortBy string, limit int) ([]dto.TopProductDTO, error)
 }
 
 type reportService struct {
@@ -38,15 +39,15 @@ func normalizeDates(start, end string) (string, string) {
 // ============================
 // Sales Report
 // ============================
-func (s *reportService) GetSalesReport(start, end, groupBy string) (dto.AdminSalesReportResponse, error) {
+func (s *reportService) GetSalesReport(ctx context.Context, start, end, groupBy string) (dto.AdminSalesReportResponse, error) {
 	start, end = normalizeDates(start, end)
 
-	summary, err := s.repo.GetSalesSummary(start, end)
+	summary, err := s.repo.GetSalesSummary(ctx, start, end)
 	if err != nil {
 		return dto.AdminSalesReportResponse{}, err
 	}
 
-	points, err := s.repo.GetSalesTimeseries(start, end, groupBy)
+	points, err := s.repo.GetSalesTimeseries(ctx, start, end, groupBy)
 	if err != nil {
 		return dto.AdminSalesReportResponse{}, err
 	}
@@ -60,13 +61,13 @@ func (s *reportService) GetSalesReport(start, end, groupBy string) (dto.AdminSal
 // ============================
 // Top Products
 // ============================
-func (s *reportService) GetTopProducts(start, end, sortBy string, limit int) ([]dto.TopProductDTO, error) {
+func (s *reportService) GetTopProducts(ctx context.Context, start, end, sortBy string, limit int) ([]dto.TopProductDTO, error) {
 	start, end = normalizeDates(start, end)
 	if limit <= 0 || limit > 100 {
 		limit = 10
 	}
 
-	products, err := s.repo.GetTopProducts(start, end, sortBy, limit)
+	products, err := s.repo.GetTopProducts(ctx, start, end, sortBy, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -1,45 +1,584 @@
 package service
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"flowo-backend/config"
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/model"
+	"flowo-backend/internal/moderation"
+	"flowo-backend/internal/pagination"
 	"flowo-backend/internal/repository"
+	"flowo-backend/internal/storage"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	maxReviewCommentLength = 2000
+	maxReviewPhotos        = 5
+	// reviews rated this or lower count as complaints for the unanswered list
+	defaultComplaintRating = 2
 )
 
 type ReviewService struct {
-	Repo repository.ReviewRepository
+	Repo          repository.ReviewRepository
+	Stats         *ProductStatsService
+	Filter        moderation.Filter
+	Storage       storage.Storage
+	Images        config.StorageConfig
+	Notifications NotificationService
+	// Recommendations relearns the author's preferences when a review
+	// changes after it was learned from
+	Recommendations RecommendationService
 }
 
-func NewReviewService(repo repository.ReviewRepository) *ReviewService {
-	return &ReviewService{Repo: repo}
+func NewReviewService(
+	repo repository.ReviewRepository,
+	stats *ProductStatsService,
+	filter moderation.Filter,
+	store storage.Storage,
+	notifications NotificationService,
+	recommendations RecommendationService,
+	cfg *config.Config,
+) *ReviewService {
+	return &ReviewService{
+		Repo:            repo,
+		Stats:           stats,
+		Filter:          filter,
+		Storage:         store,
+		Images:          cfg.Storage,
+		Notifications:   notifications,
+		Recommendations: recommendations,
+	}
 }
 
-func (s *ReviewService) CreateReview(productID int, firebaseUID string, req dto.CreateReviewRequest) error {
+// CreateReview publishes a verified-purchase review, or holds it for
+// moderation when the filter flags its text
+func (s *ReviewService) CreateReview(ctx context.Context, productID int, firebaseUID string, req dto.CreateReviewRequest) (*dto.ReviewResponse, error) {
+	comment, err := validateReview(req.Rating, req.Comment)
+	if err != nil {
+		return nil, err
+	}
+
 	review := model.Review{
 		ProductID:   productID,
 		FirebaseUID: firebaseUID,
 		Rating:      req.Rating,
-		Comment:     req.Comment,
+		Comment:     comment,
+	}
+	s.screen(&review, false)
+
+	if err := s.Repo.CreateReview(ctx, &review, req.OrderItemID); err != nil {
+		return nil, err
+	}
+	if review.Status == model.ReviewApproved {
+		s.Stats.ReviewChanged(ctx, productID)
 	}
-	return s.Repo.CreateReview(&review)
+	if created, err := s.Repo.GetReviewByID(ctx, review.ReviewID); err == nil {
+		review = *created
+	}
+
+	res := toReviewResponse(review)
+	return &res, nil
 }
 
-func (s *ReviewService) GetReviewsByProduct(productID int) ([]dto.ReviewResponse, error) {
-	reviews, err := s.Repo.GetReviewsByProductID(productID)
+// UpdateReview lets the author edit their review. The new text is screened
+// again, and a rejected review goes back to the moderation queue.
+func (s *ReviewService) UpdateReview(ctx context.Context, reviewID int, firebaseUID string, req dto.UpdateReviewRequest) (*dto.ReviewResponse, error) {
+	review, err := s.ownReview(ctx, reviewID, firebaseUID)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := validateReview(req.Rating, req.Comment)
 	if err != nil {
 		return nil, err
 	}
 
-	var res []dto.ReviewResponse
-	for _, r := range reviews {
-		res = append(res, dto.ReviewResponse{
-			ReviewID:    r.ReviewID,
-			ProductID:   r.ProductID,
-			FirebaseUID: r.FirebaseUID,
-			Rating:      r.Rating,
-			Comment:     r.Comment,
-			ReviewDate:  r.ReviewDate.Format("2006-01-02 15:04:05"),
-		})
+	review.Rating = req.Rating
+	review.Comment = comment
+	s.screen(review, review.Status == model.ReviewRejected)
+
+	if err := s.Repo.UpdateReview(ctx, review); err != nil {
+		return nil, err
+	}
+	s.Stats.ReviewChanged(ctx, review.ProductID)
+	s.Recommendations.RelearnUserPreferences(ctx, review.FirebaseUID)
+
+	res := toReviewResponse(*review)
+	return &res, nil
+}
+
+func (s *ReviewService) DeleteReview(ctx context.Context, reviewID int, firebaseUID string) error {
+	review, err := s.ownReview(ctx, reviewID, firebaseUID)
+	if err != nil {
+		return err
+	}
+	photos, err := s.Repo.GetReviewPhotos(ctx, []int{reviewID})
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.DeleteReview(ctx, reviewID); err != nil {
+		return err
+	}
+	for _, p := range photos[reviewID] {
+		s.removeFiles(p.StorageKey, p.ThumbnailKey)
+	}
+	s.Stats.ReviewChanged(ctx, review.ProductID)
+	s.Recommendations.RelearnUserPreferences(ctx, review.FirebaseUID)
+	return nil
+}
+
+// GetReviewsByProduct lists a product's published reviews with their photos
+// and the product's rating distribution. Newest first pages by cursor or by
+// page; the other sorts page by page only.
+func (s *ReviewService) GetReviewsByProduct(ctx context.Context, productID int, sort, cursor string, page, limit int) (*dto.ReviewListResponse, error) {
+	switch sort {
+	case "":
+		sort = "newest"
+	case "newest", "highest", "lowest", "most_helpful":
+	default:
+		return nil, apperror.Validation("invalid review sort", apperror.Field("sort", "is invalid"))
+	}
+	if cursor != "" && sort != "newest" {
+		return nil, apperror.Validation("cursor pagination requires newest sort", apperror.Field("cursor", "requires newest sort"))
+	}
+	req, err := pagination.NewRequest(cursor, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, total, err := s.Repo.GetReviewsByProductID(ctx, productID, sort, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachDetails(ctx, reviews); err != nil {
+		return nil, err
+	}
+	counts, err := s.Repo.GetRatingDistribution(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := buildReviewList(req, reviews, total)
+	res.Summary = ratingSummary(counts)
+	return res, nil
+}
+
+// GetModerationQueue lists the reviews in a moderation status, pending by default
+func (s *ReviewService) GetModerationQueue(ctx context.Context, status, cursor string, page, limit int) (*dto.ReviewListResponse, error) {
+	if status == "" {
+		status = model.ReviewPending
+	}
+	if !isValidReviewStatus(status) {
+		return nil, apperror.Validation("invalid review status", apperror.Field("status", "is invalid"))
+	}
+	req, err := pagination.NewRequest(cursor, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, total, err := s.Repo.GetReviewsByStatus(ctx, status, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.adminReviewList(ctx, req, reviews, total)
+}
+
+// GetUnansweredReviews lists published reviews rated maxRating or lower,
+// 2 by default, that have no staff reply yet, newest first
+func (s *ReviewService) GetUnansweredReviews(ctx context.Context, maxRating int, cursor string, page, limit int) (*dto.ReviewListResponse, error) {
+	if maxRating == 0 {
+		maxRating = defaultComplaintRating
+	}
+	if maxRating < 1 || maxRating > 5 {
+		return nil, apperror.Validation("maximum rating must be between 1 and 5", apperror.Field("max_rating", "must be between 1 and 5"))
+	}
+	req, err := pagination.NewRequest(cursor, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, total, err := s.Repo.GetUnansweredReviews(ctx, maxRating, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.adminReviewList(ctx, req, reviews, total)
+}
+
+// adminReviewList builds a review list for staff, which shows reviewer UIDs
+func (s *ReviewService) adminReviewList(ctx context.Context, req pagination.Request, reviews []model.Review, total int) (*dto.ReviewListResponse, error) {
+	if err := s.attachDetails(ctx, reviews); err != nil {
+		return nil, err
+	}
+
+	res := buildReviewList(req, reviews, total)
+	for i := range res.Reviews {
+		res.Reviews[i].FirebaseUID = reviews[i].FirebaseUID
 	}
 	return res, nil
 }
+
+// ModerateReview approves or rejects a review; only approved reviews are
+// published and count towards the product rating
+func (s *ReviewService) ModerateReview(ctx context.Context, reviewID int, moderator string, req dto.ModerateReviewRequest) error {
+	if req.Status != model.ReviewApproved && req.Status != model.ReviewRejected {
+		return apperror.Validation("invalid review status", apperror.Field("status", "is invalid"))
+	}
+	review, err := s.Repo.GetReviewByID(ctx, reviewID)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.ModerateReview(ctx, reviewID, req.Status, strings.TrimSpace(req.Reason), moderator); err != nil {
+		return err
+	}
+	s.Stats.ReviewChanged(ctx, review.ProductID)
+	// pending and approved reviews are both learned from
+	if req.Status != review.Status && (req.Status == model.ReviewRejected || review.Status == model.ReviewRejected) {
+		s.Recommendations.RelearnUserPreferences(ctx, review.FirebaseUID)
+	}
+	return nil
+}
+
+// UploadReviewPhoto validates the image, stores it with a thumbnail and
+// attaches both to the caller's review
+func (s *ReviewService) UploadReviewPhoto(ctx context.Context, reviewID int, firebaseUID string, data []byte) (*model.ReviewPhoto, error) {
+	if _, err := s.ownReview(ctx, reviewID, firebaseUID); err != nil {
+		return nil, err
+	}
+
+	ext, err := storage.ValidateImage(data, storage.ImageLimits{
+		MaxBytes:     s.Images.MaxImageBytes,
+		MinDimension: s.Images.MinImageSize,
+		MaxDimension: s.Images.MaxImageSize,
+	})
+	if err != nil {
+		return nil, apperror.Validation(fmt.Sprintf("invalid image: %v", err))
+	}
+
+	thumb, err := storage.Thumbnail(data, s.Images.ThumbnailSize)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("reviews/%d/%s%s", reviewID, name, ext)
+	thumbKey := fmt.Sprintf("reviews/%d/thumb_%s.jpg", reviewID, name)
+
+	url, err := s.Storage.Save(key, data)
+	if err != nil {
+		return nil, err
+	}
+	thumbURL, err := s.Storage.Save(thumbKey, thumb)
+	if err != nil {
+		s.removeFiles(key)
+		return nil, err
+	}
+
+	photo := &model.ReviewPhoto{
+		ReviewID:     reviewID,
+		ImageURL:     url,
+		ThumbnailURL: thumbURL,
+		StorageKey:   key,
+		ThumbnailKey: thumbKey,
+	}
+	if err := s.Repo.AddReviewPhoto(ctx, photo, maxReviewPhotos); err != nil {
+		s.removeFiles(key, thumbKey)
+		return nil, err
+	}
+	return photo, nil
+}
+
+// DeleteReviewPhoto removes the photo row first and then its stored files
+func (s *ReviewService) DeleteReviewPhoto(ctx context.Context, reviewID, photoID int, firebaseUID string) error {
+	if _, err := s.ownReview(ctx, reviewID, firebaseUID); err != nil {
+		return err
+	}
+	photo, err := s.Repo.GetReviewPhotoByID(ctx, photoID)
+	if err != nil {
+		return err
+	}
+	if photo.ReviewID != reviewID {
+		return apperror.NotFound("photo not found")
+	}
+	if err := s.Repo.DeleteReviewPhoto(ctx, photoID); err != nil {
+		return err
+	}
+	s.removeFiles(photo.StorageKey, photo.ThumbnailKey)
+	return nil
+}
+
+// VoteReview records whether the user found a published review helpful;
+// voting again replaces the earlier vote
+func (s *ReviewService) VoteReview(ctx context.Context, reviewID int, firebaseUID string, helpful bool) (*dto.ReviewVoteResponse, error) {
+	if err := s.checkVotable(ctx, reviewID, firebaseUID); err != nil {
+		return nil, err
+	}
+	up, down, err := s.Repo.SetReviewVote(ctx, reviewID, firebaseUID, helpful)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ReviewVoteResponse{ReviewID: reviewID, HelpfulCount: up, NotHelpfulCount: down}, nil
+}
+
+func (s *ReviewService) RemoveReviewVote(ctx context.Context, reviewID int, firebaseUID string) (*dto.ReviewVoteResponse, error) {
+	if err := s.checkVotable(ctx, reviewID, firebaseUID); err != nil {
+		return nil, err
+	}
+	up, down, err := s.Repo.DeleteReviewVote(ctx, reviewID, firebaseUID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ReviewVoteResponse{ReviewID: reviewID, HelpfulCount: up, NotHelpfulCount: down}, nil
+}
+
+// checkVotable allows votes on published reviews by other users only
+func (s *ReviewService) checkVotable(ctx context.Context, reviewID int, firebaseUID string) error {
+	review, err := s.Repo.GetReviewByID(ctx, reviewID)
+	if err != nil {
+		return err
+	}
+	if review.Status != model.ReviewApproved {
+		return apperror.NotFound("review not found")
+	}
+	if review.FirebaseUID == firebaseUID {
+		return apperror.Forbidden("cannot vote on your own review")
+	}
+	return nil
+}
+
+// ReplyToReview posts a public staff reply to a review, or to an earlier
+// reply of it, and lets the reviewer know. Only admins reach it: the admin
+// review routes require the Admin role.
+func (s *ReviewService) ReplyToReview(ctx context.Context, reviewID int, staffUID string, req dto.CreateReviewReplyRequest) (*dto.ReviewReplyResponse, error) {
+	message := strings.TrimSpace(req.Message)
+	if message == "" {
+		return nil, apperror.Validation("reply message is required", apperror.Field("message", "is required"))
+	}
+
+	review, err := s.Repo.GetReviewByID(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ParentReplyID != nil {
+		parent, err := s.Repo.GetReviewReplyByID(ctx, *req.ParentReplyID)
+		if err != nil || parent.ReviewID != reviewID {
+			return nil, apperror.NotFound("parent reply not found")
+		}
+	}
+
+	reply := model.ReviewReply{
+		ReviewID:      reviewID,
+		ParentReplyID: req.ParentReplyID,
+		FirebaseUID:   staffUID,
+		Message:       message,
+	}
+	if err := s.Repo.CreateReviewReply(ctx, &reply); err != nil {
+		return nil, err
+	}
+	if created, err := s.Repo.GetReviewReplyByID(ctx, reply.ReplyID); err == nil {
+		reply = *created
+	}
+
+	if err := s.Notifications.Notify(ctx, review.FirebaseUID, "review_reply",
+		"The shop replied to your review", excerpt(message, 200)); err != nil {
+		log.Warn().Err(err).Int("review_id", reviewID).Msg("Failed to notify reviewer of reply")
+	}
+
+	threads := buildReplyThreads([]model.ReviewReply{reply})
+	return &threads[0], nil
+}
+
+// DeleteReviewReply removes a staff reply and the replies answering it
+func (s *ReviewService) DeleteReviewReply(ctx context.Context, reviewID, replyID int) error {
+	reply, err := s.Repo.GetReviewReplyByID(ctx, replyID)
+	if err != nil {
+		return err
+	}
+	if reply.ReviewID != reviewID {
+		return apperror.NotFound("reply not found")
+	}
+	return s.Repo.DeleteReviewReply(ctx, replyID)
+}
+
+// attachDetails loads the photos and staff replies of the reviews
+func (s *ReviewService) attachDetails(ctx context.Context, reviews []model.Review) error {
+	ids := make([]int, len(reviews))
+	for i, r := range reviews {
+		ids[i] = r.ReviewID
+	}
+	photos, err := s.Repo.GetReviewPhotos(ctx, ids)
+	if err != nil {
+		return err
+	}
+	replies, err := s.Repo.GetReviewReplies(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range reviews {
+		reviews[i].Photos = photos[reviews[i].ReviewID]
+		reviews[i].Replies = replies[reviews[i].ReviewID]
+	}
+	return nil
+}
+
+func (s *ReviewService) removeFiles(keys ...string) {
+	for _, key := range keys {
+		if err := s.Storage.Delete(key); err != nil {
+			log.Warn().Err(err).Str("key", key).Msg("Failed to remove stored file")
+		}
+	}
+}
+
+// screen runs the filter over the review text: flagged reviews wait for a
+// moderator, as do edits of a rejected review, the rest are published
+func (s *ReviewService) screen(review *model.Review, wasRejected bool) {
+	review.Status = model.ReviewApproved
+	review.ModerationReason = nil
+
+	result := s.Filter.Check(review.Comment)
+	switch {
+	case result.Flagged:
+		reason := "flagged: " + strings.Join(result.Reasons, ", ")
+		review.Status = model.ReviewPending
+		review.ModerationReason = &reason
+	case wasRejected:
+		reason := "edited after rejection"
+		review.Status = model.ReviewPending
+		review.ModerationReason = &reason
+	}
+}
+
+func (s *ReviewService) ownReview(ctx context.Context, reviewID int, firebaseUID string) (*model.Review, error) {
+	review, err := s.Repo.GetReviewByID(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.FirebaseUID != firebaseUID {
+		return nil, apperror.Forbidden("not your review")
+	}
+	return review, nil
+}
+
+func validateReview(rating int, comment string) (string, error) {
+	if rating < 1 || rating > 5 {
+		return "", apperror.Validation("rating must be between 1 and 5", apperror.Field("rating", "must be between 1 and 5"))
+	}
+	comment = strings.TrimSpace(comment)
+	if len([]rune(comment)) > maxReviewCommentLength {
+		return "", apperror.Validation("comment is too long", apperror.Field("comment", "is too long"))
+	}
+	return comment, nil
+}
+
+func isValidReviewStatus(status string) bool {
+	return status == model.ReviewPending || status == model.ReviewApproved || status == model.ReviewRejected
+}
+
+func buildReviewList(req pagination.Request, reviews []model.Review, total int) *dto.ReviewListResponse {
+	reviews, cursors := pagination.Trim(req, reviews, func(r model.Review) pagination.Cursor {
+		return pagination.NewCursor(r.ReviewDate, r.ReviewID)
+	})
+
+	res := []dto.ReviewResponse{}
+	for _, r := range reviews {
+		res = append(res, toReviewResponse(r))
+	}
+	return &dto.ReviewListResponse{
+		Reviews:    res,
+		Pagination: *buildPaginationInfo(req, total, cursors),
+	}
+}
+
+// ratingSummary turns per-star counts into the distribution from 5 stars
+// down to 1 and the average rating rounded to one decimal
+func ratingSummary(counts map[int]int) *dto.RatingSummary {
+	summary := &dto.RatingSummary{Distribution: make([]dto.RatingCount, 0, 5)}
+	sum := 0
+	for rating := 5; rating >= 1; rating-- {
+		count := counts[rating]
+		summary.Distribution = append(summary.Distribution, dto.RatingCount{Rating: rating, Count: count})
+		summary.ReviewCount += count
+		sum += rating * count
+	}
+	if summary.ReviewCount > 0 {
+		summary.AverageRating = math.Round(float64(sum)/float64(summary.ReviewCount)*10) / 10
+	}
+	return summary
+}
+
+func toReviewResponse(r model.Review) dto.ReviewResponse {
+	res := dto.ReviewResponse{
+		ReviewID:         r.ReviewID,
+		ProductID:        r.ProductID,
+		ReviewerName:     r.ReviewerName,
+		Rating:           r.Rating,
+		Comment:          r.Comment,
+		ReviewDate:       r.ReviewDate.Format("2006-01-02 15:04:05"),
+		VerifiedPurchase: r.VerifiedPurchase(),
+		Status:           r.Status,
+		ModerationReason: r.ModerationReason,
+		HelpfulCount:     r.HelpfulCount,
+		NotHelpfulCount:  r.NotHelpfulCount,
+		Photos:           r.Photos,
+	}
+	if res.Photos == nil {
+		res.Photos = []model.ReviewPhoto{}
+	}
+	res.Replies = buildReplyThreads(r.Replies)
+	if r.UpdatedAt != nil {
+		res.UpdatedAt = r.UpdatedAt.Format("2006-01-02 15:04:05")
+	}
+	return res
+}
+
+// buildReplyThreads nests replies under the reply they answer, keeping the
+// given order. Replies whose parent is missing are shown at the top level.
+func buildReplyThreads(replies []model.ReviewReply) []dto.ReviewReplyResponse {
+	children := make(map[int][]model.ReviewReply)
+	known := make(map[int]bool, len(replies))
+	for _, r := range replies {
+		known[r.ReplyID] = true
+	}
+	var roots []model.ReviewReply
+	for _, r := range replies {
+		if r.ParentReplyID != nil && known[*r.ParentReplyID] {
+			children[*r.ParentReplyID] = append(children[*r.ParentReplyID], r)
+		} else {
+			roots = append(roots, r)
+		}
+	}
+
+	var build func(list []model.ReviewReply) []dto.ReviewReplyResponse
+	build = func(list []model.ReviewReply) []dto.ReviewReplyResponse {
+		res := make([]dto.ReviewReplyResponse, 0, len(list))
+		for _, r := range list {
+			res = append(res, dto.ReviewReplyResponse{
+				ReplyID:       r.ReplyID,
+				ParentReplyID: r.ParentReplyID,
+				AuthorName:    r.AuthorName,
+				Message:       r.Message,
+				CreatedAt:     r.CreatedAt.Format("2006-01-02 15:04:05"),
+				Replies:       build(children[r.ReplyID]),
+			})
+		}
+		return res
+	}
+	return build(roots)
+}
+
+// excerpt shortens text to at most n runes for notifications
+func excerpt(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n-1]) + "…"
+}
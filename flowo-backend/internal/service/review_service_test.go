@@ -0,0 +1,158 @@
+package service
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/model"
+	"flowo-backend/internal/moderation"
+)
+
+func TestValidateReview(t *testing.T) {
+	tests := []struct {
+		name        string
+		rating      int
+		comment     string
+		wantComment string
+		wantField   string
+	}{
+		{"trimmed comment", 5, "  Lovely roses \n", "Lovely roses", ""},
+		{"empty comment", 1, "", "", ""},
+		{"longest comment", 4, strings.Repeat("á", maxReviewCommentLength), strings.Repeat("á", maxReviewCommentLength), ""},
+		{"comment too long", 4, strings.Repeat("a", maxReviewCommentLength+1), "", "comment"},
+		{"rating too low", 0, "ok", "", "rating"},
+		{"rating too high", 6, "ok", "", "rating"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comment, err := validateReview(tt.rating, tt.comment)
+			if tt.wantField == "" {
+				if err != nil || comment != tt.wantComment {
+					t.Errorf("validateReview() = %q, %v, want %q", comment, err, tt.wantComment)
+				}
+				return
+			}
+			appErr := apperror.From(err)
+			if appErr.Code != apperror.CodeValidation || len(appErr.Fields) != 1 || appErr.Fields[0].Field != tt.wantField {
+				t.Errorf("validateReview() error = %+v, want a validation error on %s", appErr, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestScreenReview(t *testing.T) {
+	s := &ReviewService{Filter: moderation.Chain(moderation.NewWordFilter(nil), moderation.NewSpamFilter())}
+	oldReason := "flagged: link"
+
+	tests := []struct {
+		name        string
+		comment     string
+		wasRejected bool
+		wantStatus  string
+		wantReason  string
+	}{
+		{"clean text is published", "Fresh and fragrant", false, model.ReviewApproved, ""},
+		{"flagged text waits", "see www.other-shop.com", false, model.ReviewPending, "flagged: link"},
+		{"every finding is listed", "shit, call 0912345678", false, model.ReviewPending, "flagged: profanity, phone number"},
+		{"edit of a rejected review waits", "Fresh and fragrant", true, model.ReviewPending, "edited after rejection"},
+		{"flagged edit of a rejected review", "see www.other-shop.com", true, model.ReviewPending, "flagged: link"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review := model.Review{Comment: tt.comment, Status: model.ReviewRejected, ModerationReason: &oldReason}
+			s.screen(&review, tt.wasRejected)
+
+			var reason string
+			if review.ModerationReason != nil {
+				reason = *review.ModerationReason
+			}
+			if review.Status != tt.wantStatus || reason != tt.wantReason {
+				t.Errorf("screen() = %s %q, want %s %q", review.Status, reason, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestRatingSummary(t *testing.T) {
+	distribution := func(five, four, three, two, one int) []dto.RatingCount {
+		return []dto.RatingCount{
+			{Rating: 5, Count: five},
+			{Rating: 4, Count: four},
+			{Rating: 3, Count: three},
+			{Rating: 2, Count: two},
+			{Rating: 1, Count: one},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		counts map[int]int
+		want   dto.RatingSummary
+	}{
+		{"no reviews", nil, dto.RatingSummary{Distribution: distribution(0, 0, 0, 0, 0)}},
+		{"single rating", map[int]int{4: 3}, dto.RatingSummary{AverageRating: 4, ReviewCount: 3, Distribution: distribution(0, 3, 0, 0, 0)}},
+		// 16 / 4 = 4
+		{"mixed ratings", map[int]int{5: 1, 4: 2, 3: 1}, dto.RatingSummary{AverageRating: 4, ReviewCount: 4, Distribution: distribution(1, 2, 1, 0, 0)}},
+		// 13 / 3 = 4.33
+		{"rounded to one decimal", map[int]int{5: 2, 3: 1}, dto.RatingSummary{AverageRating: 4.3, ReviewCount: 3, Distribution: distribution(2, 0, 1, 0, 0)}},
+		{"out of range ratings are ignored", map[int]int{0: 4, 6: 1, 2: 1}, dto.RatingSummary{AverageRating: 2, ReviewCount: 1, Distribution: distribution(0, 0, 0, 1, 0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ratingSummary(tt.counts); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ratingSummary() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildReplyThreads(t *testing.T) {
+	id := func(v int) *int { return &v }
+	reply := func(replyID int, parent *int) model.ReviewReply {
+		return model.ReviewReply{ReplyID: replyID, ParentReplyID: parent, CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	}
+	// replies as ids, children in brackets
+	var format func(replies []dto.ReviewReplyResponse) string
+	format = func(replies []dto.ReviewReplyResponse) string {
+		parts := make([]string, 0, len(replies))
+		for _, r := range replies {
+			part := strconv.Itoa(r.ReplyID)
+			if len(r.Replies) > 0 {
+				part += "[" + format(r.Replies) + "]"
+			}
+			parts = append(parts, part)
+		}
+		return strings.Join(parts, " ")
+	}
+
+	tests := []struct {
+		name    string
+		replies []model.ReviewReply
+		want    string
+	}{
+		{"no replies", nil, ""},
+		{"answers to the review", []model.ReviewReply{reply(1, nil), reply(2, nil)}, "1 2"},
+		{
+			name:    "nested replies keep their order",
+			replies: []model.ReviewReply{reply(1, nil), reply(2, id(1)), reply(3, nil), reply(4, id(2)), reply(5, id(1))},
+			want:    "1[2[4] 5] 3",
+		},
+		{"reply to a missing reply is shown at the top", []model.ReviewReply{reply(1, nil), reply(2, id(9))}, "1 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildReplyThreads(tt.replies)
+			if got == nil {
+				t.Fatal("buildReplyThreads() = nil, want an empty list")
+			}
+			if f := format(got); f != tt.want {
+				t.Errorf("buildReplyThreads() = %s, want %s", f, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/model"
+	"flowo-backend/internal/repository"
+	"flowo-backend/internal/search"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+)
+
+// Popular queries become suggestions once searched this often
+const (
+	minPopularQueryCount = 3
+	maxPopularQueries    = 500
+	maxQueryLength       = 100
+	maxTrackedQueries    = 20000
+	// width of SearchQuery.display_query
+	maxDisplayQueryLength = 255
+)
+
+// ProductIndexer keeps the in-process full-text index and the autocomplete
+// trie in sync with the catalog. Both are built on startup; until then search
+// falls back to SQL LIKE matching.
+type ProductIndexer struct {
+	repo       repository.Repository
+	searchRepo repository.SearchRepository
+	index      *search.Index
+	suggester  *search.Suggester
+	ready      atomic.Bool
+
+	mu          sync.Mutex
+	queryCounts map[string]int
+}
+
+func NewProductIndexer(lifecycle fx.Lifecycle, repo repository.Repository, searchRepo repository.SearchRepository) *ProductIndexer {
+	indexer := &ProductIndexer{
+		repo:        repo,
+		searchRepo:  searchRepo,
+		index:       search.NewIndex(),
+		suggester:   search.NewSuggester(),
+		queryCounts: make(map[string]int),
+	}
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := indexer.Rebuild(ctx); err != nil {
+				log.Error().Err(err).Msg("Failed to build search index")
+			}
+			if err := indexer.loadPopularQueries(ctx); err != nil {
+				log.Error().Err(err).Msg("Failed to load popular search queries")
+			}
+			return nil
+		},
+	})
+	return indexer
+}
+
+// Ready reports whether the index has been built
+func (i *ProductIndexer) Ready() bool {
+	return i.ready.Load()
+}
+
+// Rebuild reindexes the whole catalog; used on startup and after changes that
+// touch many products, such as renaming a flower type or an import
+func (i *ProductIndexer) Rebuild(ctx context.Context) error {
+	products, err := i.repo.GetProductsForIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	flowerTypes, err := i.repo.GetAllFlowerTypes(ctx)
+	if err != nil {
+		return err
+	}
+	occasions, err := i.repo.GetAllOccasions(ctx)
+	if err != nil {
+		return err
+	}
+
+	docs := make([]search.Document, 0, len(products))
+	entries := make([]search.Entry, 0, len(products)+len(flowerTypes)+len(occasions))
+	for _, p := range products {
+		docs = append(docs, productDocument(p))
+		entries = append(entries, productEntry(p))
+	}
+	for _, ft := range flowerTypes {
+		entries = append(entries, search.Entry{Kind: search.KindFlowerType, Text: ft.Name})
+	}
+	for _, o := range occasions {
+		entries = append(entries, search.Entry{Kind: search.KindOccasion, Text: o.Name})
+	}
+
+	i.index.Replace(docs)
+	i.suggester.ReplaceKinds([]string{search.KindProduct, search.KindFlowerType, search.KindOccasion}, entries)
+	i.ready.Store(true)
+
+	log.Info().Int("products", len(docs)).Msg("Search index built")
+	return nil
+}
+
+// IndexProduct reindexes one product, or drops it when it no longer exists
+func (i *ProductIndexer) IndexProduct(ctx context.Context, productID uint) {
+	product, err := i.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		if apperror.Is(err, apperror.CodeNotFound) {
+			i.RemoveProduct(productID)
+			return
+		}
+		log.Error().Err(err).Uint("product_id", productID).Msg("Failed to index product")
+		return
+	}
+
+	occasions, err := i.repo.GetProductOccasions(ctx, productID)
+	if err != nil {
+		log.Error().Err(err).Uint("product_id", productID).Msg("Failed to index product")
+		return
+	}
+	product.Occasions = occasions
+
+	i.index.Upsert(productDocument(*product))
+	i.suggester.Upsert(productEntry(*product))
+}
+
+func (i *ProductIndexer) RemoveProduct(productID uint) {
+	i.index.Remove(productID)
+	i.suggester.Remove(search.Entry{Kind: search.KindProduct, ProductID: productID})
+}
+
+// Search returns the matching products, best match first
+func (i *ProductIndexer) Search(query string) []search.Hit {
+	return i.index.Search(query)
+}
+
+// Suggest returns completions for a partially typed query
+func (i *ProductIndexer) Suggest(prefix string, limit int) []search.Entry {
+	return i.suggester.Suggest(prefix, limit)
+}
+
+// RecordQuery counts a search that found products. Queries searched often
+// enough are offered as suggestions; the count is persisted in the background
+// so searching never waits on it.
+func (i *ProductIndexer) RecordQuery(ctx context.Context, query string) {
+	normalized := search.NormalizeQuery(query)
+	if normalized == "" || len(normalized) > maxQueryLength {
+		return
+	}
+
+	i.mu.Lock()
+	count, tracked := i.queryCounts[normalized]
+	if tracked || len(i.queryCounts) < maxTrackedQueries {
+		count++
+		i.queryCounts[normalized] = count
+	}
+	i.mu.Unlock()
+
+	if count >= minPopularQueryCount {
+		i.suggester.Upsert(search.Entry{Kind: search.KindQuery, Text: normalized, Weight: float64(count)})
+	}
+
+	// only the normalized form is length-checked; the query as typed can
+	// still be longer than its column
+	display := strings.TrimSpace(query)
+	if runes := []rune(display); len(runes) > maxDisplayQueryLength {
+		display = string(runes[:maxDisplayQueryLength])
+	}
+
+	// recorded after the response is sent, so it must outlive the request
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := i.searchRepo.RecordSearchQuery(ctx, normalized, display); err != nil {
+			log.Warn().Err(err).Msg("Failed to record search query")
+		}
+	}()
+}
+
+func (i *ProductIndexer) loadPopularQueries(ctx context.Context) error {
+	stats, err := i.searchRepo.GetPopularSearchQueries(ctx, minPopularQueryCount, maxPopularQueries)
+	if err != nil {
+		return err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, stat := range stats {
+		i.queryCounts[stat.NormalizedQuery] += stat.SearchCount
+		i.suggester.Upsert(search.Entry{
+			Kind:   search.KindQuery,
+			Text:   stat.NormalizedQuery,
+			Weight: float64(i.queryCounts[stat.NormalizedQuery]),
+		})
+	}
+	return nil
+}
+
+// RebuildAsync rebuilds the index without holding up the request that
+// caused it
+func (i *ProductIndexer) RebuildAsync(ctx context.Context) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := i.Rebuild(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to rebuild search index")
+		}
+	}()
+}
+
+func productEntry(p model.Product) search.Entry {
+	return search.Entry{Kind: search.KindProduct, Text: p.Name, ProductID: p.ProductID}
+}
+
+func productDocument(p model.Product) search.Document {
+	return search.Document{
+		ProductID:   p.ProductID,
+		Name:        p.Name,
+		Description: p.Description,
+		FlowerType:  p.FlowerType,
+		Occasions:   p.Occasions,
+	}
+}
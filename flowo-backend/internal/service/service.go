@@ -1,52 +1,74 @@
 package service
 
 import (
-	"errors"
+	"context"
+
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/model"
+	"flowo-backend/internal/pagination"
 	"flowo-backend/internal/repository"
 	"math"
 	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
 type Service interface {
-	CreateTodo(input *dto.TodoCreate) (*model.Todo, error)
-	GetAllTodos() ([]model.Todo, error)
-	GetTodoByID(id uint) (*model.Todo, error)
-	UpdateTodo(id uint, input *dto.TodoCreate) (*model.Todo, error)
-	DeleteTodo(id uint) error
-
-	GetAllProducts() ([]model.Product, error)
-	GetProductByID(id uint) (*model.Product, error)
-	CreateProduct(input *dto.ProductCreate) (*model.Product, error)
-	UpdateProduct(id uint, input *dto.ProductCreate) error
-	DeleteProduct(id uint) error
-	GetProductsByFlowerType(flowerType string) ([]model.Product, error)
-	GetAllFlowerTypes() ([]model.FlowerType, error)
-
-	GetAllProductsWithEffectivePrice() ([]dto.ProductResponse, error)
-	GetProductByIDWithEffectivePrice(id uint) (*dto.ProductResponse, error)
+	CreateTodo(ctx context.Context, input *dto.TodoCreate) (*model.Todo, error)
+	GetAllTodos(ctx context.Context) ([]model.Todo, error)
+	GetTodoByID(ctx context.Context, id uint) (*model.Todo, error)
+	UpdateTodo(ctx context.Context, id uint, input *dto.TodoCreate) (*model.Todo, error)
+	DeleteTodo(ctx context.Context, id uint) error
+
+	GetAllProducts(ctx context.Context) ([]model.Product, error)
+	GetProductByID(ctx context.Context, id uint) (*model.Product, error)
+	CreateProduct(ctx context.Context, input *dto.ProductCreate) (*model.Product, error)
+	UpdateProduct(ctx context.Context, id uint, input *dto.ProductCreate) error
+	DeleteProduct(ctx context.Context, id uint) error
+	GetProductsByFlowerType(ctx context.Context, flowerType string) ([]model.Product, error)
+	GetAllFlowerTypes(ctx context.Context) ([]model.FlowerType, error)
+
+	GetAllProductsWithEffectivePrice(ctx context.Context) ([]dto.ProductResponse, error)
+	GetProductByIDWithEffectivePrice(ctx context.Context, id uint) (*dto.ProductResponse, error)
 
 	// product search and filtering methods
-	SearchProducts(query *dto.ProductSearchQuery) (*model.ProductSearchResponse, error)
-	GetProductDetails(id uint) (*model.Product, error)
-	GetAllOccasions() ([]model.Occasion, error)
-	GetSearchFilters() (*model.FilterOptions, error)
+	SearchProducts(ctx context.Context, query *dto.ProductSearchQuery) (*model.ProductSearchResponse, error)
+	GetProductDetails(ctx context.Context, id uint) (*model.Product, error)
+	GetAllOccasions(ctx context.Context) ([]model.Occasion, error)
+	GetSearchFilters(ctx context.Context) (*model.FilterOptions, error)
+	SuggestProducts(prefix string, limit int) *dto.SuggestionResponse
+
+	// product variant methods
+	GetProductVariants(ctx context.Context, productID uint) ([]model.ProductVariant, error)
+	CreateVariant(ctx context.Context, productID uint, input *dto.ProductVariantCreate) (*model.ProductVariant, error)
+	UpdateVariant(ctx context.Context, productID, variantID uint, input *dto.ProductVariantCreate) error
+	DeleteVariant(ctx context.Context, productID, variantID uint) error
 }
 
 type service struct {
 	repo           repository.Repository
 	pricingService *PricingService
+	indexer        *ProductIndexer
+	priceTable     *PriceTableService
+	recCache       *RecommendationCache
 }
 
-func NewService(repo repository.Repository, pricingService *PricingService) Service {
+// maxSearchMatches caps how many full-text matches are handed to SQL for
+// filtering and paging
+const maxSearchMatches = 1000
+
+func NewService(repo repository.Repository, pricingService *PricingService, indexer *ProductIndexer, priceTable *PriceTableService, recCache *RecommendationCache) Service {
 	return &service{
 		repo:           repo,
 		pricingService: pricingService,
+		indexer:        indexer,
+		priceTable:     priceTable,
+		recCache:       recCache,
 	}
 }
 
-func (s *service) CreateTodo(input *dto.TodoCreate) (*model.Todo, error) {
+func (s *service) CreateTodo(ctx context.Context, input *dto.TodoCreate) (*model.Todo, error) {
 	todo := &model.Todo{
 		Title:       input.Title,
 		Description: input.Description,
@@ -57,7 +79,7 @@ func (s *service) CreateTodo(input *dto.TodoCreate) (*model.Todo, error) {
 		todo.Status = "pending"
 	}
 
-	err := s.repo.Create(todo)
+	err := s.repo.Create(ctx, todo)
 	if err != nil {
 		return nil, err
 	}
@@ -65,20 +87,20 @@ func (s *service) CreateTodo(input *dto.TodoCreate) (*model.Todo, error) {
 	return todo, nil
 }
 
-func (s *service) GetAllTodos() ([]model.Todo, error) {
-	return s.repo.FindAll()
+func (s *service) GetAllTodos(ctx context.Context) ([]model.Todo, error) {
+	return s.repo.FindAll(ctx)
 }
 
-func (s *service) GetTodoByID(id uint) (*model.Todo, error) {
-	todo, err := s.repo.FindByID(id)
+func (s *service) GetTodoByID(ctx context.Context, id uint) (*model.Todo, error) {
+	todo, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	return todo, nil
 }
 
-func (s *service) UpdateTodo(id uint, input *dto.TodoCreate) (*model.Todo, error) {
-	todo, err := s.repo.FindByID(id)
+func (s *service) UpdateTodo(ctx context.Context, id uint, input *dto.TodoCreate) (*model.Todo, error) {
+	todo, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +111,7 @@ func (s *service) UpdateTodo(id uint, input *dto.TodoCreate) (*model.Todo, error
 		todo.Status = input.Status
 	}
 
-	err = s.repo.Update(todo)
+	err = s.repo.Update(ctx, todo)
 	if err != nil {
 		return nil, err
 	}
@@ -97,38 +119,38 @@ func (s *service) UpdateTodo(id uint, input *dto.TodoCreate) (*model.Todo, error
 	return todo, nil
 }
 
-func (s *service) DeleteTodo(id uint) error {
-	todo, err := s.repo.FindByID(id)
+func (s *service) DeleteTodo(ctx context.Context, id uint) error {
+	todo, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
 	if todo == nil {
-		return errors.New("todo not found")
+		return apperror.NotFound("todo not found")
 	}
 
-	return s.repo.Delete(id)
+	return s.repo.Delete(ctx, id)
 }
 
-func (s *service) GetAllProducts() ([]model.Product, error) {
-	products, err := s.repo.GetAllProducts()
+func (s *service) GetAllProducts(ctx context.Context) ([]model.Product, error) {
+	products, err := s.repo.GetAllProducts(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return products, nil
 }
 
-func (s *service) GetProductByID(id uint) (*model.Product, error) {
-	product, err := s.repo.GetProductByID(id)
+func (s *service) GetProductByID(ctx context.Context, id uint) (*model.Product, error) {
+	product, err := s.repo.GetProductByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	return product, nil
 }
 
-func (s *service) CreateProduct(input *dto.ProductCreate) (*model.Product, error) {
+func (s *service) CreateProduct(ctx context.Context, input *dto.ProductCreate) (*model.Product, error) {
 	// Validate input
-	if err := s.validateProductInput(input); err != nil {
+	if err := validateProductInput(input); err != nil {
 		return nil, err
 	}
 
@@ -141,55 +163,66 @@ func (s *service) CreateProduct(input *dto.ProductCreate) (*model.Product, error
 		StockQuantity: input.StockQuantity,
 	}
 
-	err := s.repo.CreateProduct(product)
+	err := s.repo.CreateProduct(ctx, product, input.Actor)
 	if err != nil {
 		return nil, err
 	}
+	s.indexer.IndexProduct(ctx, product.ProductID)
+	s.priceTable.RefreshProduct(ctx, product.ProductID)
 
 	return product, nil
 }
 
-func (s *service) UpdateProduct(id uint, input *dto.ProductCreate) error {
+func (s *service) UpdateProduct(ctx context.Context, id uint, input *dto.ProductCreate) error {
 	// Validate input
-	if err := s.validateProductInput(input); err != nil {
+	if err := validateProductInput(input); err != nil {
 		return err
 	}
 
-	_, err := s.repo.GetProductByID(id)
+	_, err := s.repo.GetProductByID(ctx, id)
 	if err != nil {
 		//check if the product exists
-		if err.Error() == "not found" {
-			return errors.New("product not found")
+		if apperror.Is(err, apperror.CodeNotFound) {
+			return apperror.NotFound("product not found")
 		}
 		return err
 	}
 
-	err = s.repo.UpdateProduct(id, input)
+	err = s.repo.UpdateProduct(ctx, id, input)
 	if err != nil {
 		return err
 	}
+	s.indexer.IndexProduct(ctx, id)
+	s.priceTable.RefreshProduct(ctx, id)
 
 	return nil
 }
 
-func (s *service) DeleteProduct(id uint) error {
-	_, err := s.repo.GetProductByID(id)
+func (s *service) DeleteProduct(ctx context.Context, id uint) error {
+	_, err := s.repo.GetProductByID(ctx, id)
 	if err != nil {
 		return err
 	}
-	return s.repo.DeleteProduct(id)
+	if err := s.repo.DeleteProduct(ctx, id); err != nil {
+		return err
+	}
+	s.indexer.RemoveProduct(id)
+	s.priceTable.RefreshProduct(ctx, id)
+	// the product may be cached in anyone's recommendations
+	s.recCache.InvalidateAll(ctx)
+	return nil
 }
 
-func (s *service) GetProductsByFlowerType(flowerType string) ([]model.Product, error) {
-	products, err := s.repo.GetProductsByFlowerType(flowerType)
+func (s *service) GetProductsByFlowerType(ctx context.Context, flowerType string) ([]model.Product, error) {
+	products, err := s.repo.GetProductsByFlowerType(ctx, flowerType)
 	if err != nil {
 		return nil, err
 	}
 	return products, nil
 }
 
-func (s *service) GetAllFlowerTypes() ([]model.FlowerType, error) {
-	flowerTypes, err := s.repo.GetAllFlowerTypes()
+func (s *service) GetAllFlowerTypes(ctx context.Context) ([]model.FlowerType, error) {
+	flowerTypes, err := s.repo.GetAllFlowerTypes(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -211,14 +244,14 @@ func ToProductResponse(p model.Product, effectivePrice float64) dto.ProductRespo
 	}
 }
 
-func (s *service) GetAllProductsWithEffectivePrice() ([]dto.ProductResponse, error) {
-	products, err := s.repo.GetAllProducts()
+func (s *service) GetAllProductsWithEffectivePrice(ctx context.Context) ([]dto.ProductResponse, error) {
+	products, err := s.repo.GetAllProducts(ctx)
 	if err != nil {
 		return nil, err
 	}
 	var result []dto.ProductResponse
 	for _, p := range products {
-		price, err := s.pricingService.GetEffectivePriceCache(p, time.Now())
+		price, err := s.pricingService.GetEffectivePriceCache(ctx, p, time.Now())
 		if err != nil {
 			price = p.BasePrice
 		}
@@ -227,13 +260,13 @@ func (s *service) GetAllProductsWithEffectivePrice() ([]dto.ProductResponse, err
 	return result, nil
 }
 
-func (s *service) GetProductByIDWithEffectivePrice(id uint) (*dto.ProductResponse, error) {
-	product, err := s.repo.GetProductByID(id)
+func (s *service) GetProductByIDWithEffectivePrice(ctx context.Context, id uint) (*dto.ProductResponse, error) {
+	product, err := s.repo.GetProductByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	price, err := s.pricingService.GetEffectivePrice(*product, time.Now())
+	price, err := s.pricingService.GetEffectivePrice(ctx, *product, time.Now())
 	if err != nil {
 		price = product.BasePrice
 	}
@@ -244,88 +277,194 @@ func (s *service) GetProductByIDWithEffectivePrice(id uint) (*dto.ProductRespons
 
 // Enhanced methods for advanced search and filtering
 
-func (s *service) SearchProducts(query *dto.ProductSearchQuery) (*model.ProductSearchResponse, error) {
+func (s *service) SearchProducts(ctx context.Context, query *dto.ProductSearchQuery) (*model.ProductSearchResponse, error) {
 	// Set defaults for pagination
-	if query.Page < 1 {
-		query.Page = 1
-	}
-	if query.Limit < 1 {
-		query.Limit = 20
-	}
-	if query.Limit > 100 {
-		query.Limit = 100
+	page, err := pagination.NewRequest(query.Cursor, query.Page, query.Limit)
+	if err != nil {
+		return nil, err
 	}
+	query.Page, query.Limit = page.Page, page.Limit
 
 	// Validate price range
 	if query.PriceMin != nil && query.PriceMax != nil && *query.PriceMin > *query.PriceMax {
-		return nil, errors.New("minimum price cannot be greater than maximum price")
+		return nil, apperror.Validation("minimum price cannot be greater than maximum price", apperror.Field("price_min", "cannot be greater than price_max"))
+	}
+
+	if query.MinRating != nil && (*query.MinRating < 0 || *query.MinRating > 5) {
+		return nil, apperror.Validation("minimum rating must be between 0 and 5", apperror.Field("min_rating", "must be between 0 and 5"))
 	}
 
 	// Validate status/condition
-	if query.Condition != "" && !s.isValidStatus(query.Condition) {
-		return nil, errors.New("invalid product condition")
+	for _, condition := range query.Condition {
+		if !isValidStatus(condition) {
+			return nil, apperror.Validation("invalid product condition", apperror.Field("condition", "is invalid"))
+		}
 	}
 
 	// Validate sort option
 	if query.SortBy != "" && !s.isValidSortOption(query.SortBy) {
-		return nil, errors.New("invalid sort option")
+		return nil, apperror.Validation("invalid sort option", apperror.Field("sort_by", "is invalid"))
+	}
+
+	// Rank free-text queries with the search index; the repository then
+	// applies the other filters to the matches
+	query.MatchedIDs = nil
+	if query.Query != "" && s.indexer.Ready() {
+		hits := s.indexer.Search(query.Query)
+		if len(hits) == 0 {
+			return s.emptySearchResponse(ctx, query, page)
+		}
+		if len(hits) > maxSearchMatches {
+			hits = hits[:maxSearchMatches]
+		}
+		for _, hit := range hits {
+			query.MatchedIDs = append(query.MatchedIDs, hit.ProductID)
+		}
+		if query.SortBy == "" {
+			query.SortBy = "relevance"
+		}
+	}
+
+	// Only the newest-first order can be paged with cursors
+	if query.SortBy == "" {
+		query.SortBy = "newest"
+	}
+	keyset := query.SortBy == "newest"
+	if page.Cursor != nil && !keyset {
+		return nil, apperror.Validation("cursor pagination requires newest sort", apperror.Field("cursor", "requires newest sort"))
+	}
+
+	// Price filters and sorting read the precomputed effective prices; if
+	// they cannot be refreshed, search the previous ones rather than fail
+	if err := s.priceTable.EnsureFresh(ctx, time.Now()); err != nil {
+		log.Warn().Err(err).Msg("Searching with stale effective prices")
 	}
 
 	// Search products
-	products, total, err := s.repo.SearchProducts(query)
+	products, total, err := s.repo.SearchProducts(ctx, query, page)
 	if err != nil {
 		return nil, err
 	}
+	if query.Query != "" && total > 0 && page.Cursor == nil && page.Page == 1 {
+		s.indexer.RecordQuery(ctx, query.Query)
+	}
 
 	// Build pagination info
-	pagination := s.buildPaginationInfo(query.Page, query.Limit, total)
+	var cursors pagination.Result
+	if keyset {
+		products, cursors = pagination.Trim(page, products, func(p model.Product) pagination.Cursor {
+			return pagination.NewCursor(p.CreatedAt, int(p.ProductID))
+		})
+	} else if len(products) > page.Limit {
+		products = products[:page.Limit]
+	}
+	paginationInfo := buildPaginationInfo(page, total, cursors)
 
 	// Get filter options
-	filters, err := s.GetSearchFilters()
+	filters, err := s.GetSearchFilters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	facets, err := s.repo.GetSearchFacets(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
 	response := &model.ProductSearchResponse{
 		Products:   products,
-		Pagination: *pagination,
+		Pagination: *paginationInfo,
 		Filters:    *filters,
+		Facets:     facets,
 	}
 
 	return response, nil
 }
 
-func (s *service) GetProductDetails(id uint) (*model.Product, error) {
-	product, err := s.repo.GetProductDetailByID(id)
+// SuggestProducts completes a partially typed search query from product
+// names, flower types, occasions and popular queries
+func (s *service) SuggestProducts(prefix string, limit int) *dto.SuggestionResponse {
+	if limit < 1 || limit > 20 {
+		limit = 8
+	}
+
+	response := &dto.SuggestionResponse{Query: prefix, Suggestions: []dto.Suggestion{}}
+	for _, e := range s.indexer.Suggest(prefix, limit) {
+		response.Suggestions = append(response.Suggestions, dto.Suggestion{
+			Text:      e.Text,
+			Type:      e.Kind,
+			ProductID: e.ProductID,
+		})
+	}
+	return response
+}
+
+func (s *service) emptySearchResponse(ctx context.Context, query *dto.ProductSearchQuery, page pagination.Request) (*model.ProductSearchResponse, error) {
+	filters, err := s.GetSearchFilters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ProductSearchResponse{
+		Products:   []model.Product{},
+		Pagination: *buildPaginationInfo(page, 0, pagination.Result{}),
+		Filters:    *filters,
+		Facets:     emptySearchFacets(query),
+	}, nil
+}
+
+// emptySearchFacets are the facets of a search without matches: nothing is
+// counted, but the selected values are listed like GetSearchFacets does
+func emptySearchFacets(query *dto.ProductSearchQuery) *model.SearchFacets {
+	selected := func(values []string) []model.FacetCount {
+		counts := []model.FacetCount{}
+		seen := make(map[string]bool)
+		for _, v := range values {
+			if !seen[v] {
+				seen[v] = true
+				counts = append(counts, model.FacetCount{Value: v, Count: 0, Selected: true})
+			}
+		}
+		return counts
+	}
+	return &model.SearchFacets{
+		FlowerTypes:    selected(query.FlowerType),
+		Occasions:      selected(query.Occasion),
+		Statuses:       selected(query.Condition),
+		PriceHistogram: []model.PriceBucket{},
+	}
+}
+
+func (s *service) GetProductDetails(ctx context.Context, id uint) (*model.Product, error) {
+	product, err := s.repo.GetProductDetailByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	return product, nil
 }
 
-func (s *service) GetAllOccasions() ([]model.Occasion, error) {
-	occasions, err := s.repo.GetAllOccasions()
+func (s *service) GetAllOccasions(ctx context.Context) ([]model.Occasion, error) {
+	occasions, err := s.repo.GetAllOccasions(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return occasions, nil
 }
 
-func (s *service) GetSearchFilters() (*model.FilterOptions, error) {
+func (s *service) GetSearchFilters(ctx context.Context) (*model.FilterOptions, error) {
 	// Get flower types
-	flowerTypes, err := s.repo.GetAllFlowerTypes()
+	flowerTypes, err := s.repo.GetAllFlowerTypes(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get occasions
-	occasions, err := s.repo.GetAllOccasions()
+	occasions, err := s.repo.GetAllOccasions(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get price range
-	priceRange, err := s.repo.GetPriceRange()
+	priceRange, err := s.repo.GetPriceRange(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -339,31 +478,130 @@ func (s *service) GetSearchFilters() (*model.FilterOptions, error) {
 	return filters, nil
 }
 
+func (s *service) GetProductVariants(ctx context.Context, productID uint) ([]model.ProductVariant, error) {
+	if _, err := s.repo.GetProductByID(ctx, productID); err != nil {
+		if apperror.Is(err, apperror.CodeNotFound) {
+			return nil, apperror.NotFound("product not found")
+		}
+		return nil, err
+	}
+	return s.repo.GetProductVariants(ctx, productID)
+}
+
+func (s *service) CreateVariant(ctx context.Context, productID uint, input *dto.ProductVariantCreate) (*model.ProductVariant, error) {
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		if apperror.Is(err, apperror.CodeNotFound) {
+			return nil, apperror.NotFound("product not found")
+		}
+		return nil, err
+	}
+
+	if err := s.validateVariantInput(product, input); err != nil {
+		return nil, err
+	}
+
+	variant := &model.ProductVariant{
+		ProductID:     productID,
+		SKU:           input.SKU,
+		Name:          input.Name,
+		Size:          input.Size,
+		Color:         input.Color,
+		Wrapping:      input.Wrapping,
+		PriceDelta:    input.PriceDelta,
+		StockQuantity: input.StockQuantity,
+	}
+
+	if err := s.repo.CreateVariant(ctx, variant, input.Actor); err != nil {
+		return nil, err
+	}
+
+	return variant, nil
+}
+
+func (s *service) UpdateVariant(ctx context.Context, productID, variantID uint, input *dto.ProductVariantCreate) error {
+	product, err := s.getProductVariant(ctx, productID, variantID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.validateVariantInput(product, input); err != nil {
+		return err
+	}
+
+	return s.repo.UpdateVariant(ctx, variantID, input)
+}
+
+func (s *service) DeleteVariant(ctx context.Context, productID, variantID uint) error {
+	if _, err := s.getProductVariant(ctx, productID, variantID); err != nil {
+		return err
+	}
+	return s.repo.DeleteVariant(ctx, variantID)
+}
+
 // Helper methods
 
-func (s *service) validateProductInput(input *dto.ProductCreate) error {
+// getProductVariant checks that the variant exists and belongs to the product
+func (s *service) getProductVariant(ctx context.Context, productID, variantID uint) (*model.Product, error) {
+	product, err := s.repo.GetProductByID(ctx, productID)
+	if err != nil {
+		if apperror.Is(err, apperror.CodeNotFound) {
+			return nil, apperror.NotFound("product not found")
+		}
+		return nil, err
+	}
+
+	variant, err := s.repo.GetVariantByID(ctx, variantID)
+	if err != nil {
+		if apperror.Is(err, apperror.CodeNotFound) {
+			return nil, apperror.NotFound("variant not found")
+		}
+		return nil, err
+	}
+	if variant.ProductID != productID {
+		return nil, apperror.NotFound("variant not found")
+	}
+
+	return product, nil
+}
+
+func (s *service) validateVariantInput(product *model.Product, input *dto.ProductVariantCreate) error {
+	if input.SKU == "" {
+		return apperror.Validation("variant sku is required", apperror.Field("sku", "is required"))
+	}
+	if input.StockQuantity < 0 {
+		return apperror.Validation("stock quantity cannot be negative", apperror.Field("stock_quantity", "cannot be negative"))
+	}
+	if product.BasePrice+input.PriceDelta <= 0 {
+		return apperror.Validation("variant price must be greater than 0", apperror.Field("price_delta", "must leave a price greater than 0"))
+	}
+	return nil
+}
+
+// validateProductInput checks the fields every product needs, for single edits and bulk imports alike
+func validateProductInput(input *dto.ProductCreate) error {
 	if input.Name == "" {
-		return errors.New("product name is required")
+		return apperror.Validation("product name is required", apperror.Field("name", "is required"))
 	}
 	if input.Description == "" {
-		return errors.New("product description is required")
+		return apperror.Validation("product description is required", apperror.Field("description", "is required"))
 	}
 	if input.FlowerType == "" {
-		return errors.New("flower type is required")
+		return apperror.Validation("flower type is required", apperror.Field("flower_type", "is required"))
 	}
 	if input.BasePrice <= 0 {
-		return errors.New("base price must be greater than 0")
+		return apperror.Validation("base price must be greater than 0", apperror.Field("base_price", "must be greater than 0"))
 	}
 	if input.StockQuantity < 0 {
-		return errors.New("stock quantity cannot be negative")
+		return apperror.Validation("stock quantity cannot be negative", apperror.Field("stock_quantity", "cannot be negative"))
 	}
-	if !s.isValidStatus(input.Status) {
-		return errors.New("invalid product status")
+	if !isValidStatus(input.Status) {
+		return apperror.Validation("invalid product status", apperror.Field("status", "is invalid"))
 	}
 	return nil
 }
 
-func (s *service) isValidStatus(status string) bool {
+func isValidStatus(status string) bool {
 	validStatuses := []string{"NewFlower", "OldFlower", "LowStock"}
 	for _, validStatus := range validStatuses {
 		if status == validStatus {
@@ -375,7 +613,7 @@ func (s *service) isValidStatus(status string) bool {
 
 func (s *service) isValidSortOption(sortBy string) bool {
 	validSortOptions := []string{
-		"price_asc", "price_desc", "name_asc", "name_desc", "newest", "best_selling",
+		"relevance", "price_asc", "price_desc", "name_asc", "name_desc", "newest", "best_selling", "top_rated",
 	}
 	for _, validOption := range validSortOptions {
 		if sortBy == validOption {
@@ -385,17 +623,26 @@ func (s *service) isValidSortOption(sortBy string) bool {
 	return false
 }
 
-func (s *service) buildPaginationInfo(page, limit, total int) *model.PaginationInfo {
-	totalPages := int(math.Ceil(float64(total) / float64(limit)))
-	hasNext := page < totalPages
-	hasPrev := page > 1
-
-	return &model.PaginationInfo{
-		Page:       page,
-		Limit:      limit,
+// buildPaginationInfo describes a listing page. In page mode (no cursor) the
+// page numbers follow from total; cursors are still returned when the
+// listing supports them, so clients can switch to cursor paging.
+func buildPaginationInfo(page pagination.Request, total int, cursors pagination.Result) *model.PaginationInfo {
+	info := &model.PaginationInfo{
+		Limit:      page.Limit,
 		Total:      total,
-		TotalPages: totalPages,
-		HasNext:    hasNext,
-		HasPrev:    hasPrev,
+		TotalPages: int(math.Ceil(float64(total) / float64(page.Limit))),
+		NextCursor: cursors.NextCursor,
+		PrevCursor: cursors.PrevCursor,
+	}
+
+	if page.Cursor != nil {
+		info.HasNext = cursors.HasNext
+		info.HasPrev = cursors.HasPrev
+		return info
 	}
-}
\ No newline at end of file
+
+	info.Page = page.Page
+	info.HasNext = page.Page < info.TotalPages
+	info.HasPrev = page.Page > 1
+	return info
+}
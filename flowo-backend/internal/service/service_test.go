@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"flowo-backend/internal/apperror"
+	"flowo-backend/internal/dto"
+	"flowo-backend/internal/model"
+)
+
+func TestEmptySearchFacets(t *testing.T) {
+	selected := func(values ...string) []model.FacetCount {
+		counts := []model.FacetCount{}
+		for _, v := range values {
+			counts = append(counts, model.FacetCount{Value: v, Selected: true})
+		}
+		return counts
+	}
+
+	tests := []struct {
+		name  string
+		query dto.ProductSearchQuery
+		want  model.SearchFacets
+	}{
+		{
+			name:  "no filters",
+			query: dto.ProductSearchQuery{Query: "tulip"},
+			want:  model.SearchFacets{FlowerTypes: selected(), Occasions: selected(), Statuses: selected(), PriceHistogram: []model.PriceBucket{}},
+		},
+		{
+			name: "selected values are listed with no matches",
+			query: dto.ProductSearchQuery{
+				FlowerType: []string{"Rose", "Lily", "Rose"},
+				Occasion:   []string{"Birthday"},
+				Condition:  []string{"NewFlower"},
+			},
+			want: model.SearchFacets{
+				FlowerTypes:    selected("Rose", "Lily"),
+				Occasions:      selected("Birthday"),
+				Statuses:       selected("NewFlower"),
+				PriceHistogram: []model.PriceBucket{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := emptySearchFacets(&tt.query); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("emptySearchFacets() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateVariantInput(t *testing.T) {
+	product := &model.Product{BasePrice: 30}
+
+	tests := []struct {
+		name      string
+		input     dto.ProductVariantCreate
+		wantField string
+	}{
+		{"valid", dto.ProductVariantCreate{SKU: "ROSE-24", PriceDelta: 20, StockQuantity: 5}, ""},
+		{"discounted variant", dto.ProductVariantCreate{SKU: "ROSE-6", PriceDelta: -25}, ""},
+		{"missing sku", dto.ProductVariantCreate{PriceDelta: 20}, "sku"},
+		{"negative stock", dto.ProductVariantCreate{SKU: "ROSE-24", StockQuantity: -1}, "stock_quantity"},
+		{"free variant", dto.ProductVariantCreate{SKU: "ROSE-1", PriceDelta: -30}, "price_delta"},
+	}
+	s := &service{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.validateVariantInput(product, &tt.input)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("validateVariantInput() = %v, want nil", err)
+				}
+				return
+			}
+			appErr := apperror.From(err)
+			if appErr.Code != apperror.CodeValidation || len(appErr.Fields) != 1 || appErr.Fields[0].Field != tt.wantField {
+				t.Errorf("validateVariantInput() = %+v, want a validation error on %s", appErr, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestSearchProductsValidation(t *testing.T) {
+	rating := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name      string
+		query     dto.ProductSearchQuery
+		wantField string
+	}{
+		{"rating below zero", dto.ProductSearchQuery{MinRating: rating(-0.5)}, "min_rating"},
+		{"rating above five", dto.ProductSearchQuery{MinRating: rating(5.1)}, "min_rating"},
+		{"unknown sort", dto.ProductSearchQuery{SortBy: "most_reviewed"}, "sort_by"},
+		{"unknown condition", dto.ProductSearchQuery{Condition: []string{"Wilted"}}, "condition"},
+	}
+	// invalid queries are rejected before the repository is used
+	s := &service{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.SearchProducts(context.Background(), &tt.query)
+			appErr := apperror.From(err)
+			if appErr.Code != apperror.CodeValidation || len(appErr.Fields) != 1 || appErr.Fields[0].Field != tt.wantField {
+				t.Errorf("SearchProducts() error = %+v, want a validation error on %s", appErr, tt.wantField)
+			}
+		})
+	}
+
+	if !s.isValidSortOption("top_rated") {
+		t.Error("isValidSortOption(\"top_rated\") = false, want true")
+	}
+}
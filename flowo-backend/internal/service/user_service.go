@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
+	"flowo-backend/internal/apperror"
 	"flowo-backend/internal/dto"
 	"flowo-backend/internal/model"
+	"flowo-backend/internal/pagination"
 	"flowo-backend/internal/repository"
 	"fmt"
 
@@ -11,14 +13,14 @@ import (
 )
 
 type UserService interface {
-	CreateUserFromFirebase(firebaseUID string, email string) (*model.User, error)
-	GetUserByFirebaseUID(firebaseUID string) (*model.User, error)
-	GetUserByEmail(email string) (*model.User, error)
-	GetCompleteUserInfo(firebaseUID string) (*CompleteUserInfo, error)
-	UpdateUserFromFirebase(firebaseUID string) (*model.User, error)
-	UpdateUserProfile(firebaseUID string, updateData *dto.UpdateProfileRequest) (*model.User, error)
-	GetAllUsers() ([]*model.UserWithAddress, error)
-	SoftDeleteUser(firebaseUID string) error
+	CreateUserFromFirebase(ctx context.Context, firebaseUID string, email string) (*model.User, error)
+	GetUserByFirebaseUID(ctx context.Context, firebaseUID string) (*model.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	GetCompleteUserInfo(ctx context.Context, firebaseUID string) (*CompleteUserInfo, error)
+	UpdateUserFromFirebase(ctx context.Context, firebaseUID string) (*model.User, error)
+	UpdateUserProfile(ctx context.Context, firebaseUID string, updateData *dto.UpdateProfileRequest) (*model.User, error)
+	GetAllUsers(ctx context.Context, cursor string, page, limit int) (*dto.UserListResponse, error)
+	SoftDeleteUser(ctx context.Context, firebaseUID string) error
 }
 
 type userService struct {
@@ -40,9 +42,9 @@ func NewUserService(userRepo repository.UserRepository, firebaseAuth *auth.Clien
 }
 
 // CreateUserFromFirebase creates a local user record from Firebase user data
-func (s *userService) CreateUserFromFirebase(firebaseUID string, email string) (*model.User, error) {
+func (s *userService) CreateUserFromFirebase(ctx context.Context, firebaseUID string, email string) (*model.User, error) {
 	// Check if user already exists in local database
-	existingUser, err := s.userRepo.GetUserByFirebaseUID(firebaseUID)
+	existingUser, err := s.userRepo.GetUserByFirebaseUID(ctx, firebaseUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check existing user: %w", err)
 	}
@@ -52,7 +54,7 @@ func (s *userService) CreateUserFromFirebase(firebaseUID string, email string) (
 	}
 
 	// Get additional info from Firebase if needed
-	firebaseUser, err := s.firebaseAuth.GetUser(context.Background(), firebaseUID)
+	firebaseUser, err := s.firebaseAuth.GetUser(ctx, firebaseUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Firebase user: %w", err)
 	}
@@ -73,7 +75,7 @@ func (s *userService) CreateUserFromFirebase(firebaseUID string, email string) (
 	}
 
 	// Create user in database
-	err = s.userRepo.CreateUser(user)
+	err = s.userRepo.CreateUser(ctx, user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user in database: %w", err)
 	}
@@ -82,38 +84,38 @@ func (s *userService) CreateUserFromFirebase(firebaseUID string, email string) (
 }
 
 // GetUserByFirebaseUID retrieves user from local database
-func (s *userService) GetUserByFirebaseUID(firebaseUID string) (*model.User, error) {
-	user, err := s.userRepo.GetUserByFirebaseUID(firebaseUID)
+func (s *userService) GetUserByFirebaseUID(ctx context.Context, firebaseUID string) (*model.User, error) {
+	user, err := s.userRepo.GetUserByFirebaseUID(ctx, firebaseUID)
 	if err != nil {
 		return nil, err
 	}
 	if user != nil {
 		if user.IsDeleted {
-			return nil, fmt.Errorf("account has been deactivated")
+			return nil, apperror.Forbidden("account has been deactivated")
 		}
 	}
 	return user, nil
 }
 
 // GetUserByEmail retrieves user from local database by email
-func (s *userService) GetUserByEmail(email string) (*model.User, error) {
-	return s.userRepo.GetUserByEmail(email)
+func (s *userService) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	return s.userRepo.GetUserByEmail(ctx, email)
 }
 
 // GetCompleteUserInfo retrieves both local and Firebase user information
-func (s *userService) GetCompleteUserInfo(firebaseUID string) (*CompleteUserInfo, error) {
+func (s *userService) GetCompleteUserInfo(ctx context.Context, firebaseUID string) (*CompleteUserInfo, error) {
 	// Get local user data
-	localUser, err := s.userRepo.GetUserByFirebaseUID(firebaseUID)
+	localUser, err := s.userRepo.GetUserByFirebaseUID(ctx, firebaseUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get local user: %w", err)
 	}
 
 	if localUser == nil {
-		return nil, fmt.Errorf("user not found in local database")
+		return nil, apperror.NotFound("user not found in local database")
 	}
 
 	// Get Firebase user data
-	firebaseUser, err := s.firebaseAuth.GetUser(context.Background(), firebaseUID)
+	firebaseUser, err := s.firebaseAuth.GetUser(ctx, firebaseUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Firebase user: %w", err)
 	}
@@ -125,19 +127,19 @@ func (s *userService) GetCompleteUserInfo(firebaseUID string) (*CompleteUserInfo
 }
 
 // UpdateUserFromFirebase updates local user data from Firebase
-func (s *userService) UpdateUserFromFirebase(firebaseUID string) (*model.User, error) {
+func (s *userService) UpdateUserFromFirebase(ctx context.Context, firebaseUID string) (*model.User, error) {
 	// Get current local user
-	localUser, err := s.userRepo.GetUserByFirebaseUID(firebaseUID)
+	localUser, err := s.userRepo.GetUserByFirebaseUID(ctx, firebaseUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get local user: %w", err)
 	}
 
 	if localUser == nil {
-		return nil, fmt.Errorf("user not found in local database")
+		return nil, apperror.NotFound("user not found in local database")
 	}
 
 	// Get updated Firebase data
-	firebaseUser, err := s.firebaseAuth.GetUser(context.Background(), firebaseUID)
+	firebaseUser, err := s.firebaseAuth.GetUser(ctx, firebaseUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Firebase user: %w", err)
 	}
@@ -151,7 +153,7 @@ func (s *userService) UpdateUserFromFirebase(firebaseUID string) (*model.User, e
 	}
 
 	// Save updated user
-	err = s.userRepo.UpdateUser(localUser)
+	err = s.userRepo.UpdateUser(ctx, localUser)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
@@ -160,15 +162,15 @@ func (s *userService) UpdateUserFromFirebase(firebaseUID string) (*model.User, e
 }
 
 // UpdateUserProfile updates user profile information in the local database
-func (s *userService) UpdateUserProfile(firebaseUID string, updateData *dto.UpdateProfileRequest) (*model.User, error) {
+func (s *userService) UpdateUserProfile(ctx context.Context, firebaseUID string, updateData *dto.UpdateProfileRequest) (*model.User, error) {
 	// Get current local user
-	localUser, err := s.userRepo.GetUserByFirebaseUID(firebaseUID)
+	localUser, err := s.userRepo.GetUserByFirebaseUID(ctx, firebaseUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get local user: %w", err)
 	}
 
 	if localUser == nil {
-		return nil, fmt.Errorf("user not found in local database")
+		return nil, apperror.NotFound("user not found in local database")
 	}
 
 	// Update fields if provided
@@ -183,7 +185,7 @@ func (s *userService) UpdateUserProfile(firebaseUID string, updateData *dto.Upda
 	}
 
 	// Save updated user
-	err = s.userRepo.UpdateUser(localUser)
+	err = s.userRepo.UpdateUser(ctx, localUser)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update user profile: %w", err)
 	}
@@ -191,18 +193,38 @@ func (s *userService) UpdateUserProfile(firebaseUID string, updateData *dto.Upda
 	return localUser, nil
 }
 
-func (s *userService) GetAllUsers() ([]*model.UserWithAddress, error) {
-	return s.userRepo.GetAllUsers()
+// GetAllUsers lists users newest first, by cursor or by page
+func (s *userService) GetAllUsers(ctx context.Context, cursor string, page, limit int) (*dto.UserListResponse, error) {
+	req, err := pagination.NewRequest(cursor, page, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	users, total, err := s.userRepo.GetAllUsers(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	users, cursors := pagination.Trim(req, users, func(u *model.UserWithAddress) pagination.Cursor {
+		return pagination.Cursor{Time: u.CreatedAt, ID: u.FirebaseUID}
+	})
+	if users == nil {
+		users = []*model.UserWithAddress{}
+	}
+
+	return &dto.UserListResponse{
+		Users:      users,
+		Pagination: *buildPaginationInfo(req, total, cursors),
+	}, nil
 }
 
-func (s *userService) SoftDeleteUser(firebaseUID string) error {
+func (s *userService) SoftDeleteUser(ctx context.Context, firebaseUID string) error {
 
-	user, err := s.userRepo.GetUserByFirebaseUID(firebaseUID)
+	user, err := s.userRepo.GetUserByFirebaseUID(ctx, firebaseUID)
 	if err != nil {
 		return err
 	}
 	if user == nil {
-		return fmt.Errorf("user not found or already deleted")
+		return apperror.NotFound("user not found or already deleted")
 	}
-	return s.userRepo.SoftDeleteUser(firebaseUID)
+	return s.userRepo.SoftDeleteUser(ctx, firebaseUID)
 }
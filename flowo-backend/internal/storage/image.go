@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"net/http"
+
+	// register decoders for image.Decode
+	_ "image/gif"
+	_ "image/png"
+)
+
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ImageLimits bounds what an uploaded image may look like
+type ImageLimits struct {
+	MaxBytes     int64
+	MinDimension int
+	MaxDimension int
+}
+
+// ValidateImage checks size, type and dimensions of an uploaded image and
+// returns the file extension matching its content
+func ValidateImage(data []byte, limits ImageLimits) (string, error) {
+	if len(data) == 0 {
+		return "", errors.New("image is empty")
+	}
+	if limits.MaxBytes > 0 && int64(len(data)) > limits.MaxBytes {
+		return "", fmt.Errorf("image exceeds maximum size of %d bytes", limits.MaxBytes)
+	}
+
+	ext, ok := allowedImageTypes[http.DetectContentType(data)]
+	if !ok {
+		return "", errors.New("unsupported image type, use JPEG, PNG or GIF")
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", errors.New("image could not be decoded")
+	}
+	if limits.MinDimension > 0 && (cfg.Width < limits.MinDimension || cfg.Height < limits.MinDimension) {
+		return "", fmt.Errorf("image must be at least %dx%d pixels", limits.MinDimension, limits.MinDimension)
+	}
+	if limits.MaxDimension > 0 && (cfg.Width > limits.MaxDimension || cfg.Height > limits.MaxDimension) {
+		return "", fmt.Errorf("image must be at most %dx%d pixels", limits.MaxDimension, limits.MaxDimension)
+	}
+
+	return ext, nil
+}
+
+// Thumbnail scales the image down so its longest side is at most maxSide
+// pixels and encodes it as JPEG. Smaller images are re-encoded as is.
+func Thumbnail(data []byte, maxSide int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > maxSide || h > maxSide {
+		if w >= h {
+			h = h * maxSide / w
+			w = maxSide
+		} else {
+			w = w * maxSide / h
+			h = maxSide
+		}
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	scaleBox(dst, src)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleBox downsamples src into dst by averaging the source pixels that fall
+// into each destination pixel
+func scaleBox(dst *image.RGBA, src image.Image) {
+	sb := src.Bounds()
+	db := dst.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	dw, dh := db.Dx(), db.Dy()
+
+	for y := 0; y < dh; y++ {
+		y0 := sb.Min.Y + y*sh/dh
+		y1 := sb.Min.Y + (y+1)*sh/dh
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < dw; x++ {
+			x0 := sb.Min.X + x*sw/dw
+			x1 := sb.Min.X + (x+1)*sw/dw
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					bl += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(bl / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+}
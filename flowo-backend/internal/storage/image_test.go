@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodeImage(t *testing.T, format string, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 40, B: 80, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestValidateImage(t *testing.T) {
+	limits := ImageLimits{MaxBytes: 1 << 20, MinDimension: 10, MaxDimension: 100}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		limits  ImageLimits
+		wantExt string
+	}{
+		{"png", encodeImage(t, "png", 50, 40), limits, ".png"},
+		{"jpeg", encodeImage(t, "jpeg", 50, 40), limits, ".jpg"},
+		{"gif", encodeImage(t, "gif", 50, 40), limits, ".gif"},
+		{"smallest and largest sides allowed", encodeImage(t, "png", 10, 100), limits, ".png"},
+		{"no limits", encodeImage(t, "png", 1, 500), ImageLimits{}, ".png"},
+		{"empty", nil, limits, ""},
+		{"too many bytes", encodeImage(t, "png", 50, 40), ImageLimits{MaxBytes: 10}, ""},
+		{"not an image", []byte("<html><body>hi</body></html>"), limits, ""},
+		{"truncated", encodeImage(t, "png", 50, 40)[:20], limits, ""},
+		{"too small", encodeImage(t, "png", 50, 9), limits, ""},
+		{"too large", encodeImage(t, "png", 101, 50), limits, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ext, err := ValidateImage(tt.data, tt.limits)
+			if tt.wantExt == "" {
+				if err == nil {
+					t.Errorf("ValidateImage() = %q, want an error", ext)
+				}
+				return
+			}
+			if err != nil || ext != tt.wantExt {
+				t.Errorf("ValidateImage() = %q, %v, want %q", ext, err, tt.wantExt)
+			}
+		})
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		name         string
+		w, h         int
+		maxSide      int
+		wantW, wantH int
+	}{
+		{"landscape", 400, 200, 100, 100, 50},
+		{"portrait", 200, 400, 100, 50, 100},
+		{"square", 300, 300, 100, 100, 100},
+		{"small image is kept", 60, 40, 100, 60, 40},
+		{"thin image keeps a pixel", 1000, 2, 100, 100, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumb, err := Thumbnail(encodeImage(t, "png", tt.w, tt.h), tt.maxSide)
+			if err != nil {
+				t.Fatal(err)
+			}
+			img, format, err := image.Decode(bytes.NewReader(thumb))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != "jpeg" {
+				t.Errorf("thumbnail format = %s, want jpeg", format)
+			}
+			if b := img.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Errorf("thumbnail is %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			}
+			// averaging a single colour keeps it, up to JPEG loss
+			r, g, b, _ := img.At(0, 0).RGBA()
+			if diff(r>>8, 200) > 8 || diff(g>>8, 40) > 8 || diff(b>>8, 80) > 8 {
+				t.Errorf("thumbnail colour = %d,%d,%d, want about 200,40,80", r>>8, g>>8, b>>8)
+			}
+		})
+	}
+
+	if _, err := Thumbnail([]byte("not an image"), 100); err == nil {
+		t.Error("Thumbnail() of garbage succeeded")
+	}
+}
+
+func diff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
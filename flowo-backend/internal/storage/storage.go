@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"flowo-backend/config"
+)
+
+// Storage keeps uploaded files (product and review images) and tells where
+// they can be fetched from
+type Storage interface {
+	// Save stores data under key and returns its public URL
+	Save(key string, data []byte) (string, error)
+	Delete(key string) error
+	URL(key string) string
+}
+
+// LocalStorage stores files on the local filesystem under a base directory
+// that is served by the HTTP server at a public URL prefix
+type LocalStorage struct {
+	baseDir   string
+	publicURL string
+}
+
+func NewLocalStorage(cfg *config.Config) (Storage, error) {
+	if err := os.MkdirAll(cfg.Storage.LocalDir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating storage directory: %v", err)
+	}
+	return &LocalStorage{
+		baseDir:   cfg.Storage.LocalDir,
+		publicURL: strings.TrimRight(cfg.Storage.PublicURL, "/"),
+	}, nil
+}
+
+func (s *LocalStorage) Save(key string, data []byte) (string, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return s.URL(key), nil
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.publicURL + "/" + key
+}
+
+// path resolves key inside the base directory, rejecting keys that escape it
+func (s *LocalStorage) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if cleaned == "/" {
+		return "", errors.New("invalid storage key")
+	}
+	return filepath.Join(s.baseDir, cleaned), nil
+}
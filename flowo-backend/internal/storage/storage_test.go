@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStorage(t *testing.T) {
+	dir := t.TempDir()
+	s := &LocalStorage{baseDir: dir, publicURL: "http://localhost:8081/uploads"}
+
+	tests := []struct {
+		name     string
+		key      string
+		wantPath string
+		wantErr  bool
+	}{
+		{"nested key", "products/1/rose.png", "products/1/rose.png", false},
+		{"escaping key stays inside", "../../etc/passwd", "etc/passwd", false},
+		{"empty key", "", "", true},
+		{"root key", "/", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := s.Save(tt.key, []byte("data"))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Save(%q) = %s, want an error", tt.key, url)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "http://localhost:8081/uploads/" + tt.key; url != want {
+				t.Errorf("Save() = %s, want %s", url, want)
+			}
+			path := filepath.Join(dir, tt.wantPath)
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("saved file missing at %s: %v", path, err)
+			}
+
+			if err := s.Delete(tt.key); err != nil {
+				t.Errorf("Delete() = %v", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("file still exists after Delete(): %v", err)
+			}
+			// deleting twice is not an error
+			if err := s.Delete(tt.key); err != nil {
+				t.Errorf("second Delete() = %v", err)
+			}
+		})
+	}
+}
@@ -6,7 +6,6 @@ import { makeApiRequest } from "@/config/api";
 
 
 
-type ApiEnvelope<T> = { message?: string; data?: T };
 type ApiOrder = {
   order_id: number;
   firebase_uid: string;
@@ -15,6 +14,11 @@ type ApiOrder = {
   order_date: string;
 };
 
+type ApiOrderPage = {
+  orders?: ApiOrder[];
+  pagination?: { has_next?: boolean; next_cursor?: string };
+};
+
 type UIOrder = {
   id: number;
   uid: string;
@@ -298,43 +302,53 @@ export default function AdminOrders() {
     (window as any).__ORDERS_DEBUG__ = rec;
 
     try {
-      const res = await fetch(url, {
-        headers: { Accept: "application/json" },
-        credentials: "include",
-        // mode: "cors" // usually default; uncomment if you want to be explicit
-      });
-
-      rec.status = res.status;
-      rec.statusText = res.statusText;
-      rec.responseUrl = res.url;
-
-      // Read once as text so we can show it if JSON fails
-      const text = await res.text();
-      rec.responseText = text;
+      // the endpoint is paged; filters and stats need every order
+      const list: ApiOrder[] = [];
+      let cursor = "";
+      for (;;) {
+        const pageUrl = `${url}?limit=100${cursor ? `&cursor=${encodeURIComponent(cursor)}` : ""}`;
+        const res = await fetch(pageUrl, {
+          headers: { Accept: "application/json" },
+          credentials: "include",
+          // mode: "cors" // usually default; uncomment if you want to be explicit
+        });
+
+        rec.status = res.status;
+        rec.statusText = res.statusText;
+        rec.responseUrl = res.url;
+
+        // Read once as text so we can show it if JSON fails
+        const text = await res.text();
+        rec.responseText = text;
+
+        if (!res.ok) {
+          // Provide helpful hints by status
+          if (res.status === 404) {
+            rec.hint = rec.hint || "404 Not Found. Check the path on the server and your API_BASE.";
+          } else if (res.status === 401 || res.status === 403) {
+            rec.hint =
+              rec.hint ||
+              "Unauthorized/Forbidden. If your API requires cookies, make sure it sets CORS headers with 'Access-Control-Allow-Credentials: true' and a specific 'Access-Control-Allow-Origin' (not '*'), and the auth cookie is 'SameSite=None; Secure'.";
+          }
+          throw new Error(text || `HTTP ${res.status} ${res.statusText}`);
+        }
 
-      if (!res.ok) {
-        // Provide helpful hints by status
-        if (res.status === 404) {
-          rec.hint = rec.hint || "404 Not Found. Check the path on the server and your API_BASE.";
-        } else if (res.status === 401 || res.status === 403) {
+        let parsed: ApiOrderPage;
+        try {
+          parsed = text ? JSON.parse(text) : { orders: [] };
+        } catch {
           rec.hint =
             rec.hint ||
-            "Unauthorized/Forbidden. If your API requires cookies, make sure it sets CORS headers with 'Access-Control-Allow-Credentials: true' and a specific 'Access-Control-Allow-Origin' (not '*'), and the auth cookie is 'SameSite=None; Secure'.";
+            "Response was not valid JSON. Ensure the server returns 'application/json' and valid JSON content.";
+          throw new Error("Invalid JSON from /admins/orders");
         }
-        throw new Error(text || `HTTP ${res.status} ${res.statusText}`);
-      }
+        list.push(...(parsed.orders ?? []));
 
-      let parsed: ApiEnvelope<ApiOrder[]> | ApiOrder[];
-      try {
-        parsed = text ? JSON.parse(text) : [];
-      } catch {
-        rec.hint =
-          rec.hint ||
-          "Response was not valid JSON. Ensure the server returns 'application/json' and valid JSON content.";
-        throw new Error("Invalid JSON from /admins/orders");
+        const next = parsed.pagination?.has_next ? parsed.pagination.next_cursor : undefined;
+        if (!next) break;
+        cursor = next;
       }
 
-      const list: ApiOrder[] = Array.isArray(parsed) ? parsed : parsed.data ?? [];
       setRows(
         list.map((o) => ({
           id: o.order_id,
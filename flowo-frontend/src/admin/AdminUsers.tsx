@@ -26,6 +26,11 @@ type ApiUser = {
   };
 };
 
+type ApiUserPage = {
+  users: ApiUser[];
+  pagination?: { has_next?: boolean; next_cursor?: string };
+};
+
 type UIUser = {
   id: string;               // firebase_uid
   name: string;             // full_name | username | email local part
@@ -97,16 +102,25 @@ export default function AdminUsers() {
     (async () => {
       setLoading(true); setErr(null);
       try {
-        const res = await fetch(`${API_BASE}/admin/users`, {
-          headers: { Accept: "application/json" },
-          credentials: "include",
-        });
-        const text = await res.text();
-        if (!res.ok) throw new Error(text || `HTTP ${res.status}`);
-
-        let parsed: ApiEnvelope<ApiUser[]> | ApiUser[];
-        try { parsed = JSON.parse(text); } catch { throw new Error("Invalid JSON from /admin/users"); }
-        const list: ApiUser[] = Array.isArray(parsed) ? parsed : parsed.data ?? [];
+        // the endpoint is paged; stats and filters need every user
+        const list: ApiUser[] = [];
+        let cursor = "";
+        for (;;) {
+          const res = await fetch(`${API_BASE}/admin/users?limit=100${cursor ? `&cursor=${encodeURIComponent(cursor)}` : ""}`, {
+            headers: { Accept: "application/json" },
+            credentials: "include",
+          });
+          const text = await res.text();
+          if (!res.ok) throw new Error(text || `HTTP ${res.status}`);
+
+          let parsed: ApiEnvelope<ApiUserPage>;
+          try { parsed = JSON.parse(text); } catch { throw new Error("Invalid JSON from /admin/users"); }
+          list.push(...(parsed.data?.users ?? []));
+
+          const next = parsed.data?.pagination?.has_next ? parsed.data.pagination.next_cursor : undefined;
+          if (!next) break;
+          cursor = next;
+        }
 
         if (!alive) return;
         setRows(list.map(toUI));
@@ -1,4 +1,5 @@
 import { useEffect } from "react";
+import { useSearchParams } from "react-router-dom";
 import Container from "@/components/layout/Container";
 import CartItem from "@/components/cart/CartItem";
 import CartSummary from "@/components/cart/CartSummary";
@@ -11,11 +12,30 @@ export default function Cart() {
   const error = useCart((s) => s.error);
   const fetchCart = useCart((s) => s.fetchCart);
   const clearError = useCart((s) => s.clearError);
+  const prefill = useCart((s) => s.prefill);
+  const [searchParams, setSearchParams] = useSearchParams();
 
-  // Fetch cart data on component mount
+  // Fetch cart data on component mount. Links from occasion reminders carry
+  // ?add=1,2,3: those products are added first, then the parameter is
+  // dropped so a reload does not add them again.
+  const addParam = searchParams.get("add");
   useEffect(() => {
-    fetchCart();
-  }, [fetchCart]);
+    const ids = (addParam ?? "")
+      .split(",")
+      .map((id) => parseInt(id, 10))
+      .filter((id) => id > 0)
+      .slice(0, 20);
+    if (ids.length === 0) {
+      fetchCart();
+      return;
+    }
+    prefill(ids).then(() =>
+      setSearchParams((params) => {
+        params.delete("add");
+        return params;
+      }, { replace: true }),
+    );
+  }, [addParam, fetchCart, prefill, setSearchParams]);
 
   return (
     <Container className="py-10">
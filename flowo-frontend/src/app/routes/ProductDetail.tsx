@@ -52,10 +52,19 @@ type Review = {
   updated_at: string;
   user_name?: string;
 };
+// One page of GET /products/:id/reviews
+type ApiReview = {
+  review_id: number;
+  product_id: number;
+  reviewer_name?: string;
+  rating: number;
+  comment: string;
+  review_date: string;
+  updated_at?: string;
+};
 type ReviewsApiResponse = {
-  message?: string;
-  data?: Review[];
-  error?: string;
+  reviews?: ApiReview[];
+  pagination?: { has_next?: boolean; next_cursor?: string };
 };
 type SubmitReviewResponse = {
   message?: string;
@@ -104,26 +113,45 @@ async function fetchProductReviews(productId: string): Promise<Review[]> {
   const reviewsUrl = `${API_BASE}/products/${productId}/reviews`;
   console.log("[ProductDetail] Fetching reviews from:", reviewsUrl);
 
-  const response = await fetch(reviewsUrl, {
-    headers: { Accept: "application/json" },
-    credentials: "include",
-  });
+  const reviews: Review[] = [];
+  let cursor = "";
+  // the endpoint is paged; the product page shows every review
+  for (;;) {
+    const pageUrl = `${reviewsUrl}?limit=100${cursor ? `&cursor=${encodeURIComponent(cursor)}` : ""}`;
+    const response = await fetch(pageUrl, {
+      headers: { Accept: "application/json" },
+      credentials: "include",
+    });
 
-  const raw = await response.text();
-  if (!response.ok) {
-    throw new Error(raw || `HTTP ${response.status} - Failed to fetch reviews`);
-  }
+    const raw = await response.text();
+    if (!response.ok) {
+      throw new Error(raw || `HTTP ${response.status} - Failed to fetch reviews`);
+    }
 
-  let parsed: ReviewsApiResponse | Review[];
-  try {
-    parsed = JSON.parse(raw);
-  } catch {
-    throw new Error("Invalid JSON from reviews endpoint");
-  }
+    let parsed: ReviewsApiResponse;
+    try {
+      parsed = JSON.parse(raw);
+    } catch {
+      throw new Error("Invalid JSON from reviews endpoint");
+    }
 
-  // Handle both direct response and envelope response
-  const reviewsData: Review[] = Array.isArray(parsed) ? parsed : parsed.data ?? [];
-  return reviewsData;
+    for (const r of parsed.reviews ?? []) {
+      reviews.push({
+        id: r.review_id,
+        user_id: 0,
+        product_id: r.product_id,
+        rating: r.rating,
+        comment: r.comment,
+        created_at: r.review_date,
+        updated_at: r.updated_at || r.review_date,
+        user_name: r.reviewer_name,
+      });
+    }
+
+    const next = parsed.pagination?.has_next ? parsed.pagination.next_cursor : undefined;
+    if (!next) return reviews;
+    cursor = next;
+  }
 }
 
 // Function to submit a new review
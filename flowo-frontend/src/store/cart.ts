@@ -29,6 +29,7 @@ type CartState = {
   // actions
   fetchCart: () => Promise<void>;
   add: (productId: number, quantity?: number) => Promise<void>;
+  prefill: (productIds: number[]) => Promise<void>;
   increment: (id: number) => Promise<void>;
   decrement: (id: number) => Promise<void>;
   remove: (id: number) => Promise<void>;
@@ -139,6 +140,22 @@ export const useCart = create<CartState>((set, get) => ({
     }
   },
 
+  // Adds one of each product not in the cart yet, e.g. from the link in an
+  // occasion reminder; unavailable products are skipped by the server
+  prefill: async (productIds: number[]) => {
+    set({ loading: true, error: null });
+    try {
+      await makeApiRequest('/cart/prefill', {
+        method: 'POST',
+        body: JSON.stringify({ product_ids: productIds }),
+      });
+      await get().fetchCart();
+    } catch (error: any) {
+      console.error('Failed to prefill cart:', error);
+      set({ error: error.message, loading: false });
+    }
+  },
+
   increment: async (id: number) => {
     const currentItem = get().items.find(item => item.id === id);
     if (!currentItem) {